@@ -3,18 +3,28 @@ package config
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 )
 
 // Config represents the application configuration.
 type Config struct {
-	ServerConfig    ServerConfig    `yaml:"server"`
-	DBConfig        DBConfig        `yaml:"db"`
-	OpenMeteoConfig OpenMeteoConfig `yaml:"openmeteo"`
+	ServerConfig        ServerConfig        `yaml:"server"`
+	DBConfig            DBConfig            `yaml:"db"`
+	OpenMeteoConfig     OpenMeteoConfig     `yaml:"openmeteo"`
+	ForecastCacheConfig ForecastCacheConfig `yaml:"forecast_cache"`
+	SnapshotConfig      SnapshotConfig      `yaml:"weather_snapshots"`
+	PrefetchConfig      PrefetchConfig      `yaml:"prefetch"`
+	ReadTimeoutConfig   ReadTimeoutConfig   `yaml:"read_timeouts"`
+	WebhookConfig       WebhookConfig       `yaml:"webhooks"`
+	AccuracyConfig      AccuracyConfig      `yaml:"forecast_accuracy"`
+	AuthConfig          AuthConfig          `yaml:"auth"`
 }
 
 // Validate validates the configuration.
-func (c Config) Validate() error {
+// Defaults and secrets resolved by the sub configurations are written back to c.
+func (c *Config) Validate() error {
 	if err := c.DBConfig.Validate(); err != nil {
 		return err
 	}
@@ -24,6 +34,27 @@ func (c Config) Validate() error {
 	if err := c.ServerConfig.Validate(); err != nil {
 		return err
 	}
+	if err := c.ForecastCacheConfig.Validate(); err != nil {
+		return err
+	}
+	if err := c.SnapshotConfig.Validate(); err != nil {
+		return err
+	}
+	if err := c.PrefetchConfig.Validate(); err != nil {
+		return err
+	}
+	if err := c.ReadTimeoutConfig.Validate(); err != nil {
+		return err
+	}
+	if err := c.WebhookConfig.Validate(); err != nil {
+		return err
+	}
+	if err := c.AccuracyConfig.Validate(); err != nil {
+		return err
+	}
+	if err := c.AuthConfig.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -76,8 +107,29 @@ func (c DBConfig) DSN() string {
 
 // OpenMeteoConfig represents the OpenMeteo configuration.
 type OpenMeteoConfig struct {
-	APIURL  string        `yaml:"api_url"`
-	Timeout time.Duration `yaml:"timeout"`
+	// APIURL is the default base URL, used for every endpoint without its own URL.
+	APIURL       string `yaml:"api_url"`
+	ForecastURL  string `yaml:"forecast_url"`
+	ElevationURL string `yaml:"elevation_url"`
+	ArchiveURL   string `yaml:"archive_url"`
+	GeocodingURL string `yaml:"geocoding_url"`
+	FloodURL     string `yaml:"flood_url"`
+	// APIKeyEnv is the name of the environment variable holding the API key, OPENMETEO_API_KEY by default.
+	APIKeyEnv string `yaml:"api_key_env"`
+	// APIKeyFile is the path of a file holding the API key, it takes precedence over APIKeyEnv.
+	APIKeyFile string `yaml:"api_key_file"`
+	// Headers are extra headers sent with every request, e.g. for a self-hosted mirror behind a proxy.
+	Headers   map[string]string `yaml:"headers"`
+	Timeout   time.Duration     `yaml:"timeout"`
+	RateLimit RateLimitConfig   `yaml:"rate_limit"`
+	// Model is the weather model of the forecasts, empty for the Open-Meteo best_match.
+	Model string `yaml:"model"`
+	// GridResolutions overrides the grid resolution in degrees per model, coordinates in the same
+	// grid cell are fetched once. A zero resolution only merges exact coordinates.
+	GridResolutions map[string]float64 `yaml:"grid_resolutions"`
+
+	// APIKey is resolved from APIKeyFile or APIKeyEnv by Validate, it is never read from the yaml file.
+	APIKey string `yaml:"-"`
 }
 
 // Validate validates the OpenMeteo configuration.
@@ -85,8 +137,335 @@ func (c *OpenMeteoConfig) Validate() error {
 	if c.APIURL == "" {
 		return errors.New("openmeteoconfig api_url is required")
 	}
+	if c.ForecastURL == "" {
+		c.ForecastURL = c.APIURL
+	}
+	if c.ElevationURL == "" {
+		c.ElevationURL = c.APIURL
+	}
+	if c.ArchiveURL == "" {
+		c.ArchiveURL = c.APIURL
+	}
+	if c.GeocodingURL == "" {
+		c.GeocodingURL = c.APIURL
+	}
+	if c.FloodURL == "" {
+		c.FloodURL = c.APIURL
+	}
 	if c.Timeout == 0 {
 		c.Timeout = 15 * time.Second
 	}
+	if err := c.RateLimit.Validate(); err != nil {
+		return err
+	}
+	for model, resolution := range c.GridResolutions {
+		if resolution < 0 {
+			return fmt.Errorf("openmeteoconfig grid_resolutions of %s must not be negative", model)
+		}
+	}
+
+	if c.APIKeyEnv == "" {
+		c.APIKeyEnv = "OPENMETEO_API_KEY"
+	}
+	switch {
+	case c.APIKeyFile != "":
+		out, err := os.ReadFile(c.APIKeyFile)
+		if err != nil {
+			return fmt.Errorf("openmeteoconfig api_key_file: %w", err)
+		}
+		c.APIKey = strings.TrimSpace(string(out))
+		if c.APIKey == "" {
+			return errors.New("openmeteoconfig api_key_file is empty")
+		}
+	case c.APIKeyEnv != "":
+		// An unset variable is allowed, the client falls back to the free API without a key.
+		c.APIKey = strings.TrimSpace(os.Getenv(c.APIKeyEnv))
+	}
+	return nil
+}
+
+// RateLimitConfig represents the client side rate limit of the OpenMeteo API.
+// The limits are counted in OpenMeteo calls, a zero limit disables the window.
+type RateLimitConfig struct {
+	PerMinute int `yaml:"per_minute"`
+	PerHour   int `yaml:"per_hour"`
+	PerDay    int `yaml:"per_day"`
+	// MaxWait is how long a request may be queued for budget, zero rejects it right away.
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+// Validate validates the rate limit configuration.
+func (c RateLimitConfig) Validate() error {
+	if c.PerMinute < 0 || c.PerHour < 0 || c.PerDay < 0 {
+		return errors.New("ratelimitconfig limits must not be negative")
+	}
+	if c.MaxWait < 0 {
+		return errors.New("ratelimitconfig max_wait must not be negative")
+	}
+	return nil
+}
+
+// ForecastCacheConfig represents the in-process forecast cache configuration.
+type ForecastCacheConfig struct {
+	// ModelUpdateInterval is how often the weather models are updated,
+	// cached forecasts expire at the next update.
+	ModelUpdateInterval time.Duration `yaml:"model_update_interval"`
+	// MaxEntries is the number of cached forecasts, the least recently used are evicted first.
+	// Zero disables the cache.
+	MaxEntries int `yaml:"max_entries"`
+}
+
+// Validate validates the forecast cache configuration.
+func (c *ForecastCacheConfig) Validate() error {
+	if c.MaxEntries < 0 {
+		return errors.New("forecastcacheconfig max_entries must not be negative")
+	}
+	if c.ModelUpdateInterval == 0 {
+		c.ModelUpdateInterval = time.Hour
+	}
+	return nil
+}
+
+// SnapshotConfig represents the configuration of the weather snapshots stored in the database.
+type SnapshotConfig struct {
+	// ModelUpdateInterval is how often the weather models are updated,
+	// a snapshot is fresh until the next update.
+	ModelUpdateInterval time.Duration `yaml:"model_update_interval"`
+	// StaleWhileRevalidate is how long after it was fetched a snapshot that is not fresh anymore
+	// is still served while it is refreshed in the background.
+	StaleWhileRevalidate time.Duration `yaml:"stale_while_revalidate"`
+	// RefreshTimeout bounds a background refresh, 30s by default.
+	RefreshTimeout time.Duration `yaml:"refresh_timeout"`
+}
+
+// Validate validates the weather snapshot configuration.
+func (c *SnapshotConfig) Validate() error {
+	if c.ModelUpdateInterval < 0 || c.StaleWhileRevalidate < 0 || c.RefreshTimeout < 0 {
+		return errors.New("snapshotconfig durations must not be negative")
+	}
+	if c.ModelUpdateInterval == 0 {
+		c.ModelUpdateInterval = time.Hour
+	}
+	if c.RefreshTimeout == 0 {
+		c.RefreshTimeout = 30 * time.Second
+	}
+	return nil
+}
+
+// PrefetchConfig represents the configuration of the background forecast prefetcher.
+type PrefetchConfig struct {
+	// Interval is the time between two prefetches of every power plant forecast, zero disables the prefetcher.
+	Interval time.Duration `yaml:"interval"`
+	// BatchSize is the number of power plants per weather API call, 100 by default.
+	BatchSize int `yaml:"batch_size"`
+	// Concurrency is the number of batches fetched at the same time, 1 by default.
+	Concurrency int `yaml:"concurrency"`
+	// ForecastDays are the forecast lengths prefetched, 7 days by default.
+	ForecastDays []int `yaml:"forecast_days"`
+	// RecentTraffic is how long after being read a power plant is prefetched first, 1h by default.
+	RecentTraffic time.Duration `yaml:"recent_traffic"`
+}
+
+// Validate validates the prefetch configuration.
+func (c *PrefetchConfig) Validate() error {
+	if c.Interval < 0 || c.RecentTraffic < 0 {
+		return errors.New("prefetchconfig durations must not be negative")
+	}
+	if c.BatchSize < 0 || c.Concurrency < 0 {
+		return errors.New("prefetchconfig batch_size and concurrency must not be negative")
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 100
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = 1
+	}
+	if len(c.ForecastDays) == 0 {
+		c.ForecastDays = []int{7}
+	}
+	if c.RecentTraffic == 0 {
+		c.RecentTraffic = time.Hour
+	}
+	return nil
+}
+
+// ReadTimeoutConfig represents the time budget of each upstream call made while reading power plants.
+// A call running out of budget only fails its own fields, the rest of the power plant is returned.
+type ReadTimeoutConfig struct {
+	// Forecast is the budget of the weather forecast, 10s by default.
+	Forecast time.Duration `yaml:"forecast"`
+	// Elevation is the budget of the missing elevations, 5s by default.
+	Elevation time.Duration `yaml:"elevation"`
+}
+
+// Validate validates the read timeout configuration.
+func (c *ReadTimeoutConfig) Validate() error {
+	if c.Forecast < 0 || c.Elevation < 0 {
+		return errors.New("readtimeoutconfig durations must not be negative")
+	}
+	if c.Forecast == 0 {
+		c.Forecast = 10 * time.Second
+	}
+	if c.Elevation == 0 {
+		c.Elevation = 5 * time.Second
+	}
+	return nil
+}
+
+// WebhookConfig represents the configuration of the webhook dispatcher.
+type WebhookConfig struct {
+	// PollInterval is the time between two polls of the delivery queue, zero disables the dispatcher.
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// BatchSize is the number of deliveries claimed per poll, 10 by default.
+	BatchSize int `yaml:"batch_size"`
+	// Timeout bounds each delivery attempt, 10s by default.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts is the number of failed attempts after which a delivery is dead, 8 by default.
+	MaxAttempts int `yaml:"max_attempts"`
+	// InitialBackoff is the wait before the first retry, doubled for every retry after it, 30s by default.
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff caps the wait between two retries, 1h by default.
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// AllowPrivateNetworks lets the deliveries reach loopback, link-local and private addresses, e.g. for
+	// receivers on the same network. They are refused by default, so a subscription cannot reach internal services.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+}
+
+// Validate validates the webhook configuration.
+func (c *WebhookConfig) Validate() error {
+	if c.PollInterval < 0 || c.Timeout < 0 || c.InitialBackoff < 0 || c.MaxBackoff < 0 {
+		return errors.New("webhookconfig durations must not be negative")
+	}
+	if c.BatchSize < 0 || c.MaxAttempts < 0 {
+		return errors.New("webhookconfig batch_size and max_attempts must not be negative")
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 10
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = 8
+	}
+	if c.InitialBackoff == 0 {
+		c.InitialBackoff = 30 * time.Second
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = time.Hour
+	}
 	return nil
 }
+
+// AccuracyConfig represents the configuration of the forecast accuracy tracking.
+type AccuracyConfig struct {
+	// RunAt is the UTC time of day, as 15:04, the accuracy statistics are materialized at every night.
+	// Empty disables the tracking, the issued forecasts are not recorded either.
+	RunAt string `yaml:"run_at"`
+	// MaxLeadHours is the longest lead time of the recorded forecasts, 72 by default.
+	MaxLeadHours int `yaml:"max_lead_hours"`
+	// LeadBucketHours is the width of the lead time buckets of the statistics, 24 by default.
+	LeadBucketHours int `yaml:"lead_bucket_hours"`
+	// ObservationDelay is how long after a day its observations are complete in the weather archive,
+	// the day is compared once the delay is over, 5 days by default.
+	ObservationDelay time.Duration `yaml:"observation_delay"`
+	// BatchSize is the number of power plants per weather API call, 100 by default.
+	BatchSize int `yaml:"batch_size"`
+}
+
+// Validate validates the forecast accuracy configuration.
+func (c *AccuracyConfig) Validate() error {
+	if c.RunAt != "" {
+		if _, err := time.Parse("15:04", c.RunAt); err != nil {
+			return errors.New("accuracyconfig run_at must be a time of day as 15:04")
+		}
+	}
+	if c.MaxLeadHours < 0 || c.LeadBucketHours < 0 || c.BatchSize < 0 {
+		return errors.New("accuracyconfig max_lead_hours, lead_bucket_hours and batch_size must not be negative")
+	}
+	if c.ObservationDelay < 0 {
+		return errors.New("accuracyconfig observation_delay must not be negative")
+	}
+	if c.MaxLeadHours == 0 {
+		c.MaxLeadHours = 72
+	}
+	if c.MaxLeadHours > 16*24 {
+		return errors.New("accuracyconfig max_lead_hours must be at most 384, the longest forecast")
+	}
+	if c.LeadBucketHours == 0 {
+		c.LeadBucketHours = 24
+	}
+	if c.ObservationDelay == 0 {
+		c.ObservationDelay = 5 * 24 * time.Hour
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 100
+	}
+	return nil
+}
+
+// NextRun returns the first time of day RunAt strictly after now, in UTC.
+// It must only be called on a validated configuration with RunAt set.
+func (c AccuracyConfig) NextRun(now time.Time) time.Time {
+	at, _ := time.Parse("15:04", c.RunAt)
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// AuthConfig represents the configuration of the JWT authentication, besides the API keys of the organizations.
+// The tokens are signed with an HS256 secret or with the RS256 keys of a JSON Web Key Set, the JWTs are refused
+// when neither is set.
+type AuthConfig struct {
+	// HS256SecretEnv is the name of the environment variable holding the HS256 secret.
+	HS256SecretEnv string `yaml:"hs256_secret_env"`
+	// HS256SecretFile is the path of a file holding the HS256 secret, it takes precedence over HS256SecretEnv.
+	HS256SecretFile string `yaml:"hs256_secret_file"`
+	// JWKSFile is the path of the JSON Web Key Set with the RS256 public keys.
+	JWKSFile string `yaml:"jwks_file"`
+	// JWKSURL is the URL of the JSON Web Key Set, instead of JWKSFile. It is fetched at startup, and again
+	// when a token has an unknown key ID, at most once a minute.
+	JWKSURL string `yaml:"jwks_url"`
+	// Issuer and Audience are the iss and aud claims required from the tokens, empty to accept any.
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// Leeway is the clock skew allowed on the exp and nbf claims.
+	Leeway time.Duration `yaml:"leeway"`
+
+	// HS256Secret is resolved from HS256SecretFile or HS256SecretEnv by Validate, it is never read from the yaml file.
+	HS256Secret string `yaml:"-"`
+}
+
+// Validate validates the auth configuration.
+func (c *AuthConfig) Validate() error {
+	if c.JWKSFile != "" && c.JWKSURL != "" {
+		return errors.New("authconfig jwks_file and jwks_url are exclusive")
+	}
+	if c.Leeway < 0 {
+		return errors.New("authconfig leeway must not be negative")
+	}
+
+	switch {
+	case c.HS256SecretFile != "":
+		out, err := os.ReadFile(c.HS256SecretFile)
+		if err != nil {
+			return fmt.Errorf("authconfig hs256_secret_file: %w", err)
+		}
+		c.HS256Secret = strings.TrimSpace(string(out))
+		if c.HS256Secret == "" {
+			return errors.New("authconfig hs256_secret_file is empty")
+		}
+	case c.HS256SecretEnv != "":
+		// An unset variable is allowed, the HS256 tokens are then refused.
+		c.HS256Secret = strings.TrimSpace(os.Getenv(c.HS256SecretEnv))
+	}
+	return nil
+}
+
+// JWTEnabled returns true when the tokens can be verified, with an HS256 secret or a JSON Web Key Set.
+func (c AuthConfig) JWTEnabled() bool {
+	return c.HS256Secret != "" || c.JWKSFile != "" || c.JWKSURL != ""
+}
@@ -0,0 +1,17 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gcathelines/tensor-energy-case/internal/audit"
+)
+
+// AuditOperation is a root field middleware naming the mutations in the context as their operation,
+// so the audit log records which mutation made each change.
+func AuditOperation(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+	if field := graphql.GetRootFieldContext(ctx); field != nil && field.Object == "Mutation" {
+		ctx = audit.WithOperation(ctx, field.Field.Name)
+	}
+	return next(ctx)
+}
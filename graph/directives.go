@@ -0,0 +1,19 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gcathelines/tensor-energy-case/internal/auth"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// HasRole is the @hasRole directive, it resolves the field only for the principals with the role, see auth.Middleware.
+func HasRole(ctx context.Context, obj any, next graphql.Resolver, role types.Role) (any, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || !principal.HasRole(role) {
+		return nil, fmt.Errorf("%w, the %s role is required", types.ErrForbidden, role)
+	}
+	return next(ctx)
+}
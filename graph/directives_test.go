@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/gcathelines/tensor-energy-case/internal/auth"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+func TestHasRole(t *testing.T) {
+	// The resolvers are never reached by the refused queries, so they run without a usecase.
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{},
+		Directives: DirectiveRoot{HasRole: HasRole},
+	}))
+	srv.SetErrorPresenter(ErrorPresenter)
+
+	tests := []struct {
+		name  string
+		role  types.Role
+		query string
+	}{
+		{
+			name:  "viewer reads the Open-Meteo usage",
+			role:  types.RoleViewer,
+			query: `{ openMeteoUsage { window used } }`,
+		},
+		{
+			name:  "viewer reads the forecast cache stats",
+			role:  types.RoleViewer,
+			query: `{ forecastCacheStats { hits misses } }`,
+		},
+		{
+			name:  "editor reads the audit log",
+			role:  types.RoleEditor,
+			query: `{ auditLog { id } }`,
+		},
+		{
+			name:  "viewer creates a portfolio",
+			role:  types.RoleViewer,
+			query: `mutation { createPortfolio(input: {name: "Fund I"}) { id } }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := &auth.Principal{Subject: "alice", OrganizationID: 1, Roles: []types.Role{tt.role}}
+			c := client.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				srv.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+			}))
+
+			var resp map[string]any
+			err := c.Post(tt.query, &resp)
+			if err == nil || !strings.Contains(err.Error(), "FORBIDDEN") {
+				t.Fatalf("expected a FORBIDDEN error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestHasRole_Granted(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "Default", APIKey: true, Roles: []types.Role{types.RoleAdmin}})
+	next := func(ctx context.Context) (any, error) { return "resolved", nil }
+
+	got, err := HasRole(ctx, nil, next, types.RoleAdmin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "resolved" {
+		t.Fatalf("expected the field to be resolved, got: %v", got)
+	}
+
+	if _, err := HasRole(context.Background(), nil, next, types.RoleViewer); !errors.Is(err, types.ErrForbidden) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrForbidden, err)
+	}
+}
@@ -0,0 +1,31 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPresenter adds a code extension to the errors clients are expected to handle,
+// so they don't have to match on error messages.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var ambiguousPlaceErr *types.AmbiguousPlaceError
+	switch {
+	case errors.Is(err, types.ErrUpstreamRateLimited):
+		gqlErr.Extensions = map[string]any{"code": "UPSTREAM_RATE_LIMITED"}
+	case errors.Is(err, types.ErrForbidden):
+		gqlErr.Extensions = map[string]any{"code": "FORBIDDEN"}
+	case errors.As(err, &ambiguousPlaceErr):
+		gqlErr.Extensions = map[string]any{
+			"code":       "AMBIGUOUS_PLACE",
+			"candidates": ambiguousPlaceErr.Candidates,
+		}
+	}
+
+	return gqlErr
+}
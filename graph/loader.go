@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// loaderWait is how long a loader collects the fields resolved together, e.g. for every power plant of a page,
+// before loading them in one batch.
+const loaderWait = 5 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the resolvers of the fields of list elements within an operation.
+type loaders struct {
+	generationForecast *generationForecastLoader
+}
+
+// WithLoaders is an operation middleware giving each operation its loaders, see loaders.
+func (r *Resolver) WithLoaders(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	ctx = context.WithValue(ctx, loadersKey{}, &loaders{
+		generationForecast: &generationForecastLoader{load: r.usecase.GetGenerationForecasts},
+	})
+	return next(ctx)
+}
+
+// loadersFrom returns the loaders of the operation, nil outside of WithLoaders.
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
+
+// generationForecastLoader batches the generation forecasts of the power plants resolved within loaderWait,
+// so a page of power plants makes one batch of upstream calls instead of one per power plant.
+type generationForecastLoader struct {
+	load func(ctx context.Context, powerPlants []*types.PowerPlant) ([][]types.GenerationForecast, []error)
+
+	mu    sync.Mutex
+	batch *generationForecastBatch
+}
+
+type generationForecastBatch struct {
+	powerPlants []*types.PowerPlant
+	forecasts   [][]types.GenerationForecast
+	errs        []error
+	done        chan struct{}
+}
+
+// Load returns the generation forecast of the power plant, loaded with the other power plants of its batch.
+// The batch is loaded with the context of its first power plant.
+func (l *generationForecastLoader) Load(ctx context.Context, powerPlant *types.PowerPlant) ([]types.GenerationForecast, error) {
+	l.mu.Lock()
+	batch := l.batch
+	if batch == nil {
+		batch = &generationForecastBatch{done: make(chan struct{})}
+		l.batch = batch
+		time.AfterFunc(loaderWait, func() {
+			l.mu.Lock()
+			l.batch = nil
+			l.mu.Unlock()
+
+			batch.forecasts, batch.errs = l.load(ctx, batch.powerPlants)
+			close(batch.done)
+		})
+	}
+	i := len(batch.powerPlants)
+	batch.powerPlants = append(batch.powerPlants, powerPlant)
+	l.mu.Unlock()
+
+	select {
+	case <-batch.done:
+		return batch.forecasts[i], batch.errs[i]
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerationForecastLoader(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]int64
+	)
+	loader := &generationForecastLoader{load: func(ctx context.Context, powerPlants []*types.PowerPlant) ([][]types.GenerationForecast, []error) {
+		forecasts := make([][]types.GenerationForecast, len(powerPlants))
+		errs := make([]error, len(powerPlants))
+		ids := make([]int64, len(powerPlants))
+		for i, powerPlant := range powerPlants {
+			ids[i] = powerPlant.ID
+			forecasts[i] = []types.GenerationForecast{{Time: fmt.Sprint(powerPlant.ID)}}
+		}
+
+		mu.Lock()
+		batches = append(batches, ids)
+		mu.Unlock()
+		return forecasts, errs
+	}}
+
+	var wg sync.WaitGroup
+	for id := int64(1); id <= 3; id++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+
+			forecast, err := loader.Load(context.Background(), &types.PowerPlant{ID: id})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if diff := cmp.Diff([]types.GenerationForecast{{Time: fmt.Sprint(id)}}, forecast); diff != "" {
+				t.Errorf("unexpected forecast of power plant %d (-want +got):\n%s", id, diff)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("expected the power plants to be loaded in 1 batch, got: %v", batches)
+	}
+
+	// A later power plant starts a new batch.
+	if _, err := loader.Load(context.Background(), &types.PowerPlant{ID: 4}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got: %v", batches)
+	}
+}
@@ -2,13 +2,102 @@
 
 package graph
 
+import (
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+type CreateAlertRuleInput struct {
+	// ID of the power plant
+	PowerPlantID int64 `json:"powerPlantID"`
+	// Forecast variable compared to the threshold
+	Variable types.AlertVariable `json:"variable"`
+	// Comparison of the forecasted value to the threshold
+	Operator types.AlertOperator `json:"operator"`
+	// Threshold in the unit of the variable
+	Threshold float64 `json:"threshold"`
+	// How many hours ahead of now the forecast is watched, between 1 and 384
+	HorizonHours int `json:"horizonHours"`
+	// How the hourly values are combined before being compared to the threshold
+	Aggregation *types.AlertAggregation `json:"aggregation,omitempty"`
+}
+
+type CreateMaintenanceWindowInput struct {
+	// ID of the power plant, it must have a capacityMW
+	PowerPlantID int64     `json:"powerPlantID"`
+	Start        time.Time `json:"start"`
+	// End of the window, after start
+	End time.Time `json:"end"`
+	// Why the capacity is unavailable
+	Reason *string `json:"reason,omitempty"`
+	// Unavailable capacity in MW, at most the capacityMW of the power plant. Exactly one of unavailableMW and unavailablePercent is required.
+	UnavailableMw *float64 `json:"unavailableMW,omitempty"`
+	// Unavailable capacity in percent of the capacityMW of the power plant, at most 100
+	UnavailablePercent *float64 `json:"unavailablePercent,omitempty"`
+}
+
+type CreatePortfolioInput struct {
+	// Unique name of the portfolio, at most 100 characters
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
 type CreatePowerPlantInput struct {
 	// Name of the power plant
 	Name string `json:"name"`
-	// Latitude in degrees
-	Latitude float64 `json:"latitude"`
-	// Longitude in degrees
-	Longitude float64 `json:"longitude"`
+	// Latitude in degrees, required unless placeName is set
+	Latitude *float64 `json:"latitude,omitempty"`
+	// Longitude in degrees, required unless placeName is set
+	Longitude *float64 `json:"longitude,omitempty"`
+	// Place name resolved to coordinates when latitude and longitude are omitted.
+	// It can be narrowed down with the region or country after a comma, e.g. "Springfield, Illinois".
+	PlaceName *string `json:"placeName,omitempty"`
+	// Energy source of the power plant.
+	// SOLAR requires capacityMW and dcCapacityMW, WIND, HYDRO and STORAGE require capacityMW.
+	Type *types.PowerPlantType `json:"type,omitempty"`
+	// Nameplate capacity in MW, the AC capacity for solar power plants and the power capacity for storage
+	CapacityMw *float64 `json:"capacityMW,omitempty"`
+	// DC capacity of the panels in MW, between 0.8 and 2 times capacityMW, solar power plants only
+	DcCapacityMw *float64 `json:"dcCapacityMW,omitempty"`
+	// Orientation and losses of the panels, solar power plants only
+	SolarArray *types.SolarArrayConfig `json:"solarArray,omitempty"`
+	// River discharge in m³/s at which the power plant reaches its capacity, hydro power plants only
+	DesignDischargeM3s *float64 `json:"designDischargeM3s,omitempty"`
+	// Date the power plant started operating, not in the future for OPERATIONAL and DECOMMISSIONED power plants
+	CommissionedAt *time.Time `json:"commissionedAt,omitempty"`
+	// Lifecycle stage of the power plant
+	Status *types.PowerPlantStatus `json:"status,omitempty"`
+	// Company operating the power plant
+	Operator *string `json:"operator,omitempty"`
+	// Turbine model of the power plant, wind power plants only, requires turbineCount
+	TurbineModelID *int64 `json:"turbineModelID,omitempty"`
+	// Number of turbines of the turbine model, wind power plants only, requires turbineModelID
+	TurbineCount *int `json:"turbineCount,omitempty"`
+}
+
+type CreateTurbineModelInput struct {
+	// Name of the turbine model
+	Name string `json:"name"`
+	// Manufacturer of the turbine
+	Manufacturer *string `json:"manufacturer,omitempty"`
+	// Output of one turbine at the rated wind speed in kW
+	RatedPowerKw float64 `json:"ratedPowerKW"`
+	// Hub height wind speed in m/s from which the turbine produces
+	CutInSpeed float64 `json:"cutInSpeed"`
+	// Hub height wind speed in m/s from which the turbine shuts down, greater than cutInSpeed
+	CutOutSpeed float64 `json:"cutOutSpeed"`
+	// At least 2 points sorted by wind speed, at the standard air density, with outputs up to ratedPowerKW
+	PowerCurve []types.PowerCurvePoint `json:"powerCurve"`
+}
+
+type CreateWebhookSubscriptionInput struct {
+	// Absolute http or https URL the events are posted to
+	URL string `json:"url"`
+	// Types of the events posted to the URL, at least one
+	EventTypes []types.WebhookEventType `json:"eventTypes"`
+	// Secret signing the payloads, at least 16 characters, it is never returned
+	Secret string `json:"secret"`
 }
 
 type Mutation struct {
@@ -17,6 +106,41 @@ type Mutation struct {
 type Query struct {
 }
 
+type UpdateAlertRuleInput struct {
+	// ID of the alert rule
+	ID int64 `json:"id"`
+	// Forecast variable compared to the threshold
+	Variable *types.AlertVariable `json:"variable,omitempty"`
+	// Comparison of the forecasted value to the threshold
+	Operator *types.AlertOperator `json:"operator,omitempty"`
+	// Threshold in the unit of the variable
+	Threshold *float64 `json:"threshold,omitempty"`
+	// How many hours ahead of now the forecast is watched, between 1 and 384
+	HorizonHours *int `json:"horizonHours,omitempty"`
+	// How the hourly values are combined before being compared to the threshold
+	Aggregation *types.AlertAggregation `json:"aggregation,omitempty"`
+}
+
+type UpdateMaintenanceWindowInput struct {
+	// ID of the maintenance window
+	ID     int64      `json:"id"`
+	Start  *time.Time `json:"start,omitempty"`
+	End    *time.Time `json:"end,omitempty"`
+	Reason *string    `json:"reason,omitempty"`
+	// Unavailable capacity in MW, it clears unavailablePercent. At most one of unavailableMW and unavailablePercent is allowed.
+	UnavailableMw *float64 `json:"unavailableMW,omitempty"`
+	// Unavailable capacity in percent, it clears unavailableMW
+	UnavailablePercent *float64 `json:"unavailablePercent,omitempty"`
+}
+
+type UpdatePortfolioInput struct {
+	// ID of the portfolio
+	ID int64 `json:"id"`
+	// Unique name of the portfolio, at most 100 characters
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
 type UpdatePowerPlantInput struct {
 	// ID of the power plant
 	ID int64 `json:"id"`
@@ -26,4 +150,52 @@ type UpdatePowerPlantInput struct {
 	Latitude *float64 `json:"latitude,omitempty"`
 	// Longitude in degrees
 	Longitude *float64 `json:"longitude,omitempty"`
+	// Energy source of the power plant, the power plant is validated again against the rules of the type
+	Type *types.PowerPlantType `json:"type,omitempty"`
+	// Nameplate capacity in MW
+	CapacityMw *float64 `json:"capacityMW,omitempty"`
+	// DC capacity of the panels in MW, solar power plants only
+	DcCapacityMw *float64 `json:"dcCapacityMW,omitempty"`
+	// Orientation and losses of the panels, solar power plants only, replaces the whole solar array
+	SolarArray *types.SolarArrayConfig `json:"solarArray,omitempty"`
+	// River discharge in m³/s at which the power plant reaches its capacity, hydro power plants only
+	DesignDischargeM3s *float64 `json:"designDischargeM3s,omitempty"`
+	// Date the power plant started operating
+	CommissionedAt *time.Time `json:"commissionedAt,omitempty"`
+	// Lifecycle stage of the power plant
+	Status *types.PowerPlantStatus `json:"status,omitempty"`
+	// Company operating the power plant
+	Operator *string `json:"operator,omitempty"`
+	// Turbine model of the power plant, wind power plants only, requires turbineCount
+	TurbineModelID *int64 `json:"turbineModelID,omitempty"`
+	// Number of turbines of the turbine model, wind power plants only, requires turbineModelID
+	TurbineCount *int `json:"turbineCount,omitempty"`
+}
+
+type UpdateTurbineModelInput struct {
+	// ID of the turbine model
+	ID int64 `json:"id"`
+	// Name of the turbine model
+	Name *string `json:"name,omitempty"`
+	// Manufacturer of the turbine
+	Manufacturer *string `json:"manufacturer,omitempty"`
+	// Output of one turbine at the rated wind speed in kW
+	RatedPowerKw *float64 `json:"ratedPowerKW,omitempty"`
+	// Hub height wind speed in m/s from which the turbine produces
+	CutInSpeed *float64 `json:"cutInSpeed,omitempty"`
+	// Hub height wind speed in m/s from which the turbine shuts down
+	CutOutSpeed *float64 `json:"cutOutSpeed,omitempty"`
+	// Replaces the whole power curve
+	PowerCurve []types.PowerCurvePoint `json:"powerCurve,omitempty"`
+}
+
+type UpdateWebhookSubscriptionInput struct {
+	// ID of the webhook subscription
+	ID int64 `json:"id"`
+	// Absolute http or https URL the events are posted to, the queued deliveries are posted to the new URL
+	URL *string `json:"url,omitempty"`
+	// Types of the events posted to the URL, replaces the whole list
+	EventTypes []types.WebhookEventType `json:"eventTypes,omitempty"`
+	// Secret signing the payloads, the queued deliveries are signed with the new secret
+	Secret *string `json:"secret,omitempty"`
 }
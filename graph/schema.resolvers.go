@@ -6,32 +6,399 @@ package graph
 
 import (
 	"context"
+	"time"
 
 	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/gcathelines/tensor-energy-case/internal/usecase"
 )
 
 // CreatePowerPlant is the resolver for the createPowerPlant field.
 func (r *mutationResolver) CreatePowerPlant(ctx context.Context, input CreatePowerPlantInput) (*types.PowerPlant, error) {
-	return r.usecase.CreatePowerPlant(ctx, input.Name, input.Latitude, input.Longitude)
+	var (
+		lat, long float64
+		placeName string
+	)
+	if input.Latitude != nil {
+		lat = *input.Latitude
+	}
+	if input.Longitude != nil {
+		long = *input.Longitude
+	}
+	if input.PlaceName != nil {
+		placeName = *input.PlaceName
+	}
+
+	metadata := types.PowerPlantMetadata{
+		CapacityMW:         input.CapacityMw,
+		DCCapacityMW:       input.DcCapacityMw,
+		SolarArray:         input.SolarArray,
+		DesignDischargeM3s: input.DesignDischargeM3s,
+		CommissionedAt:     input.CommissionedAt,
+		Operator:           input.Operator,
+		TurbineModelID:     input.TurbineModelID,
+		TurbineCount:       input.TurbineCount,
+	}
+	if input.Type != nil {
+		metadata.Type = *input.Type
+	}
+	if input.Status != nil {
+		metadata.Status = *input.Status
+	}
+
+	return r.usecase.CreatePowerPlant(ctx, input.Name, lat, long, placeName, metadata)
 }
 
 // UpdatePowerPlant is the resolver for the updatePowerPlant field.
 func (r *mutationResolver) UpdatePowerPlant(ctx context.Context, input UpdatePowerPlantInput) (*types.PowerPlant, error) {
-	return r.usecase.UpdatePowerPlant(ctx, input.ID, input.Name, input.Latitude, input.Longitude)
+	return r.usecase.UpdatePowerPlant(ctx, input.ID, input.Name, input.Latitude, input.Longitude, types.PowerPlantMetadataUpdate{
+		Type:               input.Type,
+		CapacityMW:         input.CapacityMw,
+		DCCapacityMW:       input.DcCapacityMw,
+		SolarArray:         input.SolarArray,
+		DesignDischargeM3s: input.DesignDischargeM3s,
+		CommissionedAt:     input.CommissionedAt,
+		Status:             input.Status,
+		Operator:           input.Operator,
+		TurbineModelID:     input.TurbineModelID,
+		TurbineCount:       input.TurbineCount,
+	})
+}
+
+// SetPowerPlantElevation is the resolver for the setPowerPlantElevation field.
+func (r *mutationResolver) SetPowerPlantElevation(ctx context.Context, id int64, elevation *float64) (*types.PowerPlant, error) {
+	return r.usecase.SetPowerPlantElevation(ctx, id, elevation)
+}
+
+// AddPowerPlantTags is the resolver for the addPowerPlantTags field.
+func (r *mutationResolver) AddPowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	return r.usecase.AddPowerPlantTags(ctx, id, tags)
+}
+
+// RemovePowerPlantTags is the resolver for the removePowerPlantTags field.
+func (r *mutationResolver) RemovePowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	return r.usecase.RemovePowerPlantTags(ctx, id, tags)
+}
+
+// CreatePortfolio is the resolver for the createPortfolio field.
+func (r *mutationResolver) CreatePortfolio(ctx context.Context, input CreatePortfolioInput) (*types.Portfolio, error) {
+	return r.usecase.CreatePortfolio(ctx, types.Portfolio{
+		Name:        input.Name,
+		Description: input.Description,
+	})
+}
+
+// UpdatePortfolio is the resolver for the updatePortfolio field.
+func (r *mutationResolver) UpdatePortfolio(ctx context.Context, input UpdatePortfolioInput) (*types.Portfolio, error) {
+	return r.usecase.UpdatePortfolio(ctx, input.ID, types.PortfolioUpdate{
+		Name:        input.Name,
+		Description: input.Description,
+	})
+}
+
+// DeletePortfolio is the resolver for the deletePortfolio field.
+func (r *mutationResolver) DeletePortfolio(ctx context.Context, id int64) (bool, error) {
+	if err := r.usecase.DeletePortfolio(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AddPowerPlantsToPortfolio is the resolver for the addPowerPlantsToPortfolio field.
+func (r *mutationResolver) AddPowerPlantsToPortfolio(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (*types.Portfolio, error) {
+	return r.usecase.AddPowerPlantsToPortfolio(ctx, portfolioID, powerPlantIDs)
+}
+
+// RemovePowerPlantsFromPortfolio is the resolver for the removePowerPlantsFromPortfolio field.
+func (r *mutationResolver) RemovePowerPlantsFromPortfolio(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (*types.Portfolio, error) {
+	return r.usecase.RemovePowerPlantsFromPortfolio(ctx, portfolioID, powerPlantIDs)
+}
+
+// CreateTurbineModel is the resolver for the createTurbineModel field.
+func (r *mutationResolver) CreateTurbineModel(ctx context.Context, input CreateTurbineModelInput) (*types.TurbineModel, error) {
+	return r.usecase.CreateTurbineModel(ctx, types.TurbineModel{
+		Name:         input.Name,
+		Manufacturer: input.Manufacturer,
+		RatedPowerKW: input.RatedPowerKw,
+		CutInSpeed:   input.CutInSpeed,
+		CutOutSpeed:  input.CutOutSpeed,
+		PowerCurve:   input.PowerCurve,
+	})
+}
+
+// UpdateTurbineModel is the resolver for the updateTurbineModel field.
+func (r *mutationResolver) UpdateTurbineModel(ctx context.Context, input UpdateTurbineModelInput) (*types.TurbineModel, error) {
+	return r.usecase.UpdateTurbineModel(ctx, input.ID, types.TurbineModelUpdate{
+		Name:         input.Name,
+		Manufacturer: input.Manufacturer,
+		RatedPowerKW: input.RatedPowerKw,
+		CutInSpeed:   input.CutInSpeed,
+		CutOutSpeed:  input.CutOutSpeed,
+		PowerCurve:   input.PowerCurve,
+	})
+}
+
+// DeleteTurbineModel is the resolver for the deleteTurbineModel field.
+func (r *mutationResolver) DeleteTurbineModel(ctx context.Context, id int64) (bool, error) {
+	if err := r.usecase.DeleteTurbineModel(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateAlertRule is the resolver for the createAlertRule field.
+func (r *mutationResolver) CreateAlertRule(ctx context.Context, input CreateAlertRuleInput) (*types.AlertRule, error) {
+	rule := types.AlertRule{
+		PowerPlantID: input.PowerPlantID,
+		Variable:     input.Variable,
+		Operator:     input.Operator,
+		Threshold:    input.Threshold,
+		HorizonHours: input.HorizonHours,
+	}
+	if input.Aggregation != nil {
+		rule.Aggregation = *input.Aggregation
+	}
+
+	return r.usecase.CreateAlertRule(ctx, rule)
+}
+
+// UpdateAlertRule is the resolver for the updateAlertRule field.
+func (r *mutationResolver) UpdateAlertRule(ctx context.Context, input UpdateAlertRuleInput) (*types.AlertRule, error) {
+	return r.usecase.UpdateAlertRule(ctx, input.ID, types.AlertRuleUpdate{
+		Variable:     input.Variable,
+		Operator:     input.Operator,
+		Threshold:    input.Threshold,
+		HorizonHours: input.HorizonHours,
+		Aggregation:  input.Aggregation,
+	})
+}
+
+// DeleteAlertRule is the resolver for the deleteAlertRule field.
+func (r *mutationResolver) DeleteAlertRule(ctx context.Context, id int64) (bool, error) {
+	if err := r.usecase.DeleteAlertRule(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateMaintenanceWindow is the resolver for the createMaintenanceWindow field.
+func (r *mutationResolver) CreateMaintenanceWindow(ctx context.Context, input CreateMaintenanceWindowInput) (*types.MaintenanceWindow, error) {
+	window := types.MaintenanceWindow{
+		PowerPlantID:       input.PowerPlantID,
+		Start:              input.Start,
+		End:                input.End,
+		UnavailableMW:      input.UnavailableMw,
+		UnavailablePercent: input.UnavailablePercent,
+	}
+	if input.Reason != nil {
+		window.Reason = *input.Reason
+	}
+
+	return r.usecase.CreateMaintenanceWindow(ctx, window)
+}
+
+// UpdateMaintenanceWindow is the resolver for the updateMaintenanceWindow field.
+func (r *mutationResolver) UpdateMaintenanceWindow(ctx context.Context, input UpdateMaintenanceWindowInput) (*types.MaintenanceWindow, error) {
+	return r.usecase.UpdateMaintenanceWindow(ctx, input.ID, types.MaintenanceWindowUpdate{
+		Start:              input.Start,
+		End:                input.End,
+		Reason:             input.Reason,
+		UnavailableMW:      input.UnavailableMw,
+		UnavailablePercent: input.UnavailablePercent,
+	})
+}
+
+// DeleteMaintenanceWindow is the resolver for the deleteMaintenanceWindow field.
+func (r *mutationResolver) DeleteMaintenanceWindow(ctx context.Context, id int64) (bool, error) {
+	if err := r.usecase.DeleteMaintenanceWindow(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateWebhookSubscription is the resolver for the createWebhookSubscription field.
+func (r *mutationResolver) CreateWebhookSubscription(ctx context.Context, input CreateWebhookSubscriptionInput) (*types.WebhookSubscription, error) {
+	return r.usecase.CreateWebhookSubscription(ctx, types.WebhookSubscription{
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Secret:     input.Secret,
+	})
+}
+
+// UpdateWebhookSubscription is the resolver for the updateWebhookSubscription field.
+func (r *mutationResolver) UpdateWebhookSubscription(ctx context.Context, input UpdateWebhookSubscriptionInput) (*types.WebhookSubscription, error) {
+	return r.usecase.UpdateWebhookSubscription(ctx, input.ID, types.WebhookSubscriptionUpdate{
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Secret:     input.Secret,
+	})
+}
+
+// DeleteWebhookSubscription is the resolver for the deleteWebhookSubscription field.
+func (r *mutationResolver) DeleteWebhookSubscription(ctx context.Context, id int64) (bool, error) {
+	if err := r.usecase.DeleteWebhookSubscription(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RedeliverWebhook is the resolver for the redeliverWebhook field.
+func (r *mutationResolver) RedeliverWebhook(ctx context.Context, id int64) (*types.WebhookDelivery, error) {
+	return r.usecase.RedeliverWebhook(ctx, id)
+}
+
+// RecordProduction is the resolver for the recordProduction field.
+func (r *mutationResolver) RecordProduction(ctx context.Context, readings []types.ProductionReading) (*types.ProductionIngestResult, error) {
+	return r.usecase.RecordProduction(ctx, readings)
+}
+
+// PowerPlants is the resolver for the powerPlants field.
+func (r *portfolioResolver) PowerPlants(ctx context.Context, obj *types.Portfolio, lastID *int64, count *int, forecastDays *int) ([]types.PowerPlant, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	if forecastDays == nil {
+		defaultForecastDays := 7
+		forecastDays = &defaultForecastDays
+	}
+
+	filter := types.PowerPlantFilter{PortfolioID: &obj.ID}
+	return r.usecase.GetPowerPlantsByFilter(ctx, filter, *lastID, *count, *forecastDays)
+}
+
+// WeatherForecasts is the resolver for the weatherForecasts field.
+func (r *powerPlantResolver) WeatherForecasts(ctx context.Context, obj *types.PowerPlant, forecastDays *int, gapFillHours *int) ([]types.WeatherForecast, error) {
+	if obj.ForecastErr != nil {
+		return nil, obj.ForecastErr
+	}
+
+	if gapFillHours == nil {
+		defaultGapFillHours := 0
+		gapFillHours = &defaultGapFillHours
+	}
+
+	return usecase.FillForecastGaps(obj.WeatherForecasts, *gapFillHours)
+}
+
+// HasPrecipitationToday is the resolver for the hasPrecipitationToday field.
+func (r *powerPlantResolver) HasPrecipitationToday(ctx context.Context, obj *types.PowerPlant) (*bool, error) {
+	if obj.ForecastErr != nil {
+		return nil, obj.ForecastErr
+	}
+
+	return &obj.HasPrecipitationToday, nil
+}
+
+// Elevation is the resolver for the elevation field.
+func (r *powerPlantResolver) Elevation(ctx context.Context, obj *types.PowerPlant) (*float64, error) {
+	if obj.ElevationErr != nil && obj.ElevationOverride == nil {
+		return nil, obj.ElevationErr
+	}
+
+	return &obj.Elevation, nil
+}
+
+// DemElevation is the resolver for the demElevation field.
+func (r *powerPlantResolver) DemElevation(ctx context.Context, obj *types.PowerPlant) (*float64, error) {
+	if obj.ElevationErr != nil {
+		return nil, obj.ElevationErr
+	}
+
+	return obj.DEMElevation, nil
+}
+
+// TurbineModel is the resolver for the turbineModel field.
+func (r *powerPlantResolver) TurbineModel(ctx context.Context, obj *types.PowerPlant) (*types.TurbineModel, error) {
+	if obj.TurbineModelID == nil {
+		return nil, nil
+	}
+
+	return r.usecase.GetTurbineModel(ctx, *obj.TurbineModelID)
+}
+
+// GenerationForecast is the resolver for the generationForecast field.
+func (r *powerPlantResolver) GenerationForecast(ctx context.Context, obj *types.PowerPlant) ([]types.GenerationForecast, error) {
+	if loaders := loadersFrom(ctx); loaders != nil {
+		return loaders.generationForecast.Load(ctx, obj)
+	}
+	return r.usecase.GetGenerationForecast(ctx, obj)
+}
+
+// AlertRules is the resolver for the alertRules field.
+func (r *powerPlantResolver) AlertRules(ctx context.Context, obj *types.PowerPlant) ([]types.AlertRule, error) {
+	return r.usecase.GetAlertRules(ctx, obj.ID)
+}
+
+// ActiveAlerts is the resolver for the activeAlerts field.
+func (r *powerPlantResolver) ActiveAlerts(ctx context.Context, obj *types.PowerPlant) ([]types.Alert, error) {
+	return r.usecase.GetActiveAlerts(ctx, obj.ID)
+}
+
+// ProductionReadings is the resolver for the productionReadings field.
+func (r *powerPlantResolver) ProductionReadings(ctx context.Context, obj *types.PowerPlant, from time.Time, to time.Time) ([]types.ProductionReading, error) {
+	return r.usecase.GetProductionReadings(ctx, obj.ID, from, to)
+}
+
+// ForecastAccuracy is the resolver for the forecastAccuracy field.
+func (r *powerPlantResolver) ForecastAccuracy(ctx context.Context, obj *types.PowerPlant, days *int) ([]types.ForecastAccuracy, error) {
+	if days == nil {
+		defaultDays := 30
+		days = &defaultDays
+	}
+	return r.usecase.GetForecastAccuracy(ctx, obj.ID, *days)
+}
+
+// MaintenanceWindows is the resolver for the maintenanceWindows field.
+func (r *powerPlantResolver) MaintenanceWindows(ctx context.Context, obj *types.PowerPlant, from *time.Time, to *time.Time) ([]types.MaintenanceWindow, error) {
+	return r.usecase.GetMaintenanceWindows(ctx, obj.ID, from, to)
+}
+
+// Availability is the resolver for the availability field.
+func (r *powerPlantResolver) Availability(ctx context.Context, obj *types.PowerPlant, from time.Time, to time.Time) ([]types.AvailabilityPeriod, error) {
+	return r.usecase.GetAvailability(ctx, obj, from, to)
+}
+
+// Portfolios is the resolver for the portfolios field.
+func (r *powerPlantResolver) Portfolios(ctx context.Context, obj *types.PowerPlant) ([]types.Portfolio, error) {
+	return r.usecase.GetPowerPlantPortfolios(ctx, obj.ID)
+}
+
+// History is the resolver for the history field.
+func (r *powerPlantResolver) History(ctx context.Context, obj *types.PowerPlant) ([]types.PowerPlantVersion, error) {
+	return r.usecase.GetPowerPlantHistory(ctx, obj.ID)
+}
+
+// Organization is the resolver for the organization field.
+func (r *queryResolver) Organization(ctx context.Context) (*types.Organization, error) {
+	return r.usecase.GetOrganization(ctx)
 }
 
 // PowerPlant is the resolver for the powerPlant field.
-func (r *queryResolver) PowerPlant(ctx context.Context, id int64, forecastDays *int) (*types.PowerPlant, error) {
+func (r *queryResolver) PowerPlant(ctx context.Context, id int64, forecastDays *int, asOf *time.Time) (*types.PowerPlant, error) {
 	if forecastDays == nil {
 		defaultForecastDays := 7
 		forecastDays = &defaultForecastDays
 	}
 
+	if asOf != nil {
+		return r.usecase.GetPowerPlantAsOf(ctx, id, *asOf, *forecastDays)
+	}
 	return r.usecase.GetPowerPlant(ctx, id, *forecastDays)
 }
 
 // PowerPlants is the resolver for the powerPlants field.
-func (r *queryResolver) PowerPlants(ctx context.Context, lastID *int64, count *int, forecastDays *int) ([]types.PowerPlant, error) {
+func (r *queryResolver) PowerPlants(ctx context.Context, lastID *int64, count *int, forecastDays *int, portfolioID *int64, tags []string, asOf *time.Time) ([]types.PowerPlant, error) {
 	if lastID == nil {
 		defaultLastID := int64(0)
 		lastID = &defaultLastID
@@ -47,14 +414,187 @@ func (r *queryResolver) PowerPlants(ctx context.Context, lastID *int64, count *i
 		forecastDays = &defaultForecastDays
 	}
 
+	if portfolioID != nil || len(tags) > 0 || asOf != nil {
+		filter := types.PowerPlantFilter{PortfolioID: portfolioID, Tags: tags, AsOf: asOf}
+		return r.usecase.GetPowerPlantsByFilter(ctx, filter, *lastID, *count, *forecastDays)
+	}
 	return r.usecase.GetPowerPlants(ctx, *lastID, *count, *forecastDays)
 }
 
+// Portfolio is the resolver for the portfolio field.
+func (r *queryResolver) Portfolio(ctx context.Context, id int64) (*types.Portfolio, error) {
+	return r.usecase.GetPortfolio(ctx, id)
+}
+
+// Portfolios is the resolver for the portfolios field.
+func (r *queryResolver) Portfolios(ctx context.Context, lastID *int64, count *int) ([]types.Portfolio, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	return r.usecase.GetPortfolios(ctx, *lastID, *count)
+}
+
+// Geocode is the resolver for the geocode field.
+func (r *queryResolver) Geocode(ctx context.Context, query string, count *int) ([]types.Location, error) {
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	return r.usecase.Geocode(ctx, query, *count)
+}
+
+// OpenMeteoUsage is the resolver for the openMeteoUsage field.
+func (r *queryResolver) OpenMeteoUsage(ctx context.Context) ([]types.RateLimitWindow, error) {
+	return r.usecase.GetWeatherAPIUsage(), nil
+}
+
+// ForecastCacheStats is the resolver for the forecastCacheStats field.
+func (r *queryResolver) ForecastCacheStats(ctx context.Context) (*types.CacheStats, error) {
+	stats := r.usecase.GetForecastCacheStats()
+	return &stats, nil
+}
+
+// TurbineModel is the resolver for the turbineModel field.
+func (r *queryResolver) TurbineModel(ctx context.Context, id int64) (*types.TurbineModel, error) {
+	return r.usecase.GetTurbineModel(ctx, id)
+}
+
+// TurbineModels is the resolver for the turbineModels field.
+func (r *queryResolver) TurbineModels(ctx context.Context, lastID *int64, count *int) ([]types.TurbineModel, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	return r.usecase.GetTurbineModels(ctx, *lastID, *count)
+}
+
+// AlertRule is the resolver for the alertRule field.
+func (r *queryResolver) AlertRule(ctx context.Context, id int64) (*types.AlertRule, error) {
+	return r.usecase.GetAlertRule(ctx, id)
+}
+
+// MaintenanceWindow is the resolver for the maintenanceWindow field.
+func (r *queryResolver) MaintenanceWindow(ctx context.Context, id int64) (*types.MaintenanceWindow, error) {
+	return r.usecase.GetMaintenanceWindow(ctx, id)
+}
+
+// Alerts is the resolver for the alerts field.
+func (r *queryResolver) Alerts(ctx context.Context, powerPlantID *int64, lastID *int64, count *int) ([]types.Alert, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	return r.usecase.GetAlerts(ctx, powerPlantID, *lastID, *count)
+}
+
+// AuditLog is the resolver for the auditLog field.
+func (r *queryResolver) AuditLog(ctx context.Context, entityType *types.AuditEntityType, entityID *int64, actor *string, from *time.Time, to *time.Time, lastID *int64, count *int) ([]types.AuditEvent, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	filter := types.AuditEventFilter{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Actor:      actor,
+		From:       from,
+		To:         to,
+	}
+	return r.usecase.GetAuditLog(ctx, filter, *lastID, *count)
+}
+
+// WebhookSubscriptions is the resolver for the webhookSubscriptions field.
+func (r *queryResolver) WebhookSubscriptions(ctx context.Context, lastID *int64, count *int) ([]types.WebhookSubscription, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	return r.usecase.GetWebhookSubscriptions(ctx, *lastID, *count)
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, subscriptionID *int64, status *types.WebhookDeliveryStatus, lastID *int64, count *int) ([]types.WebhookDelivery, error) {
+	if lastID == nil {
+		defaultLastID := int64(0)
+		lastID = &defaultLastID
+	}
+
+	if count == nil {
+		defaultCount := 10
+		count = &defaultCount
+	}
+
+	return r.usecase.GetWebhookDeliveries(ctx, subscriptionID, status, *lastID, *count)
+}
+
+// PortfolioSummary is the resolver for the portfolioSummary field.
+func (r *queryResolver) PortfolioSummary(ctx context.Context, filter *types.PowerPlantFilter, from time.Time, to time.Time, granularity *types.Granularity) (*types.PortfolioSummary, error) {
+	if filter == nil {
+		filter = &types.PowerPlantFilter{}
+	}
+
+	if granularity == nil {
+		defaultGranularity := types.GranularityDay
+		granularity = &defaultGranularity
+	}
+
+	return r.usecase.GetPortfolioSummary(ctx, *filter, from, to, *granularity)
+}
+
+// Log is the resolver for the log field.
+func (r *webhookDeliveryResolver) Log(ctx context.Context, obj *types.WebhookDelivery) ([]types.WebhookDeliveryAttempt, error) {
+	return r.usecase.GetWebhookDeliveryAttempts(ctx, obj.ID)
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Portfolio returns PortfolioResolver implementation.
+func (r *Resolver) Portfolio() PortfolioResolver { return &portfolioResolver{r} }
+
+// PowerPlant returns PowerPlantResolver implementation.
+func (r *Resolver) PowerPlant() PowerPlantResolver { return &powerPlantResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// WebhookDelivery returns WebhookDeliveryResolver implementation.
+func (r *Resolver) WebhookDelivery() WebhookDeliveryResolver { return &webhookDeliveryResolver{r} }
+
 type mutationResolver struct{ *Resolver }
+type portfolioResolver struct{ *Resolver }
+type powerPlantResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type webhookDeliveryResolver struct{ *Resolver }
@@ -0,0 +1,79 @@
+// Package audit carries who makes a change, and as part of which request, in the context of a call.
+//
+// The database sets them on the session before each statement, and a trigger records them with every change
+// in the audit_events table, see database.Open.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// RequestIDHeader is the header holding the ID of a request, generated when the request has none.
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength is the longest request ID kept from a request, a longer one is replaced.
+	maxRequestIDLength = 128
+)
+
+type (
+	actorKey     struct{}
+	operationKey struct{}
+	requestIDKey struct{}
+)
+
+// WithActor returns a copy of the context acting as the actor, e.g. the authenticated caller.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor of the context, empty if it has none.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// WithOperation returns a copy of the context running the operation, e.g. the name of a mutation.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// Operation returns the operation of the context, empty if it has none.
+func Operation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// WithRequestID returns a copy of the context serving the request of the ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID of the context, empty if it has none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Middleware serves the requests with next, with the request ID of their X-Request-ID header in the context,
+// or a random one when the header is missing or too long. The request ID is sent back in the same header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+	})
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	// crypto/rand.Read does not fail on the supported platforms.
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{
+			name:      "keeps the request ID",
+			requestID: "req-42",
+		},
+		{
+			name:      "generates a missing request ID",
+			generated: true,
+		},
+		{
+			name:      "replaces a too long request ID",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+			generated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestID string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestID = RequestID(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			Middleware(next).ServeHTTP(rec, req)
+
+			if tt.generated && (len(requestID) != 32 || requestID == tt.requestID) {
+				t.Fatalf("expected a generated request ID, got: %s", requestID)
+			}
+			if !tt.generated && requestID != tt.requestID {
+				t.Fatalf("expected request ID %s, got: %s", tt.requestID, requestID)
+			}
+			if header := rec.Header().Get(RequestIDHeader); header != requestID {
+				t.Fatalf("expected the request ID %s in the response, got: %s", requestID, header)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package auth authenticates the callers of the HTTP endpoints.
+//
+// A caller sends either a bearer JWT in the Authorization header, see JWTVerifier, or the API key of its
+// organization in the X-API-Key header. Its request then acts for the organization of its credentials,
+// see the tenant package, with the roles of its principal. An API key has every role in its organization.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gcathelines/tensor-energy-case/internal/audit"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/gcathelines/tensor-energy-case/internal/usecase"
+)
+
+const (
+	// APIKeyHeader is the header holding the API key of a request.
+	APIKeyHeader = "X-API-Key"
+	// bearerScheme is the scheme of the Authorization header holding a JWT.
+	bearerScheme = "Bearer "
+)
+
+var _ authenticator = (*usecase.Usecase)(nil)
+
+type authenticator interface {
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (*types.Organization, error)
+}
+
+// Middleware authenticates the requests by their bearer JWT, checked by verifier, or by their API key, and serves
+// them with next acting for the organization of the principal, as its actor. verifier is nil when JWTs are disabled.
+// A request without valid credentials gets a 401 response.
+func Middleware(authenticator authenticator, verifier *JWTVerifier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var principal *Principal
+		switch authorization, apiKey := r.Header.Get("Authorization"), r.Header.Get(APIKeyHeader); {
+		case authorization != "":
+			if len(authorization) < len(bearerScheme) || !strings.EqualFold(authorization[:len(bearerScheme)], bearerScheme) {
+				writeError(w, http.StatusUnauthorized, "the Authorization header must hold a bearer token")
+				return
+			}
+			if verifier == nil {
+				writeError(w, http.StatusUnauthorized, "bearer tokens are not accepted, set the "+APIKeyHeader+" header")
+				return
+			}
+
+			var err error
+			principal, err = verifier.Verify(strings.TrimSpace(authorization[len(bearerScheme):]))
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+		case apiKey != "":
+			organization, err := authenticator.AuthenticateAPIKey(r.Context(), apiKey)
+			switch {
+			case err == nil:
+			case errors.Is(err, types.ErrInvalidAPIKey):
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			default:
+				// The error may come from the database, it is not shown to the unauthenticated caller.
+				log.Printf("error authenticating API key: %v", err)
+				writeError(w, http.StatusInternalServerError, types.ErrInternal.Error())
+				return
+			}
+
+			principal = &Principal{
+				Subject:        organization.Name,
+				APIKey:         true,
+				OrganizationID: organization.ID,
+				Roles:          []types.Role{types.RoleAdmin},
+			}
+		default:
+			writeError(w, http.StatusUnauthorized, "missing credentials, set the Authorization header to a bearer token or the "+APIKeyHeader+" header")
+			return
+		}
+
+		ctx := tenant.WithOrganization(r.Context(), principal.OrganizationID)
+		ctx = audit.WithActor(ctx, principal.Actor())
+		ctx = WithPrincipal(ctx, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: msg})
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/audit"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// fakeAuthenticator knows the API key "key-7" of the organization 7, and fails with a database error on "broken".
+type fakeAuthenticator struct{}
+
+func (fakeAuthenticator) AuthenticateAPIKey(ctx context.Context, apiKey string) (*types.Organization, error) {
+	switch apiKey {
+	case "key-7":
+		return &types.Organization{ID: 7, Name: "Trading"}, nil
+	case "broken":
+		return nil, errors.New("pq: password authentication failed for user \"user\"")
+	}
+	return nil, types.ErrInvalidAPIKey
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier := newTestVerifier(t)
+	valid := signHS256(t, map[string]any{"sub": "alice", "org": 7, "roles": []string{"editor"}, "exp": time.Now().Add(time.Hour).Unix()})
+	expired := signHS256(t, map[string]any{"sub": "alice", "org": 7, "exp": time.Now().Add(-time.Hour).Unix()})
+
+	tests := []struct {
+		name                 string
+		apiKey               string
+		authorization        string
+		verifier             *JWTVerifier
+		expectedStatus       int
+		expectedError        string
+		expectedOrganization int64
+		expectedActor        string
+		expectedRole         types.Role
+	}{
+		{
+			name:           "missing credentials",
+			verifier:       verifier,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "missing credentials, set the Authorization header to a bearer token or the X-API-Key header",
+		},
+		{
+			name:           "invalid API key",
+			apiKey:         "key-8",
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid API key",
+		},
+		{
+			name:           "authenticator error",
+			apiKey:         "broken",
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  types.ErrInternal.Error(),
+		},
+		{
+			name:                 "valid API key",
+			apiKey:               "key-7",
+			expectedStatus:       http.StatusOK,
+			expectedOrganization: 7,
+			expectedActor:        "api-key:Trading",
+			expectedRole:         types.RoleAdmin,
+		},
+		{
+			name:                 "valid token",
+			authorization:        "Bearer " + valid,
+			verifier:             verifier,
+			expectedStatus:       http.StatusOK,
+			expectedOrganization: 7,
+			expectedActor:        "user:alice",
+			expectedRole:         types.RoleEditor,
+		},
+		{
+			name:           "token takes precedence over the API key",
+			authorization:  "Bearer " + expired,
+			apiKey:         "key-7",
+			verifier:       verifier,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  ErrExpiredToken.Error(),
+		},
+		{
+			name:           "missing token",
+			authorization:  "Bearer ",
+			verifier:       verifier,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "malformed token: expected 3 parts separated by dots",
+		},
+		{
+			name:           "malformed token",
+			authorization:  "Bearer not-a-token",
+			verifier:       verifier,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "malformed token: expected 3 parts separated by dots",
+		},
+		{
+			name:           "not a bearer token",
+			authorization:  "Basic dXNlcjpwYXNz",
+			verifier:       verifier,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "the Authorization header must hold a bearer token",
+		},
+		{
+			name:           "tokens disabled",
+			authorization:  "Bearer " + valid,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "bearer tokens are not accepted, set the X-API-Key header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var organizationID int64
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, ok := tenant.OrganizationID(r.Context())
+				if !ok {
+					t.Fatal("expected the request to act for an organization")
+				}
+				organizationID = id
+				if actor := audit.Actor(r.Context()); actor != tt.expectedActor {
+					t.Fatalf("expected actor: %q, got: %q", tt.expectedActor, actor)
+				}
+				principal, ok := PrincipalFromContext(r.Context())
+				if !ok || !principal.HasRole(tt.expectedRole) || principal.HasRole(types.RoleAdmin) != (tt.expectedRole == types.RoleAdmin) {
+					t.Fatalf("expected a principal with the %s role, got: %+v", tt.expectedRole, principal)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			Middleware(fakeAuthenticator{}, tt.verifier, next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+			if organizationID != tt.expectedOrganization {
+				t.Fatalf("expected organization %d, got %d", tt.expectedOrganization, organizationID)
+			}
+			if tt.expectedError == "" {
+				return
+			}
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if body.Error != tt.expectedError {
+				t.Fatalf("expected error: %s, got: %s", tt.expectedError, body.Error)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *Principal
+		expectedStatus int
+	}{
+		{
+			name:           "role granted",
+			principal:      &Principal{Subject: "alice", Roles: []types.Role{types.RoleViewer, types.RoleEditor}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "role granted by a higher role",
+			principal:      &Principal{Subject: "Trading", APIKey: true, Roles: []types.Role{types.RoleAdmin}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "role missing",
+			principal:      &Principal{Subject: "bob", Roles: []types.Role{types.RoleViewer}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "not authenticated",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(http.MethodPost, "/production", nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			RequireRole(types.RoleEditor, next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
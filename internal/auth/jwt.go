@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+var (
+	ErrMalformedToken = errors.New("malformed token")
+	ErrExpiredToken   = errors.New("token is expired")
+	// ErrInvalidToken is returned for a well formed token that is not accepted, e.g. with a wrong signature.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+const (
+	// jwksTimeout is the timeout of the request fetching the JSON Web Key Set from its URL.
+	jwksTimeout = 10 * time.Second
+	// jwksRefreshInterval is the shortest time between two fetches of the JSON Web Key Set from its URL.
+	// A token with an unknown key ID fetches it again, e.g. after a key rotation, at most that often so
+	// the tokens with made up key IDs cannot flood the issuer.
+	jwksRefreshInterval = time.Minute
+)
+
+// JWTVerifier verifies the bearer JWTs of the requests, signed with HS256 or RS256.
+//
+// The tokens must have a sub claim, the subject, an exp claim, and an org claim with the ID of the organization
+// they act for. Their roles claim lists their roles, e.g. ["editor"], a token without any known role is a viewer.
+type JWTVerifier struct {
+	hs256Secret []byte
+	// jwksURL is the URL the RS256 public keys are fetched from, empty when they are read from a file.
+	jwksURL string
+
+	// mu guards rs256Keys and fetchedAt.
+	mu sync.RWMutex
+	// rs256Keys are the RS256 public keys by key ID, see loadJWKS.
+	rs256Keys map[string]*rsa.PublicKey
+	// fetchedAt is the time the JSON Web Key Set was last fetched from jwksURL.
+	fetchedAt time.Time
+	// refreshMu serializes the fetches of the JSON Web Key Set.
+	refreshMu sync.Mutex
+
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWTVerifier returns a verifier of the tokens signed with the HS256 secret or the keys of the JSON Web Key Set
+// of the configuration. The JSON Web Key Set is fetched now when it is configured by URL, and again when a token
+// has an unknown key ID.
+func NewJWTVerifier(ctx context.Context, cfg config.AuthConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		jwksURL:  cfg.JWKSURL,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+		now:      time.Now,
+	}
+	if cfg.HS256Secret != "" {
+		v.hs256Secret = []byte(cfg.HS256Secret)
+	}
+
+	var (
+		jwks []byte
+		err  error
+	)
+	switch {
+	case cfg.JWKSFile != "":
+		jwks, err = os.ReadFile(cfg.JWKSFile)
+	case cfg.JWKSURL != "":
+		jwks, err = fetchJWKS(ctx, cfg.JWKSURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading the JSON Web Key Set: %w", err)
+	}
+	if jwks != nil {
+		if v.rs256Keys, err = loadJWKS(jwks); err != nil {
+			return nil, fmt.Errorf("error loading the JSON Web Key Set: %w", err)
+		}
+		v.fetchedAt = v.now()
+	}
+	return v, nil
+}
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jwtClaims are the claims of a token read by the verifier.
+type jwtClaims struct {
+	Subject        string   `json:"sub"`
+	Issuer         string   `json:"iss"`
+	Audience       audience `json:"aud"`
+	ExpiresAt      *float64 `json:"exp"`
+	NotBefore      *float64 `json:"nbf"`
+	OrganizationID int64    `json:"org"`
+	Roles          []string `json:"roles"`
+}
+
+// audience is the aud claim, a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var single string
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		*a = audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// Verify checks the signature and the claims of the token, and returns its principal.
+// It returns ErrMalformedToken, ErrExpiredToken or ErrInvalidToken, wrapped with the reason, when the token is refused.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts separated by dots", ErrMalformedToken)
+	}
+
+	var (
+		header jwtHeader
+		claims jwtClaims
+	)
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformedToken, err)
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrMalformedToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformedToken, err)
+	}
+
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+	if err := v.verifyClaims(claims); err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{Subject: claims.Subject, OrganizationID: claims.OrganizationID, Roles: []types.Role{types.RoleViewer}}
+	for _, name := range claims.Roles {
+		if role := types.Role(strings.ToUpper(name)); role.IsValid() && !slices.Contains(principal.Roles, role) {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+	return principal, nil
+}
+
+// verifySignature checks the signature of the signing input with the key of the algorithm of the header.
+// The HS256 tokens are only checked with the secret and the RS256 ones with the public keys, so a public key
+// cannot be used as an HMAC secret.
+func (v *JWTVerifier) verifySignature(header jwtHeader, signingInput string, signature []byte) error {
+	switch header.Algorithm {
+	case "HS256":
+		if v.hs256Secret == nil {
+			return fmt.Errorf("%w: HS256 tokens are not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.hs256Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("%w: wrong signature", ErrInvalidToken)
+		}
+		return nil
+	case "RS256":
+		key, err := v.rs256Key(header.KeyID)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: wrong signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Algorithm)
+}
+
+// rs256Key returns the public key of the key ID, which may be empty when the JSON Web Key Set has a single key.
+// An unknown key ID refreshes the JSON Web Key Set fetched from its URL, see refreshJWKS.
+func (v *JWTVerifier) rs256Key(keyID string) (*rsa.PublicKey, error) {
+	key, err := v.lookupRS256Key(keyID)
+	if err == nil || v.jwksURL == "" || !v.refreshJWKS() {
+		return key, err
+	}
+	return v.lookupRS256Key(keyID)
+}
+
+func (v *JWTVerifier) lookupRS256Key(keyID string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if len(v.rs256Keys) == 0 {
+		return nil, fmt.Errorf("%w: RS256 tokens are not accepted", ErrInvalidToken)
+	}
+	if keyID == "" && len(v.rs256Keys) == 1 {
+		for _, key := range v.rs256Keys {
+			return key, nil
+		}
+	}
+	key, ok := v.rs256Keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key ID %q", ErrInvalidToken, keyID)
+	}
+	return key, nil
+}
+
+// refreshJWKS fetches the JSON Web Key Set from its URL again, unless it was fetched less than jwksRefreshInterval ago.
+// It returns true if the keys were replaced. A failed fetch keeps the current keys.
+func (v *JWTVerifier) refreshJWKS() bool {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	v.mu.RLock()
+	fetchedAt := v.fetchedAt
+	v.mu.RUnlock()
+	now := v.now()
+	if now.Sub(fetchedAt) < jwksRefreshInterval {
+		return false
+	}
+
+	v.mu.Lock()
+	v.fetchedAt = now
+	v.mu.Unlock()
+
+	jwks, err := fetchJWKS(context.Background(), v.jwksURL)
+	if err != nil {
+		log.Printf("error refreshing the JSON Web Key Set: %v", err)
+		return false
+	}
+	keys, err := loadJWKS(jwks)
+	if err != nil {
+		log.Printf("error refreshing the JSON Web Key Set: %v", err)
+		return false
+	}
+
+	v.mu.Lock()
+	v.rs256Keys = keys
+	v.mu.Unlock()
+	return true
+}
+
+func (v *JWTVerifier) verifyClaims(claims jwtClaims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if !now.Before(numericDate(*claims.ExpiresAt).Add(v.leeway)) {
+		return ErrExpiredToken
+	}
+	if claims.NotBefore != nil && now.Add(v.leeway).Before(numericDate(*claims.NotBefore)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	if claims.OrganizationID <= 0 {
+		return fmt.Errorf("%w: missing org claim", ErrInvalidToken)
+	}
+	return nil
+}
+
+// numericDate returns the time of a JWT NumericDate, in seconds since the epoch.
+func numericDate(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// jsonWebKey is a key of a JSON Web Key Set, see RFC 7517.
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+// loadJWKS returns the RSA signature keys of the JSON Web Key Set by key ID, the other keys are skipped.
+func loadJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Algorithm != "" && jwk.Algorithm != "RS256") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: modulus: %w", jwk.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: exponent: %w", jwk.KeyID, err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("key %q: unsupported exponent", jwk.KeyID)
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 signature key")
+	}
+	return keys, nil
+}
+
+func fetchJWKS(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, jwksTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testHS256Secret = "test-secret"
+
+// testRSAKey signs the RS256 tokens of the tests, its public key is in the JSON Web Key Set of newTestVerifier.
+var testRSAKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// newTestVerifier returns a verifier of the HS256 tokens of testHS256Secret and of the RS256 tokens of testRSAKey,
+// with the key ID "test-key", loaded from a JSON Web Key Set file.
+func newTestVerifier(t *testing.T) *JWTVerifier {
+	t.Helper()
+
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ignored", "crv": "P-256"},
+		{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(testRSAKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(testRSAKey.E)).Bytes()),
+		},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifier, err := NewJWTVerifier(context.Background(), config.AuthConfig{
+		HS256Secret: testHS256Secret,
+		JWKSFile:    path,
+		Issuer:      "https://issuer.test",
+		Leeway:      time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return verifier
+}
+
+func signHS256(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	claims = withIssuer(claims)
+	signingInput := encodeSegment(t, map[string]any{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(testHS256Secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, keyID string, claims map[string]any) string {
+	t.Helper()
+
+	claims = withIssuer(claims)
+	signingInput := encodeSegment(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": keyID}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// withIssuer sets the issuer expected by newTestVerifier on the claims without one.
+func withIssuer(claims map[string]any) map[string]any {
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = "https://issuer.test"
+	}
+	return claims
+}
+
+// withSignatureOf returns the token with the signature of the other token.
+func withSignatureOf(token, other string) string {
+	return token[:strings.LastIndex(token, ".")] + other[strings.LastIndex(other, "."):]
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	verifier := newTestVerifier(t)
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name      string
+		token     string
+		expected  *Principal
+		expectErr error
+	}{
+		{
+			name:     "valid HS256 token",
+			token:    signHS256(t, map[string]any{"sub": "alice", "org": 7, "roles": []string{"editor", "unknown"}, "exp": exp}),
+			expected: &Principal{Subject: "alice", OrganizationID: 7, Roles: []types.Role{types.RoleViewer, types.RoleEditor}},
+		},
+		{
+			name:     "valid RS256 token",
+			token:    signRS256(t, "test-key", map[string]any{"sub": "bob", "org": 2, "roles": []string{"ADMIN"}, "exp": exp}),
+			expected: &Principal{Subject: "bob", OrganizationID: 2, Roles: []types.Role{types.RoleViewer, types.RoleAdmin}},
+		},
+		{
+			name:     "RS256 token without key ID, the key set has a single key",
+			token:    signRS256(t, "", map[string]any{"sub": "bob", "org": 2, "exp": exp}),
+			expected: &Principal{Subject: "bob", OrganizationID: 2, Roles: []types.Role{types.RoleViewer}},
+		},
+		{
+			name:     "expired within the leeway",
+			token:    signHS256(t, map[string]any{"sub": "alice", "org": 7, "exp": time.Now().Add(-30 * time.Second).Unix()}),
+			expected: &Principal{Subject: "alice", OrganizationID: 7, Roles: []types.Role{types.RoleViewer}},
+		},
+		{
+			name:      "expired token",
+			token:     signHS256(t, map[string]any{"sub": "alice", "org": 7, "exp": time.Now().Add(-2 * time.Minute).Unix()}),
+			expectErr: ErrExpiredToken,
+		},
+		{
+			name:      "missing exp claim",
+			token:     signHS256(t, map[string]any{"sub": "alice", "org": 7}),
+			expectErr: ErrInvalidToken,
+		},
+		{
+			name:      "not valid yet",
+			token:     signHS256(t, map[string]any{"sub": "alice", "org": 7, "exp": exp, "nbf": time.Now().Add(10 * time.Minute).Unix()}),
+			expectErr: ErrInvalidToken,
+		},
+		{
+			name:      "missing token",
+			token:     "",
+			expectErr: ErrMalformedToken,
+		},
+		{
+			name:      "malformed token",
+			token:     "a.b",
+			expectErr: ErrMalformedToken,
+		},
+		{
+			name:      "malformed header",
+			token:     "not-base64!." + encodeSegment(t, map[string]any{"sub": "alice"}) + ".c2ln",
+			expectErr: ErrMalformedToken,
+		},
+		{
+			name:      "malformed claims",
+			token:     encodeSegment(t, map[string]any{"alg": "HS256"}) + "." + base64.RawURLEncoding.EncodeToString([]byte("{")) + ".c2ln",
+			expectErr: ErrMalformedToken,
+		},
+		{
+			name:      "wrong signature",
+			token:     withSignatureOf(signHS256(t, map[string]any{"sub": "alice", "org": 7, "exp": exp}), signHS256(t, map[string]any{"sub": "mallory", "org": 7, "exp": exp})),
+			expectErr: ErrInvalidToken,
+		},
+		{
+			name:      "unsigned token",
+			token:     encodeSegment(t, map[string]any{"alg": "none"}) + "." + encodeSegment(t, map[string]any{"sub": "alice", "org": 7, "exp": exp}) + ".",
+			expectErr: ErrInvalidToken,
+		},
+		{
+			name:      "unknown key ID",
+			token:     signRS256(t, "other-key", map[string]any{"sub": "bob", "org": 2, "exp": exp}),
+			expectErr: ErrInvalidToken,
+		},
+		{
+			name:      "unexpected issuer",
+			token:     signHS256(t, map[string]any{"sub": "alice", "org": 7, "exp": exp, "iss": "https://other.test"}),
+			expectErr: ErrInvalidToken,
+		},
+		{
+			name:      "missing organization",
+			token:     signHS256(t, map[string]any{"sub": "alice", "exp": exp}),
+			expectErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(tt.token)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, principal); diff != "" {
+				t.Fatalf("unexpected principal (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_HS256Disabled(t *testing.T) {
+	verifier, err := NewJWTVerifier(context.Background(), config.AuthConfig{Audience: "api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token := signHS256(t, map[string]any{"sub": "alice", "org": 7, "aud": "api", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := verifier.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected error: %v, got: %v", ErrInvalidToken, err)
+	}
+}
+
+func TestJWTVerifier_JWKSRotation(t *testing.T) {
+	jwks := func(keyID string) []byte {
+		data, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(testRSAKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(testRSAKey.E)).Bytes()),
+		}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return data
+	}
+
+	var (
+		requests atomic.Int32
+		served   atomic.Value
+	)
+	served.Store(jwks("key-1"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(served.Load().([]byte))
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(context.Background(), config.AuthConfig{JWKSURL: server.URL, Issuer: "https://issuer.test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+	exp := now.Add(time.Hour).Unix()
+
+	// The issuer rotates its key right after the startup, the new key ID is only fetched after the refresh interval.
+	served.Store(jwks("key-2"))
+	if _, err := verifier.Verify(signRS256(t, "key-2", map[string]any{"sub": "bob", "org": 2, "exp": exp})); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected error: %v, got: %v", ErrInvalidToken, err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected 1 request within the refresh interval, got: %d", got)
+	}
+
+	now = now.Add(jwksRefreshInterval)
+	if _, err := verifier.Verify(signRS256(t, "key-2", map[string]any{"sub": "bob", "org": 2, "exp": exp})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := verifier.Verify(signRS256(t, "key-3", map[string]any{"sub": "bob", "org": 2, "exp": exp})); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected error: %v, got: %v", ErrInvalidToken, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("expected 2 requests, got: %d", got)
+	}
+}
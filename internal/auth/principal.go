@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject is the sub claim of a JWT, or the name of the organization of an API key.
+	Subject string
+	// APIKey is true for a caller authenticated by the API key of its organization.
+	APIKey         bool
+	OrganizationID int64
+	Roles          []types.Role
+}
+
+// Actor returns the actor of the changes made by the principal, see the audit package.
+func (p *Principal) Actor() string {
+	if p.APIKey {
+		return "api-key:" + p.Subject
+	}
+	return "user:" + p.Subject
+}
+
+// HasRole returns true if one of the roles of the principal grants the role.
+func (p *Principal) HasRole(role types.Role) bool {
+	for _, r := range p.Roles {
+		if r.Grants(role) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of the context authenticated as the principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the context, false if it is not authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// RequireRole serves the requests with next when their principal has the role, see Middleware.
+// The other requests get a 403 response.
+func RequireRole(role types.Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok || !principal.HasRole(role) {
+			writeError(w, http.StatusForbidden, types.ErrForbidden.Error()+", the "+string(role)+" role is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// alertRuleColumns are the columns scanned by scanAlertRule, in order.
+const alertRuleColumns = `id, power_plant_id, variable, operator, threshold, horizon_hours, aggregation,
+	created_at, updated_at`
+
+// alertColumns are the columns scanned by scanAlert, in order.
+const alertColumns = `id, rule_id, power_plant_id, variable, operator, threshold, aggregation,
+	forecast_time, value, triggered_at, last_seen_at, resolved_at`
+
+// CreateAlertRule creates a new alert rule in the database.
+// It returns types.ErrPowerPlantNotFound when the power plant does not exist.
+func (d *Database) CreateAlertRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error) {
+	query := `INSERT INTO alert_rules (power_plant_id, variable, operator, threshold, horizon_hours, aggregation)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING ` + alertRuleColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		rule.PowerPlantID,
+		rule.Variable,
+		rule.Operator,
+		rule.Threshold,
+		rule.HorizonHours,
+		rule.Aggregation,
+	)
+
+	created, err := scanAlertRule(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return nil, types.ErrPowerPlantNotFound
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateAlertRule updates an existing alert rule in the database, the power plant of a rule does not change.
+func (d *Database) UpdateAlertRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error) {
+	query := `UPDATE alert_rules
+	SET variable = $1, operator = $2, threshold = $3, horizon_hours = $4, aggregation = $5,
+	updated_at = NOW()
+	WHERE id = $6
+	RETURNING ` + alertRuleColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		rule.Variable,
+		rule.Operator,
+		rule.Threshold,
+		rule.HorizonHours,
+		rule.Aggregation,
+		rule.ID,
+	)
+
+	return scanAlertRule(row)
+}
+
+// DeleteAlertRule deletes the alert rule with the given ID.
+// Its active alert is resolved, and its alerts are kept in the history without a rule.
+func (d *Database) DeleteAlertRule(ctx context.Context, id int64) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE alerts SET resolved_at = NOW() WHERE rule_id = $1 AND resolved_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// GetAlertRule returns the alert rule with the given ID.
+func (d *Database) GetAlertRule(ctx context.Context, id int64) (*types.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + `
+	FROM alert_rules WHERE id = $1`
+
+	row := d.db.QueryRowContext(ctx, query, id)
+
+	return scanAlertRule(row)
+}
+
+// GetAlertRules returns the alert rules of the given power plants, ordered by ID in ascending order.
+func (d *Database) GetAlertRules(ctx context.Context, powerPlantIDs []int64) ([]types.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + `
+	FROM alert_rules WHERE power_plant_id = ANY($1)
+	ORDER BY id`
+
+	rows, err := d.db.QueryContext(ctx, query, pq.Array(powerPlantIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []types.AlertRule{}
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, *rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// RecordAlert records a triggered alert rule. The active alert of the rule is updated with the
+// latest forecast if there is one, otherwise a new alert is created.
+// It returns the recorded alert, a new one has its LastSeenAt equal to its TriggeredAt.
+func (d *Database) RecordAlert(ctx context.Context, alert *types.Alert) (*types.Alert, error) {
+	query := `INSERT INTO alerts (rule_id, power_plant_id, variable, operator, threshold, aggregation,
+			forecast_time, value, triggered_at, last_seen_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+			ON CONFLICT (rule_id) WHERE resolved_at IS NULL DO UPDATE
+			SET variable = EXCLUDED.variable, operator = EXCLUDED.operator, threshold = EXCLUDED.threshold,
+			aggregation = EXCLUDED.aggregation, forecast_time = EXCLUDED.forecast_time, value = EXCLUDED.value,
+			last_seen_at = EXCLUDED.last_seen_at
+			RETURNING ` + alertColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		alert.RuleID,
+		alert.PowerPlantID,
+		alert.Variable,
+		alert.Operator,
+		alert.Threshold,
+		alert.Aggregation,
+		alert.ForecastTime,
+		alert.Value,
+		alert.TriggeredAt,
+	)
+
+	return scanAlert(row)
+}
+
+// ResolveAlert resolves the active alert of the rule, if there is one.
+// It returns the resolved alert, nil when the rule had no active alert.
+func (d *Database) ResolveAlert(ctx context.Context, ruleID int64, resolvedAt time.Time) (*types.Alert, error) {
+	query := `UPDATE alerts SET resolved_at = $1 WHERE rule_id = $2 AND resolved_at IS NULL
+	RETURNING ` + alertColumns
+
+	alert, err := scanAlert(d.db.QueryRowContext(ctx, query, resolvedAt, ruleID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return alert, err
+}
+
+// GetActiveAlerts returns the active alerts of the power plant, ordered by ID in ascending order.
+func (d *Database) GetActiveAlerts(ctx context.Context, powerPlantID int64) ([]types.Alert, error) {
+	query := `SELECT ` + alertColumns + `
+	FROM alerts WHERE power_plant_id = $1 AND resolved_at IS NULL
+	ORDER BY id`
+
+	return d.queryAlerts(ctx, query, powerPlantID)
+}
+
+// GetAlerts returns the active and resolved alerts with the given last ID and count,
+// of every power plant when powerPlantID is nil. The alerts are ordered by ID in ascending order.
+func (d *Database) GetAlerts(ctx context.Context, powerPlantID *int64, lastID int64, count int) ([]types.Alert, error) {
+	query := `SELECT ` + alertColumns + `
+	FROM alerts WHERE id > $1 AND ($2::BIGINT IS NULL OR power_plant_id = $2)
+	ORDER BY id
+	FETCH FIRST $3 ROWS ONLY`
+
+	return d.queryAlerts(ctx, query, lastID, powerPlantID, count)
+}
+
+func (d *Database) queryAlerts(ctx context.Context, query string, args ...any) ([]types.Alert, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []types.Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// scanAlertRule scans a row selected with alertRuleColumns.
+func scanAlertRule(row scanner) (*types.AlertRule, error) {
+	var (
+		data      types.AlertRule
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.PowerPlantID,
+		&data.Variable,
+		&data.Operator,
+		&data.Threshold,
+		&data.HorizonHours,
+		&data.Aggregation,
+		&data.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if updatedAt.Valid {
+		data.UpdatedAt = updatedAt.Time
+	}
+
+	return &data, nil
+}
+
+// scanAlert scans a row selected with alertColumns.
+func scanAlert(row scanner) (*types.Alert, error) {
+	var (
+		data       types.Alert
+		ruleID     sql.NullInt64
+		resolvedAt sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&ruleID,
+		&data.PowerPlantID,
+		&data.Variable,
+		&data.Operator,
+		&data.Threshold,
+		&data.Aggregation,
+		&data.ForecastTime,
+		&data.Value,
+		&data.TriggeredAt,
+		&data.LastSeenAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if ruleID.Valid {
+		data.RuleID = &ruleID.Int64
+	}
+	if resolvedAt.Valid {
+		data.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &data, nil
+}
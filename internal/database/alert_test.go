@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDatabase_AlertRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:      "alerted plant",
+		Latitude:  46.5,
+		Longitude: 8.1,
+		PowerPlantMetadata: types.PowerPlantMetadata{
+			Type:   types.PowerPlantTypeOther,
+			Status: types.PowerPlantStatusOperational,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = testDB.CreateAlertRule(ctx, &types.AlertRule{
+		PowerPlantID: -1,
+		Variable:     types.AlertVariableWindSpeed,
+		Operator:     types.AlertOperatorGreaterThan,
+		Threshold:    90,
+		HorizonHours: 48,
+		Aggregation:  types.AlertAggregationNone,
+	})
+	if !errors.Is(err, types.ErrPowerPlantNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPowerPlantNotFound, err)
+	}
+
+	rule, err := testDB.CreateAlertRule(ctx, &types.AlertRule{
+		PowerPlantID: powerPlant.ID,
+		Variable:     types.AlertVariableWindSpeed,
+		Operator:     types.AlertOperatorGreaterThan,
+		Threshold:    90,
+		HorizonHours: 48,
+		Aggregation:  types.AlertAggregationNone,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.ID == 0 || rule.CreatedAt.Equal(time.Time{}) {
+		t.Fatalf("expected id and created at to be set, got: %+v", rule)
+	}
+
+	rule.Variable = types.AlertVariablePrecipitation
+	rule.Threshold = 20
+	rule.Aggregation = types.AlertAggregationDailySum
+	updated, err := testDB.UpdateAlertRule(ctx, rule)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.UpdatedAt.Equal(time.Time{}) {
+		t.Fatalf("expected updated at to be set, got: %v", updated.UpdatedAt)
+	}
+
+	rules, err := testDB.GetAlertRules(ctx, []int64{powerPlant.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]types.AlertRule{*rule}, rules, cmpopts.IgnoreFields(types.AlertRule{}, "CreatedAt", "UpdatedAt")); diff != "" {
+		t.Fatalf("unexpected alert rules (-want +got):\n%s", diff)
+	}
+
+	// The second evaluation updates the active alert instead of recording a new one.
+	firstSeen := time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)
+	lastSeen := firstSeen.Add(time.Hour)
+	for i, seenAt := range []time.Time{firstSeen, lastSeen} {
+		recorded, err := testDB.RecordAlert(ctx, &types.Alert{
+			RuleID:       &rule.ID,
+			PowerPlantID: powerPlant.ID,
+			Variable:     rule.Variable,
+			Operator:     rule.Operator,
+			Threshold:    rule.Threshold,
+			Aggregation:  rule.Aggregation,
+			ForecastTime: "2024-09-07",
+			Value:        25 + float64(i),
+			TriggeredAt:  seenAt,
+			LastSeenAt:   seenAt,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if isNew := recorded.LastSeenAt.Equal(recorded.TriggeredAt); isNew != (i == 0) {
+			t.Fatalf("expected a new alert: %v, got: %+v", i == 0, recorded)
+		}
+	}
+
+	active, err := testDB.GetActiveAlerts(ctx, powerPlant.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 1 {
+		t.Fatalf("expected 1 active alert, got: %+v", active)
+	}
+	expected := types.Alert{
+		ID:           active[0].ID,
+		RuleID:       &rule.ID,
+		PowerPlantID: powerPlant.ID,
+		Variable:     types.AlertVariablePrecipitation,
+		Operator:     types.AlertOperatorGreaterThan,
+		Threshold:    20,
+		Aggregation:  types.AlertAggregationDailySum,
+		ForecastTime: "2024-09-07",
+		Value:        26,
+		TriggeredAt:  firstSeen,
+		LastSeenAt:   lastSeen,
+	}
+	if diff := cmp.Diff(expected, active[0], cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+		t.Fatalf("unexpected active alert (-want +got):\n%s", diff)
+	}
+
+	resolved, err := testDB.ResolveAlert(ctx, rule.ID, lastSeen.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved == nil || resolved.ID != expected.ID || resolved.ResolvedAt == nil {
+		t.Fatalf("expected alert %d to be resolved, got: %+v", expected.ID, resolved)
+	}
+	resolved, err = testDB.ResolveAlert(ctx, rule.ID, lastSeen.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved != nil {
+		t.Fatalf("expected no active alert to resolve, got: %+v", resolved)
+	}
+	active, err = testDB.GetActiveAlerts(ctx, powerPlant.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 0 {
+		t.Fatalf("expected no active alert, got: %+v", active)
+	}
+
+	// A new evaluation meeting the condition triggers a new alert.
+	_, err = testDB.RecordAlert(ctx, &types.Alert{
+		RuleID:       &rule.ID,
+		PowerPlantID: powerPlant.ID,
+		Variable:     rule.Variable,
+		Operator:     rule.Operator,
+		Threshold:    rule.Threshold,
+		Aggregation:  rule.Aggregation,
+		ForecastTime: "2024-09-08",
+		Value:        30,
+		TriggeredAt:  lastSeen.Add(2 * time.Hour),
+		LastSeenAt:   lastSeen.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Deleting the rule resolves its alert and keeps the history.
+	if err := testDB.DeleteAlertRule(ctx, rule.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := testDB.DeleteAlertRule(ctx, rule.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+	if _, err := testDB.GetAlertRule(ctx, rule.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+
+	history, err := testDB.GetAlerts(ctx, &powerPlant.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 alerts, got: %+v", history)
+	}
+	for _, alert := range history {
+		if alert.RuleID != nil || alert.ResolvedAt == nil {
+			t.Fatalf("expected a resolved alert without rule, got: %+v", alert)
+		}
+	}
+
+	page, err := testDB.GetAlerts(ctx, nil, history[0].ID, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != history[1].ID {
+		t.Fatalf("expected alert %d, got: %+v", history[1].ID, page)
+	}
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// auditEventColumns are the columns scanned by scanAuditEvent, in order.
+const auditEventColumns = `id, occurred_at, actor, action, operation, entity_type, entity_id, changes, request_id`
+
+// GetAuditEvents returns the audit events matching the filter, ordered by ID.
+// The events are written by the audit_change trigger in the transaction of the change, never by the service.
+func (d *Database) GetAuditEvents(ctx context.Context, filter types.AuditEventFilter, lastID int64, count int) ([]types.AuditEvent, error) {
+	query := `SELECT ` + auditEventColumns + `
+	FROM audit_events
+	WHERE id > $1
+		AND ($2::TIMESTAMP IS NULL OR occurred_at >= $2)
+		AND ($3::TIMESTAMP IS NULL OR occurred_at < $3)
+		AND ($4::VARCHAR IS NULL OR entity_type = $4)
+		AND ($5::BIGINT IS NULL OR entity_id = $5)
+		AND ($6::VARCHAR IS NULL OR actor = $6)
+	ORDER BY id
+	FETCH FIRST $7 ROWS ONLY`
+
+	// occurred_at has no time zone, the bounds are compared in UTC.
+	var from, to *time.Time
+	if filter.From != nil {
+		utc := filter.From.UTC()
+		from = &utc
+	}
+	if filter.To != nil {
+		utc := filter.To.UTC()
+		to = &utc
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, lastID, from, to, filter.EntityType, filter.EntityID, filter.Actor, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []types.AuditEvent{}
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, *event)
+	}
+
+	return events, rows.Err()
+}
+
+// scanAuditEvent scans a row selected with auditEventColumns.
+func scanAuditEvent(row scanner) (*types.AuditEvent, error) {
+	var (
+		data      types.AuditEvent
+		operation sql.NullString
+		requestID sql.NullString
+		changes   []byte
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.OccurredAt,
+		&data.Actor,
+		&data.Action,
+		&operation,
+		&data.EntityType,
+		&data.EntityID,
+		&changes,
+		&requestID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if operation.Valid {
+		data.Operation = &operation.String
+	}
+	if requestID.Valid {
+		data.RequestID = &requestID.String
+	}
+
+	data.Changes, err = decodeAuditChanges(changes)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// decodeAuditChanges decodes the changes written by the audit_change trigger, a JSON object of the before and
+// after values keyed by column, into changes sorted by field. A JSON null value is decoded as nil.
+func decodeAuditChanges(data []byte) ([]types.AuditChange, error) {
+	var columns map[string]struct {
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	}
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return nil, err
+	}
+
+	changes := make([]types.AuditChange, 0, len(columns))
+	for field, values := range columns {
+		changes = append(changes, types.AuditChange{
+			Field:  field,
+			Before: rawJSONValue(values.Before),
+			After:  rawJSONValue(values.After),
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// rawJSONValue returns the JSON value as a string, nil for a missing or null value.
+func rawJSONValue(value json.RawMessage) *string {
+	if len(value) == 0 || string(value) == "null" {
+		return nil
+	}
+	str := string(value)
+	return &str
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/audit"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDatabase_AuditEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	actorCtx := audit.WithRequestID(audit.WithOperation(audit.WithActor(ctx, "api-key:tests"), "createPowerPlant"), "request-1")
+	powerPlant, err := testDB.CreatePowerPlant(actorCtx, &types.PowerPlant{
+		Name:               "audited plant",
+		Latitude:           46.5,
+		Longitude:          8.1,
+		PowerPlantMetadata: defaultMetadata,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The changes made without an actor are recorded as the system.
+	if err := testDB.UpdatePowerPlantElevation(ctx, powerPlant.ID, 1200); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	powerPlantType := types.AuditEntityPowerPlant
+	filter := types.AuditEventFilter{EntityType: &powerPlantType, EntityID: &powerPlant.ID}
+	events, err := testDB.GetAuditEvents(ctx, filter, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 audit events, got: %+v", events)
+	}
+
+	created := events[0]
+	if created.Actor != "api-key:tests" || created.Action != types.AuditActionCreate ||
+		created.Operation == nil || *created.Operation != "createPowerPlant" ||
+		created.RequestID == nil || *created.RequestID != "request-1" {
+		t.Fatalf("unexpected created event: %+v", created)
+	}
+	var name *types.AuditChange
+	for i, change := range created.Changes {
+		if change.Field == "name" {
+			name = &created.Changes[i]
+		}
+	}
+	if diff := cmp.Diff(&types.AuditChange{Field: "name", After: ptr(`"audited plant"`)}, name); diff != "" {
+		t.Fatalf("unexpected name change (-want +got):\n%s", diff)
+	}
+
+	updated := events[1]
+	if updated.Actor != "system" || updated.Action != types.AuditActionUpdate || updated.Operation != nil || updated.RequestID != nil {
+		t.Fatalf("unexpected updated event: %+v", updated)
+	}
+	if diff := cmp.Diff([]types.AuditChange{{Field: "elevation", After: ptr("1200")}}, updated.Changes); diff != "" {
+		t.Fatalf("unexpected changes (-want +got):\n%s", diff)
+	}
+
+	actor := "api-key:tests"
+	events, err = testDB.GetAuditEvents(ctx, types.AuditEventFilter{EntityID: &powerPlant.ID, Actor: &actor}, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != created.ID {
+		t.Fatalf("expected the created event, got: %+v", events)
+	}
+
+	// The bounds of the time range are the same instants in any time zone.
+	offset := time.FixedZone("", 2*60*60)
+	from, to := created.OccurredAt.In(offset), updated.OccurredAt.Add(time.Second).In(offset)
+	events, err = testDB.GetAuditEvents(ctx, types.AuditEventFilter{EntityID: &powerPlant.ID, From: &from, To: &to}, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected the 2 audit events in the time range, got: %+v", events)
+	}
+
+	// The production readings are audited as changes of their power plant.
+	readingTime := time.Date(2024, 9, 6, 10, 0, 0, 0, time.UTC)
+	_, _, err = testDB.RecordProductionReadings(actorCtx, []types.ProductionReading{
+		{PowerPlantID: powerPlant.ID, Time: readingTime, PowerMW: 12.5, Quality: types.ProductionQualityMeasured},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readingType := types.AuditEntityProductionReading
+	events, err = testDB.GetAuditEvents(ctx, types.AuditEventFilter{EntityType: &readingType, EntityID: &powerPlant.ID}, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != types.AuditActionCreate || events[0].Actor != "api-key:tests" {
+		t.Fatalf("expected the created production reading event, got: %+v", events)
+	}
+
+	// The audit events are append-only, and hidden from the other organizations.
+	if _, err := testDB.db.ExecContext(ctx, `UPDATE audit_events SET actor = 'forged' WHERE id = $1`, created.ID); err == nil {
+		t.Fatal("expected the update of an audit event to be refused")
+	}
+	if _, err := testDB.db.ExecContext(ctx, `DELETE FROM audit_events WHERE id = $1`, created.ID); err == nil {
+		t.Fatal("expected the deletion of an audit event to be refused")
+	}
+
+	other := createTestOrganization("other audit tests")
+	events, err = testDB.GetAuditEvents(tenant.WithOrganization(ctx, other.ID), filter, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected the audit events of another organization to be hidden, got: %+v", events)
+	}
+}
@@ -3,10 +3,18 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
 )
 
+// powerPlantColumns are the columns scanned by scanPowerPlant, in order.
+const powerPlantColumns = `id, name, latitude, longitude, elevation, elevation_override,
+	type, capacity_mw, dc_capacity_mw, solar_array, design_discharge_m3s, turbine_model_id, turbine_count,
+	commissioned_at, status, operator, tags, created_at, updated_at`
+
 // Database represents the database repository.
 type Database struct {
 	db *sql.DB
@@ -22,160 +30,387 @@ func NewDatabase(db *sql.DB) *Database {
 // CreatePowerPlant creates a new power plant in the database.
 // This function returns the created power plant with the generated ID.
 func (d *Database) CreatePowerPlant(ctx context.Context, powerPlant *types.PowerPlant) (*types.PowerPlant, error) {
-	query := `INSERT INTO power_plants (name, latitude, longitude) 
-			VALUES ($1, $2, $3)
-			RETURNING id, name, latitude, longitude, created_at`
+	solarArray, err := marshalSolarArray(powerPlant.SolarArray)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO power_plants (name, latitude, longitude, elevation, elevation_override,
+			type, capacity_mw, dc_capacity_mw, solar_array, design_discharge_m3s, turbine_model_id, turbine_count,
+			commissioned_at, status, operator) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			RETURNING ` + powerPlantColumns
 
 	rows := d.db.QueryRowContext(ctx, query,
 		powerPlant.Name,
 		powerPlant.Latitude,
 		powerPlant.Longitude,
+		powerPlant.DEMElevation,
+		powerPlant.ElevationOverride,
+		powerPlant.Type,
+		powerPlant.CapacityMW,
+		powerPlant.DCCapacityMW,
+		solarArray,
+		powerPlant.DesignDischargeM3s,
+		powerPlant.TurbineModelID,
+		powerPlant.TurbineCount,
+		powerPlant.CommissionedAt,
+		powerPlant.Status,
+		powerPlant.Operator,
 	)
 
-	var data types.PowerPlant
-	err := rows.Scan(
-		&data.ID,
-		&data.Name,
-		&data.Latitude,
-		&data.Longitude,
-		&data.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &data, nil
+	return scanPowerPlant(rows)
 }
 
 // UpdatePowerPlant updates an existing power plant in the database.
 func (d *Database) UpdatePowerPlant(ctx context.Context, powerPlant *types.PowerPlant) (*types.PowerPlant, error) {
+	solarArray, err := marshalSolarArray(powerPlant.SolarArray)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `UPDATE power_plants 
-	SET name = $1, latitude = $2, longitude = $3, updated_at = NOW()
-	WHERE id = $4
-	RETURNING id, name, latitude, longitude, created_at, updated_at`
+	SET name = $1, latitude = $2, longitude = $3, elevation = $4, elevation_override = $5,
+	type = $6, capacity_mw = $7, dc_capacity_mw = $8, solar_array = $9, design_discharge_m3s = $10,
+	turbine_model_id = $11, turbine_count = $12, commissioned_at = $13, status = $14, operator = $15,
+	updated_at = NOW()
+	WHERE id = $16
+	RETURNING ` + powerPlantColumns
 
 	rows := d.db.QueryRowContext(ctx, query,
 		powerPlant.Name,
 		powerPlant.Latitude,
 		powerPlant.Longitude,
+		powerPlant.DEMElevation,
+		powerPlant.ElevationOverride,
+		powerPlant.Type,
+		powerPlant.CapacityMW,
+		powerPlant.DCCapacityMW,
+		solarArray,
+		powerPlant.DesignDischargeM3s,
+		powerPlant.TurbineModelID,
+		powerPlant.TurbineCount,
+		powerPlant.CommissionedAt,
+		powerPlant.Status,
+		powerPlant.Operator,
 		powerPlant.ID,
 	)
 
-	var data types.PowerPlant
-	err := rows.Scan(
-		&data.ID,
-		&data.Name,
-		&data.Latitude,
-		&data.Longitude,
-		&data.CreatedAt,
-		&data.UpdatedAt,
-	)
+	return scanPowerPlant(rows)
+}
+
+// UpdatePowerPlantElevation sets the elevation from the elevation API of a power plant.
+// It is not a change made by a user, so updated_at is left untouched.
+func (d *Database) UpdatePowerPlantElevation(ctx context.Context, id int64, elevation float64) error {
+	query := `UPDATE power_plants SET elevation = $1 WHERE id = $2`
+
+	res, err := d.db.ExecContext(ctx, query, elevation, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &data, nil
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AddPowerPlantTags adds the tags to the power plant, the tags it already has are kept once.
+func (d *Database) AddPowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	query := `UPDATE power_plants
+	SET tags = ARRAY(SELECT DISTINCT tag FROM unnest(tags || $2::VARCHAR[]) AS tag ORDER BY tag),
+	updated_at = NOW()
+	WHERE id = $1
+	RETURNING ` + powerPlantColumns
+
+	row := d.db.QueryRowContext(ctx, query, id, pq.Array(tags))
+
+	return scanPowerPlant(row)
+}
+
+// RemovePowerPlantTags removes the tags from the power plant, the tags it does not have are ignored.
+func (d *Database) RemovePowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	query := `UPDATE power_plants
+	SET tags = ARRAY(SELECT tag FROM unnest(tags) AS tag WHERE tag <> ALL($2::VARCHAR[]) ORDER BY tag),
+	updated_at = NOW()
+	WHERE id = $1
+	RETURNING ` + powerPlantColumns
+
+	row := d.db.QueryRowContext(ctx, query, id, pq.Array(tags))
+
+	return scanPowerPlant(row)
 }
 
 // GetPowerPlant returns the power plant with the given ID.
 func (d *Database) GetPowerPlant(ctx context.Context, id int64) (*types.PowerPlant, error) {
-	query := `SELECT id, name, latitude, longitude, created_at, updated_at
+	query := `SELECT ` + powerPlantColumns + `
 	FROM power_plants WHERE id = $1`
 
 	rows := d.db.QueryRowContext(ctx, query, id)
 
-	var (
-		data      types.PowerPlant
-		updatedAt sql.NullTime
-	)
-	err := rows.Scan(
-		&data.ID,
-		&data.Name,
-		&data.Latitude,
-		&data.Longitude,
-		&data.CreatedAt,
-		&updatedAt,
-	)
+	return scanPowerPlant(rows)
+}
+
+// GetPowerPlantAsOf returns the version of the power plant with the given ID valid at the given time,
+// sql.ErrNoRows when it did not exist yet.
+func (d *Database) GetPowerPlantAsOf(ctx context.Context, id int64, asOf time.Time) (*types.PowerPlant, error) {
+	query := `SELECT ` + powerPlantColumns + `
+	FROM ` + powerPlantsAsOf("$2") + ` WHERE id = $1`
+
+	rows := d.db.QueryRowContext(ctx, query, id, asOf.UTC())
+
+	return scanPowerPlant(rows)
+}
+
+// GetPowerPlantHistory returns every version of the power plant with the given ID, oldest first.
+func (d *Database) GetPowerPlantHistory(ctx context.Context, id int64) ([]types.PowerPlantVersion, error) {
+	query := `SELECT ` + powerPlantColumns + `, valid_from, valid_to
+	FROM power_plants_history WHERE id = $1
+	ORDER BY valid_from`
+
+	rows, err := d.db.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	if updatedAt.Valid {
-		data.UpdatedAt = updatedAt.Time
+	versions := []types.PowerPlantVersion{}
+	for rows.Next() {
+		var (
+			version types.PowerPlantVersion
+			validTo sql.NullTime
+		)
+		powerPlant, err := scanPowerPlant(versionScanner{row: rows, dest: []any{&version.ValidFrom, &validTo}})
+		if err != nil {
+			return nil, err
+		}
+		version.PowerPlant = *powerPlant
+		if validTo.Valid {
+			version.ValidTo = &validTo.Time
+		}
+
+		versions = append(versions, version)
 	}
 
-	return &data, nil
+	return versions, rows.Err()
+}
+
+// powerPlantsAsOf is a FROM item with the versions of the power plants valid at the time of the given parameter,
+// in place of the power_plants table.
+func powerPlantsAsOf(param string) string {
+	return `(SELECT * FROM power_plants_history
+		WHERE valid_from <= ` + param + ` AND (valid_to IS NULL OR valid_to > ` + param + `)) AS power_plants`
 }
 
 // GetPowerPlantForUpdate returns the power plant with the given ID and lock the row.
 func (d *Database) GetPowerPlantForUpdate(ctx context.Context, id int64) (*types.PowerPlant, error) {
-	query := `SELECT id, name, latitude, longitude, created_at, updated_at
+	query := `SELECT ` + powerPlantColumns + `
 	FROM power_plants WHERE id = $1
 	FOR UPDATE`
 
 	rows := d.db.QueryRowContext(ctx, query, id)
 
-	var (
-		data      types.PowerPlant
-		updatedAt sql.NullTime
-	)
-	err := rows.Scan(
-		&data.ID,
-		&data.Name,
-		&data.Latitude,
-		&data.Longitude,
-		&data.CreatedAt,
-		&updatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	if updatedAt.Valid {
-		data.UpdatedAt = updatedAt.Time
-	}
-
-	return &data, nil
+	return scanPowerPlant(rows)
 }
 
 // GetPowerPlants returns the power plants with the given last ID and count.
 // The power plants are ordered by ID in ascending order.
 func (d *Database) GetPowerPlants(ctx context.Context, lastID int64, count int) ([]types.PowerPlant, error) {
-	query := `SELECT id, name, latitude, longitude, created_at, updated_at
+	query := `SELECT ` + powerPlantColumns + `
 	FROM power_plants WHERE id > $1
 	ORDER BY id 
 	FETCH FIRST $2 ROWS ONLY`
 
+	return d.queryPowerPlants(ctx, query, lastID, count)
+}
+
+// GetPowerPlantsByFilter returns the power plants matching the filter, paginated like GetPowerPlants.
+// With filter.AsOf, the power plants are the versions valid at that time, see GetPowerPlantAsOf.
+func (d *Database) GetPowerPlantsByFilter(ctx context.Context, filter types.PowerPlantFilter, lastID int64, count int) ([]types.PowerPlant, error) {
+	source := `power_plants`
+	if filter.AsOf != nil {
+		source = powerPlantsAsOf("$12")
+	}
+
+	query := `SELECT ` + powerPlantColumns + `
+	FROM ` + source + ` WHERE id > $1
+	AND (COALESCE(cardinality($2::VARCHAR[]), 0) = 0 OR type = ANY($2))
+	AND (COALESCE(cardinality($3::VARCHAR[]), 0) = 0 OR status = ANY($3))
+	AND ($4::VARCHAR IS NULL OR operator = $4)
+	AND ($5::NUMERIC IS NULL OR latitude BETWEEN $5 AND $6)
+	AND ($7::NUMERIC IS NULL OR CASE
+		WHEN $7 <= $8::NUMERIC THEN longitude BETWEEN $7 AND $8
+		ELSE longitude >= $7 OR longitude <= $8
+	END)
+	AND ($9::BIGINT IS NULL OR id IN (SELECT power_plant_id FROM portfolio_power_plants WHERE portfolio_id = $9))
+	AND (COALESCE(cardinality($10::VARCHAR[]), 0) = 0 OR tags @> $10)
+	ORDER BY id
+	FETCH FIRST $11 ROWS ONLY`
+
+	var minLat, maxLat, minLong, maxLong *float64
+	if filter.Region != nil {
+		minLat, maxLat = &filter.Region.MinLatitude, &filter.Region.MaxLatitude
+		minLong, maxLong = &filter.Region.MinLongitude, &filter.Region.MaxLongitude
+	}
+
+	args := []any{
+		lastID,
+		pq.Array(filter.Types),
+		pq.Array(filter.Statuses),
+		filter.Operator,
+		minLat, maxLat,
+		minLong, maxLong,
+		filter.PortfolioID,
+		pq.Array(filter.Tags),
+		count,
+	}
+	if filter.AsOf != nil {
+		args = append(args, filter.AsOf.UTC())
+	}
+	return d.queryPowerPlants(ctx, query, args...)
+}
+
+// GetPowerPlantsMissingElevation returns the power plants without an elevation from the elevation API,
+// with the given last ID and count. The power plants are ordered by ID in ascending order.
+func (d *Database) GetPowerPlantsMissingElevation(ctx context.Context, lastID int64, count int) ([]types.PowerPlant, error) {
+	query := `SELECT ` + powerPlantColumns + `
+	FROM power_plants WHERE id > $1 AND elevation IS NULL
+	ORDER BY id 
+	FETCH FIRST $2 ROWS ONLY`
+
+	return d.queryPowerPlants(ctx, query, lastID, count)
+}
+
+func (d *Database) queryPowerPlants(ctx context.Context, query string, args ...any) ([]types.PowerPlant, error) {
 	powerPlants := []types.PowerPlant{}
-	rows, err := d.db.QueryContext(ctx, query, lastID, count)
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var (
-			data      types.PowerPlant
-			updatedAt sql.NullTime
-		)
-		err := rows.Scan(
-			&data.ID,
-			&data.Name,
-			&data.Latitude,
-			&data.Longitude,
-			&data.CreatedAt,
-			&updatedAt,
-		)
+		data, err := scanPowerPlant(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if updatedAt.Valid {
-			data.UpdatedAt = updatedAt.Time
-		}
+		powerPlants = append(powerPlants, *data)
+	}
+
+	return powerPlants, rows.Err()
+}
 
-		powerPlants = append(powerPlants, data)
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// versionScanner scans the columns following the ones of a scan function into dest,
+// e.g. the validity of a power plant version after the columns of scanPowerPlant.
+type versionScanner struct {
+	row  scanner
+	dest []any
+}
+
+func (s versionScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.dest...)...)
+}
+
+// scanPowerPlant scans a row selected with powerPlantColumns.
+func scanPowerPlant(row scanner) (*types.PowerPlant, error) {
+	var (
+		data              types.PowerPlant
+		elevation         sql.NullFloat64
+		elevationOverride sql.NullFloat64
+		capacityMW        sql.NullFloat64
+		dcCapacityMW      sql.NullFloat64
+		solarArray        []byte
+		designDischarge   sql.NullFloat64
+		turbineModelID    sql.NullInt64
+		turbineCount      sql.NullInt64
+		commissionedAt    sql.NullTime
+		operator          sql.NullString
+		updatedAt         sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.Name,
+		&data.Latitude,
+		&data.Longitude,
+		&elevation,
+		&elevationOverride,
+		&data.Type,
+		&capacityMW,
+		&dcCapacityMW,
+		&solarArray,
+		&designDischarge,
+		&turbineModelID,
+		&turbineCount,
+		&commissionedAt,
+		&data.Status,
+		&operator,
+		pq.Array(&data.Tags),
+		&data.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if elevation.Valid {
+		data.DEMElevation = &elevation.Float64
 	}
+	if elevationOverride.Valid {
+		data.ElevationOverride = &elevationOverride.Float64
+	}
+	if capacityMW.Valid {
+		data.CapacityMW = &capacityMW.Float64
+	}
+	if dcCapacityMW.Valid {
+		data.DCCapacityMW = &dcCapacityMW.Float64
+	}
+	if solarArray != nil {
+		if err := json.Unmarshal(solarArray, &data.SolarArray); err != nil {
+			return nil, err
+		}
+	}
+	if designDischarge.Valid {
+		data.DesignDischargeM3s = &designDischarge.Float64
+	}
+	if turbineModelID.Valid {
+		data.TurbineModelID = &turbineModelID.Int64
+	}
+	if turbineCount.Valid {
+		count := int(turbineCount.Int64)
+		data.TurbineCount = &count
+	}
+	if commissionedAt.Valid {
+		data.CommissionedAt = &commissionedAt.Time
+	}
+	if operator.Valid {
+		data.Operator = &operator.String
+	}
+	if updatedAt.Valid {
+		data.UpdatedAt = updatedAt.Time
+	}
+	if data.Tags == nil {
+		data.Tags = []string{}
+	}
+	data.ResolveElevation()
 
-	return powerPlants, nil
+	return &data, nil
+}
+
+// marshalSolarArray returns the solar_array column value of a solar array, NULL when there is none.
+func marshalSolarArray(solarArray *types.SolarArrayConfig) (any, error) {
+	if solarArray == nil {
+		return nil, nil
+	}
+	return json.Marshal(solarArray)
 }
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+const issuedForecastColumns = `power_plant_id, issued_at, time, temperature, precipitation,
+	wind_speed, wind_speed_100m, shortwave_radiation, generation_mw`
+
+// RecordIssuedForecasts saves the issued forecasts with a COPY into a staging table.
+// A forecast already saved for the same power plant, issue and hour is kept as is.
+// It returns the number of saved forecasts.
+func (d *Database) RecordIssuedForecasts(ctx context.Context, forecasts []types.IssuedForecast) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE issued_forecasts_staging
+		(LIKE issued_forecasts INCLUDING DEFAULTS) ON COMMIT DROP`)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("issued_forecasts_staging",
+		"power_plant_id", "issued_at", "time", "temperature", "precipitation",
+		"wind_speed", "wind_speed_100m", "shortwave_radiation", "generation_mw"))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, f := range forecasts {
+		_, err := stmt.ExecContext(ctx, f.PowerPlantID, f.IssuedAt.UTC(), f.Time.UTC(),
+			f.Weather.Temperature, f.Weather.Precipitation, f.Weather.WindSpeed,
+			f.Weather.WindSpeed100m, f.Weather.ShortwaveRadiation, f.GenerationMW)
+		if err != nil {
+			return 0, err
+		}
+	}
+	// The empty Exec flushes the COPY.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO issued_forecasts (`+issuedForecastColumns+`)
+	SELECT `+issuedForecastColumns+` FROM issued_forecasts_staging
+	ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return 0, err
+	}
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(saved), tx.Commit()
+}
+
+// GetIssuedForecasts returns the issued forecasts of the power plants for the hours from from to to, excluded,
+// ordered by power plant, hour and issue.
+func (d *Database) GetIssuedForecasts(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.IssuedForecast, error) {
+	query := `SELECT ` + issuedForecastColumns + `
+	FROM issued_forecasts
+	WHERE power_plant_id = ANY($1) AND time >= $2 AND time < $3
+	ORDER BY power_plant_id, time, issued_at`
+
+	rows, err := d.db.QueryContext(ctx, query, pq.Array(powerPlantIDs), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forecasts := []types.IssuedForecast{}
+	for rows.Next() {
+		var f types.IssuedForecast
+		err := rows.Scan(&f.PowerPlantID, &f.IssuedAt, &f.Time, &f.Weather.Temperature, &f.Weather.Precipitation,
+			&f.Weather.WindSpeed, &f.Weather.WindSpeed100m, &f.Weather.ShortwaveRadiation, &f.GenerationMW)
+		if err != nil {
+			return nil, err
+		}
+		forecasts = append(forecasts, f)
+	}
+
+	return forecasts, rows.Err()
+}
+
+// GetIssuedForecastDays returns the days, at 00:00 UTC, with issued forecasts for hours before before.
+func (d *Database) GetIssuedForecastDays(ctx context.Context, before time.Time) ([]time.Time, error) {
+	query := `SELECT DISTINCT date_trunc('day', time) AS day
+	FROM issued_forecasts
+	WHERE time < $1
+	ORDER BY day`
+
+	rows, err := d.db.QueryContext(ctx, query, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []time.Time{}
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+// DeleteIssuedForecasts deletes the issued forecasts for hours before before.
+// It returns the number of deleted forecasts.
+func (d *Database) DeleteIssuedForecasts(ctx context.Context, before time.Time) (int, error) {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM issued_forecasts WHERE time < $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
+
+// GetHourlyProduction returns the average production of the power plants per hour, from the readings
+// that are not SUSPECT. Like the forecasts, the average is set at the end of its hour: the readings from
+// 10:00 to 10:59 are averaged at 11:00. Only the hours from from to to, excluded, are returned,
+// ordered by power plant and hour.
+func (d *Database) GetHourlyProduction(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.ProductionReading, error) {
+	query := `SELECT power_plant_id, date_trunc('hour', time) + INTERVAL '1 hour' AS hour, AVG(power_mw)
+	FROM production_readings
+	WHERE power_plant_id = ANY($1) AND time >= $2 AND time < $3 AND quality <> $4
+	GROUP BY power_plant_id, hour
+	ORDER BY power_plant_id, hour`
+
+	rows, err := d.db.QueryContext(ctx, query, pq.Array(powerPlantIDs),
+		from.Add(-time.Hour).UTC(), to.Add(-time.Hour).UTC(), types.ProductionQualitySuspect)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []types.ProductionReading{}
+	for rows.Next() {
+		var reading types.ProductionReading
+		if err := rows.Scan(&reading.PowerPlantID, &reading.Time, &reading.PowerMW); err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings, rows.Err()
+}
+
+// SaveForecastErrorSums saves the error sums, replacing the ones of the same power plant, day,
+// variable and lead time bucket, so a day can be materialized again.
+func (d *Database) SaveForecastErrorSums(ctx context.Context, sums []types.ForecastErrorSums) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO forecast_error_sums (power_plant_id, day, variable,
+		lead_hours_from, lead_hours_to, count, sum_error, sum_abs_error, sum_squared_error)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (power_plant_id, day, variable, lead_hours_from) DO UPDATE
+	SET lead_hours_to = EXCLUDED.lead_hours_to, count = EXCLUDED.count, sum_error = EXCLUDED.sum_error,
+		sum_abs_error = EXCLUDED.sum_abs_error, sum_squared_error = EXCLUDED.sum_squared_error`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range sums {
+		_, err := stmt.ExecContext(ctx, s.PowerPlantID, s.Day.UTC(), s.Variable, s.LeadHoursFrom, s.LeadHoursTo,
+			s.Count, s.SumError, s.SumAbsError, s.SumSquaredError)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetForecastErrorSums returns the error sums of the power plant from the day from to the day to, excluded,
+// added up per variable and lead time bucket, ordered by variable and lead time.
+func (d *Database) GetForecastErrorSums(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ForecastErrorSums, error) {
+	query := `SELECT variable, lead_hours_from, MAX(lead_hours_to), SUM(count),
+		SUM(sum_error), SUM(sum_abs_error), SUM(sum_squared_error)
+	FROM forecast_error_sums
+	WHERE power_plant_id = $1 AND day >= $2 AND day < $3
+	GROUP BY variable, lead_hours_from
+	ORDER BY variable, lead_hours_from`
+
+	rows, err := d.db.QueryContext(ctx, query, powerPlantID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := []types.ForecastErrorSums{}
+	for rows.Next() {
+		s := types.ForecastErrorSums{PowerPlantID: powerPlantID}
+		err := rows.Scan(&s.Variable, &s.LeadHoursFrom, &s.LeadHoursTo, &s.Count,
+			&s.SumError, &s.SumAbsError, &s.SumSquaredError)
+		if err != nil {
+			return nil, err
+		}
+		sums = append(sums, s)
+	}
+
+	return sums, rows.Err()
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDatabase_ForecastAccuracy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:      "tracked plant",
+		Latitude:  47.1,
+		Longitude: 8.3,
+		PowerPlantMetadata: types.PowerPlantMetadata{
+			Type:       types.PowerPlantTypeWind,
+			CapacityMW: ptr(30.0),
+			Status:     types.PowerPlantStatusOperational,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	issued := []types.IssuedForecast{
+		{
+			PowerPlantID: powerPlant.ID, IssuedAt: day.Add(-time.Hour), Time: day,
+			Weather: types.WeatherForecast{Temperature: ptr(3.5), WindSpeed: ptr(12.0)}, GenerationMW: ptr(10.0),
+		},
+		{
+			PowerPlantID: powerPlant.ID, IssuedAt: day.Add(-time.Hour), Time: day.Add(time.Hour),
+			Weather: types.WeatherForecast{Temperature: ptr(4.0)},
+		},
+	}
+
+	// Recording the same run again keeps the saved forecasts.
+	for _, expected := range []int{2, 0} {
+		recorded, err := testDB.RecordIssuedForecasts(ctx, issued)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if recorded != expected {
+			t.Fatalf("expected %d recorded forecasts, got: %d", expected, recorded)
+		}
+	}
+
+	got, err := testDB.GetIssuedForecasts(ctx, []int64{powerPlant.ID}, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(issued, got, cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+		t.Fatalf("unexpected issued forecasts (-want +got):\n%s", diff)
+	}
+
+	days, err := testDB.GetIssuedForecastDays(ctx, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.ContainsFunc(days, day.Equal) {
+		t.Fatalf("expected the days to contain %s, got: %v", day, days)
+	}
+
+	// The readings of 23:00 to 23:59 are averaged at 00:00, the suspect one is ignored.
+	_, _, err = testDB.RecordProductionReadings(ctx, []types.ProductionReading{
+		{PowerPlantID: powerPlant.ID, Time: day.Add(-time.Hour), PowerMW: 9, Quality: types.ProductionQualityMeasured},
+		{PowerPlantID: powerPlant.ID, Time: day.Add(-30 * time.Minute), PowerMW: 11, Quality: types.ProductionQualityEstimated},
+		{PowerPlantID: powerPlant.ID, Time: day.Add(-15 * time.Minute), PowerMW: 30, Quality: types.ProductionQualitySuspect},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	production, err := testDB.GetHourlyProduction(ctx, []int64{powerPlant.ID}, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedProduction := []types.ProductionReading{{PowerPlantID: powerPlant.ID, Time: day, PowerMW: 10}}
+	if diff := cmp.Diff(expectedProduction, production, cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+		t.Fatalf("unexpected hourly production (-want +got):\n%s", diff)
+	}
+
+	deleted, err := testDB.DeleteIssuedForecasts(ctx, day.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted < 1 {
+		t.Fatalf("expected the forecast of %s to be deleted, got %d deleted", day, deleted)
+	}
+
+	// The sums of a day are replaced, the days add up.
+	sums := func(day time.Time, count int, sumError float64) types.ForecastErrorSums {
+		return types.ForecastErrorSums{
+			PowerPlantID: powerPlant.ID, Day: day, Variable: types.AccuracyVariableTemperature,
+			LeadHoursFrom: 0, LeadHoursTo: 24, Count: count, SumError: sumError, SumAbsError: sumError, SumSquaredError: sumError * sumError,
+		}
+	}
+	if err := testDB.SaveForecastErrorSums(ctx, []types.ForecastErrorSums{sums(day, 5, 5)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = testDB.SaveForecastErrorSums(ctx, []types.ForecastErrorSums{sums(day, 2, 1), sums(day.AddDate(0, 0, 1), 3, 2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	accuracy, err := testDB.GetForecastErrorSums(ctx, powerPlant.ID, day, day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []types.ForecastErrorSums{{
+		PowerPlantID: powerPlant.ID, Variable: types.AccuracyVariableTemperature,
+		LeadHoursFrom: 0, LeadHoursTo: 24, Count: 5, SumError: 3, SumAbsError: 3, SumSquaredError: 5,
+	}}
+	if diff := cmp.Diff(expected, accuracy); diff != "" {
+		t.Fatalf("unexpected forecast error sums (-want +got):\n%s", diff)
+	}
+}
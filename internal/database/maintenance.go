@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// maintenanceWindowColumns are the columns scanned by scanMaintenanceWindow, in order.
+const maintenanceWindowColumns = `id, power_plant_id, start_time, end_time, reason, unavailable_mw, unavailable_percent,
+	created_at, updated_at`
+
+// CreateMaintenanceWindow creates a new maintenance window in the database.
+// It returns types.ErrPowerPlantNotFound when the power plant does not exist and
+// types.ErrMaintenanceOverlap when the window overlaps another window of the power plant.
+func (d *Database) CreateMaintenanceWindow(ctx context.Context, window *types.MaintenanceWindow) (*types.MaintenanceWindow, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockMaintenanceWindows(ctx, tx, window); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO maintenance_windows (power_plant_id, start_time, end_time, reason, unavailable_mw, unavailable_percent)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING ` + maintenanceWindowColumns
+
+	row := tx.QueryRowContext(ctx, query,
+		window.PowerPlantID,
+		window.Start.UTC(),
+		window.End.UTC(),
+		window.Reason,
+		window.UnavailableMW,
+		window.UnavailablePercent,
+	)
+
+	created, err := scanMaintenanceWindow(row)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+// UpdateMaintenanceWindow updates an existing maintenance window in the database, the power plant of a window
+// does not change. It returns types.ErrMaintenanceOverlap when the window overlaps another window of the power plant.
+func (d *Database) UpdateMaintenanceWindow(ctx context.Context, window *types.MaintenanceWindow) (*types.MaintenanceWindow, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockMaintenanceWindows(ctx, tx, window); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE maintenance_windows
+	SET start_time = $1, end_time = $2, reason = $3, unavailable_mw = $4, unavailable_percent = $5,
+	updated_at = NOW()
+	WHERE id = $6
+	RETURNING ` + maintenanceWindowColumns
+
+	row := tx.QueryRowContext(ctx, query,
+		window.Start.UTC(),
+		window.End.UTC(),
+		window.Reason,
+		window.UnavailableMW,
+		window.UnavailablePercent,
+		window.ID,
+	)
+
+	updated, err := scanMaintenanceWindow(row)
+	if err != nil {
+		return nil, err
+	}
+	return updated, tx.Commit()
+}
+
+// lockMaintenanceWindows locks the power plant of the window, so its windows are checked and written
+// one transaction at a time, then checks the window does not overlap another window of the power plant.
+func lockMaintenanceWindows(ctx context.Context, tx *sql.Tx, window *types.MaintenanceWindow) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM power_plants WHERE id = $1 FOR UPDATE`, window.PowerPlantID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ErrPowerPlantNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var overlaps bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM maintenance_windows
+		WHERE power_plant_id = $1 AND id <> $2 AND start_time < $4 AND end_time > $3
+	)`, window.PowerPlantID, window.ID, window.Start.UTC(), window.End.UTC()).Scan(&overlaps)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return types.ErrMaintenanceOverlap
+	}
+	return nil
+}
+
+// DeleteMaintenanceWindow deletes the maintenance window with the given ID.
+func (d *Database) DeleteMaintenanceWindow(ctx context.Context, id int64) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM maintenance_windows WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetMaintenanceWindow returns the maintenance window with the given ID.
+func (d *Database) GetMaintenanceWindow(ctx context.Context, id int64) (*types.MaintenanceWindow, error) {
+	query := `SELECT ` + maintenanceWindowColumns + `
+	FROM maintenance_windows WHERE id = $1`
+
+	row := d.db.QueryRowContext(ctx, query, id)
+
+	return scanMaintenanceWindow(row)
+}
+
+// GetMaintenanceWindows returns the maintenance windows of the given power plants overlapping from to to, excluded,
+// ordered by power plant and start.
+func (d *Database) GetMaintenanceWindows(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.MaintenanceWindow, error) {
+	query := `SELECT ` + maintenanceWindowColumns + `
+	FROM maintenance_windows
+	WHERE power_plant_id = ANY($1) AND start_time < $3 AND end_time > $2
+	ORDER BY power_plant_id, start_time`
+
+	rows, err := d.db.QueryContext(ctx, query, pq.Array(powerPlantIDs), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []types.MaintenanceWindow{}
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, *window)
+	}
+
+	return windows, rows.Err()
+}
+
+func scanMaintenanceWindow(row scanner) (*types.MaintenanceWindow, error) {
+	var (
+		data      types.MaintenanceWindow
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.PowerPlantID,
+		&data.Start,
+		&data.End,
+		&data.Reason,
+		&data.UnavailableMW,
+		&data.UnavailablePercent,
+		&data.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if updatedAt.Valid {
+		data.UpdatedAt = updatedAt.Time
+	}
+
+	return &data, nil
+}
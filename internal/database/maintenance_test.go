@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDatabase_MaintenanceWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:      "maintained plant",
+		Latitude:  46.5,
+		Longitude: 8.1,
+		PowerPlantMetadata: types.PowerPlantMetadata{
+			Type:       types.PowerPlantTypeWind,
+			CapacityMW: ptr(30.0),
+			Status:     types.PowerPlantStatusOperational,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 9, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	first, err := testDB.CreateMaintenanceWindow(ctx, &types.MaintenanceWindow{
+		PowerPlantID: powerPlant.ID, Start: at(6, 8), End: at(6, 16), Reason: "Gearbox inspection", UnavailableMW: ptr(10.0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Windows may touch, not overlap.
+	second, err := testDB.CreateMaintenanceWindow(ctx, &types.MaintenanceWindow{
+		PowerPlantID: powerPlant.ID, Start: at(6, 16), End: at(7, 0), UnavailablePercent: ptr(100.0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = testDB.CreateMaintenanceWindow(ctx, &types.MaintenanceWindow{
+		PowerPlantID: powerPlant.ID, Start: at(6, 12), End: at(6, 18), UnavailableMW: ptr(5.0),
+	})
+	if !errors.Is(err, types.ErrMaintenanceOverlap) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrMaintenanceOverlap, err)
+	}
+
+	_, err = testDB.CreateMaintenanceWindow(ctx, &types.MaintenanceWindow{
+		PowerPlantID: -1, Start: at(6, 12), End: at(6, 18), UnavailableMW: ptr(5.0),
+	})
+	if !errors.Is(err, types.ErrPowerPlantNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPowerPlantNotFound, err)
+	}
+
+	// A window does not overlap itself.
+	first.End = at(6, 14)
+	first.UnavailableMW, first.UnavailablePercent = nil, ptr(50.0)
+	updated, err := testDB.UpdateMaintenanceWindow(ctx, first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.UpdatedAt.IsZero() {
+		t.Fatal("expected updatedAt to be set")
+	}
+
+	second.Start = at(6, 13)
+	if _, err := testDB.UpdateMaintenanceWindow(ctx, second); !errors.Is(err, types.ErrMaintenanceOverlap) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrMaintenanceOverlap, err)
+	}
+
+	got, err := testDB.GetMaintenanceWindow(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &types.MaintenanceWindow{
+		ID: first.ID, PowerPlantID: powerPlant.ID, Start: at(6, 8), End: at(6, 14), Reason: "Gearbox inspection", UnavailablePercent: ptr(50.0),
+	}
+	opts := cmpopts.IgnoreFields(types.MaintenanceWindow{}, "CreatedAt", "UpdatedAt")
+	if diff := cmp.Diff(expected, got, opts, cmpopts.EquateApproxTime(time.Second)); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	windows, err := testDB.GetMaintenanceWindows(ctx, []int64{powerPlant.ID}, at(6, 10), at(6, 17))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(windows) != 2 || windows[0].ID != first.ID || windows[1].ID != second.ID {
+		t.Fatalf("expected both windows in order, got: %+v", windows)
+	}
+
+	windows, err = testDB.GetMaintenanceWindows(ctx, []int64{powerPlant.ID}, at(6, 14), at(6, 16))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(windows) != 0 {
+		t.Fatalf("expected no window between them, got: %+v", windows)
+	}
+
+	if err := testDB.DeleteMaintenanceWindow(ctx, first.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := testDB.DeleteMaintenanceWindow(ctx, first.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+}
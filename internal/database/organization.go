@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// organizationColumns are the columns scanned by scanOrganization, in order.
+const organizationColumns = `id, name, created_at`
+
+// CreateOrganization creates a new organization in the database, authenticated by the API key of the given hash.
+// It returns types.ErrOrganizationNameTaken when another organization has the same name.
+func (d *Database) CreateOrganization(ctx context.Context, organization *types.Organization, apiKeyHash []byte) (*types.Organization, error) {
+	query := `INSERT INTO organizations (name, api_key_hash)
+			VALUES ($1, $2)
+			RETURNING ` + organizationColumns
+
+	row := d.db.QueryRowContext(ctx, query, organization.Name, apiKeyHash)
+
+	created, err := scanOrganization(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil, types.ErrOrganizationNameTaken
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetOrganization returns the organization with the given ID.
+func (d *Database) GetOrganization(ctx context.Context, id int64) (*types.Organization, error) {
+	query := `SELECT ` + organizationColumns + `
+	FROM organizations WHERE id = $1`
+
+	row := d.db.QueryRowContext(ctx, query, id)
+
+	return scanOrganization(row)
+}
+
+// GetOrganizationByAPIKey returns the organization authenticated by the API key of the given hash.
+func (d *Database) GetOrganizationByAPIKey(ctx context.Context, apiKeyHash []byte) (*types.Organization, error) {
+	query := `SELECT ` + organizationColumns + `
+	FROM organizations WHERE api_key_hash = $1`
+
+	row := d.db.QueryRowContext(ctx, query, apiKeyHash)
+
+	return scanOrganization(row)
+}
+
+// GetOrganizations returns every organization, ordered by ID.
+func (d *Database) GetOrganizations(ctx context.Context) ([]types.Organization, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+organizationColumns+` FROM organizations ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	organizations := []types.Organization{}
+	for rows.Next() {
+		organization, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		organizations = append(organizations, *organization)
+	}
+
+	return organizations, rows.Err()
+}
+
+func scanOrganization(row scanner) (*types.Organization, error) {
+	var data types.Organization
+	if err := row.Scan(&data.ID, &data.Name, &data.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// portfolioColumns are the columns scanned by scanPortfolio, in order.
+const portfolioColumns = `id, name, description, created_at, updated_at`
+
+// CreatePortfolio creates a new portfolio in the database.
+// It returns types.ErrPortfolioNameTaken when another portfolio has the same name.
+func (d *Database) CreatePortfolio(ctx context.Context, portfolio *types.Portfolio) (*types.Portfolio, error) {
+	query := `INSERT INTO portfolios (name, description)
+			VALUES ($1, $2)
+			RETURNING ` + portfolioColumns
+
+	row := d.db.QueryRowContext(ctx, query, portfolio.Name, portfolio.Description)
+
+	created, err := scanPortfolio(row)
+	if err != nil {
+		return nil, portfolioError(err)
+	}
+	return created, nil
+}
+
+// UpdatePortfolio updates an existing portfolio in the database.
+// It returns types.ErrPortfolioNameTaken when another portfolio has the same name.
+func (d *Database) UpdatePortfolio(ctx context.Context, portfolio *types.Portfolio) (*types.Portfolio, error) {
+	query := `UPDATE portfolios
+	SET name = $1, description = $2, updated_at = NOW()
+	WHERE id = $3
+	RETURNING ` + portfolioColumns
+
+	row := d.db.QueryRowContext(ctx, query, portfolio.Name, portfolio.Description, portfolio.ID)
+
+	updated, err := scanPortfolio(row)
+	if err != nil {
+		return nil, portfolioError(err)
+	}
+	return updated, nil
+}
+
+// portfolioError maps the unique violation of the portfolio name to types.ErrPortfolioNameTaken.
+func portfolioError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return types.ErrPortfolioNameTaken
+	}
+	return err
+}
+
+// DeletePortfolio deletes the portfolio with the given ID, its power plants are kept.
+func (d *Database) DeletePortfolio(ctx context.Context, id int64) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM portfolios WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetPortfolio returns the portfolio with the given ID.
+func (d *Database) GetPortfolio(ctx context.Context, id int64) (*types.Portfolio, error) {
+	query := `SELECT ` + portfolioColumns + `
+	FROM portfolios WHERE id = $1`
+
+	row := d.db.QueryRowContext(ctx, query, id)
+
+	return scanPortfolio(row)
+}
+
+// GetPortfolios returns the portfolios with the given last ID and count.
+// The portfolios are ordered by ID in ascending order.
+func (d *Database) GetPortfolios(ctx context.Context, lastID int64, count int) ([]types.Portfolio, error) {
+	query := `SELECT ` + portfolioColumns + `
+	FROM portfolios WHERE id > $1
+	ORDER BY id
+	FETCH FIRST $2 ROWS ONLY`
+
+	return d.queryPortfolios(ctx, query, lastID, count)
+}
+
+// GetPowerPlantPortfolios returns the portfolios of the power plant, ordered by name.
+func (d *Database) GetPowerPlantPortfolios(ctx context.Context, powerPlantID int64) ([]types.Portfolio, error) {
+	query := `SELECT ` + portfolioColumns + `
+	FROM portfolios
+	WHERE id IN (SELECT portfolio_id FROM portfolio_power_plants WHERE power_plant_id = $1)
+	ORDER BY name`
+
+	return d.queryPortfolios(ctx, query, powerPlantID)
+}
+
+func (d *Database) queryPortfolios(ctx context.Context, query string, args ...any) ([]types.Portfolio, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	portfolios := []types.Portfolio{}
+	for rows.Next() {
+		portfolio, err := scanPortfolio(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		portfolios = append(portfolios, *portfolio)
+	}
+
+	return portfolios, rows.Err()
+}
+
+// AddPortfolioPowerPlants adds the power plants to the portfolio, the power plants already in it are kept.
+// It returns the number of added power plants, types.ErrPortfolioNotFound when the portfolio does not exist
+// and types.ErrPowerPlantNotFound when one of the power plants does not exist, then none is added.
+func (d *Database) AddPortfolioPowerPlants(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (int, error) {
+	query := `INSERT INTO portfolio_power_plants (portfolio_id, power_plant_id)
+	SELECT $1, unnest($2::BIGINT[])
+	ON CONFLICT DO NOTHING`
+
+	res, err := d.db.ExecContext(ctx, query, portfolioID, pq.Array(powerPlantIDs))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			if pqErr.Constraint == "portfolio_power_plants_portfolio_id_fkey" {
+				return 0, types.ErrPortfolioNotFound
+			}
+			return 0, types.ErrPowerPlantNotFound
+		}
+		return 0, err
+	}
+
+	added, err := res.RowsAffected()
+	return int(added), err
+}
+
+// RemovePortfolioPowerPlants removes the power plants from the portfolio, the power plants not in it are ignored.
+// It returns the number of removed power plants.
+func (d *Database) RemovePortfolioPowerPlants(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (int, error) {
+	query := `DELETE FROM portfolio_power_plants
+	WHERE portfolio_id = $1 AND power_plant_id = ANY($2)`
+
+	res, err := d.db.ExecContext(ctx, query, portfolioID, pq.Array(powerPlantIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
+
+func scanPortfolio(row scanner) (*types.Portfolio, error) {
+	var (
+		data        types.Portfolio
+		description sql.NullString
+		updatedAt   sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.Name,
+		&description,
+		&data.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		data.Description = &description.String
+	}
+	if updatedAt.Valid {
+		data.UpdatedAt = updatedAt.Time
+	}
+
+	return &data, nil
+}
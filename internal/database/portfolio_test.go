@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDatabase_Portfolio(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	// The names are unique, the suffix lets the test run again on the same database.
+	suffix := time.Now().Format(time.RFC3339Nano)
+	portfolio, err := testDB.CreatePortfolio(ctx, &types.Portfolio{Name: "Fund I " + suffix, Description: ptr("First fund")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = testDB.CreatePortfolio(ctx, &types.Portfolio{Name: portfolio.Name})
+	if !errors.Is(err, types.ErrPortfolioNameTaken) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPortfolioNameTaken, err)
+	}
+
+	other, err := testDB.CreatePortfolio(ctx, &types.Portfolio{Name: "Team North " + suffix})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other.Name = portfolio.Name
+	if _, err := testDB.UpdatePortfolio(ctx, other); !errors.Is(err, types.ErrPortfolioNameTaken) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPortfolioNameTaken, err)
+	}
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+			Name:               fmt.Sprintf("grouped plant %d", i),
+			Latitude:           46.5,
+			Longitude:          8.1,
+			PowerPlantMetadata: defaultMetadata,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, powerPlant.ID)
+	}
+
+	added, err := testDB.AddPortfolioPowerPlants(ctx, portfolio.ID, ids[:2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("expected 2 added power plants, got: %d", added)
+	}
+
+	// The power plants already in the portfolio are kept once.
+	added, err = testDB.AddPortfolioPowerPlants(ctx, portfolio.ID, ids)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added != 1 {
+		t.Fatalf("expected 1 added power plant, got: %d", added)
+	}
+
+	if _, err := testDB.AddPortfolioPowerPlants(ctx, portfolio.ID, []int64{ids[0], -1}); !errors.Is(err, types.ErrPowerPlantNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPowerPlantNotFound, err)
+	}
+	if _, err := testDB.AddPortfolioPowerPlants(ctx, -1, ids); !errors.Is(err, types.ErrPortfolioNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPortfolioNotFound, err)
+	}
+
+	removed, err := testDB.RemovePortfolioPowerPlants(ctx, portfolio.ID, []int64{ids[1], -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected 1 removed power plant, got: %d", removed)
+	}
+
+	if _, err := testDB.AddPortfolioPowerPlants(ctx, other.ID, ids[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := testDB.AddPowerPlantTags(ctx, ids[0], []string{"team-north", "offshore"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	powerPlant, err := testDB.AddPowerPlantTags(ctx, ids[0], []string{"offshore", "fund-i"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"fund-i", "offshore", "team-north"}, powerPlant.Tags); diff != "" {
+		t.Fatalf("unexpected tags (-want +got):\n%s", diff)
+	}
+	if _, err := testDB.AddPowerPlantTags(ctx, ids[2], []string{"offshore"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   types.PowerPlantFilter
+		expected []int64
+	}{
+		{
+			name:     "portfolio",
+			filter:   types.PowerPlantFilter{PortfolioID: &portfolio.ID},
+			expected: []int64{ids[0], ids[2]},
+		},
+		{
+			name:     "every tag",
+			filter:   types.PowerPlantFilter{Tags: []string{"offshore", "team-north"}},
+			expected: []int64{ids[0]},
+		},
+		{
+			name:     "portfolio and tag",
+			filter:   types.PowerPlantFilter{PortfolioID: &portfolio.ID, Tags: []string{"offshore"}},
+			expected: []int64{ids[0], ids[2]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			powerPlants, err := testDB.GetPowerPlantsByFilter(ctx, tt.filter, ids[0]-1, 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]int64, 0, len(powerPlants))
+			for _, powerPlant := range powerPlants {
+				got = append(got, powerPlant.ID)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Fatalf("unexpected power plants (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	portfolios, err := testDB.GetPowerPlantPortfolios(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(portfolios) != 2 || portfolios[0].ID != portfolio.ID || portfolios[1].ID != other.ID {
+		t.Fatalf("expected both portfolios sorted by name, got: %+v", portfolios)
+	}
+
+	powerPlant, err = testDB.RemovePowerPlantTags(ctx, ids[0], []string{"team-north", "unknown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"fund-i", "offshore"}, powerPlant.Tags); diff != "" {
+		t.Fatalf("unexpected tags (-want +got):\n%s", diff)
+	}
+
+	// Deleting a portfolio keeps its power plants.
+	if err := testDB.DeletePortfolio(ctx, portfolio.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := testDB.DeletePortfolio(ctx, portfolio.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+	if _, err := testDB.GetPowerPlant(ctx, ids[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+func TestDatabase_PowerPlantHistory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:               "versioned plant",
+		Latitude:           46.5,
+		Longitude:          8.1,
+		PowerPlantMetadata: defaultMetadata,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	update := *powerPlant
+	update.Name = "renamed plant"
+	if _, err := testDB.UpdatePowerPlant(ctx, &update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// An update without any change keeps the current version.
+	if _, err := testDB.UpdatePowerPlant(ctx, &update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	versions, err := testDB.GetPowerPlantHistory(ctx, powerPlant.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got: %+v", versions)
+	}
+	first, current := versions[0], versions[1]
+	if first.Name != "versioned plant" || first.ValidTo == nil || !first.ValidTo.Equal(current.ValidFrom) {
+		t.Fatalf("unexpected first version: %+v", first)
+	}
+	if current.Name != "renamed plant" || current.ValidTo != nil {
+		t.Fatalf("unexpected current version: %+v", current)
+	}
+
+	tests := []struct {
+		name         string
+		asOf         time.Time
+		expectedName string
+		expectErr    error
+	}{
+		{
+			name:         "first version",
+			asOf:         first.ValidFrom,
+			expectedName: "versioned plant",
+		},
+		{
+			name:         "current version",
+			asOf:         current.ValidFrom,
+			expectedName: "renamed plant",
+		},
+		{
+			// The time in another zone is the same instant, not the same wall clock.
+			name:         "current version with an offset",
+			asOf:         current.ValidFrom.In(time.FixedZone("", 2*60*60)),
+			expectedName: "renamed plant",
+		},
+		{
+			name:      "before the creation",
+			asOf:      first.ValidFrom.Add(-time.Second),
+			expectErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testDB.GetPowerPlantAsOf(ctx, powerPlant.ID, tt.asOf)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Name != tt.expectedName {
+				t.Fatalf("expected name: %s, got: %s", tt.expectedName, got.Name)
+			}
+
+			powerPlants, err := testDB.GetPowerPlantsByFilter(ctx, types.PowerPlantFilter{AsOf: &tt.asOf}, powerPlant.ID-1, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(powerPlants) != 1 || powerPlants[0].ID != powerPlant.ID || powerPlants[0].Name != tt.expectedName {
+				t.Fatalf("expected the %s version, got: %+v", tt.expectedName, powerPlants)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// RecordProductionReadings saves the production readings with a COPY into a staging table, then upserts
+// them on (power plant, time): a saved reading is replaced, and of the readings repeated in the batch
+// the last one wins. It returns the number of inserted and of replaced readings.
+// It returns types.ErrPowerPlantNotFound when a power plant does not exist.
+func (d *Database) RecordProductionReadings(ctx context.Context, readings []types.ProductionReading) (int, int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE production_staging(
+		"seq" INT NOT NULL,
+		"power_plant_id" BIGINT NOT NULL,
+		"time" TIMESTAMP NOT NULL,
+		"power_mw" NUMERIC NOT NULL,
+		"quality" VARCHAR NOT NULL
+	) ON COMMIT DROP`)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("production_staging", "seq", "power_plant_id", "time", "power_mw", "quality"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer stmt.Close()
+
+	for i, reading := range readings {
+		if _, err := stmt.ExecContext(ctx, i, reading.PowerPlantID, reading.Time.UTC(), reading.PowerMW, reading.Quality); err != nil {
+			return 0, 0, err
+		}
+	}
+	// The empty Exec flushes the COPY.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	// xmax is 0 for the inserted rows and set for the updated ones.
+	rows, err := tx.QueryContext(ctx, `INSERT INTO production_readings (power_plant_id, time, power_mw, quality)
+	SELECT DISTINCT ON (power_plant_id, time) power_plant_id, time, power_mw, quality
+	FROM production_staging
+	ORDER BY power_plant_id, time, seq DESC
+	ON CONFLICT (power_plant_id, time) DO UPDATE
+	SET power_mw = EXCLUDED.power_mw, quality = EXCLUDED.quality, recorded_at = NOW()
+	RETURNING xmax = 0`)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return 0, 0, types.ErrPowerPlantNotFound
+		}
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var inserted, updated int
+	for rows.Next() {
+		var isInsert bool
+		if err := rows.Scan(&isInsert); err != nil {
+			return 0, 0, err
+		}
+		if isInsert {
+			inserted++
+		} else {
+			updated++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return 0, 0, types.ErrPowerPlantNotFound
+		}
+		return 0, 0, err
+	}
+	rows.Close()
+
+	return inserted, updated, tx.Commit()
+}
+
+// GetProductionReadings returns the production readings of the power plant from from to to, excluded,
+// ordered by time.
+func (d *Database) GetProductionReadings(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ProductionReading, error) {
+	query := `SELECT power_plant_id, time, power_mw, quality
+	FROM production_readings
+	WHERE power_plant_id = $1 AND time >= $2 AND time < $3
+	ORDER BY time`
+
+	rows, err := d.db.QueryContext(ctx, query, powerPlantID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []types.ProductionReading{}
+	for rows.Next() {
+		var reading types.ProductionReading
+		if err := rows.Scan(&reading.PowerPlantID, &reading.Time, &reading.PowerMW, &reading.Quality); err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings, rows.Err()
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDatabase_ProductionReadings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:      "metered plant",
+		Latitude:  46.5,
+		Longitude: 8.1,
+		PowerPlantMetadata: types.PowerPlantMetadata{
+			Type:       types.PowerPlantTypeWind,
+			CapacityMW: ptr(30.0),
+			Status:     types.PowerPlantStatusOperational,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)
+	reading := func(minutes int, powerMW float64, quality types.ProductionQuality) types.ProductionReading {
+		return types.ProductionReading{
+			PowerPlantID: powerPlant.ID,
+			Time:         start.Add(time.Duration(minutes) * time.Minute),
+			PowerMW:      powerMW,
+			Quality:      quality,
+		}
+	}
+
+	// The second reading at 00:15 is the one recorded.
+	inserted, updated, err := testDB.RecordProductionReadings(ctx, []types.ProductionReading{
+		reading(0, 10, types.ProductionQualityMeasured),
+		reading(15, 11, types.ProductionQualityMeasured),
+		reading(15, 12, types.ProductionQualityEstimated),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inserted != 2 || updated != 0 {
+		t.Fatalf("expected 2 inserted and 0 updated readings, got %d and %d", inserted, updated)
+	}
+
+	// A reading at a saved time replaces it.
+	inserted, updated, err = testDB.RecordProductionReadings(ctx, []types.ProductionReading{
+		reading(0, 9.5, types.ProductionQualitySuspect),
+		reading(30, 13, types.ProductionQualityMeasured),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inserted != 1 || updated != 1 {
+		t.Fatalf("expected 1 inserted and 1 updated readings, got %d and %d", inserted, updated)
+	}
+
+	_, _, err = testDB.RecordProductionReadings(ctx, []types.ProductionReading{
+		{PowerPlantID: -1, Time: start, PowerMW: 1, Quality: types.ProductionQualityMeasured},
+	})
+	if !errors.Is(err, types.ErrPowerPlantNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPowerPlantNotFound, err)
+	}
+
+	readings, err := testDB.GetProductionReadings(ctx, powerPlant.ID, start, start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []types.ProductionReading{
+		reading(0, 9.5, types.ProductionQualitySuspect),
+		reading(15, 12, types.ProductionQualityEstimated),
+	}
+	if diff := cmp.Diff(expected, readings, cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+		t.Fatalf("unexpected production readings (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+
+	"github.com/gcathelines/tensor-energy-case/internal/audit"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/lib/pq"
+)
+
+// tenantRole is the role the connections act as. Unlike the owner of the tables, it is subject to
+// their row level security policies, which only let it see and write the rows of the organization
+// set in the app.organization_id setting.
+const tenantRole = "tenant_user"
+
+// Open opens a database whose connections act as the tenant role, and scope every statement to the
+// organization of its context, see the tenant package. A statement without an organization sees no row
+// of the tables of the organizations and cannot insert in them, so a query missing a condition on the
+// organization cannot read nor write the rows of another one.
+// The actor, operation and request ID of the context, see the audit package, are set along with the
+// organization, for the audit trigger to record them with the changes.
+func Open(dsn string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&tenantConnector{connector: connector}), nil
+}
+
+// tenantConnector opens the connections of Open.
+type tenantConnector struct {
+	connector *pq.Connector
+}
+
+func (c *tenantConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.(driver.ExecerContext).ExecContext(ctx, `SET ROLE `+tenantRole, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &tenantConn{conn: conn}, nil
+}
+
+func (c *tenantConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// sessionSettings are the settings of a session: the organization, actor, operation and request ID.
+type sessionSettings [4]string
+
+// tenantConn sets the settings of the context of a statement on the session before running it.
+// The settings are only sent when they change, and again after a transaction is rolled back since
+// the rollback reverts them.
+type tenantConn struct {
+	conn driver.Conn
+	// settings are the settings of the session, valid only if scoped.
+	settings sessionSettings
+	scoped   bool
+}
+
+// scope sets the settings of the context on the session, empty for the ones the context does not have.
+func (c *tenantConn) scope(ctx context.Context) error {
+	var settings sessionSettings
+	if id, ok := tenant.OrganizationID(ctx); ok {
+		settings[0] = strconv.FormatInt(id, 10)
+	}
+	settings[1], settings[2], settings[3] = audit.Actor(ctx), audit.Operation(ctx), audit.RequestID(ctx)
+	if c.scoped && c.settings == settings {
+		return nil
+	}
+
+	c.scoped = false
+	args := make([]driver.NamedValue, 0, len(settings))
+	for i, setting := range settings {
+		args = append(args, driver.NamedValue{Ordinal: i + 1, Value: setting})
+	}
+	_, err := c.conn.(driver.ExecerContext).ExecContext(ctx, `SELECT set_config('app.organization_id', $1, false),
+		set_config('app.actor', $2, false), set_config('app.operation', $3, false), set_config('app.request_id', $4, false)`, args)
+	if err != nil {
+		return err
+	}
+	c.settings, c.scoped = settings, true
+	return nil
+}
+
+func (c *tenantConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tenantConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c *tenantConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *tenantConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *tenantConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *tenantConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantTx{tx: tx, conn: c}, nil
+}
+
+func (c *tenantConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *tenantConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *tenantConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *tenantConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
+
+// tenantTx forgets the settings of the session when the transaction does not commit.
+type tenantTx struct {
+	tx   driver.Tx
+	conn *tenantConn
+}
+
+func (t *tenantTx) Commit() error {
+	err := t.tx.Commit()
+	if err != nil {
+		t.conn.scoped = false
+	}
+	return err
+}
+
+func (t *tenantTx) Rollback() error {
+	t.conn.scoped = false
+	return t.tx.Rollback()
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+func TestDatabase_TenantIsolation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	other := createTestOrganization("other tests")
+	otherCtx := tenant.WithOrganization(ctx, other.ID)
+	noTenantCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:               "isolated plant",
+		Latitude:           46.5,
+		Longitude:          8.1,
+		PowerPlantMetadata: defaultMetadata,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	portfolio, err := testDB.CreatePortfolio(otherCtx, &types.Portfolio{Name: "other portfolio"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{
+			name: "other organization",
+			ctx:  otherCtx,
+		},
+		{
+			name: "no organization",
+			ctx:  noTenantCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := testDB.GetPowerPlant(tt.ctx, powerPlant.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+			}
+
+			powerPlants, err := testDB.GetPowerPlants(tt.ctx, powerPlant.ID-1, 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, got := range powerPlants {
+				if got.ID == powerPlant.ID {
+					t.Fatalf("expected the power plant of another organization to be hidden, got: %+v", got)
+				}
+			}
+
+			// The policies hide the rows even from a query without any condition on the organization.
+			var count int
+			err = testDB.db.QueryRowContext(tt.ctx, `SELECT COUNT(*) FROM power_plants WHERE id = $1`, powerPlant.ID).Scan(&count)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != 0 {
+				t.Fatalf("expected the power plant of another organization to be hidden, got %d rows", count)
+			}
+
+			update := *powerPlant
+			update.Name = "hijacked plant"
+			if _, err := testDB.UpdatePowerPlant(tt.ctx, &update); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+			}
+			if _, _, err := testDB.RecordProductionReadings(tt.ctx, []types.ProductionReading{
+				{PowerPlantID: powerPlant.ID, Time: time.Date(2024, 9, 6, 12, 0, 0, 0, time.UTC), PowerMW: 1, Quality: types.ProductionQualityMeasured},
+			}); err == nil {
+				t.Fatal("expected the readings of the power plant of another organization to be refused")
+			}
+		})
+	}
+
+	// The references to the rows of another organization fail like references to missing rows.
+	if _, err := testDB.AddPortfolioPowerPlants(otherCtx, portfolio.ID, []int64{powerPlant.ID}); !errors.Is(err, types.ErrPowerPlantNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPowerPlantNotFound, err)
+	}
+	if _, err := testDB.AddPortfolioPowerPlants(ctx, portfolio.ID, []int64{powerPlant.ID}); !errors.Is(err, types.ErrPortfolioNotFound) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrPortfolioNotFound, err)
+	}
+
+	if _, err := testDB.CreatePowerPlant(noTenantCtx, &types.PowerPlant{Name: "orphan plant", Latitude: 1, Longitude: 1}); err == nil {
+		t.Fatal("expected a power plant without organization to be refused")
+	}
+
+	got, err := testDB.GetPowerPlant(ctx, powerPlant.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != powerPlant.Name {
+		t.Fatalf("expected the power plant to be unchanged, got name: %s", got.Name)
+	}
+}
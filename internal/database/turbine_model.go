@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// turbineModelColumns are the columns scanned by scanTurbineModel, in order.
+const turbineModelColumns = `id, name, manufacturer, rated_power_kw, cut_in_speed, cut_out_speed, power_curve,
+	created_at, updated_at`
+
+// foreignKeyViolation is the Postgres error code of a foreign key violation.
+const foreignKeyViolation = "23503"
+
+// CreateTurbineModel creates a new turbine model in the database.
+// This function returns the created turbine model with the generated ID.
+func (d *Database) CreateTurbineModel(ctx context.Context, model *types.TurbineModel) (*types.TurbineModel, error) {
+	powerCurve, err := json.Marshal(model.PowerCurve)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO turbine_models (name, manufacturer, rated_power_kw, cut_in_speed, cut_out_speed, power_curve)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING ` + turbineModelColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		model.Name,
+		model.Manufacturer,
+		model.RatedPowerKW,
+		model.CutInSpeed,
+		model.CutOutSpeed,
+		powerCurve,
+	)
+
+	return scanTurbineModel(row)
+}
+
+// UpdateTurbineModel updates an existing turbine model in the database.
+func (d *Database) UpdateTurbineModel(ctx context.Context, model *types.TurbineModel) (*types.TurbineModel, error) {
+	powerCurve, err := json.Marshal(model.PowerCurve)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE turbine_models
+	SET name = $1, manufacturer = $2, rated_power_kw = $3, cut_in_speed = $4, cut_out_speed = $5, power_curve = $6,
+	updated_at = NOW()
+	WHERE id = $7
+	RETURNING ` + turbineModelColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		model.Name,
+		model.Manufacturer,
+		model.RatedPowerKW,
+		model.CutInSpeed,
+		model.CutOutSpeed,
+		powerCurve,
+		model.ID,
+	)
+
+	return scanTurbineModel(row)
+}
+
+// DeleteTurbineModel deletes the turbine model with the given ID.
+// It returns types.ErrTurbineModelInUse when power plants are assigned to it.
+func (d *Database) DeleteTurbineModel(ctx context.Context, id int64) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM turbine_models WHERE id = $1`, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return types.ErrTurbineModelInUse
+		}
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetTurbineModel returns the turbine model with the given ID.
+func (d *Database) GetTurbineModel(ctx context.Context, id int64) (*types.TurbineModel, error) {
+	query := `SELECT ` + turbineModelColumns + `
+	FROM turbine_models WHERE id = $1`
+
+	row := d.db.QueryRowContext(ctx, query, id)
+
+	return scanTurbineModel(row)
+}
+
+// GetTurbineModels returns the turbine models with the given last ID and count.
+// The turbine models are ordered by ID in ascending order.
+func (d *Database) GetTurbineModels(ctx context.Context, lastID int64, count int) ([]types.TurbineModel, error) {
+	query := `SELECT ` + turbineModelColumns + `
+	FROM turbine_models WHERE id > $1
+	ORDER BY id
+	FETCH FIRST $2 ROWS ONLY`
+
+	rows, err := d.db.QueryContext(ctx, query, lastID, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	models := []types.TurbineModel{}
+	for rows.Next() {
+		model, err := scanTurbineModel(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		models = append(models, *model)
+	}
+
+	return models, rows.Err()
+}
+
+// scanTurbineModel scans a row selected with turbineModelColumns.
+func scanTurbineModel(row scanner) (*types.TurbineModel, error) {
+	var (
+		data         types.TurbineModel
+		manufacturer sql.NullString
+		powerCurve   []byte
+		updatedAt    sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.Name,
+		&manufacturer,
+		&data.RatedPowerKW,
+		&data.CutInSpeed,
+		&data.CutOutSpeed,
+		&powerCurve,
+		&data.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(powerCurve, &data.PowerCurve); err != nil {
+		return nil, err
+	}
+	if manufacturer.Valid {
+		data.Manufacturer = &manufacturer.String
+	}
+	if updatedAt.Valid {
+		data.UpdatedAt = updatedAt.Time
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDatabase_TurbineModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	model, err := testDB.CreateTurbineModel(ctx, &types.TurbineModel{
+		Name:         "V90-2.0",
+		Manufacturer: ptr("Vestas"),
+		RatedPowerKW: 2000,
+		CutInSpeed:   4,
+		CutOutSpeed:  25,
+		PowerCurve: []types.PowerCurvePoint{
+			{WindSpeed: 4, PowerKW: 66},
+			{WindSpeed: 8, PowerKW: 886},
+			{WindSpeed: 12, PowerKW: 1990},
+			{WindSpeed: 13, PowerKW: 2000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if model.ID == 0 || model.CreatedAt.Equal(time.Time{}) {
+		t.Fatalf("expected id and created at to be set, got: %+v", model)
+	}
+
+	model.Name = "V90-1.8"
+	model.RatedPowerKW = 1800
+	model.PowerCurve = model.PowerCurve[:3]
+	model.PowerCurve[2].PowerKW = 1800
+	updated, err := testDB.UpdateTurbineModel(ctx, model)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.UpdatedAt.Equal(time.Time{}) {
+		t.Fatalf("expected updated at to be set, got: %v", updated.UpdatedAt)
+	}
+
+	got, err := testDB.GetTurbineModel(ctx, model.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(model, got, cmpopts.IgnoreFields(types.TurbineModel{}, "CreatedAt", "UpdatedAt")); diff != "" {
+		t.Fatalf("unexpected turbine model (-want +got):\n%s", diff)
+	}
+
+	models, err := testDB.GetTurbineModels(ctx, model.ID-1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 1 || models[0].ID != model.ID {
+		t.Fatalf("expected turbine model %d, got: %+v", model.ID, models)
+	}
+
+	// A turbine model assigned to a power plant can't be deleted.
+	powerPlant, err := testDB.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:      "wind farm",
+		Latitude:  54.1,
+		Longitude: 7.2,
+		PowerPlantMetadata: types.PowerPlantMetadata{
+			Type:           types.PowerPlantTypeWind,
+			CapacityMW:     ptr(9.0),
+			Status:         types.PowerPlantStatusOperational,
+			TurbineModelID: &model.ID,
+			TurbineCount:   ptr(5),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&model.ID, powerPlant.TurbineModelID); diff != "" {
+		t.Fatalf("unexpected turbine model id (-want +got):\n%s", diff)
+	}
+
+	if err := testDB.DeleteTurbineModel(ctx, model.ID); !errors.Is(err, types.ErrTurbineModelInUse) {
+		t.Fatalf("expected error: %v, got: %v", types.ErrTurbineModelInUse, err)
+	}
+
+	powerPlant.TurbineModelID, powerPlant.TurbineCount = nil, nil
+	if _, err := testDB.UpdatePowerPlant(ctx, powerPlant); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := testDB.DeleteTurbineModel(ctx, model.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := testDB.DeleteTurbineModel(ctx, model.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// WeatherSnapshots represents the weather snapshot repository.
+type WeatherSnapshots struct {
+	db *sql.DB
+}
+
+// NewWeatherSnapshots creates a new weather snapshot repository.
+func NewWeatherSnapshots(db *sql.DB) *WeatherSnapshots {
+	return &WeatherSnapshots{
+		db: db,
+	}
+}
+
+// GetWeatherSnapshots returns the stored snapshots of the given locations, in no particular order.
+// Locations without a snapshot are left out.
+func (w *WeatherSnapshots) GetWeatherSnapshots(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherSnapshot, error) {
+	query := `SELECT latitude, longitude, forecast_days, forecast, fetched_at
+		FROM weather_snapshots
+		WHERE forecast_days = $1
+		AND (latitude, longitude) IN (SELECT * FROM UNNEST($2::NUMERIC[], $3::NUMERIC[]))`
+
+	rows, err := w.db.QueryContext(ctx, query, forecastDays, pq.Array(latitudes), pq.Array(longitudes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make([]types.WeatherSnapshot, 0, len(latitudes))
+	for rows.Next() {
+		var (
+			snapshot types.WeatherSnapshot
+			forecast []byte
+		)
+		err := rows.Scan(
+			&snapshot.Latitude,
+			&snapshot.Longitude,
+			&snapshot.ForecastDays,
+			&forecast,
+			&snapshot.FetchedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(forecast, &snapshot.Forecast); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// SaveWeatherSnapshots inserts the snapshots, replacing the stored snapshots of the same locations.
+// A snapshot is not replaced by one fetched before it, e.g. by a slower replica.
+func (w *WeatherSnapshots) SaveWeatherSnapshots(ctx context.Context, snapshots []types.WeatherSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO weather_snapshots
+		(latitude, longitude, forecast_days, forecast, fetched_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (latitude, longitude, forecast_days) DO UPDATE
+		SET forecast = EXCLUDED.forecast, fetched_at = EXCLUDED.fetched_at
+		WHERE weather_snapshots.fetched_at < EXCLUDED.fetched_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, snapshot := range snapshots {
+		forecast, err := json.Marshal(snapshot.Forecast)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			snapshot.Latitude,
+			snapshot.Longitude,
+			snapshot.ForecastDays,
+			forecast,
+			snapshot.FetchedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
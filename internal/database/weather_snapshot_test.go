@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWeatherSnapshots_SaveAndGet(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	fetchedAt := time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC)
+	snapshots := []types.WeatherSnapshot{
+		{
+			Latitude:     52.52,
+			Longitude:    13.41,
+			ForecastDays: 7,
+			Forecast: types.WeatherForecastProperties{
+				HasPrecipitationToday: true,
+				WeatherForecasts: []types.WeatherForecast{
+					{Time: "2024-09-06T00:00", Temperature: ptr(22.1), Precipitation: ptr(0.1), WindSpeed: ptr(11.9)},
+				},
+			},
+			FetchedAt: fetchedAt,
+		},
+		{
+			Latitude:     14.13,
+			Longitude:    15.13,
+			ForecastDays: 7,
+			Forecast: types.WeatherForecastProperties{
+				WeatherForecasts: []types.WeatherForecast{},
+			},
+			FetchedAt: fetchedAt,
+		},
+	}
+
+	if err := testSnapshots.SaveWeatherSnapshots(ctx, snapshots); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An older snapshot does not replace a newer one.
+	older := snapshots[0]
+	older.FetchedAt = fetchedAt.Add(-time.Hour)
+	older.Forecast = types.WeatherForecastProperties{WeatherForecasts: []types.WeatherForecast{}}
+	if err := testSnapshots.SaveWeatherSnapshots(ctx, []types.WeatherSnapshot{older}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := testSnapshots.GetWeatherSnapshots(ctx, []float64{52.52, 14.13, 1.1}, []float64{13.41, 15.13, 2.2}, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byLatitude := map[float64]types.WeatherSnapshot{}
+	for _, snapshot := range got {
+		snapshot.FetchedAt = snapshot.FetchedAt.UTC()
+		byLatitude[snapshot.Latitude] = snapshot
+	}
+
+	expected := map[float64]types.WeatherSnapshot{
+		52.52: snapshots[0],
+		14.13: snapshots[1],
+	}
+	if diff := cmp.Diff(expected, byLatitude); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	got, err = testSnapshots.GetWeatherSnapshots(ctx, []float64{52.52}, []float64{13.41}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no snapshot for other forecast days, got: %d", len(got))
+	}
+}
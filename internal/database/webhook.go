@@ -0,0 +1,358 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/lib/pq"
+)
+
+// webhookSubscriptionColumns are the columns scanned by scanWebhookSubscription, in order.
+const webhookSubscriptionColumns = `id, url, event_types, secret, created_at, updated_at`
+
+// webhookDeliveryColumns are the columns scanned by scanWebhookDelivery, in order.
+const webhookDeliveryColumns = `id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+	delivered_at, created_at`
+
+// CreateWebhookSubscription creates a new webhook subscription in the database.
+func (d *Database) CreateWebhookSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error) {
+	query := `INSERT INTO webhook_subscriptions (url, event_types, secret)
+			VALUES ($1, $2, $3)
+			RETURNING ` + webhookSubscriptionColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		subscription.URL,
+		pq.Array(subscription.EventTypes),
+		subscription.Secret,
+	)
+
+	return scanWebhookSubscription(row)
+}
+
+// UpdateWebhookSubscription updates an existing webhook subscription in the database.
+func (d *Database) UpdateWebhookSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error) {
+	query := `UPDATE webhook_subscriptions
+	SET url = $1, event_types = $2, secret = $3, updated_at = NOW()
+	WHERE id = $4
+	RETURNING ` + webhookSubscriptionColumns
+
+	row := d.db.QueryRowContext(ctx, query,
+		subscription.URL,
+		pq.Array(subscription.EventTypes),
+		subscription.Secret,
+		subscription.ID,
+	)
+
+	return scanWebhookSubscription(row)
+}
+
+// DeleteWebhookSubscription deletes the webhook subscription with the given ID, with its deliveries.
+func (d *Database) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetWebhookSubscription returns the webhook subscription with the given ID.
+func (d *Database) GetWebhookSubscription(ctx context.Context, id int64) (*types.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + `
+	FROM webhook_subscriptions WHERE id = $1`
+
+	return scanWebhookSubscription(d.db.QueryRowContext(ctx, query, id))
+}
+
+// GetWebhookSubscriptions returns the webhook subscriptions with the given last ID and count.
+// The subscriptions are ordered by ID in ascending order.
+func (d *Database) GetWebhookSubscriptions(ctx context.Context, lastID int64, count int) ([]types.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + `
+	FROM webhook_subscriptions WHERE id > $1
+	ORDER BY id
+	FETCH FIRST $2 ROWS ONLY`
+
+	rows, err := d.db.QueryContext(ctx, query, lastID, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []types.WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, *subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// EnqueueWebhookEvent queues a delivery of the payload for every subscription to the event type.
+// It returns the number of queued deliveries.
+func (d *Database) EnqueueWebhookEvent(ctx context.Context, eventType types.WebhookEventType, payload []byte) (int, error) {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+	SELECT id, $1::VARCHAR, $2::JSONB FROM webhook_subscriptions WHERE $1::VARCHAR = ANY(event_types)`
+
+	res, err := d.db.ExecContext(ctx, query, eventType, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+// ClaimWebhookDeliveries returns up to count pending deliveries that are due, oldest first, with the
+// target of their subscription. The claimed deliveries are postponed by lease, so they are not claimed
+// again by another dispatcher while they are attempted, and are retried if the dispatcher stops.
+// next_attempt_at is in UTC, like the next attempts of RecordWebhookAttempt.
+func (d *Database) ClaimWebhookDeliveries(ctx context.Context, count int, lease time.Duration) ([]types.WebhookDispatch, error) {
+	query := `WITH claimed AS (
+		UPDATE webhook_deliveries SET next_attempt_at = (NOW() AT TIME ZONE 'UTC') + $2::BIGINT * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW() AT TIME ZONE 'UTC'
+			ORDER BY next_attempt_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT $1
+		)
+		RETURNING ` + webhookDeliveryColumns + `
+	)
+	SELECT claimed.*, s.url, s.secret
+	FROM claimed JOIN webhook_subscriptions s ON s.id = claimed.subscription_id
+	ORDER BY claimed.next_attempt_at, claimed.id`
+
+	rows, err := d.db.QueryContext(ctx, query, count, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dispatches := []types.WebhookDispatch{}
+	for rows.Next() {
+		var (
+			dispatch types.WebhookDispatch
+			url      string
+			secret   string
+		)
+		delivery, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+
+		dispatch.Delivery, dispatch.URL, dispatch.Secret = *delivery, url, secret
+		if delivery.NextAttemptAt != nil {
+			dispatch.LeasedUntil = *delivery.NextAttemptAt
+		}
+		dispatches = append(dispatches, dispatch)
+	}
+
+	return dispatches, rows.Err()
+}
+
+// RecordWebhookAttempt saves the status, attempts and next attempt of the delivery after an attempt,
+// and adds the attempt to the delivery log. The delivery is only updated while its next attempt is still
+// the lease of the claim, leasedUntil: a delivery redelivered or claimed again during the attempt keeps
+// its new state, the attempt is only added to the log.
+func (d *Database) RecordWebhookAttempt(ctx context.Context, delivery *types.WebhookDelivery, leasedUntil time.Time, attempt *types.WebhookDeliveryAttempt) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE webhook_deliveries
+	SET status = $1, attempts = $2, next_attempt_at = $3, delivered_at = $4
+	WHERE id = $5 AND next_attempt_at = $6`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.ID,
+		leasedUntil,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO webhook_delivery_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+	VALUES ($1, $2, $3, $4, $5)`,
+		delivery.ID,
+		attempt.AttemptedAt,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.DurationMS,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RedeliverWebhook queues the delivery again for an attempt right away, with a fresh count of attempts.
+// The previous attempts are kept in the delivery log.
+func (d *Database) RedeliverWebhook(ctx context.Context, id int64) (*types.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries
+	SET status = 'PENDING', attempts = 0, next_attempt_at = NOW() AT TIME ZONE 'UTC', delivered_at = NULL
+	WHERE id = $1
+	RETURNING ` + webhookDeliveryColumns
+
+	return scanWebhookDelivery(d.db.QueryRowContext(ctx, query, id))
+}
+
+// GetWebhookDelivery returns the webhook delivery with the given ID.
+func (d *Database) GetWebhookDelivery(ctx context.Context, id int64) (*types.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_deliveries WHERE id = $1`
+
+	return scanWebhookDelivery(d.db.QueryRowContext(ctx, query, id))
+}
+
+// GetWebhookDeliveries returns the webhook deliveries with the given last ID and count, of every
+// subscription when subscriptionID is nil and of every status when status is nil.
+// The deliveries are ordered by ID in ascending order.
+func (d *Database) GetWebhookDeliveries(ctx context.Context, subscriptionID *int64, status *types.WebhookDeliveryStatus, lastID int64, count int) ([]types.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_deliveries
+	WHERE id > $1 AND ($2::BIGINT IS NULL OR subscription_id = $2) AND ($3::VARCHAR IS NULL OR status = $3)
+	ORDER BY id
+	FETCH FIRST $4 ROWS ONLY`
+
+	rows, err := d.db.QueryContext(ctx, query, lastID, subscriptionID, status, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// GetWebhookDeliveryAttempts returns the delivery log of the delivery, oldest first.
+func (d *Database) GetWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]types.WebhookDeliveryAttempt, error) {
+	query := `SELECT id, delivery_id, attempted_at, status_code, error, duration_ms
+	FROM webhook_delivery_attempts WHERE delivery_id = $1
+	ORDER BY id`
+
+	rows, err := d.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []types.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var (
+			attempt    types.WebhookDeliveryAttempt
+			statusCode sql.NullInt64
+			attemptErr sql.NullString
+		)
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.AttemptedAt,
+			&statusCode,
+			&attemptErr,
+			&attempt.DurationMS,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			attempt.StatusCode = &code
+		}
+		if attemptErr.Valid {
+			attempt.Error = &attemptErr.String
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+// scanWebhookSubscription scans a row selected with webhookSubscriptionColumns.
+func scanWebhookSubscription(row scanner) (*types.WebhookSubscription, error) {
+	var (
+		data       types.WebhookSubscription
+		eventTypes pq.StringArray
+		updatedAt  sql.NullTime
+	)
+	err := row.Scan(
+		&data.ID,
+		&data.URL,
+		&eventTypes,
+		&data.Secret,
+		&data.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	data.EventTypes = make([]types.WebhookEventType, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		data.EventTypes = append(data.EventTypes, types.WebhookEventType(eventType))
+	}
+	if updatedAt.Valid {
+		data.UpdatedAt = updatedAt.Time
+	}
+
+	return &data, nil
+}
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns, followed by the extra columns.
+func scanWebhookDelivery(row scanner, extra ...any) (*types.WebhookDelivery, error) {
+	var (
+		data          types.WebhookDelivery
+		nextAttemptAt sql.NullTime
+		deliveredAt   sql.NullTime
+	)
+	dest := []any{
+		&data.ID,
+		&data.SubscriptionID,
+		&data.EventType,
+		&data.Payload,
+		&data.Status,
+		&data.Attempts,
+		&nextAttemptAt,
+		&deliveredAt,
+		&data.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if nextAttemptAt.Valid {
+		data.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		data.DeliveredAt = &deliveredAt.Time
+	}
+
+	return &data, nil
+}
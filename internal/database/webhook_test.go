@@ -0,0 +1,178 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDatabase_Webhook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(testContext(), 15*time.Second)
+	defer cancel()
+
+	subscription, err := testDB.CreateWebhookSubscription(ctx, &types.WebhookSubscription{
+		URL:        "https://incidents.example.com/hooks/energy",
+		EventTypes: []types.WebhookEventType{types.WebhookEventAlertTriggered},
+		Secret:     "0123456789abcdef",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if subscription.ID == 0 || subscription.CreatedAt.Equal(time.Time{}) {
+		t.Fatalf("expected id and created at to be set, got: %+v", subscription)
+	}
+
+	subscription.EventTypes = append(subscription.EventTypes, types.WebhookEventAlertResolved)
+	updated, err := testDB.UpdateWebhookSubscription(ctx, subscription)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(subscription, updated, cmpopts.IgnoreFields(types.WebhookSubscription{}, "UpdatedAt")); diff != "" {
+		t.Fatalf("unexpected webhook subscription (-want +got):\n%s", diff)
+	}
+
+	// Only the subscriptions to the event type get a delivery.
+	queued, err := testDB.EnqueueWebhookEvent(ctx, types.WebhookEventPowerPlantCreated, []byte(`{"type":"POWER_PLANT_CREATED"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deliveries, err := testDB.GetWebhookDeliveries(ctx, &subscription.ID, nil, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no delivery, got %d queued: %+v", queued, deliveries)
+	}
+
+	if _, err := testDB.EnqueueWebhookEvent(ctx, types.WebhookEventAlertResolved, []byte(`{"type":"ALERT_RESOLVED"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deliveries, err = testDB.GetWebhookDeliveries(ctx, &subscription.ID, ptr(types.WebhookDeliveryPending), 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].EventType != types.WebhookEventAlertResolved || deliveries[0].Payload != `{"type": "ALERT_RESOLVED"}` {
+		t.Fatalf("expected 1 pending ALERT_RESOLVED delivery, got: %+v", deliveries)
+	}
+
+	// A claimed delivery is leased and not claimed again.
+	dispatches, err := testDB.ClaimWebhookDeliveries(ctx, 1000, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var dispatch *types.WebhookDispatch
+	for i := range dispatches {
+		if dispatches[i].Delivery.ID == deliveries[0].ID {
+			dispatch = &dispatches[i]
+		}
+	}
+	if dispatch == nil || dispatch.URL != subscription.URL || dispatch.Secret != subscription.Secret {
+		t.Fatalf("expected delivery %d to be claimed, got: %+v", deliveries[0].ID, dispatches)
+	}
+	dispatches, err = testDB.ClaimWebhookDeliveries(ctx, 1000, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, d := range dispatches {
+		if d.Delivery.ID == deliveries[0].ID {
+			t.Fatalf("expected delivery %d to be leased, got it claimed again", d.Delivery.ID)
+		}
+	}
+
+	delivery := dispatch.Delivery
+	delivery.Status = types.WebhookDeliveryDead
+	delivery.Attempts = 1
+	delivery.NextAttemptAt = nil
+	err = testDB.RecordWebhookAttempt(ctx, &delivery, dispatch.LeasedUntil, &types.WebhookDeliveryAttempt{
+		AttemptedAt: time.Now().UTC(),
+		StatusCode:  ptr(500),
+		DurationMS:  42,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := testDB.GetWebhookDelivery(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != types.WebhookDeliveryDead || got.Attempts != 1 || got.NextAttemptAt != nil {
+		t.Fatalf("expected a dead delivery, got: %+v", got)
+	}
+
+	redelivered, err := testDB.RedeliverWebhook(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if redelivered.Status != types.WebhookDeliveryPending || redelivered.Attempts != 0 || redelivered.NextAttemptAt == nil {
+		t.Fatalf("expected a pending delivery, got: %+v", redelivered)
+	}
+
+	attempts, err := testDB.GetWebhookDeliveryAttempts(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attempts) != 1 || *attempts[0].StatusCode != 500 || attempts[0].Error != nil || attempts[0].DurationMS != 42 {
+		t.Fatalf("expected the attempt to be kept in the log, got: %+v", attempts)
+	}
+
+	// A redelivery during an attempt is not overwritten by the attempt, which is only logged.
+	dispatches, err = testDB.ClaimWebhookDeliveries(ctx, 1000, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dispatch = nil
+	for i := range dispatches {
+		if dispatches[i].Delivery.ID == delivery.ID {
+			dispatch = &dispatches[i]
+		}
+	}
+	if dispatch == nil || dispatch.LeasedUntil.IsZero() {
+		t.Fatalf("expected delivery %d to be claimed with a lease, got: %+v", delivery.ID, dispatches)
+	}
+	if _, err := testDB.RedeliverWebhook(ctx, delivery.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delivery = dispatch.Delivery
+	delivery.Status = types.WebhookDeliveryDead
+	delivery.Attempts = 8
+	delivery.NextAttemptAt = nil
+	err = testDB.RecordWebhookAttempt(ctx, &delivery, dispatch.LeasedUntil, &types.WebhookDeliveryAttempt{
+		AttemptedAt: time.Now().UTC(),
+		StatusCode:  ptr(500),
+		DurationMS:  42,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err = testDB.GetWebhookDelivery(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != types.WebhookDeliveryPending || got.Attempts != 0 {
+		t.Fatalf("expected the redelivered delivery to stay pending, got: %+v", got)
+	}
+	attempts, err = testDB.GetWebhookDeliveryAttempts(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts in the log, got: %+v", attempts)
+	}
+
+	if err := testDB.DeleteWebhookSubscription(ctx, subscription.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := testDB.DeleteWebhookSubscription(ctx, subscription.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+	if _, err := testDB.GetWebhookDelivery(ctx, delivery.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+}
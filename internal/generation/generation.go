@@ -0,0 +1,91 @@
+// Package generation computes the expected output of power plants from weather forecasts.
+//
+// The models are deliberately simple: they are meant to rank hours and plants, not to settle
+// energy trades. Each model returns one GenerationForecast per hour of the weather forecast,
+// with a nil power when an input is missing for the hour.
+package generation
+
+import (
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// Inputs are the data the models need besides the power plant and its weather forecasts.
+type Inputs struct {
+	// TiltedIrradiances are the hourly irradiances on the panels of solar power plants with a solar array.
+	TiltedIrradiances []types.TiltedIrradiance
+	// Discharges are the daily river discharges of hydro power plants.
+	Discharges []types.RiverDischarge
+	// TurbineModel is the turbine model of wind power plants with turbines,
+	// the others use a generic power curve.
+	TurbineModel *types.TurbineModel
+	// MaintenanceWindows are the maintenance windows of the power plant overlapping the forecast.
+	MaintenanceWindows []types.MaintenanceWindow
+}
+
+// Forecast returns the expected hourly output of the power plant from its weather forecasts,
+// reduced by its maintenance windows, see Maintenance.
+// It returns types.ErrNoGenerationModel for power plant types without a model, e.g. storage.
+func Forecast(powerPlant types.PowerPlant, inputs Inputs) ([]types.GenerationForecast, error) {
+	forecast, err := model(powerPlant, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs.MaintenanceWindows) == 0 {
+		return forecast, nil
+	}
+	return Maintenance(forecast, *powerPlant.CapacityMW, inputs.MaintenanceWindows)
+}
+
+// model returns the output of the generation model of the power plant type.
+func model(powerPlant types.PowerPlant, inputs Inputs) ([]types.GenerationForecast, error) {
+	switch powerPlant.Type {
+	case types.PowerPlantTypeSolar:
+		if powerPlant.SolarArray != nil {
+			params := NewSolarArrayParams(*powerPlant.CapacityMW, *powerPlant.DCCapacityMW, *powerPlant.SolarArray)
+			return SolarTilted(powerPlant.WeatherForecasts, inputs.TiltedIrradiances, params), nil
+		}
+		return Solar(powerPlant.WeatherForecasts, NewSolarParams(*powerPlant.CapacityMW, *powerPlant.DCCapacityMW)), nil
+	case types.PowerPlantTypeWind:
+		if inputs.TurbineModel != nil && powerPlant.TurbineCount != nil {
+			return Turbines(powerPlant.WeatherForecasts, TurbineParams{
+				Model:      *inputs.TurbineModel,
+				Count:      *powerPlant.TurbineCount,
+				ElevationM: powerPlant.Elevation,
+			}), nil
+		}
+		return Wind(powerPlant.WeatherForecasts, NewWindParams(*powerPlant.CapacityMW)), nil
+	case types.PowerPlantTypeHydro:
+		if powerPlant.DesignDischargeM3s == nil {
+			return nil, types.ErrDesignDischargeRequired
+		}
+		return Hydro(powerPlant.WeatherForecasts, inputs.Discharges, HydroParams{
+			CapacityMW:         *powerPlant.CapacityMW,
+			DesignDischargeM3s: *powerPlant.DesignDischargeM3s,
+		}), nil
+	default:
+		return nil, types.ErrNoGenerationModel
+	}
+}
+
+// forecastEach applies power to every hour of the forecasts.
+func forecastEach(forecasts []types.WeatherForecast, power func(types.WeatherForecast) *float64) []types.GenerationForecast {
+	generation := make([]types.GenerationForecast, 0, len(forecasts))
+	for _, forecast := range forecasts {
+		generation = append(generation, types.GenerationForecast{
+			Time:    forecast.Time,
+			PowerMW: power(forecast),
+		})
+	}
+	return generation
+}
+
+// clamp returns v limited to the range [low, high].
+func clamp(v, low, high float64) float64 {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
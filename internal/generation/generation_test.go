@@ -0,0 +1,95 @@
+package generation
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestForecast(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		{Time: "2024-09-06T12:00", Temperature: ptr(25.0), ShortwaveRadiation: ptr(1000.0), WindSpeed100m: ptr(27.0)},
+	}
+	irradiances := []types.TiltedIrradiance{
+		{Time: "2024-09-06T12:00", Irradiance: ptr(900.0)},
+	}
+	discharges := []types.RiverDischarge{
+		{Date: "2024-09-06", Discharge: ptr(40.0)},
+	}
+
+	tests := []struct {
+		name         string
+		metadata     types.PowerPlantMetadata
+		turbineModel *types.TurbineModel
+		expected     []types.GenerationForecast
+		expectErr    error
+	}{
+		{
+			name:     "solar",
+			metadata: types.PowerPlantMetadata{Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.5)},
+			expected: []types.GenerationForecast{{Time: "2024-09-06T12:00", PowerMW: ptr(9.03)}},
+		},
+		{
+			// Cell at 53.125 °C: 12.5 MW * 0.9 * 0.915625 * 0.9 * 0.96.
+			name: "solar with a solar array",
+			metadata: types.PowerPlantMetadata{
+				Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.5),
+				SolarArray: &types.SolarArrayConfig{Tilt: 30, Tracking: types.SolarTrackingFixed, TemperatureCoefficient: -0.003, SystemLosses: 0.1},
+			},
+			expected: []types.GenerationForecast{{Time: "2024-09-06T12:00", PowerMW: ptr(8.899875)}},
+		},
+		{
+			name:     "wind",
+			metadata: types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(30.0)},
+			expected: []types.GenerationForecast{{Time: "2024-09-06T12:00", PowerMW: ptr(6.964285714285714)}},
+		},
+		{
+			// 7.5 m/s at the standard air density: 3 turbines * 733.5 kW.
+			name:         "wind with turbines",
+			metadata:     types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(6.0), TurbineModelID: ptr(int64(1)), TurbineCount: ptr(3)},
+			turbineModel: &testTurbineModel,
+			expected:     []types.GenerationForecast{{Time: "2024-09-06T12:00", PowerMW: ptr(2.2005)}},
+		},
+		{
+			name:     "hydro",
+			metadata: types.PowerPlantMetadata{Type: types.PowerPlantTypeHydro, CapacityMW: ptr(20.0), DesignDischargeM3s: ptr(80.0)},
+			expected: []types.GenerationForecast{{Time: "2024-09-06T12:00", PowerMW: ptr(10.0)}},
+		},
+		{
+			name:      "failed, hydro without design discharge",
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeHydro, CapacityMW: ptr(20.0)},
+			expectErr: types.ErrDesignDischargeRequired,
+		},
+		{
+			name:      "failed, storage",
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeStorage, CapacityMW: ptr(20.0)},
+			expectErr: types.ErrNoGenerationModel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			powerPlant := types.PowerPlant{
+				PowerPlantMetadata:        tt.metadata,
+				WeatherForecastProperties: types.WeatherForecastProperties{WeatherForecasts: forecasts},
+			}
+
+			generation, err := Forecast(powerPlant, Inputs{TiltedIrradiances: irradiances, Discharges: discharges, TurbineModel: tt.turbineModel})
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected generation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package generation
+
+import (
+	"strings"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// HydroParams are the parameters of the hydro generation model.
+type HydroParams struct {
+	CapacityMW float64
+	// DesignDischargeM3s is the discharge in m³/s at which the power plant reaches its capacity.
+	DesignDischargeM3s float64
+}
+
+// Hydro returns the expected output of a run-of-river hydro power plant, from the daily river discharges.
+// Every hour of a day gets the output of the discharge of that day.
+func Hydro(forecasts []types.WeatherForecast, discharges []types.RiverDischarge, params HydroParams) []types.GenerationForecast {
+	byDate := make(map[string]*float64, len(discharges))
+	for _, discharge := range discharges {
+		byDate[discharge.Date] = discharge.Discharge
+	}
+
+	return forecastEach(forecasts, func(forecast types.WeatherForecast) *float64 {
+		date, _, _ := strings.Cut(forecast.Time, "T")
+		discharge := byDate[date]
+		if discharge == nil {
+			return nil
+		}
+		power := HydroPower(*discharge, params)
+		return &power
+	})
+}
+
+// HydroPower returns the output in MW for a river discharge in m³/s.
+// The head of a run-of-river power plant barely changes, so the output is proportional to the
+// discharge up to the design discharge; the water above it is spilled.
+func HydroPower(discharge float64, params HydroParams) float64 {
+	return params.CapacityMW * clamp(discharge/params.DesignDischargeM3s, 0, 1)
+}
@@ -0,0 +1,71 @@
+package generation
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestHydroPower(t *testing.T) {
+	tests := []struct {
+		name      string
+		discharge float64
+		expected  float64
+	}{
+		{
+			name:      "dry river",
+			discharge: 0,
+			expected:  0,
+		},
+		{
+			name:      "below design discharge",
+			discharge: 30,
+			expected:  7.5,
+		},
+		{
+			name:      "design discharge",
+			discharge: 80,
+			expected:  20,
+		},
+		{
+			name:      "flood, spilled above design discharge",
+			discharge: 400,
+			expected:  20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			power := HydroPower(tt.discharge, HydroParams{CapacityMW: 20, DesignDischargeM3s: 80})
+			if diff := cmp.Diff(tt.expected, power, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected power (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHydro(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		{Time: "2024-09-06T00:00"},
+		{Time: "2024-09-06T23:00"},
+		{Time: "2024-09-07T00:00"},
+		{Time: "2024-09-08T00:00"},
+	}
+	discharges := []types.RiverDischarge{
+		{Date: "2024-09-06", Discharge: ptr(40.0)},
+		{Date: "2024-09-07"},
+	}
+	expected := []types.GenerationForecast{
+		{Time: "2024-09-06T00:00", PowerMW: ptr(10.0)},
+		{Time: "2024-09-06T23:00", PowerMW: ptr(10.0)},
+		{Time: "2024-09-07T00:00"},
+		{Time: "2024-09-08T00:00"},
+	}
+
+	generation := Hydro(forecasts, discharges, HydroParams{CapacityMW: 20, DesignDischargeM3s: 80})
+	if diff := cmp.Diff(expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected generation (-want +got):\n%s", diff)
+	}
+}
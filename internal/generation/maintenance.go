@@ -0,0 +1,37 @@
+package generation
+
+import (
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// Maintenance reduces the hourly output by the capacity unavailable during the maintenance windows.
+// Like the weather, an hourly forecast covers the hour before its time, and a window covering part of
+// the hour reduces it in proportion. The output is scaled down by the unavailable share of the capacity,
+// e.g. 2 turbines out of 10 down remove 20% of the output of a wind farm whatever the wind.
+func Maintenance(forecasts []types.GenerationForecast, capacityMW float64, windows []types.MaintenanceWindow) ([]types.GenerationForecast, error) {
+	reduced := make([]types.GenerationForecast, 0, len(forecasts))
+	for _, forecast := range forecasts {
+		if forecast.PowerMW == nil {
+			reduced = append(reduced, forecast)
+			continue
+		}
+
+		end, err := time.Parse(types.ForecastTimeLayout, forecast.Time)
+		if err != nil {
+			return nil, err
+		}
+		start := end.Add(-time.Hour)
+
+		unavailable := 0.0
+		for _, window := range windows {
+			overlap := window.Overlap(start, end)
+			unavailable += float64(overlap) / float64(time.Hour) * window.UnavailableFraction(capacityMW)
+		}
+
+		power := *forecast.PowerMW * (1 - clamp(unavailable, 0, 1))
+		reduced = append(reduced, types.GenerationForecast{Time: forecast.Time, PowerMW: &power})
+	}
+	return reduced, nil
+}
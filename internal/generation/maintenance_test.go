@@ -0,0 +1,82 @@
+package generation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMaintenance(t *testing.T) {
+	forecasts := []types.GenerationForecast{
+		{Time: "2024-09-06T10:00", PowerMW: ptr(20.0)},
+		{Time: "2024-09-06T11:00", PowerMW: ptr(20.0)},
+		{Time: "2024-09-06T12:00"},
+		{Time: "2024-09-06T13:00", PowerMW: ptr(20.0)},
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 9, 6, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		windows   []types.MaintenanceWindow
+		expected  []types.GenerationForecast
+		expectErr bool
+	}{
+		{
+			name:    "unavailable MW, whole hours",
+			windows: []types.MaintenanceWindow{{Start: at(9, 0), End: at(11, 0), UnavailableMW: ptr(10.0)}},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T10:00", PowerMW: ptr(15.0)},
+				{Time: "2024-09-06T11:00", PowerMW: ptr(15.0)},
+				{Time: "2024-09-06T12:00"},
+				{Time: "2024-09-06T13:00", PowerMW: ptr(20.0)},
+			},
+		},
+		{
+			name: "unavailable percent, partial hours",
+			windows: []types.MaintenanceWindow{
+				{Start: at(9, 30), End: at(10, 30), UnavailablePercent: ptr(100.0)},
+				{Start: at(12, 0), End: at(12, 15), UnavailablePercent: ptr(50.0)},
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T10:00", PowerMW: ptr(10.0)},
+				{Time: "2024-09-06T11:00", PowerMW: ptr(10.0)},
+				{Time: "2024-09-06T12:00"},
+				{Time: "2024-09-06T13:00", PowerMW: ptr(17.5)},
+			},
+		},
+		{
+			name:      "failed, invalid time",
+			windows:   []types.MaintenanceWindow{{Start: at(9, 0), End: at(11, 0), UnavailableMW: ptr(10.0)}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := forecasts
+			if tt.expectErr {
+				input = []types.GenerationForecast{{Time: "noon", PowerMW: ptr(1.0)}}
+			}
+
+			got, err := Maintenance(input, 40, tt.windows)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected forecast (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package generation
+
+import (
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+const (
+	// standardIrradiance is the irradiance of the standard test conditions the panels are rated at, in W/m².
+	standardIrradiance = 1000
+	// standardCellTemperature is the cell temperature of the standard test conditions, in °C.
+	standardCellTemperature = 25
+	// nominalOperatingCellTemperature is the cell temperature at 800 W/m² and 20 °C ambient, in °C.
+	nominalOperatingCellTemperature = 45
+
+	// DefaultTemperatureCoefficient is the relative power change per °C of cell temperature of crystalline silicon panels.
+	DefaultTemperatureCoefficient = -0.004
+	// DefaultSystemLosses is the fraction of the DC output lost to soiling, shading, mismatch and wiring, as in PVWatts.
+	DefaultSystemLosses = 0.14
+	// DefaultInverterEfficiency is the DC to AC efficiency of the inverters.
+	DefaultInverterEfficiency = 0.96
+)
+
+// SolarParams are the parameters of the solar generation model.
+type SolarParams struct {
+	// ACCapacityMW is the inverter capacity, the output is clipped at it.
+	ACCapacityMW float64
+	// DCCapacityMW is the panel capacity at the standard test conditions.
+	DCCapacityMW           float64
+	TemperatureCoefficient float64
+	SystemLosses           float64
+	InverterEfficiency     float64
+}
+
+// NewSolarParams returns the parameters of a solar power plant with the default losses and panels.
+func NewSolarParams(acCapacityMW float64, dcCapacityMW float64) SolarParams {
+	return SolarParams{
+		ACCapacityMW:           acCapacityMW,
+		DCCapacityMW:           dcCapacityMW,
+		TemperatureCoefficient: DefaultTemperatureCoefficient,
+		SystemLosses:           DefaultSystemLosses,
+		InverterEfficiency:     DefaultInverterEfficiency,
+	}
+}
+
+// NewSolarArrayParams returns the parameters of a solar power plant with the losses and panels of its solar array.
+func NewSolarArrayParams(acCapacityMW float64, dcCapacityMW float64, array types.SolarArrayConfig) SolarParams {
+	params := NewSolarParams(acCapacityMW, dcCapacityMW)
+	params.TemperatureCoefficient = array.TemperatureCoefficient
+	params.SystemLosses = array.SystemLosses
+	return params
+}
+
+// Solar returns the expected output of a solar power plant, from the irradiance and the temperature.
+// The panels are assumed flat, so the irradiance on the panels is the global horizontal irradiance.
+// SolarTilted is used for power plants with a solar array.
+func Solar(forecasts []types.WeatherForecast, params SolarParams) []types.GenerationForecast {
+	return forecastEach(forecasts, func(forecast types.WeatherForecast) *float64 {
+		if forecast.ShortwaveRadiation == nil || forecast.Temperature == nil {
+			return nil
+		}
+		power := SolarPower(*forecast.ShortwaveRadiation, *forecast.Temperature, params)
+		return &power
+	})
+}
+
+// SolarPower returns the AC output in MW for an irradiance on the panels in W/m² and an air temperature in °C.
+//  1. The cell temperature rises above the air temperature with the irradiance, following the NOCT model.
+//  2. The DC output is proportional to the irradiance, corrected by the temperature coefficient.
+//  3. The system losses and the inverter efficiency are applied, and the output is clipped at the AC capacity.
+func SolarPower(irradiance float64, temperature float64, params SolarParams) float64 {
+	if irradiance <= 0 {
+		return 0
+	}
+
+	cellTemperature := temperature + irradiance/800*(nominalOperatingCellTemperature-20)
+	dc := params.DCCapacityMW * irradiance / standardIrradiance *
+		(1 + params.TemperatureCoefficient*(cellTemperature-standardCellTemperature))
+	ac := dc * (1 - params.SystemLosses) * params.InverterEfficiency
+
+	return clamp(ac, 0, params.ACCapacityMW)
+}
+
+// SolarTilted returns the expected output of a solar power plant with a solar array, from the irradiance
+// on its panels and the temperature. The irradiances are matched to the forecasts by time.
+func SolarTilted(forecasts []types.WeatherForecast, irradiances []types.TiltedIrradiance, params SolarParams) []types.GenerationForecast {
+	byTime := make(map[string]*float64, len(irradiances))
+	for _, irradiance := range irradiances {
+		byTime[irradiance.Time] = irradiance.Irradiance
+	}
+
+	return forecastEach(forecasts, func(forecast types.WeatherForecast) *float64 {
+		irradiance := byTime[forecast.Time]
+		if irradiance == nil || forecast.Temperature == nil {
+			return nil
+		}
+		power := SolarPower(*irradiance, *forecast.Temperature, params)
+		return &power
+	})
+}
@@ -0,0 +1,124 @@
+package generation
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestSolarPower(t *testing.T) {
+	tests := []struct {
+		name        string
+		irradiance  float64
+		temperature float64
+		params      SolarParams
+		expected    float64
+	}{
+		{
+			name:        "night",
+			irradiance:  0,
+			temperature: 12,
+			params:      NewSolarParams(10, 12.5),
+			expected:    0,
+		},
+		{
+			// Cell at 56.25 °C: 12.5 MW * 0.875 * 0.86 * 0.96.
+			name:        "standard irradiance",
+			irradiance:  1000,
+			temperature: 25,
+			params:      NewSolarParams(10, 12.5),
+			expected:    9.03,
+		},
+		{
+			// Cell at 45 °C: 12.5 MW * 0.8 * 0.92 * 0.86 * 0.96.
+			name:        "nominal operating conditions",
+			irradiance:  800,
+			temperature: 20,
+			params:      NewSolarParams(10, 12.5),
+			expected:    7.59552,
+		},
+		{
+			// Cell at 31.25 °C: 20 MW * 0.975 * 0.86 * 0.96 = 16.0992 MW, above the inverter capacity.
+			name:        "clipped at the inverter capacity",
+			irradiance:  1000,
+			temperature: 0,
+			params:      NewSolarParams(10, 20),
+			expected:    10,
+		},
+		{
+			name:        "no losses, cell at the standard temperature",
+			irradiance:  500,
+			temperature: 9.375,
+			params:      SolarParams{ACCapacityMW: 10, DCCapacityMW: 10, InverterEfficiency: 1},
+			expected:    5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			power := SolarPower(tt.irradiance, tt.temperature, tt.params)
+			if diff := cmp.Diff(tt.expected, power, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected power (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSolar(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		{Time: "2024-09-06T00:00", Temperature: ptr(15.0), ShortwaveRadiation: ptr(0.0)},
+		{Time: "2024-09-06T12:00", Temperature: ptr(25.0), ShortwaveRadiation: ptr(1000.0)},
+		{Time: "2024-09-06T13:00", Temperature: ptr(25.0)},
+		{Time: "2024-09-06T14:00", ShortwaveRadiation: ptr(800.0)},
+	}
+	expected := []types.GenerationForecast{
+		{Time: "2024-09-06T00:00", PowerMW: ptr(0.0)},
+		{Time: "2024-09-06T12:00", PowerMW: ptr(9.03)},
+		{Time: "2024-09-06T13:00"},
+		{Time: "2024-09-06T14:00"},
+	}
+
+	generation := Solar(forecasts, NewSolarParams(10, 12.5))
+	if diff := cmp.Diff(expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected generation (-want +got):\n%s", diff)
+	}
+}
+
+func TestSolarTilted(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		{Time: "2024-09-06T00:00", Temperature: ptr(15.0), ShortwaveRadiation: ptr(0.0)},
+		{Time: "2024-09-06T12:00", Temperature: ptr(20.0), ShortwaveRadiation: ptr(600.0)},
+		{Time: "2024-09-06T13:00", Temperature: ptr(20.0), ShortwaveRadiation: ptr(600.0)},
+		{Time: "2024-09-06T14:00", ShortwaveRadiation: ptr(500.0)},
+	}
+	irradiances := []types.TiltedIrradiance{
+		{Time: "2024-09-06T00:00", Irradiance: ptr(0.0)},
+		{Time: "2024-09-06T12:00", Irradiance: ptr(800.0)},
+		{Time: "2024-09-06T13:00"},
+		{Time: "2024-09-06T14:00", Irradiance: ptr(700.0)},
+	}
+	// Cell at 45 °C at noon: 12.5 MW * 0.8 * 0.92 * 0.9 * 0.96, the horizontal irradiance is not used.
+	expected := []types.GenerationForecast{
+		{Time: "2024-09-06T00:00", PowerMW: ptr(0.0)},
+		{Time: "2024-09-06T12:00", PowerMW: ptr(7.9488)},
+		{Time: "2024-09-06T13:00"},
+		{Time: "2024-09-06T14:00"},
+	}
+
+	params := NewSolarArrayParams(10, 12.5, types.SolarArrayConfig{
+		Tilt:                   30,
+		Tracking:               types.SolarTrackingFixed,
+		TemperatureCoefficient: -0.004,
+		SystemLosses:           0.1,
+	})
+	generation := SolarTilted(forecasts, irradiances, params)
+	if diff := cmp.Diff(expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected generation (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,87 @@
+package generation
+
+import (
+	"math"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+const (
+	// StandardAirDensity is the air density power curves are measured at, in kg/m³.
+	StandardAirDensity = 1.225
+	// hubHeight is the height of the wind speed forecast the power curves are fed with, in m.
+	hubHeight = 100
+
+	// specificGasConstant is the specific gas constant of dry air, in J/(kg·K).
+	specificGasConstant = 287.05
+	// lapseRate is the standard decrease of the temperature with height, in K/m.
+	lapseRate = 0.0065
+	// barometricExponent is g·M/(R·L) of the barometric formula with the standard lapse rate.
+	barometricExponent = 5.255
+)
+
+// TurbineParams are the parameters of the wind generation model of a power plant with a turbine model.
+type TurbineParams struct {
+	Model types.TurbineModel
+	Count int
+	// ElevationM is the elevation of the power plant, the turbine hubs are 100 m above it.
+	ElevationM float64
+}
+
+// Turbines returns the expected output of a wind power plant from the power curve of its turbines,
+// fed by the wind speed at 100 m. The air density at the hubs is computed from the pressure and
+// the temperature, or is the standard air density for the hours without them.
+func Turbines(forecasts []types.WeatherForecast, params TurbineParams) []types.GenerationForecast {
+	return forecastEach(forecasts, func(forecast types.WeatherForecast) *float64 {
+		if forecast.WindSpeed100m == nil {
+			return nil
+		}
+
+		density := StandardAirDensity
+		if forecast.PressureMSL != nil && forecast.Temperature != nil {
+			density = AirDensity(*forecast.PressureMSL, *forecast.Temperature, params.ElevationM, hubHeight)
+		}
+		power := float64(params.Count) * TurbinePower(*forecast.WindSpeed100m*kmhToMs, density, params.Model) / 1000
+		return &power
+	})
+}
+
+// AirDensity returns the density of dry air in kg/m³ at a hub hubHeight m above the ground, at an elevation
+// in m, from the pressure reduced to sea level in hPa and the 2 m air temperature in °C. The temperature
+// at the hub and the pressure at its height follow the standard lapse rate, so both are taken at the hub.
+func AirDensity(pressureMSL float64, temperature float64, elevation float64, hubHeight float64) float64 {
+	height := elevation + hubHeight
+	kelvin := temperature + 273.15 - lapseRate*hubHeight
+	seaLevelKelvin := kelvin + lapseRate*height
+	pressure := pressureMSL * 100 * math.Pow(1-lapseRate*height/seaLevelKelvin, barometricExponent)
+
+	return pressure / (specificGasConstant * kelvin)
+}
+
+// TurbinePower returns the output of one turbine in kW for a hub height wind speed in m/s and an air density in kg/m³.
+// The turbine only produces between its cut-in and cut-out speeds. The wind speed is normalized to the
+// standard air density of the power curve, as in IEC 61400-12-1, and the power curve is linearly interpolated at it.
+func TurbinePower(windSpeed float64, airDensity float64, model types.TurbineModel) float64 {
+	if windSpeed < model.CutInSpeed || windSpeed > model.CutOutSpeed {
+		return 0
+	}
+
+	normalized := windSpeed * math.Cbrt(airDensity/StandardAirDensity)
+	return clamp(interpolateCurve(model.PowerCurve, normalized), 0, model.RatedPowerKW)
+}
+
+// interpolateCurve returns the power of the curve at the wind speed, the power of the first or the
+// last point outside of the curve.
+func interpolateCurve(curve []types.PowerCurvePoint, windSpeed float64) float64 {
+	if windSpeed <= curve[0].WindSpeed {
+		return curve[0].PowerKW
+	}
+
+	for i := 1; i < len(curve); i++ {
+		if windSpeed <= curve[i].WindSpeed {
+			from, to := curve[i-1], curve[i]
+			return from.PowerKW + (to.PowerKW-from.PowerKW)*(windSpeed-from.WindSpeed)/(to.WindSpeed-from.WindSpeed)
+		}
+	}
+	return curve[len(curve)-1].PowerKW
+}
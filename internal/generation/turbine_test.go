@@ -0,0 +1,155 @@
+package generation
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// testTurbineModel is a 2 MW turbine with the power curve of a Vestas V90.
+var testTurbineModel = types.TurbineModel{
+	Name:         "V90-2.0",
+	RatedPowerKW: 2000,
+	CutInSpeed:   3,
+	CutOutSpeed:  25,
+	PowerCurve: []types.PowerCurvePoint{
+		{WindSpeed: 3, PowerKW: 0},
+		{WindSpeed: 4, PowerKW: 75},
+		{WindSpeed: 5, PowerKW: 190},
+		{WindSpeed: 6, PowerKW: 353},
+		{WindSpeed: 7, PowerKW: 581},
+		{WindSpeed: 8, PowerKW: 886},
+		{WindSpeed: 9, PowerKW: 1273},
+		{WindSpeed: 10, PowerKW: 1710},
+		{WindSpeed: 11, PowerKW: 1985},
+		{WindSpeed: 12, PowerKW: 2000},
+		{WindSpeed: 25, PowerKW: 2000},
+	},
+}
+
+func TestAirDensity(t *testing.T) {
+	tests := []struct {
+		name        string
+		pressureMSL float64
+		temperature float64
+		elevation   float64
+		hubHeight   float64
+		expected    float64
+	}{
+		{
+			name:        "standard atmosphere at sea level",
+			pressureMSL: 1013.25,
+			temperature: 15,
+			expected:    1.2250122659906946,
+		},
+		{
+			name:        "standard atmosphere at 1000 m",
+			pressureMSL: 1013.25,
+			temperature: 8.5,
+			elevation:   1000,
+			expected:    1.1116759301510082,
+		},
+		{
+			name:        "cold high pressure",
+			pressureMSL: 1020,
+			temperature: -10,
+			hubHeight:   100,
+			expected:    1.3361926939194624,
+		},
+		{
+			// The standard atmosphere at 200 m, the hub is 1.3 °C colder than the ground.
+			name:        "standard atmosphere with a tall hub",
+			pressureMSL: 1013.25,
+			temperature: 15,
+			hubHeight:   200,
+			expected:    1.2016682794838627,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			density := AirDensity(tt.pressureMSL, tt.temperature, tt.elevation, tt.hubHeight)
+			if diff := cmp.Diff(tt.expected, density, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected density (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTurbinePower(t *testing.T) {
+	tests := []struct {
+		name       string
+		windSpeed  float64
+		airDensity float64
+		expected   float64
+	}{
+		{
+			name:       "below cut-in",
+			windSpeed:  2.5,
+			airDensity: StandardAirDensity,
+			expected:   0,
+		},
+		{
+			name:       "on a curve point",
+			windSpeed:  8,
+			airDensity: StandardAirDensity,
+			expected:   886,
+		},
+		{
+			name:       "between curve points",
+			windSpeed:  7.5,
+			airDensity: StandardAirDensity,
+			expected:   733.5,
+		},
+		{
+			// The wind speed is normalized to 7.2612 m/s.
+			name:       "thin air",
+			windSpeed:  7.5,
+			airDensity: 1.1116759301510082,
+			expected:   660.667201797592,
+		},
+		{
+			name:       "rated",
+			windSpeed:  18,
+			airDensity: StandardAirDensity,
+			expected:   2000,
+		},
+		{
+			// Dense air does not push the turbine past its cut-out speed.
+			name:       "above cut-out",
+			windSpeed:  25.5,
+			airDensity: 1.3,
+			expected:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			power := TurbinePower(tt.windSpeed, tt.airDensity, testTurbineModel)
+			if diff := cmp.Diff(tt.expected, power, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected power (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTurbines(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		// 27 km/h is 7.5 m/s, at 100 m above the power plant in the standard atmosphere.
+		{Time: "2024-09-06T00:00", Temperature: ptr(15.0), PressureMSL: ptr(1013.25), WindSpeed100m: ptr(27.0)},
+		{Time: "2024-09-06T01:00", WindSpeed100m: ptr(27.0)},
+		{Time: "2024-09-06T02:00", Temperature: ptr(15.0), PressureMSL: ptr(1013.25)},
+	}
+	expected := []types.GenerationForecast{
+		{Time: "2024-09-06T00:00", PowerMW: ptr(10 * 726.1923618543513 / 1000)},
+		{Time: "2024-09-06T01:00", PowerMW: ptr(7.335)},
+		{Time: "2024-09-06T02:00"},
+	}
+
+	generation := Turbines(forecasts, TurbineParams{Model: testTurbineModel, Count: 10})
+	if diff := cmp.Diff(expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected generation (-want +got):\n%s", diff)
+	}
+}
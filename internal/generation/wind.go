@@ -0,0 +1,64 @@
+package generation
+
+import (
+	"math"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+const (
+	// DefaultCutInSpeed is the wind speed in m/s at which a generic turbine starts producing.
+	DefaultCutInSpeed = 3
+	// DefaultRatedSpeed is the wind speed in m/s at which a generic turbine reaches its capacity.
+	DefaultRatedSpeed = 12
+	// DefaultCutOutSpeed is the wind speed in m/s above which a generic turbine stops to protect itself.
+	DefaultCutOutSpeed = 25
+
+	kmhToMs = 1 / 3.6
+)
+
+// WindParams are the parameters of the wind generation model, a generic power curve
+// scaled to the capacity of the power plant.
+type WindParams struct {
+	CapacityMW  float64
+	CutInSpeed  float64
+	RatedSpeed  float64
+	CutOutSpeed float64
+}
+
+// NewWindParams returns the parameters of a wind power plant with the generic power curve.
+func NewWindParams(capacityMW float64) WindParams {
+	return WindParams{
+		CapacityMW:  capacityMW,
+		CutInSpeed:  DefaultCutInSpeed,
+		RatedSpeed:  DefaultRatedSpeed,
+		CutOutSpeed: DefaultCutOutSpeed,
+	}
+}
+
+// Wind returns the expected output of a wind power plant, from the wind speed at 100 m,
+// the hub height of most utility-scale turbines.
+func Wind(forecasts []types.WeatherForecast, params WindParams) []types.GenerationForecast {
+	return forecastEach(forecasts, func(forecast types.WeatherForecast) *float64 {
+		if forecast.WindSpeed100m == nil {
+			return nil
+		}
+		power := WindPower(*forecast.WindSpeed100m*kmhToMs, params)
+		return &power
+	})
+}
+
+// WindPower returns the output in MW for a hub height wind speed in m/s.
+// Between the cut-in and the rated speed the output follows the cube of the wind speed,
+// as the power of the wind does. It is flat up to the cut-out speed, and 0 above.
+func WindPower(windSpeed float64, params WindParams) float64 {
+	switch {
+	case windSpeed < params.CutInSpeed || windSpeed > params.CutOutSpeed:
+		return 0
+	case windSpeed >= params.RatedSpeed:
+		return params.CapacityMW
+	}
+
+	cutIn := math.Pow(params.CutInSpeed, 3)
+	return params.CapacityMW * (math.Pow(windSpeed, 3) - cutIn) / (math.Pow(params.RatedSpeed, 3) - cutIn)
+}
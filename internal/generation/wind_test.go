@@ -0,0 +1,77 @@
+package generation
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestWindPower(t *testing.T) {
+	tests := []struct {
+		name      string
+		windSpeed float64
+		expected  float64
+	}{
+		{
+			name:      "below cut-in",
+			windSpeed: 2.9,
+			expected:  0,
+		},
+		{
+			name:      "cut-in",
+			windSpeed: 3,
+			expected:  0,
+		},
+		{
+			// 30 MW * (7.5³ - 3³) / (12³ - 3³).
+			name:      "between cut-in and rated",
+			windSpeed: 7.5,
+			expected:  6.964285714285714,
+		},
+		{
+			name:      "rated",
+			windSpeed: 12,
+			expected:  30,
+		},
+		{
+			name:      "cut-out",
+			windSpeed: 25,
+			expected:  30,
+		},
+		{
+			name:      "above cut-out",
+			windSpeed: 25.1,
+			expected:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			power := WindPower(tt.windSpeed, NewWindParams(30))
+			if diff := cmp.Diff(tt.expected, power, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected power (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWind(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		// 27 km/h is 7.5 m/s, the 10 m wind speed is not used.
+		{Time: "2024-09-06T00:00", WindSpeed: ptr(15.0), WindSpeed100m: ptr(27.0)},
+		{Time: "2024-09-06T01:00", WindSpeed: ptr(30.0), WindSpeed100m: ptr(50.0)},
+		{Time: "2024-09-06T02:00", WindSpeed: ptr(30.0)},
+	}
+	expected := []types.GenerationForecast{
+		{Time: "2024-09-06T00:00", PowerMW: ptr(6.964285714285714)},
+		{Time: "2024-09-06T01:00", PowerMW: ptr(30.0)},
+		{Time: "2024-09-06T02:00"},
+	}
+
+	generation := Wind(forecasts, NewWindParams(30))
+	if diff := cmp.Diff(expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected generation (-want +got):\n%s", diff)
+	}
+}
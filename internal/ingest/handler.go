@@ -0,0 +1,176 @@
+// Package ingest serves the bulk upload of production readings.
+//
+// The readings are streamed from the request body, as CSV or NDJSON, and recorded in batches,
+// so an upload of any size is loaded with a bounded memory and one COPY per batch.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/gcathelines/tensor-energy-case/internal/usecase"
+)
+
+const (
+	// defaultBatchSize is the number of readings recorded at once, it is at most types.MaxProductionBatch.
+	defaultBatchSize = 5000
+	// maxRejections is the number of rejected readings listed in the response, the other ones are only counted.
+	maxRejections = 100
+)
+
+var _ recorder = (*usecase.Usecase)(nil)
+
+type recorder interface {
+	RecordProduction(ctx context.Context, readings []types.ProductionReading) (*types.ProductionIngestResult, error)
+}
+
+// rowReader reads the production readings of an upload one by one. It returns a rowError for
+// a row that cannot be read, the next rows can still be, and io.EOF at the end of the upload.
+type rowReader interface {
+	Next() (types.ProductionReading, error)
+}
+
+// rowError is the error of one row of an upload, it rejects the row only.
+type rowError struct {
+	err error
+}
+
+func (e rowError) Error() string {
+	return e.err.Error()
+}
+
+// Handler records the production readings uploaded as CSV or NDJSON, see ServeHTTP.
+type Handler struct {
+	recorder  recorder
+	batchSize int
+}
+
+// NewHandler creates a new production upload handler.
+func NewHandler(recorder recorder) *Handler {
+	return &Handler{
+		recorder:  recorder,
+		batchSize: defaultBatchSize,
+	}
+}
+
+// response is the body of the responses, the result of the rows read so far
+// with the error that stopped the upload, if any.
+type response struct {
+	*types.ProductionIngestResult
+	Error string `json:"error,omitempty"`
+}
+
+// ServeHTTP records the production readings of a POST body, read as CSV for the text/csv content type
+// and as one JSON reading per line for application/x-ndjson. The readings are validated and
+// deduplicated like the recordProduction mutation. Invalid rows are rejected and do not stop the upload,
+// but a malformed body does, with a 400 response: the readings before it are recorded.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, response{Error: "method not allowed"})
+		return
+	}
+
+	var (
+		rows rowReader
+		err  error
+	)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		rows, err = newCSVReader(r.Body)
+	case "application/x-ndjson", "application/jsonl":
+		rows = newNDJSONReader(r.Body)
+	default:
+		writeResponse(w, http.StatusUnsupportedMediaType, response{Error: "content type must be text/csv or application/x-ndjson"})
+		return
+	}
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, response{Error: err.Error()})
+		return
+	}
+
+	result, err := h.ingest(r.Context(), rows)
+	switch {
+	case err == nil:
+		writeResponse(w, http.StatusOK, response{ProductionIngestResult: result})
+	case errors.Is(err, types.ErrInternal):
+		writeResponse(w, http.StatusInternalServerError, response{ProductionIngestResult: result, Error: err.Error()})
+	default:
+		writeResponse(w, http.StatusBadRequest, response{ProductionIngestResult: result, Error: err.Error()})
+	}
+}
+
+// ingest records the readings of the rows in batches of batchSize. The rows rejected by the recorder
+// are reported with their row in the upload. A reading repeated in two batches replaces the first one,
+// it is counted as updated instead of duplicated.
+func (h *Handler) ingest(ctx context.Context, rows rowReader) (*types.ProductionIngestResult, error) {
+	result := &types.ProductionIngestResult{Rejections: []types.ProductionRejection{}}
+	reject := func(row int, msg string) {
+		result.Rejected++
+		if len(result.Rejections) < maxRejections {
+			result.Rejections = append(result.Rejections, types.ProductionRejection{Row: row, Error: msg})
+		}
+	}
+
+	var (
+		batch     = make([]types.ProductionReading, 0, h.batchSize)
+		batchRows = make([]int, 0, h.batchSize)
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		recorded, err := h.recorder.RecordProduction(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		result.Inserted += recorded.Inserted
+		result.Updated += recorded.Updated
+		result.Duplicates += recorded.Duplicates
+		for _, rejection := range recorded.Rejections {
+			reject(batchRows[rejection.Row-1], rejection.Error)
+		}
+		batch, batchRows = batch[:0], batchRows[:0]
+		return nil
+	}
+
+	for row := 1; ; row++ {
+		reading, err := rows.Next()
+		var rowErr rowError
+		switch {
+		case errors.Is(err, io.EOF):
+			return result, flush()
+		case errors.As(err, &rowErr):
+			result.Received++
+			reject(row, rowErr.Error())
+			continue
+		case err != nil:
+			if flushErr := flush(); flushErr != nil {
+				return result, flushErr
+			}
+			return result, err
+		}
+
+		result.Received++
+		batch = append(batch, reading)
+		batchRows = append(batchRows, row)
+		if len(batch) == h.batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+func writeResponse(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
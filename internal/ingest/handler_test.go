@@ -0,0 +1,160 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeRecorder keeps the batches it records and rejects the negative readings.
+type fakeRecorder struct {
+	batches [][]types.ProductionReading
+}
+
+func (f *fakeRecorder) RecordProduction(ctx context.Context, readings []types.ProductionReading) (*types.ProductionIngestResult, error) {
+	f.batches = append(f.batches, append([]types.ProductionReading(nil), readings...))
+
+	result := &types.ProductionIngestResult{Received: len(readings), Rejections: []types.ProductionRejection{}}
+	for i, reading := range readings {
+		if reading.PowerMW < 0 {
+			result.Rejected++
+			result.Rejections = append(result.Rejections, types.ProductionRejection{Row: i + 1, Error: "negative"})
+			continue
+		}
+		result.Inserted++
+	}
+	return result, nil
+}
+
+func TestHandler(t *testing.T) {
+	at := time.Date(2024, 9, 6, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		method         string
+		contentType    string
+		body           string
+		expectedStatus int
+		expected       response
+		expectedBatch  [][]types.ProductionReading
+	}{
+		{
+			name:        "csv",
+			method:      http.MethodPost,
+			contentType: "text/csv; charset=utf-8",
+			body: "time,power_plant_id,power_mw,quality\n" +
+				"2024-09-06T12:00:00Z,1,10.5,MEASURED\n" +
+				"2024-09-06T12:00:00Z,2,-1,\n" +
+				"yesterday,1,10,\n" +
+				"2024-09-06T14:00:00+02:00,1,11,ESTIMATED\n",
+			expectedStatus: http.StatusOK,
+			expected: response{ProductionIngestResult: &types.ProductionIngestResult{
+				Received: 4, Inserted: 2, Rejected: 2,
+				Rejections: []types.ProductionRejection{
+					{Row: 2, Error: "negative"},
+					{Row: 3, Error: `invalid time "yesterday", it must be RFC 3339`},
+				},
+			}},
+			expectedBatch: [][]types.ProductionReading{
+				{
+					{PowerPlantID: 1, Time: at, PowerMW: 10.5, Quality: types.ProductionQualityMeasured},
+					{PowerPlantID: 2, Time: at, PowerMW: -1},
+				},
+				{
+					{PowerPlantID: 1, Time: at, PowerMW: 11, Quality: types.ProductionQualityEstimated},
+				},
+			},
+		},
+		{
+			name:        "ndjson",
+			method:      http.MethodPost,
+			contentType: "application/x-ndjson",
+			body: `{"powerPlantID":1,"time":"2024-09-06T12:00:00Z","powerMW":0}` + "\n\n" +
+				`{"powerPlantID":1,"time":"2024-09-06T12:00:00Z"}` + "\n" +
+				`{"powerPlantID":1,` + "\n",
+			expectedStatus: http.StatusOK,
+			expected: response{ProductionIngestResult: &types.ProductionIngestResult{
+				Received: 3, Inserted: 1, Rejected: 2,
+				Rejections: []types.ProductionRejection{
+					{Row: 2, Error: "powerMW is required"},
+					{Row: 3, Error: "invalid JSON: unexpected end of JSON input"},
+				},
+			}},
+			expectedBatch: [][]types.ProductionReading{
+				{{PowerPlantID: 1, Time: at}},
+			},
+		},
+		{
+			name:           "failed, missing csv column",
+			method:         http.MethodPost,
+			contentType:    "text/csv",
+			body:           "power_plant_id,time\n1,2024-09-06T12:00:00Z\n",
+			expectedStatus: http.StatusBadRequest,
+			expected:       response{Error: "the CSV header must have the power_plant_id, time, power_mw columns"},
+		},
+		{
+			name:        "failed, malformed csv",
+			method:      http.MethodPost,
+			contentType: "text/csv",
+			body: "power_plant_id,time,power_mw\n" +
+				"1,2024-09-06T12:00:00Z,10\n" +
+				"1,\"2024-09-06T13:00:00Z,10\n",
+			expectedStatus: http.StatusBadRequest,
+			expected: response{
+				ProductionIngestResult: &types.ProductionIngestResult{Received: 1, Inserted: 1, Rejections: []types.ProductionRejection{}},
+				Error:                  "parse error on line 3, column 28: extraneous or missing \" in quoted-field",
+			},
+			expectedBatch: [][]types.ProductionReading{
+				{{PowerPlantID: 1, Time: at, PowerMW: 10}},
+			},
+		},
+		{
+			name:           "failed, unsupported content type",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           "[]",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expected:       response{Error: "content type must be text/csv or application/x-ndjson"},
+		},
+		{
+			name:           "failed, method not allowed",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+			expected:       response{Error: "method not allowed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &fakeRecorder{}
+			handler := NewHandler(recorder)
+			handler.batchSize = 2
+
+			req := httptest.NewRequest(tt.method, "/production", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			var got response
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Fatalf("unexpected response (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedBatch, recorder.batches); diff != "" {
+				t.Fatalf("unexpected batches (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// maxLineSize is the longest NDJSON line read.
+const maxLineSize = 64 * 1024
+
+// csvColumns are the required columns of a CSV upload, quality is optional.
+var csvColumns = []string{"power_plant_id", "time", "power_mw"}
+
+// csvReader reads a CSV upload with a header row naming its columns, in any order.
+// The time is RFC 3339, e.g. 2024-09-06T12:15:00Z.
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(body io.Reader) (*csvReader, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the CSV header must have the %s columns", strings.Join(csvColumns, ", "))
+		}
+	}
+
+	return &csvReader{r: r, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (types.ProductionReading, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return types.ProductionReading{}, err
+	}
+	field := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	id, err := strconv.ParseInt(field("power_plant_id"), 10, 64)
+	if err != nil {
+		return types.ProductionReading{}, rowError{fmt.Errorf("invalid power_plant_id %q", field("power_plant_id"))}
+	}
+	at, err := time.Parse(time.RFC3339, field("time"))
+	if err != nil {
+		return types.ProductionReading{}, rowError{fmt.Errorf("invalid time %q, it must be RFC 3339", field("time"))}
+	}
+	power, err := strconv.ParseFloat(field("power_mw"), 64)
+	if err != nil {
+		return types.ProductionReading{}, rowError{fmt.Errorf("invalid power_mw %q", field("power_mw"))}
+	}
+
+	return types.ProductionReading{
+		PowerPlantID: id,
+		Time:         at,
+		PowerMW:      power,
+		Quality:      types.ProductionQuality(field("quality")),
+	}, nil
+}
+
+// ndjsonReader reads an upload of one JSON reading per line, with the fields of types.ProductionReading.
+// Blank lines are skipped.
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func newNDJSONReader(body io.Reader) *ndjsonReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	return &ndjsonReader{scanner: scanner}
+}
+
+func (n *ndjsonReader) Next() (types.ProductionReading, error) {
+	for n.scanner.Scan() {
+		line := bytes.TrimSpace(n.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var reading struct {
+			types.ProductionReading
+			PowerMW *float64 `json:"powerMW"`
+		}
+		if err := json.Unmarshal(line, &reading); err != nil {
+			return types.ProductionReading{}, rowError{fmt.Errorf("invalid JSON: %v", err)}
+		}
+		if reading.PowerMW == nil {
+			return types.ProductionReading{}, rowError{errors.New("powerMW is required")}
+		}
+		reading.ProductionReading.PowerMW = *reading.PowerMW
+		return reading.ProductionReading, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return types.ProductionReading{}, err
+	}
+	return types.ProductionReading{}, io.EOF
+}
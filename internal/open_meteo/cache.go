@@ -0,0 +1,209 @@
+package open_meteo
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// CachedClient is an OpenMeteoClient with an in-process cache of weather forecasts.
+//
+// Forecasts are cached per location, keyed on the coordinates snapped to the weather model
+// grid, the number of forecast days and the requested variables. They expire at the
+// next weather model update, and the least recently used are evicted when the cache is full.
+// Concurrent misses of the same forecasts share a single upstream call.
+//
+// Cached forecasts are shared between callers and must not be modified.
+type CachedClient struct {
+	*OpenMeteoClient
+
+	updateInterval time.Duration
+	maxEntries     int
+	now            func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	group   singleflight.Group
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type cacheEntry struct {
+	key       string
+	forecast  types.WeatherForecastProperties
+	expiresAt time.Time
+}
+
+// NewCachedClient creates a new CachedClient around the client.
+// The cache is bypassed when cfg.MaxEntries is zero.
+func NewCachedClient(client *OpenMeteoClient, cfg config.ForecastCacheConfig) *CachedClient {
+	return &CachedClient{
+		OpenMeteoClient: client,
+		updateInterval:  cfg.ModelUpdateInterval,
+		maxEntries:      cfg.MaxEntries,
+		now:             time.Now,
+		lru:             list.New(),
+		entries:         map[string]*list.Element{},
+	}
+}
+
+// GetWeatherForecast returns the weather forecast for a pair of latitude and longitude,
+// from the cache when possible.
+func (c *CachedClient) GetWeatherForecast(ctx context.Context, latitude float64, longitude float64, forecastDays int) (*types.WeatherForecastProperties, error) {
+	forecasts, err := c.GetWeatherForecasts(ctx, []float64{latitude}, []float64{longitude}, forecastDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return &forecasts[0], nil
+}
+
+// GetWeatherForecasts returns the weather forecast for multiple pair latitude and longitude.
+// Only the locations missing from the cache are requested, in a single upstream call.
+func (c *CachedClient) GetWeatherForecasts(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherForecastProperties, error) {
+	if c.maxEntries == 0 {
+		return c.OpenMeteoClient.GetWeatherForecasts(ctx, latitudes, longitudes, forecastDays)
+	}
+	if len(latitudes) != len(longitudes) {
+		return nil, fmt.Errorf("latitude count %d does not match longitude count %d", len(latitudes), len(longitudes))
+	}
+
+	var (
+		forecasts = make([]types.WeatherForecastProperties, len(latitudes))
+		// missing maps the key of every missing location to the indexes it is used at.
+		missing     = map[string][]int{}
+		missingKeys []string
+		missingLats []float64
+		missingLong []float64
+	)
+	for i := range latitudes {
+		lat, long := c.SnapToGrid(latitudes[i]), c.SnapToGrid(longitudes[i])
+		key := c.key(lat, long, forecastDays)
+
+		if forecast, ok := c.get(key); ok {
+			forecasts[i] = forecast
+			continue
+		}
+
+		if _, ok := missing[key]; !ok {
+			missingKeys = append(missingKeys, key)
+			missingLats = append(missingLats, lat)
+			missingLong = append(missingLong, long)
+		}
+		missing[key] = append(missing[key], i)
+	}
+
+	c.hits.Add(int64(len(latitudes) - len(missingKeys)))
+	if len(missingKeys) == 0 {
+		return forecasts, nil
+	}
+	c.misses.Add(int64(len(missingKeys)))
+
+	// The upstream call is shared with concurrent callers, so it must not be canceled with this caller.
+	fetchCtx := context.WithoutCancel(ctx)
+	res, err, _ := c.group.Do(strings.Join(missingKeys, ";"), func() (any, error) {
+		fetched, err := c.OpenMeteoClient.GetWeatherForecasts(fetchCtx, missingLats, missingLong, forecastDays)
+		if err != nil {
+			return nil, err
+		}
+		if len(fetched) != len(missingKeys) {
+			return nil, fmt.Errorf("forecast count %d does not match location count %d", len(fetched), len(missingKeys))
+		}
+
+		for i, key := range missingKeys {
+			c.set(key, fetched[i])
+		}
+		return fetched, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, forecast := range res.([]types.WeatherForecastProperties) {
+		for _, index := range missing[missingKeys[i]] {
+			forecasts[index] = forecast
+		}
+	}
+
+	return forecasts, nil
+}
+
+// CacheStats returns the hit and miss counters of the cache.
+func (c *CachedClient) CacheStats() types.CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	return types.CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
+
+func (c *CachedClient) get(key string) (types.WeatherForecastProperties, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return types.WeatherForecastProperties{}, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return types.WeatherForecastProperties{}, false
+	}
+
+	c.lru.MoveToFront(element)
+	return entry.forecast, true
+}
+
+func (c *CachedClient) set(key string, forecast types.WeatherForecastProperties) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Forecasts only change when the weather models are updated.
+	now := c.now()
+	expiresAt := now.Truncate(c.updateInterval).Add(c.updateInterval)
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.forecast = forecast
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		forecast:  forecast,
+		expiresAt: expiresAt,
+	})
+
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// key returns the cache key of a forecast, the variables are part of the key
+// so forecasts cached before a change of the requested variables are not served.
+func (c *CachedClient) key(latitude float64, longitude float64, forecastDays int) string {
+	return fmt.Sprintf("%v,%v|%d|%s|%s", latitude, longitude, forecastDays,
+		strings.Join(forecastHourlyVariables, ","), strings.Join(forecastDailyVariables, ","))
+}
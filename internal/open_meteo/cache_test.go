@@ -0,0 +1,204 @@
+package open_meteo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingFakeOpenMeteo serves the fake OpenMeteo API and records the latitudes of every forecast request.
+func countingFakeOpenMeteo(ctx context.Context, delay time.Duration) (string, func() [][]string) {
+	var (
+		mu       sync.Mutex
+		requests [][]string
+	)
+	url := serveFakeOpenMeteoHandler(ctx, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/forecast" {
+			mu.Lock()
+			requests = append(requests, r.URL.Query()["latitude"])
+			mu.Unlock()
+			time.Sleep(delay)
+		}
+		fakeOpenMeteoHandler(w, r)
+	})
+
+	return url, func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]string{}, requests...)
+	}
+}
+
+func newTestCachedClient(fakeURL string, maxEntries int, now func() time.Time) *CachedClient {
+	c := NewCachedClient(NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:  fakeURL,
+		Timeout: 5 * time.Second,
+	}), config.ForecastCacheConfig{
+		ModelUpdateInterval: time.Hour,
+		MaxEntries:          maxEntries,
+	})
+	c.now = now
+	return c
+}
+
+func TestCachedClient_GetWeatherForecast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL, requests := countingFakeOpenMeteo(ctx, 0)
+
+	now := time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC)
+	c := newTestCachedClient(fakeURL, 10, func() time.Time { return now })
+
+	first, err := c.GetWeatherForecast(ctx, 52.52, 13.41, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Within the rounding precision, the cached forecast is served.
+	second, err := c.GetWeatherForecast(ctx, 52.521, 13.409, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(first, second); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+
+	// Other forecast days are cached separately.
+	if _, err := c.GetWeatherForecast(ctx, 52.52, 13.41, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([][]string{{"52.52"}, {"52.52"}}, requests()); diff != "" {
+		t.Fatalf("unexpected upstream requests (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(types.CacheStats{Hits: 1, Misses: 2, Entries: 2}, c.CacheStats()); diff != "" {
+		t.Fatalf("unexpected stats (-want +got):\n%s", diff)
+	}
+
+	// The forecasts expire at the next model update.
+	now = time.Date(2024, 9, 6, 11, 0, 0, 0, time.UTC)
+	if _, err := c.GetWeatherForecast(ctx, 52.52, 13.41, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(requests()); got != 3 {
+		t.Fatalf("expected 3 upstream requests after expiry, got: %d", got)
+	}
+}
+
+func TestCachedClient_GetWeatherForecastsMissingOnly(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL, requests := countingFakeOpenMeteo(ctx, 0)
+	c := newTestCachedClient(fakeURL, 10, time.Now)
+
+	if _, err := c.GetWeatherForecast(ctx, 52.52, 13.41, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	forecasts, err := c.GetWeatherForecasts(ctx,
+		[]float64{14.125, 52.52, 15.125, 14.125},
+		[]float64{15.125, 13.41, 16.125, 15.125},
+		7,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(forecasts) != 4 {
+		t.Fatalf("expected 4 forecasts, got: %d", len(forecasts))
+	}
+
+	// The cached location is left out and the duplicated one is requested once.
+	if diff := cmp.Diff([][]string{{"52.52"}, {"14.13", "15.13"}}, requests()); diff != "" {
+		t.Fatalf("unexpected upstream requests (-want +got):\n%s", diff)
+	}
+
+	// The results are returned in the order of the input.
+	if diff := cmp.Diff(forecasts[0], forecasts[3]); diff != "" {
+		t.Fatalf("expected duplicated locations to share a forecast (-want +got):\n%s", diff)
+	}
+	if forecasts[1].WeatherForecasts[0].Time != "2024-09-06T00:00" {
+		t.Fatalf("expected the cached forecast at index 1, got: %+v", forecasts[1])
+	}
+	if forecasts[2].WeatherForecasts[0].Time != "2024-09-07T00:00" {
+		t.Fatalf("expected a fetched forecast at index 2, got: %+v", forecasts[2])
+	}
+}
+
+func TestCachedClient_Eviction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL, requests := countingFakeOpenMeteo(ctx, 0)
+	c := newTestCachedClient(fakeURL, 1, time.Now)
+
+	for _, lat := range []float64{52.52, 41.38, 52.52} {
+		if _, err := c.GetWeatherForecast(ctx, lat, 13.41, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The first location was evicted by the second one, so it is requested again.
+	if diff := cmp.Diff([][]string{{"52.52"}, {"41.38"}, {"52.52"}}, requests()); diff != "" {
+		t.Fatalf("unexpected upstream requests (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(types.CacheStats{Hits: 0, Misses: 3, Entries: 1}, c.CacheStats()); diff != "" {
+		t.Fatalf("unexpected stats (-want +got):\n%s", diff)
+	}
+}
+
+func TestCachedClient_Singleflight(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL, requests := countingFakeOpenMeteo(ctx, 200*time.Millisecond)
+	c := newTestCachedClient(fakeURL, 10, time.Now)
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int64
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetWeatherForecast(ctx, 52.52, 13.41, 7); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed.Load() != 0 {
+		t.Fatalf("expected no errors, got: %d", failed.Load())
+	}
+	if got := len(requests()); got != 1 {
+		t.Fatalf("expected concurrent misses to share 1 upstream request, got: %d", got)
+	}
+}
+
+func TestCachedClient_Disabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL, requests := countingFakeOpenMeteo(ctx, 0)
+	c := newTestCachedClient(fakeURL, 0, time.Now)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetWeatherForecast(ctx, 52.52, 13.41, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := len(requests()); got != 2 {
+		t.Fatalf("expected 2 upstream requests, got: %d", got)
+	}
+}
@@ -8,28 +8,87 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/gcathelines/tensor-energy-case/config"
 	"github.com/gcathelines/tensor-energy-case/internal/types"
 )
 
+var (
+	// forecastHourlyVariables are the hourly variables requested from the forecast API.
+	forecastHourlyVariables = []string{
+		"temperature_2m",
+		"precipitation",
+		"wind_speed_10m",
+		"wind_direction_10m",
+		"shortwave_radiation",
+		"wind_speed_100m",
+		"pressure_msl",
+	}
+	// forecastDailyVariables are the daily variables requested from the forecast API.
+	forecastDailyVariables = []string{
+		"precipitation_sum",
+	}
+	// floodDailyVariables are the daily variables requested from the flood API.
+	floodDailyVariables = []string{
+		"river_discharge",
+	}
+)
+
 // OpenMeteoClient is a client for the OpenMeteo API.
 // Full documentation can be found at https://open-meteo.com/en/docs.
 type OpenMeteoClient struct {
-	apiURL     string
-	httpClient *http.Client
+	forecastURL  string
+	elevationURL string
+	archiveURL   string
+	geocodingURL string
+	floodURL     string
+	apiKey       string
+	headers      map[string]string
+	httpClient   *http.Client
+	// model is the weather model of the forecasts, empty for the Open-Meteo default.
+	model          string
+	gridResolution float64
+	// limiter is nil when no rate limit is configured.
+	limiter *rateLimiter
 }
 
 // NewOpenMeteoClient creates a new OpenMeteoClient.
+// Endpoint URLs without an explicit value fall back to cfg.APIURL.
 func NewOpenMeteoClient(cfg config.OpenMeteoConfig) *OpenMeteoClient {
+	orDefault := func(u string) string {
+		if u == "" {
+			return cfg.APIURL
+		}
+		return u
+	}
+
 	return &OpenMeteoClient{
-		apiURL: cfg.APIURL,
+		forecastURL:  orDefault(cfg.ForecastURL),
+		elevationURL: orDefault(cfg.ElevationURL),
+		archiveURL:   orDefault(cfg.ArchiveURL),
+		geocodingURL: orDefault(cfg.GeocodingURL),
+		floodURL:     orDefault(cfg.FloodURL),
+		apiKey:       cfg.APIKey,
+		headers:      cfg.Headers,
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
+		limiter:        newRateLimiter(cfg.RateLimit, time.Now),
+		model:          cfg.Model,
+		gridResolution: gridResolution(cfg.Model, cfg.GridResolutions),
 	}
 }
 
+// Usage returns the calls used per rate limit window.
+// It returns an empty list when no rate limit is configured.
+func (c *OpenMeteoClient) Usage() []types.RateLimitWindow {
+	if c.limiter == nil {
+		return []types.RateLimitWindow{}
+	}
+	return c.limiter.usage()
+}
+
 // GetWeatherForecasts returns the weather forecast for a pair of latitude and longitude.
 // Docs: https://open-meteo.com/en/docs/weather-api
 func (c *OpenMeteoClient) GetWeatherForecast(ctx context.Context, latitude float64, longitude float64, forecastDays int) (*types.WeatherForecastProperties, error) {
@@ -37,19 +96,15 @@ func (c *OpenMeteoClient) GetWeatherForecast(ctx context.Context, latitude float
 		"forecast_days": {fmt.Sprint(forecastDays)},
 		"latitude":      {fmt.Sprint(latitude)},
 		"longitude":     {fmt.Sprint(longitude)},
-		"daily": {
-			"precipitation_sum",
-		},
-		"hourly": {
-			"temperature_2m",
-			"precipitation",
-			"wind_speed_10m",
-			"wind_direction_10m",
-		},
+		"daily":         forecastDailyVariables,
+		"hourly":        forecastHourlyVariables,
+	}
+	if c.model != "" {
+		query.Set("models", c.model)
 	}
 
 	var forecast WeatherForecast
-	err := c.doRequest("/v1/forecast", query, "GET", nil, &forecast)
+	err := c.doRequest(ctx, c.forecastURL, "/v1/forecast", query, "GET", nil, &forecast)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +120,15 @@ func (c *OpenMeteoClient) GetWeatherForecast(ctx context.Context, latitude float
 // GetWeatherForecasts returns the weather forecast for multiple pair latitude and longitude.
 // Docs: https://open-meteo.com/en/docs/weather-api
 func (c *OpenMeteoClient) GetWeatherForecasts(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherForecastProperties, error) {
+	// The API responds with a single object instead of a list for a single location.
+	if len(latitudes) == 1 && len(longitudes) == 1 {
+		properties, err := c.GetWeatherForecast(ctx, latitudes[0], longitudes[0], forecastDays)
+		if err != nil {
+			return nil, err
+		}
+		return []types.WeatherForecastProperties{*properties}, nil
+	}
+
 	latsStr := make([]string, 0, len(latitudes))
 	for _, lat := range latitudes {
 		latsStr = append(latsStr, fmt.Sprint(lat))
@@ -79,19 +143,15 @@ func (c *OpenMeteoClient) GetWeatherForecasts(ctx context.Context, latitudes []f
 		"forecast_days": {fmt.Sprint(forecastDays)},
 		"latitude":      latsStr,
 		"longitude":     longsStr,
-		"daily": {
-			"precipitation_sum",
-		},
-		"hourly": {
-			"temperature_2m",
-			"precipitation",
-			"wind_speed_10m",
-			"wind_direction_10m",
-		},
+		"daily":         forecastDailyVariables,
+		"hourly":        forecastHourlyVariables,
+	}
+	if c.model != "" {
+		query.Set("models", c.model)
 	}
 
 	var forecasts []WeatherForecast
-	err := c.doRequest("/v1/forecast", query, "GET", nil, &forecasts)
+	err := c.doRequest(ctx, c.forecastURL, "/v1/forecast", query, "GET", nil, &forecasts)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +169,52 @@ func (c *OpenMeteoClient) GetWeatherForecasts(ctx context.Context, latitudes []f
 	return properties, nil
 }
 
+// GetWeatherObservations returns the hourly weather observed at each pair of latitude and longitude,
+// from the reanalysis of the archive API, from the start date to the end date included.
+// The archive lags a few days behind, the hours not available yet are nil.
+// Docs: https://open-meteo.com/en/docs/historical-weather-api
+func (c *OpenMeteoClient) GetWeatherObservations(ctx context.Context, latitudes []float64, longitudes []float64, start time.Time, end time.Time) ([][]types.WeatherForecast, error) {
+	latsStr := make([]string, 0, len(latitudes))
+	for _, lat := range latitudes {
+		latsStr = append(latsStr, fmt.Sprint(lat))
+	}
+
+	longsStr := make([]string, 0, len(longitudes))
+	for _, long := range longitudes {
+		longsStr = append(longsStr, fmt.Sprint(long))
+	}
+
+	query := url.Values{
+		"start_date": {start.UTC().Format(time.DateOnly)},
+		"end_date":   {end.UTC().Format(time.DateOnly)},
+		"latitude":   latsStr,
+		"longitude":  longsStr,
+		"hourly":     forecastHourlyVariables,
+	}
+
+	// The API responds with a single object instead of a list for a single location.
+	var archives []WeatherForecast
+	if len(latitudes) == 1 {
+		archives = make([]WeatherForecast, 1)
+		if err := c.doRequest(ctx, c.archiveURL, "/v1/archive", query, "GET", nil, &archives[0]); err != nil {
+			return nil, err
+		}
+	} else if err := c.doRequest(ctx, c.archiveURL, "/v1/archive", query, "GET", nil, &archives); err != nil {
+		return nil, err
+	}
+
+	observations := make([][]types.WeatherForecast, 0, len(archives))
+	for _, archive := range archives {
+		hourly, err := archive.Hourly.ToWeatherForecasts()
+		if err != nil {
+			return nil, err
+		}
+		observations = append(observations, hourly)
+	}
+
+	return observations, nil
+}
+
 // GetElevation returns the elevation for the given latitude and longitude.
 // Docs: https://open-meteo.com/en/docs/elevation-api
 func (c *OpenMeteoClient) GetElevations(ctx context.Context, latitudes []float64, longitudes []float64) ([]float64, error) {
@@ -128,7 +234,7 @@ func (c *OpenMeteoClient) GetElevations(ctx context.Context, latitudes []float64
 	}
 
 	var elevation Elevation
-	err := c.doRequest("/v1/elevation", query, "GET", nil, &elevation)
+	err := c.doRequest(ctx, c.elevationURL, "/v1/elevation", query, "GET", nil, &elevation)
 	if err != nil {
 		return nil, err
 	}
@@ -136,35 +242,160 @@ func (c *OpenMeteoClient) GetElevations(ctx context.Context, latitudes []float64
 	return elevation.Elevation, nil
 }
 
+// GetRiverDischarges returns the daily discharge forecast of the river nearest to each pair of
+// latitude and longitude, for forecastDays days starting today.
+// Docs: https://open-meteo.com/en/docs/flood-api
+func (c *OpenMeteoClient) GetRiverDischarges(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([][]types.RiverDischarge, error) {
+	latsStr := make([]string, 0, len(latitudes))
+	for _, lat := range latitudes {
+		latsStr = append(latsStr, fmt.Sprint(lat))
+	}
+
+	longsStr := make([]string, 0, len(longitudes))
+	for _, long := range longitudes {
+		longsStr = append(longsStr, fmt.Sprint(long))
+	}
+
+	query := url.Values{
+		"forecast_days": {fmt.Sprint(forecastDays)},
+		"latitude":      latsStr,
+		"longitude":     longsStr,
+		"daily":         floodDailyVariables,
+	}
+
+	// The API responds with a single object instead of a list for a single location.
+	var floods []Flood
+	if len(latitudes) == 1 {
+		floods = make([]Flood, 1)
+		if err := c.doRequest(ctx, c.floodURL, "/v1/flood", query, "GET", nil, &floods[0]); err != nil {
+			return nil, err
+		}
+	} else if err := c.doRequest(ctx, c.floodURL, "/v1/flood", query, "GET", nil, &floods); err != nil {
+		return nil, err
+	}
+	if len(floods) != len(latitudes) {
+		return nil, fmt.Errorf("discharge count %d does not match location count %d", len(floods), len(latitudes))
+	}
+
+	discharges := make([][]types.RiverDischarge, 0, len(floods))
+	for _, flood := range floods {
+		daily, err := flood.Daily.ToRiverDischarges()
+		if err != nil {
+			return nil, err
+		}
+		discharges = append(discharges, daily)
+	}
+
+	return discharges, nil
+}
+
+// GetTiltedIrradiance returns the hourly irradiance on the panels of a solar array at the latitude and longitude,
+// for forecastDays days starting today. The API computes it for the tilt and the azimuth of fixed arrays,
+// for a horizontal east-west tracker when the azimuth is "nan", and for a dual axis tracker when both are "nan".
+// Docs: https://open-meteo.com/en/docs
+func (c *OpenMeteoClient) GetTiltedIrradiance(ctx context.Context, latitude float64, longitude float64, array types.SolarArrayConfig, forecastDays int) ([]types.TiltedIrradiance, error) {
+	tilt, azimuth := fmt.Sprint(array.Tilt), fmt.Sprint(array.Azimuth)
+	switch array.Tracking {
+	case types.SolarTrackingSingleAxis:
+		azimuth = "nan"
+	case types.SolarTrackingDualAxis:
+		tilt, azimuth = "nan", "nan"
+	}
+
+	query := url.Values{
+		"forecast_days": {fmt.Sprint(forecastDays)},
+		"latitude":      {fmt.Sprint(latitude)},
+		"longitude":     {fmt.Sprint(longitude)},
+		"hourly":        {"global_tilted_irradiance"},
+		"tilt":          {tilt},
+		"azimuth":       {azimuth},
+	}
+	if c.model != "" {
+		query.Set("models", c.model)
+	}
+
+	var irradiance TiltedIrradiance
+	err := c.doRequest(ctx, c.forecastURL, "/v1/forecast", query, "GET", nil, &irradiance)
+	if err != nil {
+		return nil, err
+	}
+
+	return irradiance.Hourly.ToTiltedIrradiances()
+}
+
+// SearchLocations returns at most count locations matching the name, ordered by relevance.
+// Docs: https://open-meteo.com/en/docs/geocoding-api
+func (c *OpenMeteoClient) SearchLocations(ctx context.Context, name string, count int) ([]types.Location, error) {
+	query := url.Values{
+		"name":     {name},
+		"count":    {fmt.Sprint(count)},
+		"language": {"en"},
+		"format":   {"json"},
+	}
+
+	var search LocationSearch
+	err := c.doRequest(ctx, c.geocodingURL, "/v1/search", query, "GET", nil, &search)
+	if err != nil {
+		return nil, err
+	}
+
+	return search.ToLocations(), nil
+}
+
 // doRequest performs a request to the OpenMeteo API.
-//  1. It constructs the URL with the given path and query parameters.
-//  2. It creates a new HTTP request with the given method and body.
-//  3. It sends the request and checks the response status code.
+//  1. It waits for the rate limiter to have budget for the request weight.
+//  2. It constructs the URL with the given base URL, path and query parameters,
+//     adding the API key when one is configured.
+//  3. It creates a new HTTP request with the given method, body and configured headers.
+//  4. It sends the request and checks the response status code.
+//     If the status code is 401 or 403, it returns an AuthError.
+//     If the status code is 429, it returns an error wrapping types.ErrUpstreamRateLimited.
 //     If the status code is not 200, it decodes the response body into an ErrorResponse object.
-//  4. It decodes the response body into the given response object.
+//  5. It decodes the response body into the given response object.
 func (c *OpenMeteoClient) doRequest(
+	ctx context.Context,
+	baseURL string,
 	path string,
 	query url.Values,
 	method string,
 	body io.Reader,
 	response any,
 ) error {
-	reqURL, err := url.Parse(c.apiURL)
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx, requestWeight(query)); err != nil {
+			return err
+		}
+	}
+
+	reqURL, err := url.Parse(baseURL)
 	if err != nil {
 		return err
 	}
 
+	if c.apiKey != "" {
+		// Copy the query so the caller's values are left untouched.
+		withKey := url.Values{"apikey": {c.apiKey}}
+		for k, v := range query {
+			withKey[k] = v
+		}
+		query = withKey
+	}
+
 	reqURL = reqURL.JoinPath(path)
 	reqURL.RawQuery = query.Encode()
 
-	req, err := http.NewRequest(method, reqURL.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
 	if err != nil {
 		return err
 	}
 
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
 	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return redactAPIKey(err)
 	}
 
 	defer res.Body.Close()
@@ -177,6 +408,18 @@ func (c *OpenMeteoClient) doRequest(
 		// We ignore the error here because we don't want to lose the original error.
 		// Getting the error message would be nice, but it's not critical.
 		_ = json.NewDecoder(res.Body).Decode(&errorResponse)
+
+		if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+			return &AuthError{
+				StatusCode: res.StatusCode,
+				Reason:     errorResponse.Reason,
+			}
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("%w: %s", types.ErrUpstreamRateLimited, errorResponse.Reason)
+		}
+
 		if errorResponse.Error {
 			errorMsg += fmt.Sprintf(", reason: %s", errorResponse.Reason)
 		}
@@ -191,3 +434,21 @@ func (c *OpenMeteoClient) doRequest(
 
 	return nil
 }
+
+// redactAPIKey removes the API key from the URL of a transport error, e.g. a timeout,
+// so the key does not end up in the logs of the callers.
+func redactAPIKey(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	reqURL, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return &url.Error{Op: urlErr.Op, URL: "", Err: urlErr.Err}
+	}
+	query := reqURL.Query()
+	query.Del("apikey")
+	reqURL.RawQuery = query.Encode()
+	return &url.Error{Op: urlErr.Op, URL: reqURL.String(), Err: urlErr.Err}
+}
@@ -9,50 +9,222 @@ import (
 
 // ServeFakeOpenMeteo serves a fake OpenMeteo API for testing purposes.
 func ServeFakeOpenMeteo(t *testing.T, ctx context.Context) string {
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
+	return serveFakeOpenMeteoHandler(ctx, fakeOpenMeteoHandler)
+}
+
+// serveFakeOpenMeteoHandler serves the handler until the context is done.
+func serveFakeOpenMeteoHandler(ctx context.Context, handler http.HandlerFunc) string {
+	srv := httptest.NewServer(handler)
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	return srv.URL
+}
+
+// fakeOpenMeteoHandler handles the requests of the fake OpenMeteo API.
+func fakeOpenMeteoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lats := r.URL.Query()["latitude"]
+	longs := r.URL.Query()["longitude"]
+
+	// The API key is optional, but when it is sent it has to be valid.
+	if apiKey := r.URL.Query().Get("apikey"); apiKey != "" && apiKey != fakeAPIKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write(responseInvalidAPIKey)
+		return
+	}
 
-		lats := r.URL.Query()["latitude"]
-		longs := r.URL.Query()["longitude"]
+	// For testing purpose, we set if latitude and longitude is 200.1
+	// we return a bad request response.
+	if len(lats) == 1 && lats[0] == "200.1" &&
+		len(longs) == 1 && longs[0] == "200.1" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(responseBadRequest)
+		return
+	}
 
-		// For testing purpose, we set if latitude and longitude is 200.1
-		// we return a bad request response.
-		if len(lats) == 1 && lats[0] == "200.1" &&
-			len(longs) == 1 && longs[0] == "200.1" {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(responseBadRequest)
+	switch r.URL.Path {
+	case "/v1/forecast":
+		w.WriteHeader(http.StatusOK)
+
+		// The tilted irradiance depends on how the array tracks the sun.
+		if r.URL.Query().Has("tilt") {
+			switch {
+			case r.URL.Query().Get("tilt") == "nan" && r.URL.Query().Get("azimuth") == "nan":
+				w.Write(responseTiltedIrradianceDualAxis)
+			case r.URL.Query().Get("azimuth") == "nan":
+				w.Write(responseTiltedIrradianceSingleAxis)
+			default:
+				w.Write(responseTiltedIrradiance)
+			}
 			return
 		}
 
-		switch r.URL.Path {
-		case "/v1/forecast":
-			w.WriteHeader(http.StatusOK)
+		if len(lats) > 1 && len(longs) > 1 {
+			w.Write(responseForecasts)
+		} else {
+			w.Write(responseForecast)
+		}
+	case "/v1/flood":
+		w.WriteHeader(http.StatusOK)
 
-			if len(lats) > 1 && len(longs) > 1 {
-				w.Write(responseForecasts)
-			} else {
-				w.Write(responseForecast)
-			}
-		case "/v1/elevation":
-			w.WriteHeader(http.StatusOK)
-			w.Write(responseElevations)
-		default:
-			w.WriteHeader(http.StatusNotFound)
-			w.Write(responseNotFound)
+		if len(lats) > 1 && len(longs) > 1 {
+			w.Write(responseFloods)
+		} else {
+			w.Write(responseFlood)
 		}
-	}))
-	go func() {
-		<-ctx.Done()
-		srv.Close()
-	}()
-	return srv.URL
+	case "/v1/archive":
+		w.WriteHeader(http.StatusOK)
+
+		if len(lats) > 1 && len(longs) > 1 {
+			w.Write(responseArchives)
+		} else {
+			w.Write(responseArchive)
+		}
+	case "/v1/elevation":
+		w.WriteHeader(http.StatusOK)
+		w.Write(responseElevations)
+	case "/v1/search":
+		w.WriteHeader(http.StatusOK)
+
+		if r.URL.Query().Get("name") == "Zurich" {
+			w.Write(responseSearch)
+		} else {
+			w.Write(responseSearchEmpty)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(responseNotFound)
+	}
 }
 
+const fakeAPIKey = "fake-api-key"
+
 var (
-	responseNotFound   = []byte(`{"error":true,"reason":"Not Found"}`)
-	responseBadRequest = []byte(`{"error":true,"reason":"Parameter 'latitude' and 'longitude' must have the same number of elements"}`)
-	responseElevations = []byte(`{"elevation":[38.01,72.56]}`)
-	responseForecast   = []byte(`
+	responseInvalidAPIKey = []byte(`{"error":true,"reason":"Invalid API key"}`)
+	responseNotFound      = []byte(`{"error":true,"reason":"Not Found"}`)
+	responseBadRequest    = []byte(`{"error":true,"reason":"Parameter 'latitude' and 'longitude' must have the same number of elements"}`)
+	responseElevations    = []byte(`{"elevation":[38.01,72.56]}`)
+	responseFlood         = []byte(`
+		{
+			"latitude": 46.225,
+			"longitude": 6.125,
+			"generationtime_ms": 0.12,
+			"utc_offset_seconds": 0,
+			"timezone": "GMT",
+			"timezone_abbreviation": "GMT",
+			"daily_units": {"time": "iso8601", "river_discharge": "m³/s"},
+			"daily": {
+				"time": ["2024-09-06","2024-09-07"],
+				"river_discharge": [251.3,null]
+			}
+		}
+	`)
+	responseFloods = []byte(`[
+		{
+			"latitude": 46.225,
+			"longitude": 6.125,
+			"daily": {"time": ["2024-09-06"], "river_discharge": [251.3]}
+		},
+		{
+			"latitude": 47.575,
+			"longitude": 7.575,
+			"location_id": 1,
+			"daily": {"time": ["2024-09-06"], "river_discharge": [1012.6]}
+		}
+	]`)
+	responseArchive = []byte(`
+		{
+			"latitude": 52.52,
+			"longitude": 13.41,
+			"generationtime_ms": 0.21,
+			"utc_offset_seconds": 0,
+			"timezone": "GMT",
+			"timezone_abbreviation": "GMT",
+			"elevation": 38,
+			"hourly": {
+				"time": ["2024-09-01T00:00","2024-09-01T01:00"],
+				"temperature_2m": [15.2,null],
+				"precipitation": [0,null],
+				"wind_speed_10m": [8.4,null],
+				"wind_direction_10m": [240,null],
+				"shortwave_radiation": [0,null],
+				"wind_speed_100m": [17.9,null],
+				"pressure_msl": [1012.1,null]
+			}
+		}
+	`)
+	responseArchives = []byte(`[
+		{
+			"latitude": 52.52,
+			"longitude": 13.41,
+			"hourly": {
+				"time": ["2024-09-01T00:00"],
+				"temperature_2m": [15.2],
+				"precipitation": [0],
+				"wind_speed_10m": [8.4],
+				"wind_direction_10m": [240],
+				"shortwave_radiation": [0],
+				"wind_speed_100m": [17.9],
+				"pressure_msl": [1012.1]
+			}
+		},
+		{
+			"latitude": 48.85,
+			"longitude": 2.35,
+			"location_id": 1,
+			"hourly": {
+				"time": ["2024-09-01T00:00"],
+				"temperature_2m": [13.6],
+				"precipitation": [0.4],
+				"wind_speed_10m": [5.1],
+				"wind_direction_10m": [190],
+				"shortwave_radiation": [0],
+				"wind_speed_100m": [11.2],
+				"pressure_msl": [1009.8]
+			}
+		}
+	]`)
+	responseTiltedIrradiance = []byte(`
+		{
+			"latitude": 52.52,
+			"longitude": 13.41,
+			"hourly_units": {"time": "iso8601", "global_tilted_irradiance": "W/m²"},
+			"hourly": {
+				"time": ["2024-09-06T00:00","2024-09-06T12:00","2024-09-06T13:00"],
+				"global_tilted_irradiance": [0,620.5,null]
+			}
+		}
+	`)
+	responseTiltedIrradianceSingleAxis = []byte(`{"hourly": {"time": ["2024-09-06T12:00"], "global_tilted_irradiance": [700.1]}}`)
+	responseTiltedIrradianceDualAxis   = []byte(`{"hourly": {"time": ["2024-09-06T12:00"], "global_tilted_irradiance": [810.2]}}`)
+	responseSearchEmpty                = []byte(`{"generationtime_ms":0.5}`)
+	responseSearch                     = []byte(`
+		{
+			"results": [
+				{
+					"id": 2657896,
+					"name": "Zurich",
+					"latitude": 47.36667,
+					"longitude": 8.55,
+					"elevation": 429.0,
+					"feature_code": "PPLA",
+					"country_code": "CH",
+					"admin1_id": 2657895,
+					"timezone": "Europe/Zurich",
+					"population": 341730,
+					"country_id": 2658434,
+					"country": "Switzerland",
+					"admin1": "Zurich",
+					"admin2": "Zurich District"
+				}
+			],
+			"generationtime_ms": 0.7
+		}
+	`)
+	responseForecast = []byte(`
 		{
 			"latitude": 52.52,
 			"longitude": 13.41,
@@ -66,14 +238,20 @@ var (
 				"temperature_2m": "°C",
 				"precipitation": "mm",
 				"wind_speed_10m": "km/h",
-				"wind_direction_10m": "°"
+				"wind_direction_10m": "°",
+				"shortwave_radiation": "W/m²",
+				"wind_speed_100m": "km/h",
+				"pressure_msl": "hPa"
 			},
 			"hourly": {
 				"time": ["2024-09-06T00:00","2024-09-06T01:00","2024-09-06T02:00"],
 				"temperature_2m": [22.1,21.2,20.5],
 				"precipitation": [0.1,0.2,0.3],
 				"wind_speed_10m": [11.9,12.4,12.8],
-				"wind_direction_10m": [85,80,80]
+				"wind_direction_10m": [85,80,80],
+				"shortwave_radiation": [0,0,12.5],
+				"wind_speed_100m": [20.5,21.3,22],
+				"pressure_msl": [1013.2,1013.5,1013.9]
 			},
 			"daily_units": {
 				"time": "iso8601",
@@ -107,7 +285,10 @@ var (
 			"temperature_2m": "°C",
 			"precipitation": "mm",
 			"wind_speed_10m": "km/h",
-			"wind_direction_10m": "°"
+			"wind_direction_10m": "°",
+			"shortwave_radiation": "W/m²",
+			"wind_speed_100m": "km/h",
+				"pressure_msl": "hPa"
 			},
 			"hourly": {
 				"time": [
@@ -134,6 +315,21 @@ var (
 					104,
 					99,
 					120
+				],
+				"shortwave_radiation": [
+					0,
+					0,
+					0
+				],
+				"wind_speed_100m": [
+					10.1,
+					11.2,
+					12.4
+				],
+				"pressure_msl": [
+					1008.1,
+					1008.3,
+					1008.2
 				]
 			},
 			"daily": {
@@ -163,7 +359,10 @@ var (
 			"temperature_2m": "°C",
 			"precipitation": "mm",
 			"wind_speed_10m": "km/h",
-			"wind_direction_10m": "°"
+			"wind_direction_10m": "°",
+			"shortwave_radiation": "W/m²",
+			"wind_speed_100m": "km/h",
+				"pressure_msl": "hPa"
 			},
 			"hourly": {
 				"time": [
@@ -190,6 +389,21 @@ var (
 					157,
 					155,
 					152
+				],
+				"shortwave_radiation": [
+					0,
+					5,
+					null
+				],
+				"wind_speed_100m": [
+					14.6,
+					13.9,
+					11.5
+				],
+				"pressure_msl": [
+					1011.4,
+					1011.1,
+					null
 				]
 			},
 			"daily": {
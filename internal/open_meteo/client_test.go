@@ -3,6 +3,9 @@ package open_meteo
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,25 +47,34 @@ func TestOpenMeteoClient_GetWeatherForecast(t *testing.T) {
 				HasPrecipitationToday: false,
 				WeatherForecasts: []types.WeatherForecast{
 					{
-						Time:          "2024-09-06T00:00",
-						Temperature:   22.1,
-						Precipitation: 0.1,
-						WindSpeed:     11.9,
-						WindDirection: 85,
+						Time:               "2024-09-06T00:00",
+						Temperature:        ptr(22.1),
+						Precipitation:      ptr(0.1),
+						WindSpeed:          ptr(11.9),
+						WindDirection:      ptr(85.0),
+						ShortwaveRadiation: ptr(0.0),
+						WindSpeed100m:      ptr(20.5),
+						PressureMSL:        ptr(1013.2),
 					},
 					{
-						Time:          "2024-09-06T01:00",
-						Temperature:   21.2,
-						Precipitation: 0.2,
-						WindSpeed:     12.4,
-						WindDirection: 80,
+						Time:               "2024-09-06T01:00",
+						Temperature:        ptr(21.2),
+						Precipitation:      ptr(0.2),
+						WindSpeed:          ptr(12.4),
+						WindDirection:      ptr(80.0),
+						ShortwaveRadiation: ptr(0.0),
+						WindSpeed100m:      ptr(21.3),
+						PressureMSL:        ptr(1013.5),
 					},
 					{
-						Time:          "2024-09-06T02:00",
-						Temperature:   20.5,
-						Precipitation: 0.3,
-						WindSpeed:     12.8,
-						WindDirection: 80,
+						Time:               "2024-09-06T02:00",
+						Temperature:        ptr(20.5),
+						Precipitation:      ptr(0.3),
+						WindSpeed:          ptr(12.8),
+						WindDirection:      ptr(80.0),
+						ShortwaveRadiation: ptr(12.5),
+						WindSpeed100m:      ptr(22.0),
+						PressureMSL:        ptr(1013.9),
 					},
 				},
 			},
@@ -123,25 +135,34 @@ func TestOpenMeteoClient_GetWeatherForecasts(t *testing.T) {
 					HasPrecipitationToday: true,
 					WeatherForecasts: []types.WeatherForecast{
 						{
-							Time:          "2024-09-07T00:00",
-							Temperature:   18.4,
-							Precipitation: 0.2,
-							WindSpeed:     5.9,
-							WindDirection: 104,
+							Time:               "2024-09-07T00:00",
+							Temperature:        ptr(18.4),
+							Precipitation:      ptr(0.2),
+							WindSpeed:          ptr(5.9),
+							WindDirection:      ptr(104.0),
+							ShortwaveRadiation: ptr(0.0),
+							WindSpeed100m:      ptr(10.1),
+							PressureMSL:        ptr(1008.1),
 						},
 						{
-							Time:          "2024-09-07T01:00",
-							Temperature:   17.8,
-							Precipitation: 0.5,
-							WindSpeed:     6.6,
-							WindDirection: 99,
+							Time:               "2024-09-07T01:00",
+							Temperature:        ptr(17.8),
+							Precipitation:      ptr(0.5),
+							WindSpeed:          ptr(6.6),
+							WindDirection:      ptr(99.0),
+							ShortwaveRadiation: ptr(0.0),
+							WindSpeed100m:      ptr(11.2),
+							PressureMSL:        ptr(1008.3),
 						},
 						{
-							Time:          "2024-09-07T02:00",
-							Temperature:   17.3,
-							Precipitation: 2.1,
-							WindSpeed:     7.1,
-							WindDirection: 120,
+							Time:               "2024-09-07T02:00",
+							Temperature:        ptr(17.3),
+							Precipitation:      ptr(2.1),
+							WindSpeed:          ptr(7.1),
+							WindDirection:      ptr(120.0),
+							ShortwaveRadiation: ptr(0.0),
+							WindSpeed100m:      ptr(12.4),
+							PressureMSL:        ptr(1008.2),
 						},
 					},
 				},
@@ -149,25 +170,32 @@ func TestOpenMeteoClient_GetWeatherForecasts(t *testing.T) {
 					HasPrecipitationToday: false,
 					WeatherForecasts: []types.WeatherForecast{
 						{
-							Time:          "2024-09-07T00:00",
-							Temperature:   25.9,
-							Precipitation: 2.7,
-							WindSpeed:     9,
-							WindDirection: 157,
+							Time:               "2024-09-07T00:00",
+							Temperature:        ptr(25.9),
+							Precipitation:      ptr(2.7),
+							WindSpeed:          ptr(9.0),
+							WindDirection:      ptr(157.0),
+							ShortwaveRadiation: ptr(0.0),
+							WindSpeed100m:      ptr(14.6),
+							PressureMSL:        ptr(1011.4),
 						},
 						{
-							Time:          "2024-09-07T01:00",
-							Temperature:   25.5,
-							Precipitation: 2.6,
-							WindSpeed:     8.4,
-							WindDirection: 155,
+							Time:               "2024-09-07T01:00",
+							Temperature:        ptr(25.5),
+							Precipitation:      ptr(2.6),
+							WindSpeed:          ptr(8.4),
+							WindDirection:      ptr(155.0),
+							ShortwaveRadiation: ptr(5.0),
+							WindSpeed100m:      ptr(13.9),
+							PressureMSL:        ptr(1011.1),
 						},
 						{
 							Time:          "2024-09-07T02:00",
-							Temperature:   25.2,
-							Precipitation: 0.5,
-							WindSpeed:     6.9,
-							WindDirection: 152,
+							Temperature:   ptr(25.2),
+							Precipitation: ptr(0.5),
+							WindSpeed:     ptr(6.9),
+							WindDirection: ptr(152.0),
+							WindSpeed100m: ptr(11.5),
 						},
 					},
 				},
@@ -249,6 +277,307 @@ func TestOpenMeteoClient_GetElevations(t *testing.T) {
 	}
 }
 
+func TestOpenMeteoClient_GetRiverDischarges(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL := ServeFakeOpenMeteo(t, ctx)
+	cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:  fakeURL,
+		Timeout: 5 * time.Second,
+	})
+
+	tests := []struct {
+		name      string
+		lat       []float64
+		long      []float64
+		expectErr error
+		expected  [][]types.RiverDischarge
+	}{
+		{
+			name:      "failed, bad request",
+			lat:       []float64{200.1},
+			long:      []float64{200.1},
+			expectErr: errors.New("unexpected status code: 400, reason: Parameter 'latitude' and 'longitude' must have the same number of elements"),
+		},
+		{
+			name: "success, single location",
+			lat:  []float64{46.2},
+			long: []float64{6.1},
+			expected: [][]types.RiverDischarge{
+				{
+					{Date: "2024-09-06", Discharge: ptr(251.3)},
+					{Date: "2024-09-07"},
+				},
+			},
+		},
+		{
+			name: "success, multiple locations",
+			lat:  []float64{46.2, 47.56},
+			long: []float64{6.1, 7.59},
+			expected: [][]types.RiverDischarge{
+				{{Date: "2024-09-06", Discharge: ptr(251.3)}},
+				{{Date: "2024-09-06", Discharge: ptr(1012.6)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := cl.GetRiverDischarges(ctx, tt.lat, tt.long, 2)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, resp); diff != "" {
+				t.Fatalf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoClient_GetWeatherObservations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL := ServeFakeOpenMeteo(t, ctx)
+	cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:  fakeURL,
+		Timeout: 5 * time.Second,
+	})
+
+	day := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		lat       []float64
+		long      []float64
+		expectErr error
+		expected  [][]types.WeatherForecast
+	}{
+		{
+			name:      "failed, bad request",
+			lat:       []float64{200.1},
+			long:      []float64{200.1},
+			expectErr: errors.New("unexpected status code: 400, reason: Parameter 'latitude' and 'longitude' must have the same number of elements"),
+		},
+		{
+			name: "success, single location",
+			lat:  []float64{52.52},
+			long: []float64{13.41},
+			expected: [][]types.WeatherForecast{
+				{
+					{
+						Time:               "2024-09-01T00:00",
+						Temperature:        ptr(15.2),
+						Precipitation:      ptr(0.0),
+						WindSpeed:          ptr(8.4),
+						WindDirection:      ptr(240.0),
+						ShortwaveRadiation: ptr(0.0),
+						WindSpeed100m:      ptr(17.9),
+						PressureMSL:        ptr(1012.1),
+					},
+					{Time: "2024-09-01T01:00"},
+				},
+			},
+		},
+		{
+			name: "success, multiple locations",
+			lat:  []float64{52.52, 48.85},
+			long: []float64{13.41, 2.35},
+			expected: [][]types.WeatherForecast{
+				{
+					{
+						Time:               "2024-09-01T00:00",
+						Temperature:        ptr(15.2),
+						Precipitation:      ptr(0.0),
+						WindSpeed:          ptr(8.4),
+						WindDirection:      ptr(240.0),
+						ShortwaveRadiation: ptr(0.0),
+						WindSpeed100m:      ptr(17.9),
+						PressureMSL:        ptr(1012.1),
+					},
+				},
+				{
+					{
+						Time:               "2024-09-01T00:00",
+						Temperature:        ptr(13.6),
+						Precipitation:      ptr(0.4),
+						WindSpeed:          ptr(5.1),
+						WindDirection:      ptr(190.0),
+						ShortwaveRadiation: ptr(0.0),
+						WindSpeed100m:      ptr(11.2),
+						PressureMSL:        ptr(1009.8),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := cl.GetWeatherObservations(ctx, tt.lat, tt.long, day, day)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, resp); diff != "" {
+				t.Fatalf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoClient_GetTiltedIrradiance(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL := ServeFakeOpenMeteo(t, ctx)
+	cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:  fakeURL,
+		Timeout: 5 * time.Second,
+	})
+
+	tests := []struct {
+		name      string
+		lat       float64
+		long      float64
+		array     types.SolarArrayConfig
+		expectErr error
+		expected  []types.TiltedIrradiance
+	}{
+		{
+			name:      "failed, bad request",
+			lat:       200.1,
+			long:      200.1,
+			array:     types.SolarArrayConfig{Tilt: 30, Tracking: types.SolarTrackingFixed},
+			expectErr: errors.New("unexpected status code: 400, reason: Parameter 'latitude' and 'longitude' must have the same number of elements"),
+		},
+		{
+			name:  "success, fixed",
+			lat:   52.52,
+			long:  13.41,
+			array: types.SolarArrayConfig{Tilt: 30, Azimuth: -10, Tracking: types.SolarTrackingFixed},
+			expected: []types.TiltedIrradiance{
+				{Time: "2024-09-06T00:00", Irradiance: ptr(0.0)},
+				{Time: "2024-09-06T12:00", Irradiance: ptr(620.5)},
+				{Time: "2024-09-06T13:00"},
+			},
+		},
+		{
+			name:     "success, single axis",
+			lat:      52.52,
+			long:     13.41,
+			array:    types.SolarArrayConfig{Tracking: types.SolarTrackingSingleAxis},
+			expected: []types.TiltedIrradiance{{Time: "2024-09-06T12:00", Irradiance: ptr(700.1)}},
+		},
+		{
+			name:     "success, dual axis",
+			lat:      52.52,
+			long:     13.41,
+			array:    types.SolarArrayConfig{Tilt: 30, Tracking: types.SolarTrackingDualAxis},
+			expected: []types.TiltedIrradiance{{Time: "2024-09-06T12:00", Irradiance: ptr(810.2)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := cl.GetTiltedIrradiance(ctx, tt.lat, tt.long, tt.array, 1)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, resp); diff != "" {
+				t.Fatalf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoClient_SearchLocations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL := ServeFakeOpenMeteo(t, ctx)
+	cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:  fakeURL,
+		Timeout: 5 * time.Second,
+	})
+
+	tests := []struct {
+		name      string
+		query     string
+		expectErr error
+		expected  []types.Location
+	}{
+		{
+			name:     "success, no result",
+			query:    "Atlantis",
+			expected: []types.Location{},
+		},
+		{
+			name:  "success",
+			query: "Zurich",
+			expected: []types.Location{
+				{
+					ID:          2657896,
+					Name:        "Zurich",
+					Latitude:    47.36667,
+					Longitude:   8.55,
+					Elevation:   429,
+					CountryCode: "CH",
+					Country:     "Switzerland",
+					Admin1:      "Zurich",
+					Admin2:      "Zurich District",
+					Timezone:    "Europe/Zurich",
+					Population:  341730,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := cl.SearchLocations(ctx, tt.query, 10)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, resp); diff != "" {
+				t.Fatalf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestOpenMeteoClient_doRequest(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -284,7 +613,7 @@ func TestOpenMeteoClient_doRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := cl.doRequest(tt.path, nil, "GET", nil, &tt.value)
+			err := cl.doRequest(ctx, fakeURL, tt.path, nil, "GET", nil, &tt.value)
 			if tt.expectErr != nil {
 				if err == nil || err.Error() != tt.expectErr.Error() {
 					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
@@ -302,3 +631,114 @@ func TestOpenMeteoClient_doRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenMeteoClient_doRequestCredentials(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fakeURL := ServeFakeOpenMeteo(t, ctx)
+
+	tests := []struct {
+		name      string
+		apiKey    string
+		expectErr error
+	}{
+		{
+			name:      "failed, invalid api key",
+			apiKey:    "invalid",
+			expectErr: &AuthError{StatusCode: http.StatusUnauthorized, Reason: "Invalid API key"},
+		},
+		{
+			name:   "success, valid api key",
+			apiKey: fakeAPIKey,
+		},
+		{
+			name: "success, no api key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+				APIURL:  fakeURL,
+				APIKey:  tt.apiKey,
+				Timeout: 5 * time.Second,
+			})
+
+			var value map[string]any
+			err := cl.doRequest(ctx, fakeURL, "/v1/elevation", nil, "GET", nil, &value)
+			if tt.expectErr != nil {
+				var authErr *AuthError
+				if !errors.As(err, &authErr) {
+					t.Fatalf("expected auth error, got: %v", err)
+				}
+				if diff := cmp.Diff(tt.expectErr, authErr); diff != "" {
+					t.Fatalf("unexpected error (-want +got):\n%s", diff)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoClient_doRequestRedactsAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:  srv.URL,
+		APIKey:  "secret-api-key",
+		Timeout: 5 * time.Second,
+	})
+
+	_, err := cl.GetElevations(context.Background(), []float64{47.36865}, []float64{8.539183})
+	if err == nil {
+		t.Fatal("expected a transport error")
+	}
+	if strings.Contains(err.Error(), "secret-api-key") {
+		t.Fatalf("expected the API key to be redacted, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "/v1/elevation?latitude=47.36865") {
+		t.Fatalf("expected the URL without the API key, got: %v", err)
+	}
+}
+
+func TestOpenMeteoClient_endpointURLsAndHeaders(t *testing.T) {
+	var (
+		gotPath   string
+		gotHeader string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Get("X-Mirror-Token")
+		w.WriteHeader(http.StatusOK)
+		w.Write(responseElevations)
+	}))
+	defer srv.Close()
+
+	cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+		APIURL:       "http://localhost:0",
+		ElevationURL: srv.URL + "/mirror",
+		Headers:      map[string]string{"X-Mirror-Token": "token"},
+		Timeout:      5 * time.Second,
+	})
+
+	resp, err := cl.GetElevations(context.Background(), []float64{47.36865}, []float64{8.539183})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]float64{38.01, 72.56}, resp); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+	if gotPath != "/mirror/v1/elevation" {
+		t.Fatalf("expected path /mirror/v1/elevation, got: %s", gotPath)
+	}
+	if gotHeader != "token" {
+		t.Fatalf("expected header token, got: %s", gotHeader)
+	}
+}
@@ -0,0 +1,38 @@
+package open_meteo
+
+import "math"
+
+// defaultGridResolutions are the grid resolutions, in degrees, of the weather models of the forecast API.
+// best_match combines the best models of every location, down to about 1 km, so it is snapped to 0.01°.
+// Docs: https://open-meteo.com/en/docs#weather_models
+var defaultGridResolutions = map[string]float64{
+	"best_match":   0.01,
+	"ecmwf_ifs025": 0.25,
+	"gfs_global":   0.25,
+	"icon_eu":      0.0625,
+	"icon_d2":      0.02,
+}
+
+// gridResolution returns the grid resolution of the model, from the overrides or the defaults.
+// It returns 0 for an unknown model, only exact coordinates are then considered the same.
+func gridResolution(model string, overrides map[string]float64) float64 {
+	if model == "" {
+		model = "best_match"
+	}
+	if resolution, ok := overrides[model]; ok {
+		return resolution
+	}
+	return defaultGridResolutions[model]
+}
+
+// SnapToGrid returns the coordinate of the weather model grid cell the coordinate is in.
+// Coordinates in the same grid cell get the same forecast, so they are fetched and cached once.
+func (c *OpenMeteoClient) SnapToGrid(coordinate float64) float64 {
+	if c.gridResolution == 0 {
+		return coordinate
+	}
+
+	snapped := math.Round(coordinate/c.gridResolution) * c.gridResolution
+	// Drop the floating point noise of the division, e.g. 0.30000000000000004.
+	return math.Round(snapped*1e6) / 1e6
+}
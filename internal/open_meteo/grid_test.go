@@ -0,0 +1,62 @@
+package open_meteo
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+)
+
+func TestOpenMeteoClient_SnapToGrid(t *testing.T) {
+	tests := []struct {
+		name            string
+		model           string
+		gridResolutions map[string]float64
+		coordinate      float64
+		expected        float64
+	}{
+		{
+			name:       "success, default model",
+			coordinate: 40.7128,
+			expected:   40.71,
+		},
+		{
+			name:       "success, quarter degree model",
+			model:      "ecmwf_ifs025",
+			coordinate: -74.006,
+			expected:   -74,
+		},
+		{
+			name:       "success, sixteenth degree model",
+			model:      "icon_eu",
+			coordinate: 47.3667,
+			expected:   47.375,
+		},
+		{
+			name:            "success, overridden resolution",
+			model:           "ecmwf_ifs025",
+			gridResolutions: map[string]float64{"ecmwf_ifs025": 0.1},
+			coordinate:      0.34,
+			expected:        0.3,
+		},
+		{
+			name:       "success, unknown model",
+			model:      "some_model",
+			coordinate: 40.7128,
+			expected:   40.7128,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := NewOpenMeteoClient(config.OpenMeteoConfig{
+				APIURL:          "http://localhost",
+				Model:           tt.model,
+				GridResolutions: tt.gridResolutions,
+			})
+
+			if got := cl.SnapToGrid(tt.coordinate); got != tt.expected {
+				t.Fatalf("expected: %v, got: %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package open_meteo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// variablesPerCall is the number of weather variables OpenMeteo counts as a single call
+// for one location. Requests with more variables are counted as several calls.
+// Docs: https://open-meteo.com/en/pricing
+const variablesPerCall = 10
+
+// rateLimiter is a token bucket limiter with one bucket per window (minute, hour, day).
+// A request has to fit in every bucket, each bucket refills continuously up to its limit.
+//
+// Besides the buckets, it keeps the weight used in the current calendar window
+// so the consumption can be compared with the quota reported by OpenMeteo.
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows []*rateWindow
+	maxWait time.Duration
+	now     func() time.Time
+}
+
+type rateWindow struct {
+	name   string
+	limit  float64
+	period time.Duration
+	tokens float64
+	last   time.Time
+	used   float64
+	start  time.Time
+}
+
+// newRateLimiter creates a rate limiter from the configuration, now is the clock of the limiter.
+// Windows with a zero limit are not enforced, it returns nil if no window is enforced.
+func newRateLimiter(cfg config.RateLimitConfig, now func() time.Time) *rateLimiter {
+	limiter := &rateLimiter{
+		maxWait: cfg.MaxWait,
+		now:     now,
+	}
+
+	start := now()
+	for _, w := range []struct {
+		name   string
+		limit  int
+		period time.Duration
+	}{
+		{"minute", cfg.PerMinute, time.Minute},
+		{"hour", cfg.PerHour, time.Hour},
+		{"day", cfg.PerDay, 24 * time.Hour},
+	} {
+		if w.limit <= 0 {
+			continue
+		}
+		limiter.windows = append(limiter.windows, &rateWindow{
+			name:   w.name,
+			limit:  float64(w.limit),
+			period: w.period,
+			tokens: float64(w.limit),
+			last:   start,
+			start:  start.Truncate(w.period),
+		})
+	}
+
+	if len(limiter.windows) == 0 {
+		return nil
+	}
+	return limiter
+}
+
+// wait reserves weight from every window.
+// If a window has not enough budget, it queues the request for at most maxWait,
+// otherwise it returns an error wrapping types.ErrUpstreamRateLimited.
+func (l *rateLimiter) wait(ctx context.Context, weight float64) error {
+	l.mu.Lock()
+	now := l.now()
+
+	var (
+		delay     time.Duration
+		exhausted string
+	)
+	for _, w := range l.windows {
+		w.refill(now)
+		if weight > w.limit {
+			l.mu.Unlock()
+			return fmt.Errorf("%w: request weight %.1f exceeds the %s limit", types.ErrUpstreamRateLimited, weight, w.name)
+		}
+		if missing := weight - w.tokens; missing > 0 {
+			d := time.Duration(math.Ceil(missing / w.limit * float64(w.period)))
+			if d > delay {
+				delay = d
+				exhausted = w.name
+			}
+		}
+	}
+
+	if delay > l.maxWait {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: %s budget exhausted", types.ErrUpstreamRateLimited, exhausted)
+	}
+
+	// Tokens may go negative while queued, later requests then wait behind this one.
+	for _, w := range l.windows {
+		w.tokens -= weight
+		w.used += weight
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, w := range l.windows {
+			w.tokens += weight
+			w.used = math.Max(0, w.used-weight)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// usage returns the weight used in the current calendar window of every enforced window.
+func (l *rateLimiter) usage() []types.RateLimitWindow {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	windows := make([]types.RateLimitWindow, 0, len(l.windows))
+	for _, w := range l.windows {
+		w.refill(now)
+		windows = append(windows, types.RateLimitWindow{
+			Window:    w.name,
+			Limit:     int(w.limit),
+			Used:      w.used,
+			Available: math.Max(w.tokens, 0),
+			ResetsAt:  w.start.Add(w.period),
+		})
+	}
+	return windows
+}
+
+// refill adds the tokens earned since the last refill and rolls the usage counter
+// over when a new calendar window started.
+func (w *rateWindow) refill(now time.Time) {
+	elapsed := now.Sub(w.last)
+	if elapsed > 0 {
+		w.tokens = math.Min(w.limit, w.tokens+elapsed.Seconds()/w.period.Seconds()*w.limit)
+		w.last = now
+	}
+
+	if start := now.Truncate(w.period); start.After(w.start) {
+		w.start = start
+		w.used = 0
+	}
+}
+
+// requestWeight returns how many calls OpenMeteo counts for a request:
+// every location is a call, multiplied for every started block of variablesPerCall variables.
+func requestWeight(query url.Values) float64 {
+	locations := len(query["latitude"])
+	if locations == 0 {
+		locations = 1
+	}
+
+	variables := len(query["hourly"]) + len(query["daily"])
+	blocks := 1
+	if variables > variablesPerCall {
+		blocks = (variables + variablesPerCall - 1) / variablesPerCall
+	}
+
+	return float64(locations * blocks)
+}
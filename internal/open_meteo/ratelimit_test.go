@@ -0,0 +1,147 @@
+package open_meteo
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequestWeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    url.Values
+		expected float64
+	}{
+		{
+			name:     "no location",
+			query:    url.Values{},
+			expected: 1,
+		},
+		{
+			name: "single location, few variables",
+			query: url.Values{
+				"latitude": {"52.52"},
+				"hourly":   {"temperature_2m", "precipitation"},
+				"daily":    {"precipitation_sum"},
+			},
+			expected: 1,
+		},
+		{
+			name: "multiple locations, many variables",
+			query: url.Values{
+				"latitude": {"52.52", "53.1", "54.2"},
+				"hourly":   {"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+				"daily":    {"11"},
+			},
+			expected: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if weight := requestWeight(tt.query); weight != tt.expected {
+				t.Fatalf("expected weight %v, got %v", tt.expected, weight)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_wait(t *testing.T) {
+	now := time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		cfg       config.RateLimitConfig
+		weights   []float64
+		expectErr error
+	}{
+		{
+			name:    "success, within budget",
+			cfg:     config.RateLimitConfig{PerMinute: 10, PerDay: 100},
+			weights: []float64{4, 6},
+		},
+		{
+			name:      "failed, minute budget exhausted",
+			cfg:       config.RateLimitConfig{PerMinute: 10, PerDay: 100},
+			weights:   []float64{4, 6, 1},
+			expectErr: types.ErrUpstreamRateLimited,
+		},
+		{
+			name:      "failed, weight above limit",
+			cfg:       config.RateLimitConfig{PerMinute: 10, MaxWait: time.Hour},
+			weights:   []float64{11},
+			expectErr: types.ErrUpstreamRateLimited,
+		},
+		{
+			name:    "success, queued within max wait",
+			cfg:     config.RateLimitConfig{PerMinute: 6000, MaxWait: 50 * time.Millisecond},
+			weights: []float64{6000, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newRateLimiter(tt.cfg, func() time.Time { return now })
+
+			var err error
+			for _, weight := range tt.weights {
+				if err = limiter.wait(context.Background(), weight); err != nil {
+					break
+				}
+			}
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_usage(t *testing.T) {
+	now := time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC)
+
+	limiter := newRateLimiter(config.RateLimitConfig{PerMinute: 10, PerHour: 100}, func() time.Time { return now })
+
+	for _, weight := range []float64{2, 3} {
+		if err := limiter.wait(context.Background(), weight); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := []types.RateLimitWindow{
+		{Window: "minute", Limit: 10, Used: 5, Available: 5, ResetsAt: now.Add(time.Minute)},
+		{Window: "hour", Limit: 100, Used: 5, Available: 95, ResetsAt: time.Date(2024, 9, 6, 11, 0, 0, 0, time.UTC)},
+	}
+	if diff := cmp.Diff(expected, limiter.usage()); diff != "" {
+		t.Fatalf("unexpected usage (-want +got):\n%s", diff)
+	}
+
+	// A new minute resets the minute counter, the buckets refill continuously.
+	now = now.Add(time.Minute)
+	expected = []types.RateLimitWindow{
+		{Window: "minute", Limit: 10, Used: 0, Available: 10, ResetsAt: time.Date(2024, 9, 6, 10, 32, 0, 0, time.UTC)},
+		{Window: "hour", Limit: 100, Used: 5, Available: 95 + 100*60.0/3600, ResetsAt: time.Date(2024, 9, 6, 11, 0, 0, 0, time.UTC)},
+	}
+	if diff := cmp.Diff(expected, limiter.usage()); diff != "" {
+		t.Fatalf("unexpected usage (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewRateLimiter_disabled(t *testing.T) {
+	if limiter := newRateLimiter(config.RateLimitConfig{}, time.Now); limiter != nil {
+		t.Fatalf("expected no limiter, got: %+v", limiter)
+	}
+}
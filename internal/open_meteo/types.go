@@ -13,6 +13,21 @@ type ErrorResponse struct {
 	Reason string `json:"reason"`
 }
 
+// AuthError is returned when the OpenMeteo API rejects the credentials of a request,
+// e.g. a missing, invalid or expired API key.
+type AuthError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("authentication failed, status code: %d", e.StatusCode)
+	if e.Reason != "" {
+		msg += fmt.Sprintf(", reason: %s", e.Reason)
+	}
+	return msg
+}
+
 // WeatherForecast represents the response of Weather Forecast API
 // Docs: https://open-meteo.com/en/docs
 type WeatherForecast struct {
@@ -44,17 +59,23 @@ func (w WeatherForecast) ToProperties() (*types.WeatherForecastProperties, error
 	}, nil
 }
 
+// HourlyData is the hourly series of the forecast.
+// OpenMeteo returns null for hours without model data, so values are pointers to keep them apart from 0.
 type HourlyData struct {
-	Time          []string  `json:"time"`
-	Temperature   []float64 `json:"temperature_2m"`
-	Precipitation []float64 `json:"precipitation"`
-	WindSpeed     []float64 `json:"wind_speed_10m"`
-	WindDirection []float64 `json:"wind_direction_10m"`
+	Time               []string   `json:"time"`
+	Temperature        []*float64 `json:"temperature_2m"`
+	Precipitation      []*float64 `json:"precipitation"`
+	WindSpeed          []*float64 `json:"wind_speed_10m"`
+	WindDirection      []*float64 `json:"wind_direction_10m"`
+	ShortwaveRadiation []*float64 `json:"shortwave_radiation"`
+	WindSpeed100m      []*float64 `json:"wind_speed_100m"`
+	PressureMSL        []*float64 `json:"pressure_msl"`
 }
 
+// DailyData is the daily series of the forecast, null values are kept as nil.
 type DailyData struct {
-	Time             []string  `json:"time"`
-	PrecipitationSum []float64 `json:"precipitation_sum"`
+	Time             []string   `json:"time"`
+	PrecipitationSum []*float64 `json:"precipitation_sum"`
 }
 
 // ToWeatherForecasts converts the HourlyData to WeatherForecasts.
@@ -63,20 +84,26 @@ func (d HourlyData) ToWeatherForecasts() ([]types.WeatherForecast, error) {
 	if len(d.Temperature) != dataCount ||
 		len(d.Precipitation) != dataCount ||
 		len(d.WindSpeed) != dataCount ||
-		len(d.WindDirection) != dataCount {
-		msg := fmt.Sprintf("invalid data length, time %d, temp %d, precipitation %d, wind speed %d, wind direction %d",
-			dataCount, len(d.Temperature), len(d.Precipitation), len(d.WindSpeed), len(d.WindDirection))
+		len(d.WindDirection) != dataCount ||
+		len(d.ShortwaveRadiation) != dataCount ||
+		len(d.WindSpeed100m) != dataCount ||
+		len(d.PressureMSL) != dataCount {
+		msg := fmt.Sprintf("invalid data length, time %d, temp %d, precipitation %d, wind speed %d, wind direction %d, shortwave radiation %d, wind speed 100m %d, pressure %d",
+			dataCount, len(d.Temperature), len(d.Precipitation), len(d.WindSpeed), len(d.WindDirection), len(d.ShortwaveRadiation), len(d.WindSpeed100m), len(d.PressureMSL))
 		return nil, errors.New(msg)
 	}
 
 	forecasts := make([]types.WeatherForecast, 0, dataCount)
 	for i := 0; i < dataCount; i++ {
 		forecasts = append(forecasts, types.WeatherForecast{
-			Time:          d.Time[i],
-			Temperature:   d.Temperature[i],
-			Precipitation: d.Precipitation[i],
-			WindSpeed:     d.WindSpeed[i],
-			WindDirection: d.WindDirection[i],
+			Time:               d.Time[i],
+			Temperature:        d.Temperature[i],
+			Precipitation:      d.Precipitation[i],
+			WindSpeed:          d.WindSpeed[i],
+			WindDirection:      d.WindDirection[i],
+			ShortwaveRadiation: d.ShortwaveRadiation[i],
+			WindSpeed100m:      d.WindSpeed100m[i],
+			PressureMSL:        d.PressureMSL[i],
 		})
 	}
 	return forecasts, nil
@@ -91,16 +118,123 @@ func (d DailyData) HasPrecipitationToday() (bool, error) {
 	}
 
 	var hasPrecipitationToday bool
-	// First daily data is always today
-	if d.PrecipitationSum[0] > 0 {
+	// First daily data is always today, a missing sum is treated as no precipitation.
+	if d.PrecipitationSum[0] != nil && *d.PrecipitationSum[0] > 0 {
 		hasPrecipitationToday = true
 	}
 
 	return hasPrecipitationToday, nil
 }
 
+// Flood represents the response of Flood API.
+// Docs: https://open-meteo.com/en/docs/flood-api
+type Flood struct {
+	Latitude  float64        `json:"latitude"`
+	Longitude float64        `json:"longitude"`
+	Daily     FloodDailyData `json:"daily"`
+}
+
+// FloodDailyData is the daily series of the flood forecast, null values are kept as nil.
+type FloodDailyData struct {
+	Time           []string   `json:"time"`
+	RiverDischarge []*float64 `json:"river_discharge"`
+}
+
+// ToRiverDischarges converts the FloodDailyData to RiverDischarges.
+func (d FloodDailyData) ToRiverDischarges() ([]types.RiverDischarge, error) {
+	if len(d.Time) != len(d.RiverDischarge) {
+		msg := fmt.Sprintf("invalid data length time %d, river discharge %d",
+			len(d.Time), len(d.RiverDischarge))
+		return nil, errors.New(msg)
+	}
+
+	discharges := make([]types.RiverDischarge, 0, len(d.Time))
+	for i := range d.Time {
+		discharges = append(discharges, types.RiverDischarge{
+			Date:      d.Time[i],
+			Discharge: d.RiverDischarge[i],
+		})
+	}
+	return discharges, nil
+}
+
+// TiltedIrradiance represents the response of Weather Forecast API for the irradiance on a solar array.
+// Docs: https://open-meteo.com/en/docs
+type TiltedIrradiance struct {
+	Latitude  float64                `json:"latitude"`
+	Longitude float64                `json:"longitude"`
+	Hourly    TiltedIrradianceHourly `json:"hourly"`
+}
+
+// TiltedIrradianceHourly is the hourly series of the tilted irradiance, null values are kept as nil.
+type TiltedIrradianceHourly struct {
+	Time                   []string   `json:"time"`
+	GlobalTiltedIrradiance []*float64 `json:"global_tilted_irradiance"`
+}
+
+// ToTiltedIrradiances converts the TiltedIrradianceHourly to TiltedIrradiances.
+func (d TiltedIrradianceHourly) ToTiltedIrradiances() ([]types.TiltedIrradiance, error) {
+	if len(d.Time) != len(d.GlobalTiltedIrradiance) {
+		msg := fmt.Sprintf("invalid data length time %d, global tilted irradiance %d",
+			len(d.Time), len(d.GlobalTiltedIrradiance))
+		return nil, errors.New(msg)
+	}
+
+	irradiances := make([]types.TiltedIrradiance, 0, len(d.Time))
+	for i := range d.Time {
+		irradiances = append(irradiances, types.TiltedIrradiance{
+			Time:       d.Time[i],
+			Irradiance: d.GlobalTiltedIrradiance[i],
+		})
+	}
+	return irradiances, nil
+}
+
 // Elevation represents the response of Elevation API
 // Docs: https://open-meteo.com/en/docs/elevation-api
 type Elevation struct {
 	Elevation []float64 `json:"elevation"`
 }
+
+// LocationSearch represents the response of Geocoding API.
+// Docs: https://open-meteo.com/en/docs/geocoding-api
+type LocationSearch struct {
+	// Results is missing from the response when nothing matches.
+	Results []Location `json:"results"`
+}
+
+type Location struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Elevation   float64 `json:"elevation"`
+	FeatureCode string  `json:"feature_code"`
+	CountryCode string  `json:"country_code"`
+	Country     string  `json:"country"`
+	Admin1      string  `json:"admin1"`
+	Admin2      string  `json:"admin2"`
+	Timezone    string  `json:"timezone"`
+	Population  int     `json:"population"`
+}
+
+// ToLocations converts the search results to Locations.
+func (s LocationSearch) ToLocations() []types.Location {
+	locations := make([]types.Location, 0, len(s.Results))
+	for _, result := range s.Results {
+		locations = append(locations, types.Location{
+			ID:          result.ID,
+			Name:        result.Name,
+			Latitude:    result.Latitude,
+			Longitude:   result.Longitude,
+			Elevation:   result.Elevation,
+			CountryCode: result.CountryCode,
+			Country:     result.Country,
+			Admin1:      result.Admin1,
+			Admin2:      result.Admin2,
+			Timezone:    result.Timezone,
+			Population:  result.Population,
+		})
+	}
+	return locations
+}
@@ -8,6 +8,10 @@ import (
 	"github.com/google/go-cmp/cmp"
 )
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestHourlyData_ToWeatherForecast(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -19,36 +23,66 @@ func TestHourlyData_ToWeatherForecast(t *testing.T) {
 			name: "failed, invalid data count",
 			data: HourlyData{
 				Time:          []string{"2024-09-06T00:00"},
-				Temperature:   []float64{0.0},
-				Precipitation: []float64{1.1, 2.2},
-				WindSpeed:     []float64{},
-				WindDirection: []float64{},
+				Temperature:   []*float64{ptr(0.0)},
+				Precipitation: []*float64{ptr(1.1), ptr(2.2)},
+				WindSpeed:     []*float64{},
+				WindDirection: []*float64{},
+			},
+			expectErr: errors.New("invalid data length, time 1, temp 1, precipitation 2, wind speed 0, wind direction 0, shortwave radiation 0, wind speed 100m 0, pressure 0"),
+		},
+		{
+			name: "success, missing values",
+			data: HourlyData{
+				Time:               []string{"2024-09-06T00:00"},
+				Temperature:        []*float64{nil},
+				Precipitation:      []*float64{ptr(0.0)},
+				WindSpeed:          []*float64{nil},
+				WindDirection:      []*float64{ptr(1.3)},
+				ShortwaveRadiation: []*float64{ptr(0.0)},
+				WindSpeed100m:      []*float64{nil},
+				PressureMSL:        []*float64{nil},
+			},
+			expected: []types.WeatherForecast{
+				{
+					Time:               "2024-09-06T00:00",
+					Precipitation:      ptr(0.0),
+					WindDirection:      ptr(1.3),
+					ShortwaveRadiation: ptr(0.0),
+				},
 			},
-			expectErr: errors.New("invalid data length, time 1, temp 1, precipitation 2, wind speed 0, wind direction 0"),
 		},
 		{
 			name: "success",
 			data: HourlyData{
-				Time:          []string{"2024-09-06T00:00", "2024-09-06T01:00"},
-				Temperature:   []float64{0.0, 1.0},
-				Precipitation: []float64{1.2, 1.1},
-				WindSpeed:     []float64{3.4, 1.2},
-				WindDirection: []float64{5.6, 1.3},
+				Time:               []string{"2024-09-06T00:00", "2024-09-06T01:00"},
+				Temperature:        []*float64{ptr(0.0), ptr(1.0)},
+				Precipitation:      []*float64{ptr(1.2), ptr(1.1)},
+				WindSpeed:          []*float64{ptr(3.4), ptr(1.2)},
+				WindDirection:      []*float64{ptr(5.6), ptr(1.3)},
+				ShortwaveRadiation: []*float64{ptr(0.0), ptr(150.0)},
+				WindSpeed100m:      []*float64{ptr(6.1), ptr(2.4)},
+				PressureMSL:        []*float64{ptr(1012.5), ptr(1012.8)},
 			},
 			expected: []types.WeatherForecast{
 				{
-					Time:          "2024-09-06T00:00",
-					Temperature:   0.0,
-					Precipitation: 1.2,
-					WindSpeed:     3.4,
-					WindDirection: 5.6,
+					Time:               "2024-09-06T00:00",
+					Temperature:        ptr(0.0),
+					Precipitation:      ptr(1.2),
+					WindSpeed:          ptr(3.4),
+					WindDirection:      ptr(5.6),
+					ShortwaveRadiation: ptr(0.0),
+					WindSpeed100m:      ptr(6.1),
+					PressureMSL:        ptr(1012.5),
 				},
 				{
-					Time:          "2024-09-06T01:00",
-					Temperature:   1.0,
-					Precipitation: 1.1,
-					WindSpeed:     1.2,
-					WindDirection: 1.3,
+					Time:               "2024-09-06T01:00",
+					Temperature:        ptr(1.0),
+					Precipitation:      ptr(1.1),
+					WindSpeed:          ptr(1.2),
+					WindDirection:      ptr(1.3),
+					ShortwaveRadiation: ptr(150.0),
+					WindSpeed100m:      ptr(2.4),
+					PressureMSL:        ptr(1012.8),
 				},
 			},
 		},
@@ -86,7 +120,7 @@ func TestDailyData_HasPrecipitationToday(t *testing.T) {
 			name: "failed, invalid data count",
 			data: DailyData{
 				Time:             []string{"2024-09-06"},
-				PrecipitationSum: []float64{1.1, 2.2},
+				PrecipitationSum: []*float64{ptr(1.1), ptr(2.2)},
 			},
 			expectErr: errors.New("invalid data length time 1, precipitation 2"),
 		},
@@ -94,15 +128,23 @@ func TestDailyData_HasPrecipitationToday(t *testing.T) {
 			name: "success, has precipitations",
 			data: DailyData{
 				Time:             []string{"2024-09-06", "2024-09-07"},
-				PrecipitationSum: []float64{1.1, 2.2},
+				PrecipitationSum: []*float64{ptr(1.1), ptr(2.2)},
 			},
 			expected: true,
 		},
+		{
+			name: "success, missing precipitation today",
+			data: DailyData{
+				Time:             []string{"2024-09-06", "2024-09-07"},
+				PrecipitationSum: []*float64{nil, ptr(1.1)},
+			},
+			expected: false,
+		},
 		{
 			name: "success, has no precipitation",
 			data: DailyData{
 				Time:             []string{"2024-09-06", "2024-09-07"},
-				PrecipitationSum: []float64{0, 1.1},
+				PrecipitationSum: []*float64{ptr(0.0), ptr(1.1)},
 			},
 			expected: false,
 		},
@@ -128,3 +170,52 @@ func TestDailyData_HasPrecipitationToday(t *testing.T) {
 		})
 	}
 }
+
+func TestFloodDailyData_ToRiverDischarges(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      FloodDailyData
+		expected  []types.RiverDischarge
+		expectErr error
+	}{
+		{
+			name: "failed, invalid data count",
+			data: FloodDailyData{
+				Time:           []string{"2024-09-06"},
+				RiverDischarge: []*float64{ptr(1.1), ptr(2.2)},
+			},
+			expectErr: errors.New("invalid data length time 1, river discharge 2"),
+		},
+		{
+			name: "success, missing values",
+			data: FloodDailyData{
+				Time:           []string{"2024-09-06", "2024-09-07"},
+				RiverDischarge: []*float64{ptr(251.3), nil},
+			},
+			expected: []types.RiverDischarge{
+				{Date: "2024-09-06", Discharge: ptr(251.3)},
+				{Date: "2024-09-07"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discharges, err := tt.data.ToRiverDischarges()
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, discharges); diff != "" {
+				t.Fatalf("unexpected response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
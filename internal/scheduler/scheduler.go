@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/gcathelines/tensor-energy-case/internal/usecase"
+)
+
+var _ prefetcher = (*usecase.Usecase)(nil)
+
+type prefetcher interface {
+	GetOrganizations(ctx context.Context) ([]types.Organization, error)
+	PrefetchForecasts(ctx context.Context, cfg config.PrefetchConfig) (int, error)
+	EvaluateAlerts(ctx context.Context, batchSize int) (int, error)
+	RecordIssuedForecasts(ctx context.Context, cfg config.AccuracyConfig) (int, error)
+	MaterializeForecastAccuracy(ctx context.Context, cfg config.AccuracyConfig) (int, error)
+}
+
+// Scheduler runs the background jobs of the service, for every organization in turn:
+// the database only sees the rows of the organization a context acts for.
+type Scheduler struct {
+	prefetcher prefetcher
+	cfg        config.PrefetchConfig
+	accuracy   config.AccuracyConfig
+	logger     *log.Logger
+}
+
+// NewScheduler creates a new scheduler.
+func NewScheduler(prefetcher prefetcher, cfg config.PrefetchConfig, accuracy config.AccuracyConfig) *Scheduler {
+	return &Scheduler{
+		prefetcher: prefetcher,
+		cfg:        cfg,
+		accuracy:   accuracy,
+		logger:     log.Default(),
+	}
+}
+
+// Run prefetches the forecasts of every power plant right away, then every cfg.Interval,
+// until the context is done. The alert rules are evaluated and, when the accuracy tracking is enabled,
+// the issued forecasts are recorded after each prefetch. It returns once the running prefetch is stopped.
+// It returns right away when the prefetcher is disabled.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.cfg.Interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.prefetch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prefetch runs prefetchOrganization for every organization, bounded by the interval so a slow run
+// does not pile up with the next one.
+func (s *Scheduler) prefetch(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Interval)
+	defer cancel()
+
+	organizations, err := s.prefetcher.GetOrganizations(ctx)
+	if err != nil {
+		s.logger.Printf("error getting organizations: %v", err)
+		return
+	}
+	for _, organization := range organizations {
+		s.prefetchOrganization(tenant.WithOrganization(ctx, organization.ID), organization.ID)
+	}
+}
+
+// prefetchOrganization runs one prefetch then evaluates the alert rules and records the issued forecasts
+// of the organization. The alert rules are evaluated even if some forecasts failed to refresh, against the ones that did.
+func (s *Scheduler) prefetchOrganization(ctx context.Context, organizationID int64) {
+	start := time.Now()
+	refreshed, err := s.prefetcher.PrefetchForecasts(ctx, s.cfg)
+	if err != nil {
+		s.logger.Printf("error prefetching forecasts of organization %d, refreshed %d before error: %v", organizationID, refreshed, err)
+	} else {
+		s.logger.Printf("prefetched %d forecasts of organization %d in %s", refreshed, organizationID, time.Since(start))
+	}
+
+	start = time.Now()
+	triggered, err := s.prefetcher.EvaluateAlerts(ctx, s.cfg.BatchSize)
+	if err != nil {
+		s.logger.Printf("error evaluating alert rules of organization %d, triggered %d before error: %v", organizationID, triggered, err)
+	} else {
+		s.logger.Printf("evaluated alert rules of organization %d in %s, %d triggered", organizationID, time.Since(start), triggered)
+	}
+
+	if s.accuracy.RunAt == "" {
+		return
+	}
+	start = time.Now()
+	recorded, err := s.prefetcher.RecordIssuedForecasts(ctx, s.accuracy)
+	if err != nil {
+		s.logger.Printf("error recording issued forecasts of organization %d, recorded %d before error: %v", organizationID, recorded, err)
+		return
+	}
+	s.logger.Printf("recorded %d issued forecasts of organization %d in %s", recorded, organizationID, time.Since(start))
+}
+
+// RunAccuracy materializes the forecast accuracy every night at the configured time of day,
+// until the context is done. It returns right away when the accuracy tracking is disabled.
+func (s *Scheduler) RunAccuracy(ctx context.Context) {
+	if s.accuracy.RunAt == "" {
+		return
+	}
+
+	for {
+		timer := time.NewTimer(time.Until(s.accuracy.NextRun(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.materializeAccuracy(ctx)
+	}
+}
+
+// materializeAccuracy materializes the forecast accuracy of every organization.
+func (s *Scheduler) materializeAccuracy(ctx context.Context) {
+	organizations, err := s.prefetcher.GetOrganizations(ctx)
+	if err != nil {
+		s.logger.Printf("error getting organizations: %v", err)
+		return
+	}
+
+	for _, organization := range organizations {
+		start := time.Now()
+		saved, err := s.prefetcher.MaterializeForecastAccuracy(tenant.WithOrganization(ctx, organization.ID), s.accuracy)
+		if err != nil {
+			s.logger.Printf("error materializing forecast accuracy of organization %d, saved %d error sums before error: %v", organization.ID, saved, err)
+			continue
+		}
+		s.logger.Printf("materialized %d forecast error sums of organization %d in %s", saved, organization.ID, time.Since(start))
+	}
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+type fakePrefetcher struct {
+	calls       atomic.Int64
+	evaluations atomic.Int64
+	recordings  atomic.Int64
+	// untenanted counts the prefetches with a context acting for no organization.
+	untenanted atomic.Int64
+}
+
+func (f *fakePrefetcher) GetOrganizations(ctx context.Context) ([]types.Organization, error) {
+	return []types.Organization{{ID: 1, Name: "Default"}}, nil
+}
+
+func (f *fakePrefetcher) PrefetchForecasts(ctx context.Context, cfg config.PrefetchConfig) (int, error) {
+	f.calls.Add(1)
+	if _, ok := tenant.OrganizationID(ctx); !ok {
+		f.untenanted.Add(1)
+	}
+	return 1, nil
+}
+
+func (f *fakePrefetcher) EvaluateAlerts(ctx context.Context, batchSize int) (int, error) {
+	f.evaluations.Add(1)
+	return 0, nil
+}
+
+func (f *fakePrefetcher) RecordIssuedForecasts(ctx context.Context, cfg config.AccuracyConfig) (int, error) {
+	f.recordings.Add(1)
+	return 0, nil
+}
+
+func (f *fakePrefetcher) MaterializeForecastAccuracy(ctx context.Context, cfg config.AccuracyConfig) (int, error) {
+	return 0, nil
+}
+
+func TestScheduler_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		runAt    string
+		minCalls int64
+		maxCalls int64
+	}{
+		{
+			name:     "success, runs right away then every interval",
+			interval: 20 * time.Millisecond,
+			minCalls: 2,
+			maxCalls: 10,
+		},
+		{
+			name:     "success, records the issued forecasts",
+			interval: 20 * time.Millisecond,
+			runAt:    "02:00",
+			minCalls: 2,
+			maxCalls: 10,
+		},
+		{
+			name:     "success, disabled",
+			interval: 0,
+			minCalls: 0,
+			maxCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			prefetcher := &fakePrefetcher{}
+			s := NewScheduler(prefetcher, config.PrefetchConfig{Interval: tt.interval}, config.AccuracyConfig{RunAt: tt.runAt})
+
+			done := make(chan struct{})
+			go func() {
+				s.Run(ctx)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("expected Run to return once the context is done")
+			}
+
+			if calls := prefetcher.calls.Load(); calls < tt.minCalls || calls > tt.maxCalls {
+				t.Fatalf("expected between %d and %d prefetches, got: %d", tt.minCalls, tt.maxCalls, calls)
+			}
+			if untenanted := prefetcher.untenanted.Load(); untenanted != 0 {
+				t.Fatalf("expected every prefetch to act for an organization, %d did not", untenanted)
+			}
+			if calls, evaluations := prefetcher.calls.Load(), prefetcher.evaluations.Load(); evaluations != calls {
+				t.Fatalf("expected an alert evaluation per prefetch, got %d evaluations for %d prefetches", evaluations, calls)
+			}
+			expectedRecordings := int64(0)
+			if tt.runAt != "" {
+				expectedRecordings = prefetcher.calls.Load()
+			}
+			if recordings := prefetcher.recordings.Load(); recordings != expectedRecordings {
+				t.Fatalf("expected %d issued forecast recordings, got: %d", expectedRecordings, recordings)
+			}
+		})
+	}
+}
+
+func TestScheduler_RunAccuracy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	tests := []struct {
+		name  string
+		runAt string
+	}{
+		{
+			name:  "success, waits for the time of day",
+			runAt: time.Now().UTC().Add(-time.Minute).Format("15:04"),
+		},
+		{
+			name: "success, disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(&fakePrefetcher{}, config.PrefetchConfig{}, config.AccuracyConfig{RunAt: tt.runAt})
+
+			done := make(chan struct{})
+			go func() {
+				s.RunAccuracy(ctx)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("expected RunAccuracy to return once the context is done")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Package tenant carries the organization a call acts for in its context.
+//
+// The database scopes every statement to the organization of its context, so the rows of the other
+// organizations are never visible to it, see database.Open.
+package tenant
+
+import "context"
+
+type organizationKey struct{}
+
+// WithOrganization returns a copy of the context acting for the organization.
+func WithOrganization(ctx context.Context, organizationID int64) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationID)
+}
+
+// OrganizationID returns the organization the context acts for, false if it acts for none.
+func OrganizationID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(organizationKey{}).(int64)
+	return id, ok
+}
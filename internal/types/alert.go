@@ -0,0 +1,277 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// MaxAlertHorizonHours is the longest horizon of an alert rule, the longest forecast.
+const MaxAlertHorizonHours = 16 * 24
+
+var (
+	ErrInvalidAlertVariable    = errors.New("invalid alert variable")
+	ErrInvalidAlertOperator    = errors.New("invalid alert operator")
+	ErrInvalidAlertAggregation = errors.New("invalid alert aggregation")
+	ErrInvalidAlertHorizon     = errors.New("horizonHours must be between 1 and 384")
+	// ErrPowerPlantNotFound is returned when creating an alert rule for a power plant that does not exist.
+	ErrPowerPlantNotFound = errors.New("power plant not found")
+)
+
+// AlertVariable is the weather forecast variable an alert rule watches.
+type AlertVariable string
+
+const (
+	AlertVariableTemperature   AlertVariable = "TEMPERATURE"
+	AlertVariablePrecipitation AlertVariable = "PRECIPITATION"
+	AlertVariableWindSpeed     AlertVariable = "WIND_SPEED"
+	AlertVariableWindSpeed100m AlertVariable = "WIND_SPEED_100M"
+)
+
+// IsValid returns true if the variable is known.
+func (v AlertVariable) IsValid() bool {
+	switch v {
+	case AlertVariableTemperature, AlertVariablePrecipitation, AlertVariableWindSpeed, AlertVariableWindSpeed100m:
+		return true
+	}
+	return false
+}
+
+// Value returns the value of the variable in the forecast, nil when the weather model has no data.
+func (v AlertVariable) Value(forecast WeatherForecast) *float64 {
+	switch v {
+	case AlertVariableTemperature:
+		return forecast.Temperature
+	case AlertVariablePrecipitation:
+		return forecast.Precipitation
+	case AlertVariableWindSpeed:
+		return forecast.WindSpeed
+	case AlertVariableWindSpeed100m:
+		return forecast.WindSpeed100m
+	}
+	return nil
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (v *AlertVariable) UnmarshalGQL(value any) error {
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*v = AlertVariable(str)
+	if !v.IsValid() {
+		return fmt.Errorf("%s is not a valid AlertVariable", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (v AlertVariable) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(v)))
+}
+
+// AlertOperator compares the forecasted value to the threshold of an alert rule.
+type AlertOperator string
+
+const (
+	AlertOperatorGreaterThan        AlertOperator = "GREATER_THAN"
+	AlertOperatorGreaterThanOrEqual AlertOperator = "GREATER_THAN_OR_EQUAL"
+	AlertOperatorLessThan           AlertOperator = "LESS_THAN"
+	AlertOperatorLessThanOrEqual    AlertOperator = "LESS_THAN_OR_EQUAL"
+)
+
+// IsValid returns true if the operator is known.
+func (o AlertOperator) IsValid() bool {
+	switch o {
+	case AlertOperatorGreaterThan, AlertOperatorGreaterThanOrEqual, AlertOperatorLessThan, AlertOperatorLessThanOrEqual:
+		return true
+	}
+	return false
+}
+
+// Compare returns true if the value meets the condition of the operator for the threshold.
+func (o AlertOperator) Compare(value float64, threshold float64) bool {
+	switch o {
+	case AlertOperatorGreaterThan:
+		return value > threshold
+	case AlertOperatorGreaterThanOrEqual:
+		return value >= threshold
+	case AlertOperatorLessThan:
+		return value < threshold
+	case AlertOperatorLessThanOrEqual:
+		return value <= threshold
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (o *AlertOperator) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*o = AlertOperator(str)
+	if !o.IsValid() {
+		return fmt.Errorf("%s is not a valid AlertOperator", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (o AlertOperator) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(o)))
+}
+
+// AlertAggregation is how the hourly values are combined before being compared to the threshold.
+type AlertAggregation string
+
+const (
+	// AlertAggregationNone compares every hourly value.
+	AlertAggregationNone AlertAggregation = "NONE"
+	// The daily aggregations compare one value per UTC day, e.g. the precipitation of the day with DAILY_SUM.
+	AlertAggregationDailySum  AlertAggregation = "DAILY_SUM"
+	AlertAggregationDailyMin  AlertAggregation = "DAILY_MIN"
+	AlertAggregationDailyMax  AlertAggregation = "DAILY_MAX"
+	AlertAggregationDailyMean AlertAggregation = "DAILY_MEAN"
+)
+
+// IsValid returns true if the aggregation is known.
+func (a AlertAggregation) IsValid() bool {
+	switch a {
+	case AlertAggregationNone, AlertAggregationDailySum, AlertAggregationDailyMin, AlertAggregationDailyMax, AlertAggregationDailyMean:
+		return true
+	}
+	return false
+}
+
+// Aggregate combines the values, it returns nil when there is no value.
+func (a AlertAggregation) Aggregate(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := values[0]
+	switch a {
+	case AlertAggregationDailySum, AlertAggregationDailyMean:
+		for _, v := range values[1:] {
+			result += v
+		}
+		if a == AlertAggregationDailyMean {
+			result /= float64(len(values))
+		}
+	case AlertAggregationDailyMin:
+		for _, v := range values[1:] {
+			result = min(result, v)
+		}
+	case AlertAggregationDailyMax:
+		for _, v := range values[1:] {
+			result = max(result, v)
+		}
+	}
+	return &result
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (a *AlertAggregation) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*a = AlertAggregation(str)
+	if !a.IsValid() {
+		return fmt.Errorf("%s is not a valid AlertAggregation", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (a AlertAggregation) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(a)))
+}
+
+// AlertRule is a weather threshold watched on the forecast of a power plant,
+// e.g. a wind speed above 90 km/h within the next 48 hours.
+type AlertRule struct {
+	ID           int64         `json:"id"`
+	PowerPlantID int64         `json:"powerPlantID"`
+	Variable     AlertVariable `json:"variable"`
+	Operator     AlertOperator `json:"operator"`
+	Threshold    float64       `json:"threshold"`
+	// HorizonHours is how far ahead of now the forecast is watched.
+	HorizonHours int              `json:"horizonHours"`
+	Aggregation  AlertAggregation `json:"aggregation"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	UpdatedAt    time.Time        `json:"updatedAt,omitempty"`
+}
+
+// Validate validates the alert rule.
+func (r AlertRule) Validate() error {
+	if !r.Variable.IsValid() {
+		return ErrInvalidAlertVariable
+	}
+	if !r.Operator.IsValid() {
+		return ErrInvalidAlertOperator
+	}
+	if !r.Aggregation.IsValid() {
+		return ErrInvalidAlertAggregation
+	}
+	if r.HorizonHours < 1 || r.HorizonHours > MaxAlertHorizonHours {
+		return ErrInvalidAlertHorizon
+	}
+	return nil
+}
+
+// AlertRuleUpdate is a partial update of an AlertRule, nil fields are left unchanged.
+type AlertRuleUpdate struct {
+	Variable     *AlertVariable
+	Operator     *AlertOperator
+	Threshold    *float64
+	HorizonHours *int
+	Aggregation  *AlertAggregation
+}
+
+// Apply applies the update to the alert rule.
+func (u AlertRuleUpdate) Apply(r *AlertRule) {
+	if u.Variable != nil {
+		r.Variable = *u.Variable
+	}
+	if u.Operator != nil {
+		r.Operator = *u.Operator
+	}
+	if u.Threshold != nil {
+		r.Threshold = *u.Threshold
+	}
+	if u.HorizonHours != nil {
+		r.HorizonHours = *u.HorizonHours
+	}
+	if u.Aggregation != nil {
+		r.Aggregation = *u.Aggregation
+	}
+}
+
+// Alert is an alert rule triggered by a forecast. It stays active while the following forecasts
+// still meet the condition, and is resolved by the first one that does not.
+// The condition of the rule is copied, so the history keeps its meaning when the rule changes.
+type Alert struct {
+	ID int64 `json:"id"`
+	// RuleID is nil once the rule is deleted.
+	RuleID       *int64           `json:"ruleID,omitempty"`
+	PowerPlantID int64            `json:"powerPlantID"`
+	Variable     AlertVariable    `json:"variable"`
+	Operator     AlertOperator    `json:"operator"`
+	Threshold    float64          `json:"threshold"`
+	Aggregation  AlertAggregation `json:"aggregation"`
+	// ForecastTime is the first hour, or the first day for daily aggregations, meeting the condition
+	// in the latest forecast, and Value the forecasted value then.
+	ForecastTime string    `json:"forecastTime"`
+	Value        float64   `json:"value"`
+	TriggeredAt  time.Time `json:"triggeredAt"`
+	// LastSeenAt is the last evaluation that met the condition.
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidAuditRange is returned when the from of an audit log query is not before its to.
+var ErrInvalidAuditRange = errors.New("from must be before to")
+
+// AuditAction is the kind of change of an audit event.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "CREATE"
+	AuditActionUpdate AuditAction = "UPDATE"
+	AuditActionDelete AuditAction = "DELETE"
+)
+
+// IsValid returns true if the action is known.
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (a *AuditAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*a = AuditAction(str)
+	if !a.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditAction", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (a AuditAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(a)))
+}
+
+// AuditEntityType is the type of the entity changed by an audit event.
+type AuditEntityType string
+
+const (
+	AuditEntityPowerPlant          AuditEntityType = "POWER_PLANT"
+	AuditEntityTurbineModel        AuditEntityType = "TURBINE_MODEL"
+	AuditEntityAlertRule           AuditEntityType = "ALERT_RULE"
+	AuditEntityWebhookSubscription AuditEntityType = "WEBHOOK_SUBSCRIPTION"
+	AuditEntityMaintenanceWindow   AuditEntityType = "MAINTENANCE_WINDOW"
+	AuditEntityPortfolio           AuditEntityType = "PORTFOLIO"
+	// AuditEntityPortfolioMembership is a power plant added to or removed from a portfolio,
+	// the ID of the entity is the ID of the portfolio.
+	AuditEntityPortfolioMembership AuditEntityType = "PORTFOLIO_MEMBERSHIP"
+	// AuditEntityProductionReading is a production reading recorded or replaced, the ID of the entity is the ID
+	// of the power plant.
+	AuditEntityProductionReading AuditEntityType = "PRODUCTION_READING"
+	AuditEntityWebhookDelivery   AuditEntityType = "WEBHOOK_DELIVERY"
+)
+
+// IsValid returns true if the entity type is known.
+func (e AuditEntityType) IsValid() bool {
+	switch e {
+	case AuditEntityPowerPlant, AuditEntityTurbineModel, AuditEntityAlertRule, AuditEntityWebhookSubscription,
+		AuditEntityMaintenanceWindow, AuditEntityPortfolio, AuditEntityPortfolioMembership, AuditEntityProductionReading,
+		AuditEntityWebhookDelivery:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (e *AuditEntityType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditEntityType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditEntityType", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (e AuditEntityType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(e)))
+}
+
+// AuditEvent is a change of an entity recorded in the audit log, in the transaction of the change.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Actor      string          `json:"actor"`
+	Action     AuditAction     `json:"action"`
+	Operation  *string         `json:"operation"`
+	EntityType AuditEntityType `json:"entityType"`
+	EntityID   int64           `json:"entityID"`
+	Changes    []AuditChange   `json:"changes"`
+	RequestID  *string         `json:"requestID"`
+}
+
+// AuditChange is a changed field of an audit event, with its JSON encoded values before and after the change.
+// Before is nil for a created entity and After for a deleted one.
+type AuditChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// AuditEventFilter selects the audit events of an audit log query, the nil fields match every event.
+// From and To bound the time of the events, in [From, To).
+type AuditEventFilter struct {
+	EntityType *AuditEntityType
+	EntityID   *int64
+	Actor      *string
+	From       *time.Time
+	To         *time.Time
+}
+
+// Validate checks the time range of the filter.
+func (f AuditEventFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return ErrInvalidAuditRange
+	}
+	return nil
+}
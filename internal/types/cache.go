@@ -0,0 +1,11 @@
+package types
+
+// CacheStats are the counters of a cache.
+type CacheStats struct {
+	// Hits is the number of lookups served from the cache.
+	Hits int64 `json:"hits"`
+	// Misses is the number of lookups that required an upstream call.
+	Misses int64 `json:"misses"`
+	// Entries is the number of cached items.
+	Entries int `json:"entries"`
+}
@@ -0,0 +1,168 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MaxAccuracyDays is the longest period the forecast accuracy is aggregated over.
+const MaxAccuracyDays = 365
+
+var ErrInvalidAccuracyDays = errors.New("days must be between 1 and 365")
+
+// AccuracyVariable is a forecasted variable whose accuracy is tracked.
+type AccuracyVariable string
+
+const (
+	AccuracyVariableTemperature        AccuracyVariable = "TEMPERATURE"
+	AccuracyVariablePrecipitation      AccuracyVariable = "PRECIPITATION"
+	AccuracyVariableWindSpeed          AccuracyVariable = "WIND_SPEED"
+	AccuracyVariableWindSpeed100m      AccuracyVariable = "WIND_SPEED_100M"
+	AccuracyVariableShortwaveRadiation AccuracyVariable = "SHORTWAVE_RADIATION"
+	// AccuracyVariableGeneration is the generation forecast, compared to the metered production.
+	AccuracyVariableGeneration AccuracyVariable = "GENERATION"
+)
+
+// AccuracyVariables are the tracked variables, the weather ones first.
+var AccuracyVariables = []AccuracyVariable{
+	AccuracyVariableTemperature,
+	AccuracyVariablePrecipitation,
+	AccuracyVariableWindSpeed,
+	AccuracyVariableWindSpeed100m,
+	AccuracyVariableShortwaveRadiation,
+	AccuracyVariableGeneration,
+}
+
+// IsValid returns true if the variable is known.
+func (v AccuracyVariable) IsValid() bool {
+	switch v {
+	case AccuracyVariableTemperature, AccuracyVariablePrecipitation, AccuracyVariableWindSpeed,
+		AccuracyVariableWindSpeed100m, AccuracyVariableShortwaveRadiation, AccuracyVariableGeneration:
+		return true
+	}
+	return false
+}
+
+// Value returns the value of a weather variable in the forecast, nil when the weather model has no data
+// and for the generation.
+func (v AccuracyVariable) Value(forecast WeatherForecast) *float64 {
+	switch v {
+	case AccuracyVariableTemperature:
+		return forecast.Temperature
+	case AccuracyVariablePrecipitation:
+		return forecast.Precipitation
+	case AccuracyVariableWindSpeed:
+		return forecast.WindSpeed
+	case AccuracyVariableWindSpeed100m:
+		return forecast.WindSpeed100m
+	case AccuracyVariableShortwaveRadiation:
+		return forecast.ShortwaveRadiation
+	}
+	return nil
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (v *AccuracyVariable) UnmarshalGQL(value any) error {
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*v = AccuracyVariable(str)
+	if !v.IsValid() {
+		return fmt.Errorf("%s is not a valid AccuracyVariable", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (v AccuracyVariable) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(v)))
+}
+
+// IssuedForecast is the forecast of one hour of a power plant, as it was served at IssuedAt.
+type IssuedForecast struct {
+	PowerPlantID int64
+	// IssuedAt is the weather model run the forecast was served from.
+	IssuedAt time.Time
+	// Time is the forecasted hour, in UTC.
+	Time time.Time
+	// Weather holds the tracked weather variables, its Time is not set.
+	Weather WeatherForecast
+	// GenerationMW is the forecasted generation, nil when it could not be forecasted.
+	GenerationMW *float64
+}
+
+// LeadHours returns the number of hours between the issue and the forecasted hour.
+func (f IssuedForecast) LeadHours() int {
+	return int(f.Time.Sub(f.IssuedAt) / time.Hour)
+}
+
+// Value returns the value of the variable in the forecast, nil when it was not forecasted.
+func (f IssuedForecast) Value(v AccuracyVariable) *float64 {
+	if v == AccuracyVariableGeneration {
+		return f.GenerationMW
+	}
+	return v.Value(f.Weather)
+}
+
+// ForecastErrorSums are the sums of the errors of the forecasts of a variable of a power plant
+// for one day of forecasted hours and one lead time bucket. Sums add up across days,
+// so the accuracy of any period is aggregated from them.
+type ForecastErrorSums struct {
+	PowerPlantID int64
+	// Day is the day of the forecasted hours, 00:00 UTC.
+	Day      time.Time
+	Variable AccuracyVariable
+	// LeadHoursFrom and LeadHoursTo bound the lead times of the bucket, LeadHoursTo excluded.
+	LeadHoursFrom int
+	LeadHoursTo   int
+	Count         int
+	// The errors are the forecasted minus the observed values.
+	SumError        float64
+	SumAbsError     float64
+	SumSquaredError float64
+}
+
+// Add adds the error of one forecasted value to the sums.
+func (s *ForecastErrorSums) Add(forecasted float64, observed float64) {
+	e := forecasted - observed
+	s.Count++
+	s.SumError += e
+	s.SumAbsError += math.Abs(e)
+	s.SumSquaredError += e * e
+}
+
+// ForecastAccuracy is the accuracy of the forecasts of a variable of a power plant
+// over a period, for one lead time bucket.
+type ForecastAccuracy struct {
+	Variable      AccuracyVariable
+	LeadHoursFrom int
+	LeadHoursTo   int
+	// Count is the number of compared hours.
+	Count int
+	// MAE is the mean absolute error.
+	MAE float64
+	// RMSE is the root mean squared error.
+	RMSE float64
+	// Bias is the mean error, positive when the forecasts are too high.
+	Bias float64
+}
+
+// NewForecastAccuracy returns the accuracy of the aggregated sums, which must have a positive count.
+func NewForecastAccuracy(sums ForecastErrorSums) ForecastAccuracy {
+	n := float64(sums.Count)
+	return ForecastAccuracy{
+		Variable:      sums.Variable,
+		LeadHoursFrom: sums.LeadHoursFrom,
+		LeadHoursTo:   sums.LeadHoursTo,
+		Count:         sums.Count,
+		MAE:           sums.SumAbsError / n,
+		RMSE:          math.Sqrt(sums.SumSquaredError / n),
+		Bias:          sums.SumError / n,
+	}
+}
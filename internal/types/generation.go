@@ -0,0 +1,25 @@
+package types
+
+import "errors"
+
+var (
+	// ErrNoGenerationModel is returned for power plant types without a generation model.
+	ErrNoGenerationModel = errors.New("no generation model for this power plant type")
+	// ErrDesignDischargeRequired is returned for hydro power plants without a design discharge.
+	ErrDesignDischargeRequired = errors.New("designDischargeM3s is required for the generation forecast of hydro power plants")
+)
+
+// GenerationForecast is the expected output of a power plant for one hour.
+type GenerationForecast struct {
+	Time string `json:"time"`
+	// PowerMW is the average output over the hour in MW,
+	// nil when the weather model has no data for the hour.
+	PowerMW *float64 `json:"powerMW"`
+}
+
+// RiverDischarge is the forecast discharge of the river nearest to a location for one day.
+type RiverDischarge struct {
+	Date string `json:"date"`
+	// Discharge is the mean discharge in m³/s, nil when the flood model has no data for the day.
+	Discharge *float64 `json:"discharge"`
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrPlaceNotFound = errors.New("place not found")
+
+// Location is a place found by geocoding.
+type Location struct {
+	ID        int64   `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Elevation float64 `json:"elevation"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country.
+	CountryCode string `json:"countryCode"`
+	Country     string `json:"country"`
+	// Admin1 and Admin2 are the administrative areas the place belongs to, e.g. state and county.
+	Admin1     string `json:"admin1"`
+	Admin2     string `json:"admin2"`
+	Timezone   string `json:"timezone"`
+	Population int    `json:"population"`
+}
+
+// AmbiguousPlaceError is returned when a place name matches more than one location.
+type AmbiguousPlaceError struct {
+	PlaceName  string
+	Candidates []Location
+}
+
+func (e *AmbiguousPlaceError) Error() string {
+	return fmt.Sprintf("place %q is ambiguous, %d candidates found, add the region or country after a comma to narrow it down",
+		e.PlaceName, len(e.Candidates))
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxAvailabilityRange is the longest range of an availability timeline or a maintenance window listing.
+const MaxAvailabilityRange = 366 * 24 * time.Hour
+
+var (
+	ErrInvalidMaintenancePeriod      = errors.New("end must be after start")
+	ErrInvalidMaintenanceUnavailable = errors.New("exactly one of unavailableMW and unavailablePercent is required")
+	ErrInvalidUnavailableMW          = errors.New("unavailableMW must be greater than 0 and at most the capacity of the power plant")
+	ErrInvalidUnavailablePercent     = errors.New("unavailablePercent must be greater than 0 and at most 100")
+	ErrMaintenanceCapacityRequired   = errors.New("capacityMW of the power plant is required for maintenance windows")
+	ErrMaintenanceOverlap            = errors.New("maintenance window overlaps another maintenance window of the power plant")
+	ErrInvalidAvailabilityRange      = errors.New("to must be after from, at most 366 days apart")
+)
+
+// MaintenanceWindow is a planned outage of part or all of the capacity of a power plant, from Start to End excluded.
+// The windows of a power plant do not overlap.
+type MaintenanceWindow struct {
+	ID           int64     `json:"id"`
+	PowerPlantID int64     `json:"powerPlantID"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Reason       string    `json:"reason"`
+	// The unavailable capacity is set either in MW or in percent of the capacity of the power plant.
+	UnavailableMW      *float64  `json:"unavailableMW,omitempty"`
+	UnavailablePercent *float64  `json:"unavailablePercent,omitempty"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt,omitempty"`
+}
+
+// Validate validates the maintenance window against the capacity of its power plant.
+func (w MaintenanceWindow) Validate(capacityMW *float64) error {
+	if capacityMW == nil {
+		return ErrMaintenanceCapacityRequired
+	}
+	if !w.End.After(w.Start) {
+		return ErrInvalidMaintenancePeriod
+	}
+	if (w.UnavailableMW == nil) == (w.UnavailablePercent == nil) {
+		return ErrInvalidMaintenanceUnavailable
+	}
+	if w.UnavailableMW != nil && (*w.UnavailableMW <= 0 || *w.UnavailableMW > *capacityMW) {
+		return ErrInvalidUnavailableMW
+	}
+	if w.UnavailablePercent != nil && (*w.UnavailablePercent <= 0 || *w.UnavailablePercent > 100) {
+		return ErrInvalidUnavailablePercent
+	}
+	return nil
+}
+
+// UnavailableFraction returns the share of the capacity that is unavailable during the window, between 0 and 1.
+func (w MaintenanceWindow) UnavailableFraction(capacityMW float64) float64 {
+	if w.UnavailablePercent != nil {
+		return *w.UnavailablePercent / 100
+	}
+	if w.UnavailableMW != nil && capacityMW > 0 {
+		return min(*w.UnavailableMW/capacityMW, 1)
+	}
+	return 0
+}
+
+// Overlap returns how long the window overlaps the period from start to end.
+func (w MaintenanceWindow) Overlap(start time.Time, end time.Time) time.Duration {
+	from, to := w.Start, w.End
+	if start.After(from) {
+		from = start
+	}
+	if end.Before(to) {
+		to = end
+	}
+	if !to.After(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+// MaintenanceWindowUpdate is a partial update of a MaintenanceWindow, nil fields are left unchanged.
+// Setting the unavailable capacity in MW clears the percent, and the other way around.
+type MaintenanceWindowUpdate struct {
+	Start              *time.Time
+	End                *time.Time
+	Reason             *string
+	UnavailableMW      *float64
+	UnavailablePercent *float64
+}
+
+// Apply applies the update to the maintenance window.
+func (u MaintenanceWindowUpdate) Apply(w *MaintenanceWindow) {
+	if u.Start != nil {
+		w.Start = *u.Start
+	}
+	if u.End != nil {
+		w.End = *u.End
+	}
+	if u.Reason != nil {
+		w.Reason = *u.Reason
+	}
+	if u.UnavailableMW != nil {
+		w.UnavailableMW = u.UnavailableMW
+		w.UnavailablePercent = nil
+	}
+	if u.UnavailablePercent != nil {
+		w.UnavailablePercent = u.UnavailablePercent
+		w.UnavailableMW = nil
+	}
+}
+
+// AvailabilityPeriod is a period of constant available capacity of a power plant, from Start to End excluded.
+type AvailabilityPeriod struct {
+	Start         time.Time
+	End           time.Time
+	AvailableMW   float64
+	UnavailableMW float64
+	// MaintenanceWindowID and Reason are those of the maintenance window of the period, nil when fully available.
+	MaintenanceWindowID *int64
+	Reason              *string
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxOrganizationNameLength is the longest name of an organization.
+const MaxOrganizationNameLength = 100
+
+var (
+	ErrInvalidOrganizationName = errors.New("name is required, at most 100 characters")
+	ErrOrganizationNameTaken   = errors.New("an organization with this name already exists")
+	// ErrInvalidAPIKey is returned when an API key does not belong to any organization.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrOrganizationRequired is returned when a call does not act for an organization.
+	ErrOrganizationRequired = errors.New("the call must be authenticated for an organization")
+)
+
+// Organization is a tenant of the service, e.g. a business unit: every power plant and the rows related
+// to it belong to one organization, and are only visible to the callers authenticated for it.
+type Organization struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate trims the name of the organization and checks it.
+func (o *Organization) Validate() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" || len(o.Name) > MaxOrganizationNameLength {
+		return ErrInvalidOrganizationName
+	}
+	return nil
+}
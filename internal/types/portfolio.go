@@ -0,0 +1,229 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxPortfolioNameLength is the longest name of a portfolio.
+	MaxPortfolioNameLength = 100
+	// MaxTagLength is the longest tag of a power plant.
+	MaxTagLength = 64
+	// MaxMembershipBatch is the most power plants added to or removed from a portfolio at once.
+	MaxMembershipBatch = 1000
+)
+
+var (
+	ErrInvalidBoundingBox    = errors.New("region latitudes must be between -90 and 90 with minLatitude <= maxLatitude, and longitudes between -180 and 180")
+	ErrInvalidGranularity    = errors.New("invalid granularity")
+	ErrInvalidPortfolioRange = errors.New("from must be before to, both within the 16 forecast days starting today 00:00 UTC")
+	ErrInvalidPortfolioName  = errors.New("name is required, at most 100 characters")
+	ErrPortfolioNameTaken    = errors.New("a portfolio with this name already exists")
+	// ErrPortfolioNotFound is returned when adding power plants to a portfolio that does not exist.
+	ErrPortfolioNotFound    = errors.New("portfolio not found")
+	ErrInvalidTag           = errors.New("tags must be between 1 and 64 characters")
+	ErrInvalidMembershipIDs = errors.New("powerPlantIDs must have between 1 and 1000 IDs")
+)
+
+// Granularity is the length of the time buckets of a portfolio summary.
+type Granularity string
+
+const (
+	GranularityHour Granularity = "HOUR"
+	// GranularityDay buckets are UTC days.
+	GranularityDay Granularity = "DAY"
+)
+
+// IsValid returns true if the granularity is known.
+func (g Granularity) IsValid() bool {
+	switch g {
+	case GranularityHour, GranularityDay:
+		return true
+	}
+	return false
+}
+
+// Duration returns the length of the buckets.
+func (g Granularity) Duration() time.Duration {
+	if g == GranularityDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (g *Granularity) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*g = Granularity(str)
+	if !g.IsValid() {
+		return fmt.Errorf("%s is not a valid Granularity", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (g Granularity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(g)))
+}
+
+// BoundingBox is a region between two latitudes and two longitudes.
+// A box with MinLongitude greater than MaxLongitude crosses the antimeridian.
+type BoundingBox struct {
+	MinLatitude  float64 `json:"minLatitude"`
+	MaxLatitude  float64 `json:"maxLatitude"`
+	MinLongitude float64 `json:"minLongitude"`
+	MaxLongitude float64 `json:"maxLongitude"`
+}
+
+// Validate validates the bounding box.
+func (b BoundingBox) Validate() error {
+	if b.MinLatitude < -90 || b.MaxLatitude > 90 || b.MinLatitude > b.MaxLatitude {
+		return ErrInvalidBoundingBox
+	}
+	if b.MinLongitude < -180 || b.MinLongitude > 180 || b.MaxLongitude < -180 || b.MaxLongitude > 180 {
+		return ErrInvalidBoundingBox
+	}
+	return nil
+}
+
+// PowerPlantFilter selects power plants, empty fields match every power plant.
+type PowerPlantFilter struct {
+	Types       []PowerPlantType   `json:"types,omitempty"`
+	Statuses    []PowerPlantStatus `json:"statuses,omitempty"`
+	Operator    *string            `json:"operator,omitempty"`
+	Region      *BoundingBox       `json:"region,omitempty"`
+	PortfolioID *int64             `json:"portfolioID,omitempty"`
+	// Tags selects the power plants with every tag.
+	Tags []string `json:"tags,omitempty"`
+	// AsOf selects the versions of the power plants valid at that time instead of the current ones,
+	// the other fields match these versions but the portfolios are the current ones.
+	AsOf *time.Time `json:"asOf,omitempty"`
+}
+
+// Validate validates the filter.
+func (f PowerPlantFilter) Validate() error {
+	for _, t := range f.Types {
+		if !t.IsValid() {
+			return ErrInvalidPowerPlantType
+		}
+	}
+	for _, s := range f.Statuses {
+		if !s.IsValid() {
+			return ErrInvalidPowerPlantStatus
+		}
+	}
+	for _, tag := range f.Tags {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+	if f.Region != nil {
+		return f.Region.Validate()
+	}
+	return nil
+}
+
+// ValidateTag validates a tag of a power plant.
+func ValidateTag(tag string) error {
+	if tag == "" || len([]rune(tag)) > MaxTagLength {
+		return ErrInvalidTag
+	}
+	return nil
+}
+
+// NormalizeTags trims, validates, sorts and deduplicates tags. Tags are case sensitive.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if err := ValidateTag(tag); err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// Portfolio is a named group of power plants, e.g. a fund or a regional team.
+// A power plant belongs to any number of portfolios.
+type Portfolio struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt,omitempty"`
+}
+
+// Validate validates the portfolio, its name is trimmed first.
+func (p *Portfolio) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || len([]rune(p.Name)) > MaxPortfolioNameLength {
+		return ErrInvalidPortfolioName
+	}
+	return nil
+}
+
+// PortfolioUpdate is a partial update of a Portfolio, nil fields are left unchanged.
+type PortfolioUpdate struct {
+	Name        *string
+	Description *string
+}
+
+// Apply applies the update to the portfolio.
+func (u PortfolioUpdate) Apply(p *Portfolio) {
+	if u.Name != nil {
+		p.Name = *u.Name
+	}
+	if u.Description != nil {
+		p.Description = u.Description
+	}
+}
+
+// PortfolioSummary is the expected generation of the power plants matching a filter,
+// in time buckets between From and To.
+type PortfolioSummary struct {
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	Granularity Granularity `json:"granularity"`
+	PlantCount  int         `json:"plantCount"`
+	// InstalledCapacityMW is the capacity of every matching power plant, forecast or not.
+	InstalledCapacityMW float64 `json:"installedCapacityMW"`
+	ExpectedEnergyMWh   float64 `json:"expectedEnergyMWh"`
+	// CapacityFactor is the expected energy over the energy at full capacity of the
+	// forecast power plants, nil without any.
+	CapacityFactor *float64               `json:"capacityFactor,omitempty"`
+	Buckets        []PortfolioBucket      `json:"buckets"`
+	ByType         []PortfolioTypeSummary `json:"byType"`
+	// ExcludedPowerPlantIDs are the matching power plants without a generation forecast,
+	// e.g. storage or a failed irradiance call. They count in InstalledCapacityMW only.
+	ExcludedPowerPlantIDs []int64 `json:"excludedPowerPlantIDs"`
+}
+
+// PortfolioTypeSummary is the part of a PortfolioSummary of one power plant type.
+type PortfolioTypeSummary struct {
+	Type                PowerPlantType    `json:"type"`
+	PlantCount          int               `json:"plantCount"`
+	InstalledCapacityMW float64           `json:"installedCapacityMW"`
+	ExpectedEnergyMWh   float64           `json:"expectedEnergyMWh"`
+	CapacityFactor      *float64          `json:"capacityFactor,omitempty"`
+	Buckets             []PortfolioBucket `json:"buckets"`
+}
+
+// PortfolioBucket is the expected generation over one time bucket, clipped to the range of the summary.
+type PortfolioBucket struct {
+	Start          time.Time `json:"start"`
+	EnergyMWh      float64   `json:"energyMWh"`
+	AveragePowerMW float64   `json:"averagePowerMW"`
+	CapacityFactor *float64  `json:"capacityFactor,omitempty"`
+}
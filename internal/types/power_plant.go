@@ -13,21 +13,60 @@ var (
 		14: {},
 		16: {},
 	}
-	ErrInternal           = errors.New("internal error")
-	ErrInvalidLatitude    = errors.New("latitude must be between -90 and 90")
-	ErrInvalidLongitude   = errors.New("longitude must be between -180 and 180")
-	ErrInvalidForecastDay = errors.New("invalid forecast day")
+	ErrInternal            = errors.New("internal error")
+	ErrInvalidLatitude     = errors.New("latitude must be between -90 and 90")
+	ErrInvalidLongitude    = errors.New("longitude must be between -180 and 180")
+	ErrInvalidForecastDay  = errors.New("invalid forecast day")
+	ErrInvalidGapFillHours = errors.New("gap fill hours must be between 0 and 24")
+	// ErrUpstreamRateLimited is returned when the weather API budget is exhausted.
+	ErrUpstreamRateLimited = errors.New("upstream rate limited")
 )
 
 type PowerPlant struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Latitude  float64   `json:"latitude"`
-	Longitude float64   `json:"longitude"`
-	Elevation float64   `json:"elevation"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	ID        int64   `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Elevation is the elevation in use, see ResolveElevation.
+	Elevation float64 `json:"elevation"`
+	// DEMElevation is the elevation from the elevation API, nil until it is fetched.
+	DEMElevation *float64 `json:"demElevation,omitempty"`
+	// ElevationOverride is a surveyed elevation set manually, it beats DEMElevation.
+	ElevationOverride *float64  `json:"elevationOverride,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt,omitempty"`
+	// Tags are free-form labels, sorted, see NormalizeTags.
+	Tags []string `json:"tags"`
+	PowerPlantMetadata
 	WeatherForecastProperties
+
+	// ForecastErr is set when the weather forecast could not be fetched,
+	// the power plant is returned without it.
+	ForecastErr error `json:"-"`
+	// ElevationErr is set when the missing elevation could not be fetched,
+	// the power plant is returned without it.
+	ElevationErr error `json:"-"`
+}
+
+// ResolveElevation sets Elevation to the surveyed elevation if there is one,
+// otherwise to the elevation from the elevation API, or 0 when it is not fetched yet.
+func (p *PowerPlant) ResolveElevation() {
+	switch {
+	case p.ElevationOverride != nil:
+		p.Elevation = *p.ElevationOverride
+	case p.DEMElevation != nil:
+		p.Elevation = *p.DEMElevation
+	default:
+		p.Elevation = 0
+	}
+}
+
+// PowerPlantVersion is the attributes of a power plant from ValidFrom to ValidTo, excluded,
+// or still in use when ValidTo is nil.
+type PowerPlantVersion struct {
+	PowerPlant
+	ValidFrom time.Time  `json:"validFrom"`
+	ValidTo   *time.Time `json:"validTo,omitempty"`
 }
 
 type WeatherForecastProperties struct {
@@ -35,10 +74,23 @@ type WeatherForecastProperties struct {
 	WeatherForecasts      []WeatherForecast `json:"weatherForecasts"`
 }
 
+// ForecastTimeLayout is the layout of the Time of WeatherForecast, in UTC.
+const ForecastTimeLayout = "2006-01-02T15:04"
+
+// WeatherForecast is the forecast of one hour.
+// A nil value means the weather model has no data for that hour.
 type WeatherForecast struct {
-	Time          string  `json:"time"`
-	Temperature   float64 `json:"temperature"`
-	Precipitation float64 `json:"precipitation"`
-	WindSpeed     float64 `json:"windSpeed"`
-	WindDirection float64 `json:"windDirection"`
+	Time          string   `json:"time"`
+	Temperature   *float64 `json:"temperature"`
+	Precipitation *float64 `json:"precipitation"`
+	WindSpeed     *float64 `json:"windSpeed"`
+	WindDirection *float64 `json:"windDirection"`
+	// ShortwaveRadiation is the global horizontal irradiance in W/m², averaged over the preceding hour.
+	ShortwaveRadiation *float64 `json:"shortwaveRadiation"`
+	// WindSpeed100m is the wind speed at 100 m in km/h, the hub height of most wind turbines.
+	WindSpeed100m *float64 `json:"windSpeed100m"`
+	// PressureMSL is the air pressure reduced to mean sea level in hPa.
+	PressureMSL *float64 `json:"pressureMsl"`
+	// Interpolated is true when at least one value was filled by gap filling.
+	Interpolated bool `json:"interpolated"`
 }
@@ -0,0 +1,262 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidPowerPlantType   = errors.New("invalid power plant type")
+	ErrInvalidPowerPlantStatus = errors.New("invalid power plant status")
+	ErrInvalidCapacity         = errors.New("capacity must be greater than 0")
+	ErrCapacityRequired        = errors.New("capacityMW is required for solar, wind, hydro and storage power plants")
+	ErrDCCapacityRequired      = errors.New("dcCapacityMW is required for solar power plants")
+	ErrDCCapacityNotSolar      = errors.New("dcCapacityMW is only allowed for solar power plants")
+	ErrInvalidDCACRatio        = errors.New("dcCapacityMW must be between 0.8 and 2 times capacityMW")
+	ErrInvalidDesignDischarge  = errors.New("designDischargeM3s must be greater than 0")
+	ErrDesignDischargeNotHydro = errors.New("designDischargeM3s is only allowed for hydro power plants")
+	ErrTurbineNotWind          = errors.New("turbineModelID and turbineCount are only allowed for wind power plants")
+	ErrTurbineModelAndCount    = errors.New("turbineModelID and turbineCount must be set together")
+	ErrInvalidTurbineCount     = errors.New("turbineCount must be greater than 0")
+	ErrCommissionedInFuture    = errors.New("commissionedAt must not be in the future for operational and decommissioned power plants")
+)
+
+// PowerPlantType is the energy source of a power plant.
+type PowerPlantType string
+
+const (
+	PowerPlantTypeSolar   PowerPlantType = "SOLAR"
+	PowerPlantTypeWind    PowerPlantType = "WIND"
+	PowerPlantTypeHydro   PowerPlantType = "HYDRO"
+	PowerPlantTypeStorage PowerPlantType = "STORAGE"
+	PowerPlantTypeOther   PowerPlantType = "OTHER"
+)
+
+// IsValid returns true if the type is known.
+func (t PowerPlantType) IsValid() bool {
+	switch t {
+	case PowerPlantTypeSolar, PowerPlantTypeWind, PowerPlantTypeHydro, PowerPlantTypeStorage, PowerPlantTypeOther:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (t *PowerPlantType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*t = PowerPlantType(str)
+	if !t.IsValid() {
+		return fmt.Errorf("%s is not a valid PowerPlantType", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (t PowerPlantType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(t)))
+}
+
+// PowerPlantStatus is the lifecycle stage of a power plant.
+type PowerPlantStatus string
+
+const (
+	PowerPlantStatusPlanned           PowerPlantStatus = "PLANNED"
+	PowerPlantStatusUnderConstruction PowerPlantStatus = "UNDER_CONSTRUCTION"
+	PowerPlantStatusOperational       PowerPlantStatus = "OPERATIONAL"
+	PowerPlantStatusDecommissioned    PowerPlantStatus = "DECOMMISSIONED"
+)
+
+// IsValid returns true if the status is known.
+func (s PowerPlantStatus) IsValid() bool {
+	switch s {
+	case PowerPlantStatusPlanned, PowerPlantStatusUnderConstruction, PowerPlantStatusOperational, PowerPlantStatusDecommissioned:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (s *PowerPlantStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = PowerPlantStatus(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid PowerPlantStatus", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (s PowerPlantStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(s)))
+}
+
+// PowerPlantMetadata describes what a power plant is, as opposed to where it is.
+type PowerPlantMetadata struct {
+	Type PowerPlantType `json:"type"`
+	// CapacityMW is the nameplate capacity in MW, the AC capacity for solar power plants
+	// and the power capacity for storage.
+	CapacityMW *float64 `json:"capacityMW,omitempty"`
+	// DCCapacityMW is the DC capacity of the panels of solar power plants in MW.
+	DCCapacityMW *float64 `json:"dcCapacityMW,omitempty"`
+	// SolarArray is the orientation and the losses of the panels of solar power plants,
+	// nil for flat panels with the default losses.
+	SolarArray *SolarArrayConfig `json:"solarArray,omitempty"`
+	// DesignDischargeM3s is the river discharge in m³/s at which hydro power plants reach their capacity.
+	DesignDischargeM3s *float64 `json:"designDischargeM3s,omitempty"`
+	// TurbineModelID and TurbineCount are the turbines of wind power plants.
+	TurbineModelID *int64           `json:"turbineModelID,omitempty"`
+	TurbineCount   *int             `json:"turbineCount,omitempty"`
+	CommissionedAt *time.Time       `json:"commissionedAt,omitempty"`
+	Status         PowerPlantStatus `json:"status"`
+	Operator       *string          `json:"operator,omitempty"`
+}
+
+// Validate validates the metadata against the rules of the power plant type.
+//   - Solar power plants require an AC and a DC capacity, with a DC/AC ratio between 0.8 and 2.
+//   - Only solar power plants have a solar array.
+//   - Wind, hydro and storage power plants require a capacity.
+//   - Only hydro power plants have a design discharge.
+//   - Only wind power plants have turbines, a model and a count set together.
+//   - Other power plants have no required field.
+//
+// Power plants that are operational or decommissioned cannot be commissioned after now.
+func (m PowerPlantMetadata) Validate(now time.Time) error {
+	if !m.Type.IsValid() {
+		return ErrInvalidPowerPlantType
+	}
+	if !m.Status.IsValid() {
+		return ErrInvalidPowerPlantStatus
+	}
+	if m.CapacityMW != nil && *m.CapacityMW <= 0 {
+		return ErrInvalidCapacity
+	}
+	if m.DCCapacityMW != nil && *m.DCCapacityMW <= 0 {
+		return ErrInvalidCapacity
+	}
+	if m.DesignDischargeM3s != nil && *m.DesignDischargeM3s <= 0 {
+		return ErrInvalidDesignDischarge
+	}
+
+	switch m.Type {
+	case PowerPlantTypeSolar:
+		if m.CapacityMW == nil {
+			return ErrCapacityRequired
+		}
+		if m.DCCapacityMW == nil {
+			return ErrDCCapacityRequired
+		}
+		if ratio := *m.DCCapacityMW / *m.CapacityMW; ratio < 0.8 || ratio > 2 {
+			return ErrInvalidDCACRatio
+		}
+	case PowerPlantTypeWind, PowerPlantTypeHydro, PowerPlantTypeStorage:
+		if m.CapacityMW == nil {
+			return ErrCapacityRequired
+		}
+	}
+	if m.Type != PowerPlantTypeSolar && m.DCCapacityMW != nil {
+		return ErrDCCapacityNotSolar
+	}
+	if m.SolarArray != nil {
+		if m.Type != PowerPlantTypeSolar {
+			return ErrSolarArrayNotSolar
+		}
+		if err := m.SolarArray.Validate(); err != nil {
+			return err
+		}
+	}
+	if m.Type != PowerPlantTypeHydro && m.DesignDischargeM3s != nil {
+		return ErrDesignDischargeNotHydro
+	}
+	if m.TurbineModelID != nil || m.TurbineCount != nil {
+		if m.Type != PowerPlantTypeWind {
+			return ErrTurbineNotWind
+		}
+		if m.TurbineModelID == nil || m.TurbineCount == nil {
+			return ErrTurbineModelAndCount
+		}
+		if *m.TurbineCount <= 0 {
+			return ErrInvalidTurbineCount
+		}
+	}
+
+	switch m.Status {
+	case PowerPlantStatusOperational, PowerPlantStatusDecommissioned:
+		if m.CommissionedAt != nil && m.CommissionedAt.After(now) {
+			return ErrCommissionedInFuture
+		}
+	}
+
+	return nil
+}
+
+// PowerPlantMetadataUpdate is a partial update of PowerPlantMetadata, nil fields are left unchanged.
+type PowerPlantMetadataUpdate struct {
+	Type               *PowerPlantType
+	CapacityMW         *float64
+	DCCapacityMW       *float64
+	SolarArray         *SolarArrayConfig
+	DesignDischargeM3s *float64
+	TurbineModelID     *int64
+	TurbineCount       *int
+	CommissionedAt     *time.Time
+	Status             *PowerPlantStatus
+	Operator           *string
+}
+
+// Apply applies the update to the metadata.
+// The fields of a type are dropped when the type changes to another one, unless they are part of the update:
+// the DC capacity and the solar array of solar, the design discharge of hydro and the turbines of wind power plants.
+func (u PowerPlantMetadataUpdate) Apply(m *PowerPlantMetadata) {
+	if u.Type != nil {
+		if *u.Type != PowerPlantTypeSolar {
+			m.DCCapacityMW = nil
+			m.SolarArray = nil
+		}
+		if *u.Type != PowerPlantTypeHydro {
+			m.DesignDischargeM3s = nil
+		}
+		if *u.Type != PowerPlantTypeWind {
+			m.TurbineModelID = nil
+			m.TurbineCount = nil
+		}
+		m.Type = *u.Type
+	}
+	if u.CapacityMW != nil {
+		m.CapacityMW = u.CapacityMW
+	}
+	if u.DCCapacityMW != nil {
+		m.DCCapacityMW = u.DCCapacityMW
+	}
+	if u.SolarArray != nil {
+		m.SolarArray = u.SolarArray
+	}
+	if u.DesignDischargeM3s != nil {
+		m.DesignDischargeM3s = u.DesignDischargeM3s
+	}
+	if u.TurbineModelID != nil {
+		m.TurbineModelID = u.TurbineModelID
+	}
+	if u.TurbineCount != nil {
+		m.TurbineCount = u.TurbineCount
+	}
+	if u.CommissionedAt != nil {
+		m.CommissionedAt = u.CommissionedAt
+	}
+	if u.Status != nil {
+		m.Status = *u.Status
+	}
+	if u.Operator != nil {
+		m.Operator = u.Operator
+	}
+}
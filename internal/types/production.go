@@ -0,0 +1,130 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxProductionBatch is the largest number of production readings recorded at once.
+	MaxProductionBatch = 10000
+	// ProductionCapacityTolerance is the share of the capacity a reading may exceed it by,
+	// meters and nameplates are never exact.
+	ProductionCapacityTolerance = 0.1
+	// MaxProductionRange is the longest range of production readings read at once.
+	MaxProductionRange = 31 * 24 * time.Hour
+	// MaxProductionClockSkew is how far in the future a reading may be, for the clocks of the meters.
+	MaxProductionClockSkew = 5 * time.Minute
+)
+
+var (
+	ErrProductionBatchTooLarge   = fmt.Errorf("at most %d production readings can be recorded at once", MaxProductionBatch)
+	ErrProductionTimeRequired    = errors.New("time is required")
+	ErrProductionInFuture        = errors.New("time must not be in the future")
+	ErrInvalidProductionPower    = errors.New("powerMW must be a finite number")
+	ErrInvalidProductionQuality  = errors.New("invalid production quality")
+	ErrProductionExceedsCapacity = errors.New("powerMW must be within the capacity of the power plant, with a 10% tolerance")
+	ErrInvalidProductionRange    = errors.New("from must be before to, at most 31 days apart")
+)
+
+// ProductionQuality tells how a production reading was obtained.
+type ProductionQuality string
+
+const (
+	// ProductionQualityMeasured is read from the meter.
+	ProductionQualityMeasured ProductionQuality = "MEASURED"
+	// ProductionQualityEstimated fills a gap of the meter, e.g. from the neighbouring readings.
+	ProductionQualityEstimated ProductionQuality = "ESTIMATED"
+	// ProductionQualitySuspect is read from the meter but flagged by its validation, e.g. a frozen value.
+	ProductionQualitySuspect ProductionQuality = "SUSPECT"
+)
+
+// IsValid returns true if the quality is known.
+func (q ProductionQuality) IsValid() bool {
+	switch q {
+	case ProductionQualityMeasured, ProductionQualityEstimated, ProductionQualitySuspect:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (q *ProductionQuality) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*q = ProductionQuality(str)
+	if !q.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductionQuality", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (q ProductionQuality) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(q)))
+}
+
+// ProductionReading is the metered output of a power plant at a time.
+type ProductionReading struct {
+	PowerPlantID int64             `json:"powerPlantID"`
+	Time         time.Time         `json:"time"`
+	PowerMW      float64           `json:"powerMW"`
+	Quality      ProductionQuality `json:"quality"`
+}
+
+// Validate validates the reading of the power plant at now. Storage power plants may have
+// negative readings while charging, the other ones only slightly, for their own consumption.
+func (r ProductionReading) Validate(powerPlant PowerPlant, now time.Time) error {
+	if r.Time.IsZero() {
+		return ErrProductionTimeRequired
+	}
+	if r.Time.After(now.Add(MaxProductionClockSkew)) {
+		return ErrProductionInFuture
+	}
+	if math.IsNaN(r.PowerMW) || math.IsInf(r.PowerMW, 0) {
+		return ErrInvalidProductionPower
+	}
+	if !r.Quality.IsValid() {
+		return ErrInvalidProductionQuality
+	}
+
+	if powerPlant.CapacityMW == nil {
+		return nil
+	}
+	high := *powerPlant.CapacityMW * (1 + ProductionCapacityTolerance)
+	low := -*powerPlant.CapacityMW * ProductionCapacityTolerance
+	if powerPlant.Type == PowerPlantTypeStorage {
+		low = -high
+	}
+	if r.PowerMW < low || r.PowerMW > high {
+		return ErrProductionExceedsCapacity
+	}
+	return nil
+}
+
+// ProductionRejection is a production reading rejected by a recording.
+type ProductionRejection struct {
+	// Row is the 1-based position of the reading in the batch or in the upload, header excluded.
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ProductionIngestResult is the outcome of recording production readings.
+// A reading with the power plant and the time of a saved one replaces it, and a reading
+// repeated in the same batch is only recorded once, the last one winning.
+type ProductionIngestResult struct {
+	Received   int `json:"received"`
+	Inserted   int `json:"inserted"`
+	Updated    int `json:"updated"`
+	Duplicates int `json:"duplicates"`
+	Rejected   int `json:"rejected"`
+	// Rejections are the rejected readings with the reason, the first ones only for uploads.
+	Rejections []ProductionRejection `json:"rejections"`
+}
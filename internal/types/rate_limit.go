@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+// RateLimitWindow is the consumption of an upstream API quota in one window.
+type RateLimitWindow struct {
+	// Window is the name of the window, e.g. minute, hour or day.
+	Window string `json:"window"`
+	// Limit is the number of calls allowed per window.
+	Limit int `json:"limit"`
+	// Used is the number of calls counted in the current window.
+	Used float64 `json:"used"`
+	// Available is the number of calls that can be made right now without waiting.
+	Available float64 `json:"available"`
+	// ResetsAt is when the current window ends.
+	ResetsAt time.Time `json:"resetsAt"`
+}
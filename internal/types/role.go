@@ -0,0 +1,60 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrForbidden is returned when the caller does not have the role required by an operation.
+var ErrForbidden = errors.New("forbidden")
+
+// Role is the set of operations granted to a caller, each role grants the operations of the roles before it.
+type Role string
+
+const (
+	// RoleViewer reads the data of the organization.
+	RoleViewer Role = "VIEWER"
+	// RoleEditor also changes the power plants and the rows related to them.
+	RoleEditor Role = "EDITOR"
+	// RoleAdmin also manages the webhooks, reads the audit log and the usage shared by every organization.
+	RoleAdmin Role = "ADMIN"
+)
+
+// roleRanks orders the roles, a role grants the operations of the roles of a lower rank.
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// IsValid returns true if the role is known.
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Grants returns true if the role grants the operations of the other role.
+func (r Role) Grants(other Role) bool {
+	return r.IsValid() && other.IsValid() && roleRanks[r] >= roleRanks[other]
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (r *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*r = Role(str)
+	if !r.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (r Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(r)))
+}
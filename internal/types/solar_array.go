@@ -0,0 +1,100 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	ErrSolarArrayNotSolar            = errors.New("solarArray is only allowed for solar power plants")
+	ErrInvalidSolarTracking          = errors.New("invalid solar tracking")
+	ErrInvalidTilt                   = errors.New("tilt must be between 0 and 90")
+	ErrInvalidAzimuth                = errors.New("azimuth must be between -180 and 180")
+	ErrInvalidTemperatureCoefficient = errors.New("temperatureCoefficient must be between -0.01 and 0")
+	ErrInvalidSystemLosses           = errors.New("systemLosses must be between 0 and 0.5")
+)
+
+// SolarTracking is how the panels of a solar array follow the sun.
+type SolarTracking string
+
+const (
+	// SolarTrackingFixed panels keep their tilt and azimuth.
+	SolarTrackingFixed SolarTracking = "FIXED"
+	// SolarTrackingSingleAxis panels turn from east to west around a horizontal axis.
+	SolarTrackingSingleAxis SolarTracking = "SINGLE_AXIS"
+	// SolarTrackingDualAxis panels always face the sun.
+	SolarTrackingDualAxis SolarTracking = "DUAL_AXIS"
+)
+
+// IsValid returns true if the tracking is known.
+func (t SolarTracking) IsValid() bool {
+	switch t {
+	case SolarTrackingFixed, SolarTrackingSingleAxis, SolarTrackingDualAxis:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (t *SolarTracking) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*t = SolarTracking(str)
+	if !t.IsValid() {
+		return fmt.Errorf("%s is not a valid SolarTracking", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (t SolarTracking) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(t)))
+}
+
+// SolarArrayConfig is the orientation and the losses of the panels of a solar power plant.
+// The DC capacity and the inverter limit are the DCCapacityMW and the CapacityMW of the power plant.
+type SolarArrayConfig struct {
+	// Tilt is the angle of the panels from the horizontal in degrees, the axis tilt for single axis trackers.
+	Tilt float64 `json:"tilt"`
+	// Azimuth is the direction the panels face in degrees: 0 south, -90 east, 90 west and ±180 north.
+	// It is ignored by trackers.
+	Azimuth  float64       `json:"azimuth"`
+	Tracking SolarTracking `json:"tracking"`
+	// TemperatureCoefficient is the relative power change per °C of cell temperature.
+	TemperatureCoefficient float64 `json:"temperatureCoefficient"`
+	// SystemLosses is the fraction of the DC output lost to soiling, shading, mismatch and wiring.
+	SystemLosses float64 `json:"systemLosses"`
+}
+
+// Validate validates the solar array.
+func (c SolarArrayConfig) Validate() error {
+	if !c.Tracking.IsValid() {
+		return ErrInvalidSolarTracking
+	}
+	if c.Tilt < 0 || c.Tilt > 90 {
+		return ErrInvalidTilt
+	}
+	if c.Azimuth < -180 || c.Azimuth > 180 {
+		return ErrInvalidAzimuth
+	}
+	if c.TemperatureCoefficient < -0.01 || c.TemperatureCoefficient > 0 {
+		return ErrInvalidTemperatureCoefficient
+	}
+	if c.SystemLosses < 0 || c.SystemLosses > 0.5 {
+		return ErrInvalidSystemLosses
+	}
+	return nil
+}
+
+// TiltedIrradiance is the irradiance on the panels of a solar array for one hour.
+type TiltedIrradiance struct {
+	// Time of the irradiance in UTC/GMT, the irradiance is the average over the preceding hour.
+	Time string `json:"time"`
+	// Irradiance is the global tilted irradiance in W/m², nil when the weather model has no data.
+	Irradiance *float64 `json:"irradiance,omitempty"`
+}
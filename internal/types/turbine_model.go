@@ -0,0 +1,101 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTurbineModelNameRequired = errors.New("turbine model name is required")
+	ErrInvalidRatedPower        = errors.New("ratedPowerKW must be greater than 0")
+	ErrInvalidCutInCutOut       = errors.New("cutInSpeed must be at least 0 and lower than cutOutSpeed")
+	ErrPowerCurveTooShort       = errors.New("powerCurve must have at least 2 points")
+	ErrPowerCurveNotSorted      = errors.New("powerCurve wind speeds must be strictly increasing")
+	ErrInvalidPowerCurvePower   = errors.New("powerCurve powers must be between 0 and ratedPowerKW")
+	// ErrTurbineModelInUse is returned when deleting a turbine model assigned to power plants.
+	ErrTurbineModelInUse = errors.New("turbine model is assigned to power plants")
+	// ErrTurbineModelNotFound is returned when assigning a turbine model that does not exist.
+	ErrTurbineModelNotFound = errors.New("turbine model not found")
+)
+
+// TurbineModel is a wind turbine model, shared by the wind power plants using it.
+type TurbineModel struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Manufacturer *string `json:"manufacturer,omitempty"`
+	// RatedPowerKW is the output of one turbine at the rated wind speed.
+	RatedPowerKW float64 `json:"ratedPowerKW"`
+	// CutInSpeed and CutOutSpeed are the hub height wind speeds in m/s between which the turbine produces.
+	CutInSpeed  float64 `json:"cutInSpeed"`
+	CutOutSpeed float64 `json:"cutOutSpeed"`
+	// PowerCurve is the output of one turbine per hub height wind speed at the standard air density,
+	// sorted by wind speed.
+	PowerCurve []PowerCurvePoint `json:"powerCurve"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt,omitempty"`
+}
+
+// PowerCurvePoint is the output of a turbine at a wind speed.
+type PowerCurvePoint struct {
+	// WindSpeed is in m/s.
+	WindSpeed float64 `json:"windSpeed"`
+	PowerKW   float64 `json:"powerKW"`
+}
+
+// Validate validates the turbine model.
+// The power curve needs at least 2 points, sorted by wind speed, with powers up to the rated power.
+func (m TurbineModel) Validate() error {
+	if m.Name == "" {
+		return ErrTurbineModelNameRequired
+	}
+	if m.RatedPowerKW <= 0 {
+		return ErrInvalidRatedPower
+	}
+	if m.CutInSpeed < 0 || m.CutInSpeed >= m.CutOutSpeed {
+		return ErrInvalidCutInCutOut
+	}
+	if len(m.PowerCurve) < 2 {
+		return ErrPowerCurveTooShort
+	}
+	for i, point := range m.PowerCurve {
+		if i > 0 && point.WindSpeed <= m.PowerCurve[i-1].WindSpeed {
+			return ErrPowerCurveNotSorted
+		}
+		if point.PowerKW < 0 || point.PowerKW > m.RatedPowerKW {
+			return ErrInvalidPowerCurvePower
+		}
+	}
+	return nil
+}
+
+// TurbineModelUpdate is a partial update of TurbineModel, nil fields are left unchanged.
+type TurbineModelUpdate struct {
+	Name         *string
+	Manufacturer *string
+	RatedPowerKW *float64
+	CutInSpeed   *float64
+	CutOutSpeed  *float64
+	PowerCurve   []PowerCurvePoint
+}
+
+// Apply applies the update to the turbine model.
+func (u TurbineModelUpdate) Apply(m *TurbineModel) {
+	if u.Name != nil {
+		m.Name = *u.Name
+	}
+	if u.Manufacturer != nil {
+		m.Manufacturer = u.Manufacturer
+	}
+	if u.RatedPowerKW != nil {
+		m.RatedPowerKW = *u.RatedPowerKW
+	}
+	if u.CutInSpeed != nil {
+		m.CutInSpeed = *u.CutInSpeed
+	}
+	if u.CutOutSpeed != nil {
+		m.CutOutSpeed = *u.CutOutSpeed
+	}
+	if u.PowerCurve != nil {
+		m.PowerCurve = u.PowerCurve
+	}
+}
@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// WeatherSnapshot is a stored weather forecast of a location.
+type WeatherSnapshot struct {
+	// Latitude and Longitude are snapped to the weather model grid.
+	Latitude     float64
+	Longitude    float64
+	ForecastDays int
+	Forecast     WeatherForecastProperties
+	// FetchedAt is when the forecast was fetched. The weather API does not tell the model run of its
+	// forecasts, so a snapshot is fresh while no model update interval started since, see
+	// config.SnapshotConfig.ModelUpdateInterval.
+	FetchedAt time.Time
+}
@@ -0,0 +1,193 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MinWebhookSecretLength is the shortest secret accepted for signing webhook payloads.
+const MinWebhookSecretLength = 16
+
+var (
+	ErrInvalidWebhookURL        = errors.New("url must be an absolute http or https URL")
+	ErrWebhookEventTypeRequired = errors.New("eventTypes must have at least one event type")
+	ErrInvalidWebhookEventType  = errors.New("invalid webhook event type")
+	ErrInvalidWebhookSecret     = errors.New("secret must be at least 16 characters")
+)
+
+// WebhookEventType is the type of event a webhook subscription is notified of.
+type WebhookEventType string
+
+const (
+	WebhookEventAlertTriggered    WebhookEventType = "ALERT_TRIGGERED"
+	WebhookEventAlertResolved     WebhookEventType = "ALERT_RESOLVED"
+	WebhookEventPowerPlantCreated WebhookEventType = "POWER_PLANT_CREATED"
+	WebhookEventPowerPlantUpdated WebhookEventType = "POWER_PLANT_UPDATED"
+)
+
+// IsValid returns true if the event type is known.
+func (e WebhookEventType) IsValid() bool {
+	switch e {
+	case WebhookEventAlertTriggered, WebhookEventAlertResolved, WebhookEventPowerPlantCreated, WebhookEventPowerPlantUpdated:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (e *WebhookEventType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEventType", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (e WebhookEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(e)))
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery in the queue.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is waiting for its first attempt or for a retry.
+	WebhookDeliveryPending WebhookDeliveryStatus = "PENDING"
+	// WebhookDeliveryDelivered was acknowledged with a 2xx response.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "DELIVERED"
+	// WebhookDeliveryDead failed every attempt and is not retried anymore, until it is redelivered.
+	WebhookDeliveryDead WebhookDeliveryStatus = "DEAD"
+)
+
+// IsValid returns true if the status is known.
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryDead:
+		return true
+	}
+	return false
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface.
+func (s *WebhookDeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = WebhookDeliveryStatus(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (s WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(s)))
+}
+
+// WebhookSubscription is a URL notified of the events of the given types.
+type WebhookSubscription struct {
+	ID         int64              `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []WebhookEventType `json:"eventTypes"`
+	// Secret signs the payloads, it is never returned by the API.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// Validate validates the webhook subscription.
+func (s WebhookSubscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if len(s.EventTypes) == 0 {
+		return ErrWebhookEventTypeRequired
+	}
+	for _, eventType := range s.EventTypes {
+		if !eventType.IsValid() {
+			return ErrInvalidWebhookEventType
+		}
+	}
+	if len(s.Secret) < MinWebhookSecretLength {
+		return ErrInvalidWebhookSecret
+	}
+	return nil
+}
+
+// WebhookSubscriptionUpdate is a partial update of a WebhookSubscription, nil fields are left unchanged.
+type WebhookSubscriptionUpdate struct {
+	URL        *string
+	EventTypes []WebhookEventType
+	Secret     *string
+}
+
+// Apply applies the update to the webhook subscription.
+func (u WebhookSubscriptionUpdate) Apply(s *WebhookSubscription) {
+	if u.URL != nil {
+		s.URL = *u.URL
+	}
+	if u.EventTypes != nil {
+		s.EventTypes = u.EventTypes
+	}
+	if u.Secret != nil {
+		s.Secret = *u.Secret
+	}
+}
+
+// WebhookEvent is the JSON payload posted to the webhook subscriptions.
+type WebhookEvent struct {
+	Type       WebhookEventType `json:"type"`
+	OccurredAt time.Time        `json:"occurredAt"`
+	// Data is the alert or the power plant of the event.
+	Data any `json:"data"`
+}
+
+// WebhookDelivery is an event queued for a webhook subscription.
+type WebhookDelivery struct {
+	ID             int64            `json:"id"`
+	SubscriptionID int64            `json:"subscriptionID"`
+	EventType      WebhookEventType `json:"eventType"`
+	// Payload is the signed JSON body, a WebhookEvent.
+	Payload  string                `json:"payload"`
+	Status   WebhookDeliveryStatus `json:"status"`
+	Attempts int                   `json:"attempts"`
+	// NextAttemptAt is when the delivery is attempted next, nil once delivered or dead.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// WebhookDeliveryAttempt is one attempt of a webhook delivery, in the delivery log.
+type WebhookDeliveryAttempt struct {
+	ID          int64     `json:"id"`
+	DeliveryID  int64     `json:"deliveryID"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+	// StatusCode is nil when no response was received, see Error.
+	StatusCode *int    `json:"statusCode,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	DurationMS int     `json:"durationMS"`
+}
+
+// WebhookDispatch is a delivery claimed by the dispatcher, with the target of its subscription.
+type WebhookDispatch struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+	// LeasedUntil is the next attempt time set by the claim, the attempt is only recorded on the delivery
+	// while it holds, e.g. not after a redelivery.
+	LeasedUntil time.Time
+}
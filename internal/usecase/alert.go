@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// CreateAlertRule validates and creates a new alert rule.
+// The aggregation defaults to NONE, and the rule is evaluated from the next forecast refresh.
+func (u *Usecase) CreateAlertRule(ctx context.Context, rule types.AlertRule) (*types.AlertRule, error) {
+	if rule.PowerPlantID == 0 {
+		return nil, errors.New("powerPlantID is required")
+	}
+	if rule.Aggregation == "" {
+		rule.Aggregation = types.AlertAggregationNone
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := u.db.CreateAlertRule(ctx, &rule)
+	if err != nil {
+		if errors.Is(err, types.ErrPowerPlantNotFound) {
+			return nil, err
+		}
+		u.logger.Printf("error creating alert rule: %v", err)
+		return nil, types.ErrInternal
+	}
+	return created, nil
+}
+
+// UpdateAlertRule updates an alert rule by ID, the rule is validated again as a whole after the update.
+func (u *Usecase) UpdateAlertRule(ctx context.Context, id int64, update types.AlertRuleUpdate) (*types.AlertRule, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	rule, err := u.db.GetAlertRule(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting alert rule: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+
+	update.Apply(rule)
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	rule, err = u.db.UpdateAlertRule(ctx, rule)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error updating alert rule: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return rule, nil
+}
+
+// DeleteAlertRule deletes an alert rule by ID, its alerts are kept in the history.
+func (u *Usecase) DeleteAlertRule(ctx context.Context, id int64) error {
+	if id == 0 {
+		return errors.New("id is required")
+	}
+
+	err := u.db.DeleteAlertRule(ctx, id)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errors.New("id not found")
+	default:
+		u.logger.Printf("error deleting alert rule: %v", err)
+		return types.ErrInternal
+	}
+}
+
+// GetAlertRule returns an alert rule by ID.
+func (u *Usecase) GetAlertRule(ctx context.Context, id int64) (*types.AlertRule, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	rule, err := u.db.GetAlertRule(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting alert rule: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return rule, nil
+}
+
+// GetAlertRules returns the alert rules of a power plant.
+func (u *Usecase) GetAlertRules(ctx context.Context, powerPlantID int64) ([]types.AlertRule, error) {
+	rules, err := u.db.GetAlertRules(ctx, []int64{powerPlantID})
+	if err != nil {
+		u.logger.Printf("error getting alert rules: %v", err)
+		return nil, types.ErrInternal
+	}
+	return rules, nil
+}
+
+// GetActiveAlerts returns the alerts of a power plant that are not resolved.
+func (u *Usecase) GetActiveAlerts(ctx context.Context, powerPlantID int64) ([]types.Alert, error) {
+	alerts, err := u.db.GetActiveAlerts(ctx, powerPlantID)
+	if err != nil {
+		u.logger.Printf("error getting active alerts: %v", err)
+		return nil, types.ErrInternal
+	}
+	return alerts, nil
+}
+
+// GetAlerts returns the active and resolved alerts, of every power plant when powerPlantID is nil,
+// paginated like GetPowerPlants.
+func (u *Usecase) GetAlerts(ctx context.Context, powerPlantID *int64, lastID int64, count int) ([]types.Alert, error) {
+	alerts, err := u.db.GetAlerts(ctx, powerPlantID, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting alerts: %v", err)
+		return nil, types.ErrInternal
+	}
+	return alerts, nil
+}
+
+// EvaluateAlerts evaluates the alert rules of every power plant against their forecasts,
+// in batches of batchSize power plants. It runs after each forecast refresh of the prefetcher,
+// so the forecasts are served from the fresh snapshots.
+// Triggered rules record an alert, or update their active one, and the active alerts of
+// the rules no longer triggered are resolved. New and resolved alerts are published to the webhooks.
+// It returns the number of triggered rules.
+func (u *Usecase) EvaluateAlerts(ctx context.Context, batchSize int) (int, error) {
+	var (
+		triggered int
+		lastID    int64
+	)
+	for {
+		powerPlants, err := u.db.GetPowerPlants(ctx, lastID, batchSize)
+		if err != nil {
+			return triggered, fmt.Errorf("error getting power plants: %w", err)
+		}
+		if len(powerPlants) == 0 {
+			break
+		}
+
+		n, err := u.evaluateAlertRules(ctx, powerPlants)
+		triggered += n
+		if err != nil {
+			return triggered, err
+		}
+
+		if len(powerPlants) < batchSize {
+			break
+		}
+		lastID = powerPlants[len(powerPlants)-1].ID
+	}
+
+	return triggered, nil
+}
+
+// evaluateAlertRules evaluates the alert rules of the power plants.
+// The forecasts are fetched once per forecast length covering the horizons of the rules.
+func (u *Usecase) evaluateAlertRules(ctx context.Context, powerPlants []types.PowerPlant) (int, error) {
+	ids := make([]int64, 0, len(powerPlants))
+	byID := make(map[int64]types.PowerPlant, len(powerPlants))
+	for _, powerPlant := range powerPlants {
+		ids = append(ids, powerPlant.ID)
+		byID[powerPlant.ID] = powerPlant
+	}
+
+	rules, err := u.db.GetAlertRules(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error getting alert rules: %w", err)
+	}
+
+	rulesByDays := map[int][]types.AlertRule{}
+	for _, rule := range rules {
+		days := alertForecastDays(rule.HorizonHours)
+		rulesByDays[days] = append(rulesByDays[days], rule)
+	}
+
+	now := u.now().UTC()
+	triggered := 0
+	for days, rules := range rulesByDays {
+		lats := make([]float64, 0, len(rules))
+		longs := make([]float64, 0, len(rules))
+		for _, rule := range rules {
+			lats = append(lats, byID[rule.PowerPlantID].Latitude)
+			longs = append(longs, byID[rule.PowerPlantID].Longitude)
+		}
+
+		forecasts, err := u.getWeatherForecasts(ctx, lats, longs, days)
+		if err != nil {
+			return triggered, fmt.Errorf("error getting weather forecasts: %w", err)
+		}
+
+		for i, rule := range rules {
+			alert, err := evaluateAlertRule(rule, forecasts[i].WeatherForecasts, now)
+			if err != nil {
+				return triggered, fmt.Errorf("error evaluating alert rule %d: %w", rule.ID, err)
+			}
+
+			if alert == nil {
+				resolved, err := u.db.ResolveAlert(ctx, rule.ID, now)
+				if err != nil {
+					return triggered, fmt.Errorf("error resolving alert of rule %d: %w", rule.ID, err)
+				}
+				if resolved != nil {
+					u.publishEvent(ctx, types.WebhookEventAlertResolved, resolved)
+				}
+				continue
+			}
+
+			recorded, err := u.db.RecordAlert(ctx, alert)
+			if err != nil {
+				return triggered, fmt.Errorf("error recording alert of rule %d: %w", rule.ID, err)
+			}
+			// An active alert still met is only updated, the subscriptions are notified once.
+			if recorded.LastSeenAt.Equal(recorded.TriggeredAt) {
+				u.publishEvent(ctx, types.WebhookEventAlertTriggered, recorded)
+			}
+			triggered++
+		}
+	}
+
+	return triggered, nil
+}
+
+// alertForecastDays returns the shortest forecast length covering the horizon, counted from today 00:00 UTC.
+func alertForecastDays(horizonHours int) int {
+	// The horizon starts at the current hour, which is at most 23 hours after 00:00.
+	return forecastDaysCovering(horizonHours + 23)
+}
+
+// forecastDaysCovering returns the shortest forecast length with at least the given number of hours,
+// the longest one when none has enough.
+func forecastDaysCovering(hours int) int {
+	for _, days := range []int{1, 3, 7, 14, 16} {
+		if days*24 >= hours {
+			return days
+		}
+	}
+	return 16
+}
+
+// evaluateAlertRule returns the alert triggered by the forecasts between now and the horizon of the rule,
+// nil if the condition is never met. The alert is set to the first hour meeting the condition, or the
+// first day for daily aggregations, which include every hour of the days overlapping the horizon.
+// Hours without data are skipped.
+func evaluateAlertRule(rule types.AlertRule, forecasts []types.WeatherForecast, now time.Time) (*types.Alert, error) {
+	start := now.Truncate(time.Hour)
+	end := now.Add(time.Duration(rule.HorizonHours) * time.Hour)
+
+	alert := func(forecastTime string, value float64) *types.Alert {
+		return &types.Alert{
+			RuleID:       &rule.ID,
+			PowerPlantID: rule.PowerPlantID,
+			Variable:     rule.Variable,
+			Operator:     rule.Operator,
+			Threshold:    rule.Threshold,
+			Aggregation:  rule.Aggregation,
+			ForecastTime: forecastTime,
+			Value:        value,
+			TriggeredAt:  now,
+			LastSeenAt:   now,
+		}
+	}
+
+	if rule.Aggregation == types.AlertAggregationNone {
+		for _, forecast := range forecasts {
+			t, err := time.Parse(types.ForecastTimeLayout, forecast.Time)
+			if err != nil {
+				return nil, err
+			}
+			if t.Before(start) || t.After(end) {
+				continue
+			}
+
+			value := rule.Variable.Value(forecast)
+			if value != nil && rule.Operator.Compare(*value, rule.Threshold) {
+				return alert(forecast.Time, *value), nil
+			}
+		}
+		return nil, nil
+	}
+
+	var (
+		days   []string
+		values = map[string][]float64{}
+	)
+	firstDay, lastDay := start.Format(time.DateOnly), end.Format(time.DateOnly)
+	for _, forecast := range forecasts {
+		if _, err := time.Parse(types.ForecastTimeLayout, forecast.Time); err != nil {
+			return nil, err
+		}
+		day := forecast.Time[:len(time.DateOnly)]
+		if day < firstDay || day > lastDay {
+			continue
+		}
+
+		if _, ok := values[day]; !ok {
+			days = append(days, day)
+			values[day] = []float64{}
+		}
+		if value := rule.Variable.Value(forecast); value != nil {
+			values[day] = append(values[day], *value)
+		}
+	}
+
+	for _, day := range days {
+		value := rule.Aggregation.Aggregate(values[day])
+		if value != nil && rule.Operator.Compare(*value, rule.Threshold) {
+			return alert(day, *value), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestUsecase_CreateAlertRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		rule      types.AlertRule
+		expected  *types.AlertRule
+		expectErr error
+	}{
+		{
+			testName: "success, aggregation defaults to none",
+			rule:     types.AlertRule{PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, Threshold: 90, HorizonHours: 48},
+			expected: &types.AlertRule{
+				ID: 1, PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan,
+				Threshold: 90, HorizonHours: 48, Aggregation: types.AlertAggregationNone,
+			},
+		},
+		{
+			testName: "success, daily precipitation",
+			rule: types.AlertRule{
+				PowerPlantID: 1, Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThan,
+				Threshold: 20, HorizonHours: 72, Aggregation: types.AlertAggregationDailySum,
+			},
+			expected: &types.AlertRule{
+				ID: 1, PowerPlantID: 1, Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThan,
+				Threshold: 20, HorizonHours: 72, Aggregation: types.AlertAggregationDailySum,
+			},
+		},
+		{
+			testName:  "failed, empty power plant",
+			rule:      types.AlertRule{Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, HorizonHours: 48},
+			expectErr: errors.New("powerPlantID is required"),
+		},
+		{
+			testName:  "failed, unknown power plant",
+			rule:      types.AlertRule{PowerPlantID: 999, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, HorizonHours: 48},
+			expectErr: types.ErrPowerPlantNotFound,
+		},
+		{
+			testName:  "failed, invalid variable",
+			rule:      types.AlertRule{PowerPlantID: 1, Variable: "HUMIDITY", Operator: types.AlertOperatorGreaterThan, HorizonHours: 48},
+			expectErr: types.ErrInvalidAlertVariable,
+		},
+		{
+			testName:  "failed, invalid operator",
+			rule:      types.AlertRule{PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: "EQUAL", HorizonHours: 48},
+			expectErr: types.ErrInvalidAlertOperator,
+		},
+		{
+			testName:  "failed, horizon beyond the longest forecast",
+			rule:      types.AlertRule{PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, HorizonHours: 400},
+			expectErr: types.ErrInvalidAlertHorizon,
+		},
+		{
+			testName:  "failed, empty horizon",
+			rule:      types.AlertRule{PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan},
+			expectErr: types.ErrInvalidAlertHorizon,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			rule, err := testUsecase.CreateAlertRule(ctx, tt.rule)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, rule, cmpopts.IgnoreFields(types.AlertRule{}, "CreatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_UpdateAlertRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		update    types.AlertRuleUpdate
+		expected  *types.AlertRule
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       11,
+			update:   types.AlertRuleUpdate{Threshold: ptr(90.0), Aggregation: ptr(types.AlertAggregationDailyMax)},
+			expected: &types.AlertRule{
+				ID: 11, PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan,
+				Threshold: 90, HorizonHours: 48, Aggregation: types.AlertAggregationDailyMax,
+			},
+		},
+		{
+			testName:  "failed, invalid horizon",
+			id:        11,
+			update:    types.AlertRuleUpdate{HorizonHours: ptr(0)},
+			expectErr: types.ErrInvalidAlertHorizon,
+		},
+		{
+			testName:  "failed, empty id",
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			rule, err := testUsecase.UpdateAlertRule(ctx, tt.id, tt.update)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, rule, cmpopts.IgnoreFields(types.AlertRule{}, "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_DeleteAlertRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       11,
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := testUsecase.DeleteAlertRule(ctx, tt.id)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEvaluateAlertRule(t *testing.T) {
+	now := time.Date(2024, 9, 6, 6, 20, 0, 0, time.UTC)
+	forecasts := []types.WeatherForecast{
+		{Time: "2024-09-06T00:00", Temperature: ptr(5.0), Precipitation: ptr(0.0), WindSpeed: ptr(60.0)},
+		{Time: "2024-09-06T12:00", Temperature: ptr(-12.0), Precipitation: ptr(15.0), WindSpeed: ptr(95.0)},
+		{Time: "2024-09-07T00:00", Temperature: ptr(-15.0), Precipitation: ptr(10.0)},
+		{Time: "2024-09-07T12:00", Temperature: ptr(-2.0), Precipitation: ptr(0.0), WindSpeed: ptr(100.0)},
+	}
+
+	tests := []struct {
+		name          string
+		rule          types.AlertRule
+		forecasts     []types.WeatherForecast
+		expectedTime  string
+		expectedValue float64
+		expectErr     error
+	}{
+		{
+			name:          "triggered, first hour above the threshold",
+			rule:          types.AlertRule{Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, Threshold: 90, HorizonHours: 48, Aggregation: types.AlertAggregationNone},
+			expectedTime:  "2024-09-06T12:00",
+			expectedValue: 95,
+		},
+		{
+			name: "not triggered, beyond the horizon",
+			rule: types.AlertRule{Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, Threshold: 90, HorizonHours: 3, Aggregation: types.AlertAggregationNone},
+		},
+		{
+			name:          "triggered, hours before the current hour are skipped",
+			rule:          types.AlertRule{Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, Threshold: 50, HorizonHours: 48, Aggregation: types.AlertAggregationNone},
+			expectedTime:  "2024-09-06T12:00",
+			expectedValue: 95,
+		},
+		{
+			name:          "triggered, daily sum of the whole day",
+			rule:          types.AlertRule{Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThan, Threshold: 12, HorizonHours: 48, Aggregation: types.AlertAggregationDailySum},
+			expectedTime:  "2024-09-06",
+			expectedValue: 15,
+		},
+		{
+			name: "not triggered, daily sum below the threshold",
+			rule: types.AlertRule{Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThan, Threshold: 20, HorizonHours: 48, Aggregation: types.AlertAggregationDailySum},
+		},
+		{
+			name:          "triggered, daily minimum below the threshold",
+			rule:          types.AlertRule{Variable: types.AlertVariableTemperature, Operator: types.AlertOperatorLessThan, Threshold: -10, HorizonHours: 12, Aggregation: types.AlertAggregationDailyMin},
+			expectedTime:  "2024-09-06",
+			expectedValue: -12,
+		},
+		{
+			name:          "triggered, missing values are skipped",
+			rule:          types.AlertRule{Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThanOrEqual, Threshold: 100, HorizonHours: 48, Aggregation: types.AlertAggregationDailyMax},
+			expectedTime:  "2024-09-07",
+			expectedValue: 100,
+		},
+		{
+			name:      "failed, invalid forecast time",
+			rule:      types.AlertRule{Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, Threshold: 90, HorizonHours: 48, Aggregation: types.AlertAggregationNone},
+			forecasts: []types.WeatherForecast{{Time: "2024-09-06 12:00"}},
+			expectErr: errors.New(`parsing time "2024-09-06 12:00" as "2006-01-02T15:04": cannot parse " 12:00" as "T"`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.forecasts == nil {
+				tt.forecasts = forecasts
+			}
+
+			alert, err := evaluateAlertRule(tt.rule, tt.forecasts, now)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectedTime == "" {
+				if alert != nil {
+					t.Fatalf("expected no alert, got: %+v", alert)
+				}
+				return
+			}
+			if alert == nil {
+				t.Fatalf("expected an alert at %s, got none", tt.expectedTime)
+			}
+			if alert.ForecastTime != tt.expectedTime || alert.Value != tt.expectedValue {
+				t.Fatalf("expected an alert at %s of %v, got: %s of %v", tt.expectedTime, tt.expectedValue, alert.ForecastTime, alert.Value)
+			}
+		})
+	}
+}
+
+func TestUsecase_EvaluateAlerts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		db  = &fakeDB{}
+		u   = NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+		now = time.Date(2024, 9, 6, 0, 30, 0, 0, time.UTC)
+	)
+	u.now = func() time.Time { return now }
+
+	triggered, err := u.EvaluateAlerts(ctx, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if triggered != 2 {
+		t.Fatalf("expected 2 triggered rules, got: %d", triggered)
+	}
+
+	// Power plant 1 has 0.3 km/h of wind at midnight, power plant 2 has 11.4 mm of precipitation over the day.
+	expected := []types.Alert{
+		{
+			RuleID: ptr(int64(11)), PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan,
+			Threshold: 0.25, Aggregation: types.AlertAggregationNone, ForecastTime: "2024-09-06T00:00", Value: 0.3,
+			TriggeredAt: now, LastSeenAt: now,
+		},
+		{
+			RuleID: ptr(int64(22)), PowerPlantID: 2, Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThanOrEqual,
+			Threshold: 10, Aggregation: types.AlertAggregationDailySum, ForecastTime: "2024-09-06", Value: 11.4,
+			TriggeredAt: now, LastSeenAt: now,
+		},
+	}
+	if diff := cmp.Diff(expected, db.recorded, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected recorded alerts (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{21}, db.resolved); diff != "" {
+		t.Fatalf("unexpected resolved rules (-want +got):\n%s", diff)
+	}
+
+	published := []types.WebhookEventType{types.WebhookEventAlertTriggered, types.WebhookEventAlertResolved, types.WebhookEventAlertTriggered}
+	if diff := cmp.Diff(published, db.published); diff != "" {
+		t.Fatalf("unexpected published events (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// GetAuditLog returns the audit events matching the filter, paginated like GetPowerPlants.
+func (u *Usecase) GetAuditLog(ctx context.Context, filter types.AuditEventFilter, lastID int64, count int) ([]types.AuditEvent, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	events, err := u.db.GetAuditEvents(ctx, filter, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting audit events: %v", err)
+		return nil, types.ErrInternal
+	}
+	return events, nil
+}
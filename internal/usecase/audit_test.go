@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUsecase_GetAuditLog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	from := time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		testName  string
+		filter    types.AuditEventFilter
+		lastID    int64
+		expected  []int64
+		expectErr error
+	}{
+		{
+			testName: "success, every event",
+			expected: []int64{1, 2},
+		},
+		{
+			testName: "success, after the last ID",
+			lastID:   1,
+			expected: []int64{2},
+		},
+		{
+			testName: "success, by actor",
+			filter:   types.AuditEventFilter{Actor: ptr("api-key:Default")},
+			expected: []int64{1},
+		},
+		{
+			testName: "success, from",
+			filter:   types.AuditEventFilter{From: &from},
+			expected: []int64{2},
+		},
+		{
+			testName:  "error, from is not before to",
+			filter:    types.AuditEventFilter{From: &from, To: &from},
+			expectErr: types.ErrInvalidAuditRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			events, err := testUsecase.GetAuditLog(ctx, tt.filter, tt.lastID, 10)
+			if err != nil {
+				if tt.expectErr == nil || tt.expectErr.Error() != err.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if tt.expectErr != nil {
+				t.Fatalf("expected error: %v, got nil", tt.expectErr)
+			}
+
+			got := make([]int64, 0, len(events))
+			for _, event := range events {
+				got = append(got, event.ID)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Fatalf("unexpected events (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package usecase
+
+// point is a pair of coordinates.
+type point struct {
+	latitude  float64
+	longitude float64
+}
+
+// dedupePoints snaps the coordinates with snap and returns the distinct points, in order of
+// first appearance, with the index of the point of every input coordinate to fan results back out.
+// A nil snap only merges exact coordinates.
+func dedupePoints(latitudes []float64, longitudes []float64, snap func(float64) float64) ([]point, []int) {
+	var (
+		points  = make([]point, 0, len(latitudes))
+		indexes = make([]int, len(latitudes))
+		seen    = make(map[point]int, len(latitudes))
+	)
+	for i := range latitudes {
+		p := point{latitudes[i], longitudes[i]}
+		if snap != nil {
+			p = point{snap(p.latitude), snap(p.longitude)}
+		}
+
+		index, ok := seen[p]
+		if !ok {
+			index = len(points)
+			seen[p] = index
+			points = append(points, p)
+		}
+		indexes[i] = index
+	}
+
+	return points, indexes
+}
+
+// splitPoints returns the latitudes and longitudes of the points.
+func splitPoints(points []point) ([]float64, []float64) {
+	lats := make([]float64, 0, len(points))
+	longs := make([]float64, 0, len(points))
+	for _, p := range points {
+		lats = append(lats, p.latitude)
+		longs = append(longs, p.longitude)
+	}
+	return lats, longs
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_dedupePoints(t *testing.T) {
+	snapToTenth := func(coordinate float64) float64 {
+		return math.Round(coordinate*10) / 10
+	}
+
+	tests := []struct {
+		name            string
+		lats            []float64
+		longs           []float64
+		snap            func(float64) float64
+		expectedPoints  []point
+		expectedIndexes []int
+	}{
+		{
+			name:            "success, exact duplicates",
+			lats:            []float64{40.7128, 34.0522, 40.7128, 40.7129},
+			longs:           []float64{-74.0060, -118.2437, -74.0060, -74.0060},
+			expectedPoints:  []point{{40.7128, -74.0060}, {34.0522, -118.2437}, {40.7129, -74.0060}},
+			expectedIndexes: []int{0, 1, 0, 2},
+		},
+		{
+			name:            "success, same grid cell",
+			lats:            []float64{40.7128, 34.0522, 40.7128, 40.7129},
+			longs:           []float64{-74.0060, -118.2437, -74.0060, -74.0060},
+			snap:            snapToTenth,
+			expectedPoints:  []point{{40.7, -74}, {34.1, -118.2}},
+			expectedIndexes: []int{0, 1, 0, 0},
+		},
+		{
+			name:            "success, empty",
+			expectedPoints:  []point{},
+			expectedIndexes: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, indexes := dedupePoints(tt.lats, tt.longs, tt.snap)
+			if diff := cmp.Diff(tt.expectedPoints, points, cmp.AllowUnexported(point{})); diff != "" {
+				t.Fatalf("unexpected points (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedIndexes, indexes); diff != "" {
+				t.Fatalf("unexpected indexes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
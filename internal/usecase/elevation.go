@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// maxElevationBatch is the maximum number of coordinates of one elevation API request.
+// Docs: https://open-meteo.com/en/docs/elevation-api
+const maxElevationBatch = 100
+
+// fetchElevation returns the elevation of the coordinate from the elevation API.
+// The elevation is not required to save a power plant, so it returns nil on error
+// and the elevation is fetched again on the next read.
+func (u *Usecase) fetchElevation(ctx context.Context, lat float64, long float64) *float64 {
+	elevations, err := u.weatherAPI.GetElevations(ctx, []float64{lat}, []float64{long})
+	if err != nil {
+		u.logger.Printf("error getting elevation: %v", err)
+		return nil
+	}
+	if len(elevations) == 0 {
+		u.logger.Printf("error getting elevation: no elevation returned")
+		return nil
+	}
+
+	return &elevations[0]
+}
+
+// fillMissingElevations fetches and saves the elevation of the power plants saved without one,
+// e.g. because the elevation API was unavailable when they were created.
+// On error, ElevationErr is set on the power plants still without elevation, and returned.
+func (u *Usecase) fillMissingElevations(ctx context.Context, powerPlants []types.PowerPlant) error {
+	missing := make([]*types.PowerPlant, 0)
+	for i := range powerPlants {
+		if powerPlants[i].DEMElevation == nil {
+			missing = append(missing, &powerPlants[i])
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := u.saveElevations(ctx, missing); err != nil {
+		err = u.upstreamError("error filling missing elevations", err)
+		for _, powerPlant := range missing {
+			if powerPlant.DEMElevation == nil {
+				powerPlant.ElevationErr = err
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// saveElevations fetches the elevation of the power plants from the elevation API and saves it.
+// The elevation model has a 90 m resolution, so only power plants at the exact same coordinates
+// share a fetched elevation.
+func (u *Usecase) saveElevations(ctx context.Context, powerPlants []*types.PowerPlant) error {
+	lats := make([]float64, 0, len(powerPlants))
+	longs := make([]float64, 0, len(powerPlants))
+	for _, powerPlant := range powerPlants {
+		lats = append(lats, powerPlant.Latitude)
+		longs = append(longs, powerPlant.Longitude)
+	}
+	points, indexes := dedupePoints(lats, longs, nil)
+
+	elevations := make([]float64, 0, len(points))
+	for start := 0; start < len(points); start += maxElevationBatch {
+		batchLats, batchLongs := splitPoints(points[start:min(start+maxElevationBatch, len(points))])
+
+		batch, err := u.weatherAPI.GetElevations(ctx, batchLats, batchLongs)
+		if err != nil {
+			return err
+		}
+		if len(batch) != len(batchLats) {
+			return errors.New("elevation count does not match coordinate count")
+		}
+		elevations = append(elevations, batch...)
+	}
+
+	for i, powerPlant := range powerPlants {
+		elevation := elevations[indexes[i]]
+		if err := u.db.UpdatePowerPlantElevation(ctx, powerPlant.ID, elevation); err != nil {
+			return err
+		}
+
+		powerPlant.DEMElevation = &elevation
+		powerPlant.ResolveElevation()
+	}
+
+	return nil
+}
+
+// BackfillElevations fetches and saves the elevation of every power plant without one,
+// batchSize power plants at a time. It returns the number of updated power plants.
+func (u *Usecase) BackfillElevations(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 || batchSize > maxElevationBatch {
+		batchSize = maxElevationBatch
+	}
+
+	var (
+		updated int
+		lastID  int64
+	)
+	for {
+		powerPlants, err := u.db.GetPowerPlantsMissingElevation(ctx, lastID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(powerPlants) == 0 {
+			return updated, nil
+		}
+
+		batch := make([]*types.PowerPlant, 0, len(powerPlants))
+		for i := range powerPlants {
+			batch = append(batch, &powerPlants[i])
+		}
+
+		if err := u.saveElevations(ctx, batch); err != nil {
+			return updated, err
+		}
+
+		updated += len(powerPlants)
+		lastID = powerPlants[len(powerPlants)-1].ID
+	}
+}
+
+// SetPowerPlantElevation sets the surveyed elevation of a power plant,
+// it beats the elevation from the elevation API. A nil elevation removes the override.
+func (u *Usecase) SetPowerPlantElevation(ctx context.Context, id int64, elevation *float64) (*types.PowerPlant, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	powerPlant, err := u.db.GetPowerPlantForUpdate(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting power plant for update: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+
+	powerPlant.ElevationOverride = elevation
+
+	powerPlant, err = u.db.UpdatePowerPlant(ctx, powerPlant)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error updating power plant: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+
+	u.publishEvent(ctx, types.WebhookEventPowerPlantUpdated, powerPlant)
+	return powerPlant, nil
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestUsecase_BackfillElevations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		batchSize int
+		expected  int
+	}{
+		{
+			testName:  "success, single batch",
+			batchSize: 10,
+			expected:  fakeMissingElevationCount,
+		},
+		{
+			testName:  "success, multiple batches",
+			batchSize: 2,
+			expected:  fakeMissingElevationCount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			updated, err := testUsecase.BackfillElevations(ctx, tt.batchSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if updated != tt.expected {
+				t.Fatalf("expected %d updated power plants, got %d", tt.expected, updated)
+			}
+		})
+	}
+}
+
+func TestUsecase_SetPowerPlantElevation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		elevation *float64
+		expected  *types.PowerPlant
+		expectErr error
+	}{
+		{
+			testName:  "success, set override",
+			id:        1,
+			elevation: ptr(120.5),
+			expected: &types.PowerPlant{
+				ID:                 1,
+				Name:               "My Cool Power Plant",
+				Latitude:           22.11,
+				Longitude:          33.11,
+				Elevation:          120.5,
+				ElevationOverride:  ptr(120.5),
+				PowerPlantMetadata: defaultMetadata,
+			},
+		},
+		{
+			testName: "success, remove override",
+			id:       1,
+			expected: &types.PowerPlant{
+				ID:                 1,
+				Name:               "My Cool Power Plant",
+				Latitude:           22.11,
+				Longitude:          33.11,
+				PowerPlantMetadata: defaultMetadata,
+			},
+		},
+		{
+			testName:  "failed, empty id",
+			elevation: ptr(120.5),
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			elevation: ptr(120.5),
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			powerPlant, err := testUsecase.SetPowerPlantElevation(ctx, tt.id, tt.elevation)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, powerPlant, cmpopts.IgnoreFields(types.PowerPlant{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
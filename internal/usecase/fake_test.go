@@ -1,13 +1,18 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"os"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gcathelines/tensor-energy-case/config"
 	"github.com/gcathelines/tensor-energy-case/internal/types"
 )
 
@@ -16,11 +21,28 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	testUsecase = NewUsecase(&fakeWeatherAPI{}, &fakeDB{})
+	testUsecase = NewUsecase(&fakeWeatherAPI{}, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+	// The fake forecasts are of 2024-09-06.
+	testUsecase.now = func() time.Time { return time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC) }
 	code := m.Run()
 	os.Exit(code)
 }
 
+var testSnapshotConfig = config.SnapshotConfig{
+	ModelUpdateInterval:  time.Hour,
+	StaleWhileRevalidate: 6 * time.Hour,
+	RefreshTimeout:       5 * time.Second,
+}
+
+var testReadTimeouts = config.ReadTimeoutConfig{
+	Forecast:  time.Second,
+	Elevation: time.Second,
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 type fakeWeatherAPI struct{}
 
 func (f *fakeWeatherAPI) GetWeatherForecast(ctx context.Context, latitudes float64, longitudes float64, forecastDays int) (*types.WeatherForecastProperties, error) {
@@ -28,17 +50,17 @@ func (f *fakeWeatherAPI) GetWeatherForecast(ctx context.Context, latitudes float
 		WeatherForecasts: []types.WeatherForecast{
 			{
 				Time:          "2024-09-06T00:00",
-				Temperature:   1.1,
-				Precipitation: 2.2,
-				WindSpeed:     3.3,
-				WindDirection: 4.4,
+				Temperature:   ptr(1.1),
+				Precipitation: ptr(2.2),
+				WindSpeed:     ptr(3.3),
+				WindDirection: ptr(4.4),
 			},
 			{
 				Time:          "2024-09-06T01:00",
-				Temperature:   11.1,
-				Precipitation: 21.2,
-				WindSpeed:     31.3,
-				WindDirection: 41.4,
+				Temperature:   ptr(11.1),
+				Precipitation: ptr(21.2),
+				WindSpeed:     ptr(31.3),
+				WindDirection: ptr(41.4),
 			},
 		},
 		HasPrecipitationToday: true,
@@ -59,17 +81,17 @@ func (f *fakeWeatherAPI) GetWeatherForecasts(ctx context.Context, latitudes []fl
 			WeatherForecasts: []types.WeatherForecast{
 				{
 					Time:          "2024-09-06T00:00",
-					Temperature:   0.1 + float64(i),
-					Precipitation: 0.2 + float64(i),
-					WindSpeed:     0.3 + float64(i),
-					WindDirection: 0.4 + float64(i),
+					Temperature:   ptr(0.1 + float64(i)),
+					Precipitation: ptr(0.2 + float64(i)),
+					WindSpeed:     ptr(0.3 + float64(i)),
+					WindDirection: ptr(0.4 + float64(i)),
 				},
 				{
 					Time:          "2024-09-06T01:00",
-					Temperature:   0.1 + float64(i*10),
-					Precipitation: 0.2 + float64(i*10),
-					WindSpeed:     0.3 + float64(i*10),
-					WindDirection: 0.4 + float64(i*10),
+					Temperature:   ptr(0.1 + float64(i*10)),
+					Precipitation: ptr(0.2 + float64(i*10)),
+					WindSpeed:     ptr(0.3 + float64(i*10)),
+					WindDirection: ptr(0.4 + float64(i*10)),
 				},
 			},
 		})
@@ -86,11 +108,124 @@ func (f *fakeWeatherAPI) GetElevations(ctx context.Context, latitude []float64,
 	return res, nil
 }
 
-type fakeDB struct{}
+func (f *fakeWeatherAPI) GetRiverDischarges(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([][]types.RiverDischarge, error) {
+	discharges := make([][]types.RiverDischarge, 0, len(latitudes))
+	for range latitudes {
+		discharges = append(discharges, []types.RiverDischarge{
+			{Date: "2024-09-06", Discharge: ptr(40.0)},
+			{Date: "2024-09-07", Discharge: ptr(120.0)},
+		})
+	}
+
+	return discharges, nil
+}
+
+func (f *fakeWeatherAPI) GetTiltedIrradiance(ctx context.Context, latitude float64, longitude float64, array types.SolarArrayConfig, forecastDays int) ([]types.TiltedIrradiance, error) {
+	// Trackers catch more of the sun than fixed arrays.
+	irradiance := 800.0
+	if array.Tracking != types.SolarTrackingFixed {
+		irradiance = 1000.0
+	}
+
+	return []types.TiltedIrradiance{
+		{Time: "2024-09-06T12:00", Irradiance: ptr(irradiance)},
+		{Time: "2024-09-07T12:00", Irradiance: ptr(0.0)},
+	}, nil
+}
+
+// GetWeatherObservations observes the same weather at every location, the first two hours of the day,
+// without shortwave radiation.
+func (f *fakeWeatherAPI) GetWeatherObservations(ctx context.Context, latitudes []float64, longitudes []float64, start time.Time, end time.Time) ([][]types.WeatherForecast, error) {
+	observations := make([][]types.WeatherForecast, 0, len(latitudes))
+	for range latitudes {
+		observations = append(observations, []types.WeatherForecast{
+			{Time: start.Format(time.DateOnly) + "T00:00", Temperature: ptr(1.0), Precipitation: ptr(0.0), WindSpeed: ptr(2.0), WindSpeed100m: ptr(4.0)},
+			{Time: start.Format(time.DateOnly) + "T01:00", Temperature: ptr(9.0), Precipitation: ptr(0.0), WindSpeed: ptr(20.0), WindSpeed100m: ptr(40.0)},
+		})
+	}
+
+	return observations, nil
+}
+
+var (
+	fakeLocationZurich = types.Location{
+		ID: 2657896, Name: "Zurich", Latitude: 47.36667, Longitude: 8.55, Elevation: 429,
+		CountryCode: "CH", Country: "Switzerland", Admin1: "Zurich", Admin2: "Zurich District",
+		Timezone: "Europe/Zurich", Population: 341730,
+	}
+	fakeLocationSpringfieldIL = types.Location{
+		ID: 4250542, Name: "Springfield", Latitude: 39.80172, Longitude: -89.64371, Elevation: 177,
+		CountryCode: "US", Country: "United States", Admin1: "Illinois", Admin2: "Sangamon",
+		Timezone: "America/Chicago", Population: 116565,
+	}
+	fakeLocationSpringfieldMO = types.Location{
+		ID: 4409896, Name: "Springfield", Latitude: 37.21533, Longitude: -93.29824, Elevation: 396,
+		CountryCode: "US", Country: "United States", Admin1: "Missouri", Admin2: "Greene",
+		Timezone: "America/Chicago", Population: 166810,
+	}
+)
+
+func (f *fakeWeatherAPI) SearchLocations(ctx context.Context, name string, count int) ([]types.Location, error) {
+	var locations []types.Location
+	switch name {
+	case "Zurich":
+		locations = []types.Location{fakeLocationZurich}
+	case "Springfield":
+		locations = []types.Location{fakeLocationSpringfieldMO, fakeLocationSpringfieldIL}
+	default:
+		locations = []types.Location{}
+	}
+
+	if len(locations) > count {
+		locations = locations[:count]
+	}
+	return locations, nil
+}
+
+func (f *fakeWeatherAPI) Usage() []types.RateLimitWindow {
+	return []types.RateLimitWindow{
+		{
+			Window:    "minute",
+			Limit:     600,
+			Used:      10,
+			Available: 590,
+			ResetsAt:  time.Date(2024, 9, 6, 0, 1, 0, 0, time.UTC),
+		},
+	}
+}
+
+func (f *fakeWeatherAPI) SnapToGrid(coordinate float64) float64 {
+	return math.Round(coordinate*100) / 100
+}
+
+func (f *fakeWeatherAPI) CacheStats() types.CacheStats {
+	return types.CacheStats{Hits: 3, Misses: 1, Entries: 1}
+}
+
+// fakeDB keeps the alerts recorded and resolved by the alert evaluation.
+type fakeDB struct {
+	mu        sync.Mutex
+	recorded  []types.Alert
+	resolved  []int64
+	published []types.WebhookEventType
+	// production holds the recorded production readings by power plant and time.
+	production map[productionKey]types.ProductionReading
+	// issued holds the recorded issued forecasts, errorSums the saved forecast error sums.
+	issued    []types.IssuedForecast
+	errorSums []types.ForecastErrorSums
+	// apiKeyHash is the API key hash of the last created organization.
+	apiKeyHash []byte
+}
+
+type productionKey struct {
+	powerPlantID int64
+	time         time.Time
+}
 
 func (f *fakeDB) CreatePowerPlant(ctx context.Context, powerPlant *types.PowerPlant) (*types.PowerPlant, error) {
 	powerPlant.ID = 1
 	powerPlant.CreatedAt = time.Now()
+	powerPlant.ResolveElevation()
 	return powerPlant, nil
 }
 
@@ -99,13 +234,23 @@ func (f *fakeDB) UpdatePowerPlant(ctx context.Context, powerPlant *types.PowerPl
 		return nil, sql.ErrNoRows
 	}
 	powerPlant.UpdatedAt = time.Now()
+	powerPlant.ResolveElevation()
 	return powerPlant, nil
 }
 
+// fakeMeteredPowerPlants are the power plants of fakeDB with a capacity, for the production readings.
+var fakeMeteredPowerPlants = map[int64]types.PowerPlant{
+	101: {ID: 101, Name: "Metered Wind", PowerPlantMetadata: types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(30.0)}},
+	102: {ID: 102, Name: "Metered Storage", PowerPlantMetadata: types.PowerPlantMetadata{Type: types.PowerPlantTypeStorage, CapacityMW: ptr(5.0)}},
+}
+
 func (f *fakeDB) GetPowerPlant(ctx context.Context, id int64) (*types.PowerPlant, error) {
 	if id == 999 {
 		return nil, sql.ErrNoRows
 	}
+	if powerPlant, ok := fakeMeteredPowerPlants[id]; ok {
+		return &powerPlant, nil
+	}
 	return &types.PowerPlant{
 		ID:        id,
 		Name:      "My Cool Power Plant",
@@ -116,23 +261,69 @@ func (f *fakeDB) GetPowerPlant(ctx context.Context, id int64) (*types.PowerPlant
 	}, nil
 }
 
+// fakePowerPlantHistory are the versions of the power plant 1, renamed on 2024-09-01.
+var fakePowerPlantHistory = []types.PowerPlantVersion{
+	{
+		PowerPlant: types.PowerPlant{ID: 1, Name: "Old Power Plant", Latitude: 22.11, Longitude: 33.11},
+		ValidFrom:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:    ptr(time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)),
+	},
+	{
+		PowerPlant: types.PowerPlant{ID: 1, Name: "My Cool Power Plant", Latitude: 22.11, Longitude: 33.11},
+		ValidFrom:  time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func (f *fakeDB) GetPowerPlantAsOf(ctx context.Context, id int64, asOf time.Time) (*types.PowerPlant, error) {
+	for _, version := range fakePowerPlantHistory {
+		if version.ID == id && !asOf.Before(version.ValidFrom) && (version.ValidTo == nil || asOf.Before(*version.ValidTo)) {
+			return &version.PowerPlant, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDB) GetPowerPlantHistory(ctx context.Context, id int64) ([]types.PowerPlantVersion, error) {
+	versions := []types.PowerPlantVersion{}
+	for _, version := range fakePowerPlantHistory {
+		if version.ID == id {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// fakeSurveyedPowerPlantID is the power plant of fakeDB with a surveyed elevation.
+const fakeSurveyedPowerPlantID = 2
+
 func (f *fakeDB) GetPowerPlantForUpdate(ctx context.Context, id int64) (*types.PowerPlant, error) {
 	if id == 999 {
 		return nil, sql.ErrNoRows
 	}
-	return &types.PowerPlant{
+	powerPlant := &types.PowerPlant{
 		ID:        id,
 		Name:      "My Cool Power Plant",
 		Latitude:  22.11,
 		Longitude: 33.11,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-	}, nil
+		PowerPlantMetadata: types.PowerPlantMetadata{
+			Type:   types.PowerPlantTypeOther,
+			Status: types.PowerPlantStatusOperational,
+		},
+	}
+	if id == fakeSurveyedPowerPlantID {
+		powerPlant.ElevationOverride = ptr(1500.0)
+	}
+	return powerPlant, nil
 }
 
+// fakePowerPlantCount is the number of power plants in fakeDB.
+const fakePowerPlantCount = 5
+
 func (f *fakeDB) GetPowerPlants(ctx context.Context, lastID int64, count int) ([]types.PowerPlant, error) {
 	powerPlants := make([]types.PowerPlant, 0, count)
-	for i := lastID + 1; i <= lastID+int64(count); i++ {
+	for i := lastID + 1; i <= lastID+int64(count) && i <= fakePowerPlantCount; i++ {
 		powerPlants = append(powerPlants, types.PowerPlant{
 			ID:        i,
 			Name:      fmt.Sprintf("My Cool Power Plant %d", i),
@@ -145,3 +336,720 @@ func (f *fakeDB) GetPowerPlants(ctx context.Context, lastID int64, count int) ([
 
 	return powerPlants, nil
 }
+
+// fakePortfolio are the power plants of fakeDB matching a filter, one of each type with a generation
+// model, a storage power plant, a wind power plant with an unknown turbine model and a decommissioned
+// solar power plant.
+var fakePortfolio = []types.PowerPlant{
+	{ID: 1, Name: "Wind", Latitude: 52.1, Longitude: 4.3, Tags: []string{"north-sea", "offshore"}, PowerPlantMetadata: types.PowerPlantMetadata{
+		Type: types.PowerPlantTypeWind, CapacityMW: ptr(30.0), Status: types.PowerPlantStatusOperational,
+	}},
+	{ID: 2, Name: "Solar", Latitude: 37.4, Longitude: -5.9, PowerPlantMetadata: types.PowerPlantMetadata{
+		Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.5), Status: types.PowerPlantStatusOperational,
+	}},
+	{ID: 3, Name: "Hydro", Latitude: 46.2, Longitude: 6.1, PowerPlantMetadata: types.PowerPlantMetadata{
+		Type: types.PowerPlantTypeHydro, CapacityMW: ptr(20.0), DesignDischargeM3s: ptr(80.0), Status: types.PowerPlantStatusOperational,
+	}},
+	{ID: 4, Name: "Storage", Latitude: 48.8, Longitude: 2.3, PowerPlantMetadata: types.PowerPlantMetadata{
+		Type: types.PowerPlantTypeStorage, CapacityMW: ptr(5.0), Status: types.PowerPlantStatusOperational,
+	}},
+	{ID: 5, Name: "Unknown Turbines", Latitude: 55.7, Longitude: 12.5, Tags: []string{"offshore"}, PowerPlantMetadata: types.PowerPlantMetadata{
+		Type: types.PowerPlantTypeWind, CapacityMW: ptr(10.0), TurbineModelID: ptr(int64(999)), TurbineCount: ptr(5),
+		Status: types.PowerPlantStatusOperational,
+	}},
+	{ID: 6, Name: "Decommissioned Solar", Latitude: 40.4, Longitude: -3.7, PowerPlantMetadata: types.PowerPlantMetadata{
+		Type: types.PowerPlantTypeSolar, CapacityMW: ptr(15.0), DCCapacityMW: ptr(18.0), Status: types.PowerPlantStatusDecommissioned,
+	}},
+}
+
+// GetPowerPlantsByFilter only filters fakePortfolio by type, status, portfolio and tags.
+func (f *fakeDB) GetPowerPlantsByFilter(ctx context.Context, filter types.PowerPlantFilter, lastID int64, count int) ([]types.PowerPlant, error) {
+	powerPlants := make([]types.PowerPlant, 0, count)
+	for _, powerPlant := range fakePortfolio {
+		if powerPlant.ID <= lastID || len(powerPlants) == count {
+			continue
+		}
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, powerPlant.Type) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, powerPlant.Status) {
+			continue
+		}
+		if filter.PortfolioID != nil && !slices.Contains(fakePortfolioMembers[*filter.PortfolioID], powerPlant.ID) {
+			continue
+		}
+		if slices.ContainsFunc(filter.Tags, func(tag string) bool { return !slices.Contains(powerPlant.Tags, tag) }) {
+			continue
+		}
+		powerPlants = append(powerPlants, powerPlant)
+	}
+
+	return powerPlants, nil
+}
+
+func (f *fakeDB) AddPowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	if id == 999 {
+		return nil, sql.ErrNoRows
+	}
+	return &types.PowerPlant{ID: id, Name: "My Cool Power Plant", Tags: tags}, nil
+}
+
+func (f *fakeDB) RemovePowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	if id == 999 {
+		return nil, sql.ErrNoRows
+	}
+	return &types.PowerPlant{ID: id, Name: "My Cool Power Plant", Tags: []string{}}, nil
+}
+
+// fakePortfolios are the portfolios in fakeDB, fakePortfolioMembers their power plants of fakePortfolio.
+var (
+	fakePortfolios = []types.Portfolio{
+		{ID: 7, Name: "Fund I", Description: ptr("First renewable fund")},
+		{ID: 8, Name: "Nordics"},
+	}
+	fakePortfolioMembers = map[int64][]int64{
+		7: {1, 2, 3},
+		8: {5},
+	}
+)
+
+// fakePortfolioNameTaken returns types.ErrPortfolioNameTaken when another portfolio of fakePortfolios has the name.
+func fakePortfolioNameTaken(portfolio *types.Portfolio) error {
+	for _, p := range fakePortfolios {
+		if p.Name == portfolio.Name && p.ID != portfolio.ID {
+			return types.ErrPortfolioNameTaken
+		}
+	}
+	return nil
+}
+
+func (f *fakeDB) CreatePortfolio(ctx context.Context, portfolio *types.Portfolio) (*types.Portfolio, error) {
+	if err := fakePortfolioNameTaken(portfolio); err != nil {
+		return nil, err
+	}
+	portfolio.ID = 1
+	portfolio.CreatedAt = time.Now()
+	return portfolio, nil
+}
+
+func (f *fakeDB) UpdatePortfolio(ctx context.Context, portfolio *types.Portfolio) (*types.Portfolio, error) {
+	if err := fakePortfolioNameTaken(portfolio); err != nil {
+		return nil, err
+	}
+	portfolio.UpdatedAt = time.Now()
+	return portfolio, nil
+}
+
+func (f *fakeDB) DeletePortfolio(ctx context.Context, id int64) error {
+	if id == 999 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (f *fakeDB) GetPortfolio(ctx context.Context, id int64) (*types.Portfolio, error) {
+	for _, portfolio := range fakePortfolios {
+		if portfolio.ID == id {
+			return &portfolio, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDB) GetPortfolios(ctx context.Context, lastID int64, count int) ([]types.Portfolio, error) {
+	portfolios := []types.Portfolio{}
+	for _, portfolio := range fakePortfolios {
+		if portfolio.ID > lastID && len(portfolios) < count {
+			portfolios = append(portfolios, portfolio)
+		}
+	}
+	return portfolios, nil
+}
+
+func (f *fakeDB) GetPowerPlantPortfolios(ctx context.Context, powerPlantID int64) ([]types.Portfolio, error) {
+	portfolios := []types.Portfolio{}
+	for _, portfolio := range fakePortfolios {
+		if slices.Contains(fakePortfolioMembers[portfolio.ID], powerPlantID) {
+			portfolios = append(portfolios, portfolio)
+		}
+	}
+	return portfolios, nil
+}
+
+func (f *fakeDB) AddPortfolioPowerPlants(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (int, error) {
+	if slices.Contains(powerPlantIDs, 999) {
+		return 0, types.ErrPowerPlantNotFound
+	}
+	return len(powerPlantIDs), nil
+}
+
+func (f *fakeDB) RemovePortfolioPowerPlants(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (int, error) {
+	return len(powerPlantIDs), nil
+}
+
+// fakeMissingElevationCount is the number of power plants without elevation in fakeDB.
+const fakeMissingElevationCount = 3
+
+func (f *fakeDB) GetPowerPlantsMissingElevation(ctx context.Context, lastID int64, count int) ([]types.PowerPlant, error) {
+	powerPlants := make([]types.PowerPlant, 0, count)
+	for i := lastID + 1; i <= lastID+int64(count) && i <= fakeMissingElevationCount; i++ {
+		powerPlants = append(powerPlants, types.PowerPlant{
+			ID:        i,
+			Name:      fmt.Sprintf("My Cool Power Plant %d", i),
+			Latitude:  0.22 + float64(i*10),
+			Longitude: 0.44 + float64(i*10),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return powerPlants, nil
+}
+
+func (f *fakeDB) UpdatePowerPlantElevation(ctx context.Context, id int64, elevation float64) error {
+	if id == 999 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// fakeTurbineModel is the turbine model of every ID in fakeDB.
+var fakeTurbineModel = types.TurbineModel{
+	Name:         "V90-2.0",
+	Manufacturer: ptr("Vestas"),
+	RatedPowerKW: 2000,
+	CutInSpeed:   3,
+	CutOutSpeed:  25,
+	PowerCurve: []types.PowerCurvePoint{
+		{WindSpeed: 3, PowerKW: 0},
+		{WindSpeed: 8, PowerKW: 886},
+		{WindSpeed: 12, PowerKW: 2000},
+		{WindSpeed: 25, PowerKW: 2000},
+	},
+}
+
+func (f *fakeDB) CreateTurbineModel(ctx context.Context, model *types.TurbineModel) (*types.TurbineModel, error) {
+	model.ID = 1
+	model.CreatedAt = time.Now()
+	return model, nil
+}
+
+func (f *fakeDB) UpdateTurbineModel(ctx context.Context, model *types.TurbineModel) (*types.TurbineModel, error) {
+	model.UpdatedAt = time.Now()
+	return model, nil
+}
+
+// fakeTurbineModelInUse is the ID of the turbine model assigned to power plants in fakeDB.
+const fakeTurbineModelInUse = 2
+
+func (f *fakeDB) DeleteTurbineModel(ctx context.Context, id int64) error {
+	switch id {
+	case 999:
+		return sql.ErrNoRows
+	case fakeTurbineModelInUse:
+		return types.ErrTurbineModelInUse
+	}
+	return nil
+}
+
+func (f *fakeDB) GetTurbineModel(ctx context.Context, id int64) (*types.TurbineModel, error) {
+	if id == 999 {
+		return nil, sql.ErrNoRows
+	}
+	model := fakeTurbineModel
+	model.ID = id
+	return &model, nil
+}
+
+func (f *fakeDB) GetTurbineModels(ctx context.Context, lastID int64, count int) ([]types.TurbineModel, error) {
+	models := []types.TurbineModel{}
+	for i := lastID + 1; i <= lastID+int64(count) && i <= 3; i++ {
+		model := fakeTurbineModel
+		model.ID = i
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// fakeAlertRules are the alert rules in fakeDB, of the power plants 1 and 2.
+var fakeAlertRules = []types.AlertRule{
+	{ID: 11, PowerPlantID: 1, Variable: types.AlertVariableWindSpeed, Operator: types.AlertOperatorGreaterThan, Threshold: 0.25, HorizonHours: 48, Aggregation: types.AlertAggregationNone},
+	{ID: 21, PowerPlantID: 2, Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThan, Threshold: 20, HorizonHours: 48, Aggregation: types.AlertAggregationDailySum},
+	{ID: 22, PowerPlantID: 2, Variable: types.AlertVariablePrecipitation, Operator: types.AlertOperatorGreaterThanOrEqual, Threshold: 10, HorizonHours: 24, Aggregation: types.AlertAggregationDailySum},
+}
+
+func (f *fakeDB) CreateAlertRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error) {
+	if rule.PowerPlantID == 999 {
+		return nil, types.ErrPowerPlantNotFound
+	}
+	rule.ID = 1
+	rule.CreatedAt = time.Now()
+	return rule, nil
+}
+
+func (f *fakeDB) UpdateAlertRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error) {
+	rule.UpdatedAt = time.Now()
+	return rule, nil
+}
+
+func (f *fakeDB) DeleteAlertRule(ctx context.Context, id int64) error {
+	if id == 999 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (f *fakeDB) GetAlertRule(ctx context.Context, id int64) (*types.AlertRule, error) {
+	for _, rule := range fakeAlertRules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDB) GetAlertRules(ctx context.Context, powerPlantIDs []int64) ([]types.AlertRule, error) {
+	rules := []types.AlertRule{}
+	for _, rule := range fakeAlertRules {
+		for _, id := range powerPlantIDs {
+			if rule.PowerPlantID == id {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules, nil
+}
+
+func (f *fakeDB) RecordAlert(ctx context.Context, alert *types.Alert) (*types.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recorded = append(f.recorded, *alert)
+	return alert, nil
+}
+
+// ResolveAlert resolves an active alert for every rule.
+func (f *fakeDB) ResolveAlert(ctx context.Context, ruleID int64, resolvedAt time.Time) (*types.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resolved = append(f.resolved, ruleID)
+
+	alert := fakeAlert(1, 1, &resolvedAt)
+	alert.RuleID = &ruleID
+	return &alert, nil
+}
+
+// fakeAlert is the alert of every power plant in fakeDB, active unless resolvedAt is set.
+func fakeAlert(id int64, powerPlantID int64, resolvedAt *time.Time) types.Alert {
+	return types.Alert{
+		ID:           id,
+		RuleID:       ptr(int64(11)),
+		PowerPlantID: powerPlantID,
+		Variable:     types.AlertVariableWindSpeed,
+		Operator:     types.AlertOperatorGreaterThan,
+		Threshold:    90,
+		Aggregation:  types.AlertAggregationNone,
+		ForecastTime: "2024-09-06T01:00",
+		Value:        95.5,
+		TriggeredAt:  time.Date(2024, 9, 5, 12, 0, 0, 0, time.UTC),
+		LastSeenAt:   time.Date(2024, 9, 5, 18, 0, 0, 0, time.UTC),
+		ResolvedAt:   resolvedAt,
+	}
+}
+
+func (f *fakeDB) GetActiveAlerts(ctx context.Context, powerPlantID int64) ([]types.Alert, error) {
+	return []types.Alert{fakeAlert(1, powerPlantID, nil)}, nil
+}
+
+func (f *fakeDB) GetAlerts(ctx context.Context, powerPlantID *int64, lastID int64, count int) ([]types.Alert, error) {
+	alerts := []types.Alert{}
+	for i := lastID + 1; i <= lastID+int64(count) && i <= 3; i++ {
+		id := i
+		if powerPlantID != nil {
+			id = *powerPlantID
+		}
+		alerts = append(alerts, fakeAlert(i, id, ptr(time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC))))
+	}
+	return alerts, nil
+}
+
+func (f *fakeDB) CreateWebhookSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error) {
+	subscription.ID = 1
+	subscription.CreatedAt = time.Now()
+	return subscription, nil
+}
+
+func (f *fakeDB) UpdateWebhookSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error) {
+	subscription.UpdatedAt = time.Now()
+	return subscription, nil
+}
+
+func (f *fakeDB) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	if id == 999 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (f *fakeDB) GetWebhookSubscription(ctx context.Context, id int64) (*types.WebhookSubscription, error) {
+	if id == 999 {
+		return nil, sql.ErrNoRows
+	}
+	return &types.WebhookSubscription{
+		ID:         id,
+		URL:        "https://incidents.example.com/hooks/energy",
+		EventTypes: []types.WebhookEventType{types.WebhookEventAlertTriggered},
+		Secret:     "0123456789abcdef",
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func (f *fakeDB) GetWebhookSubscriptions(ctx context.Context, lastID int64, count int) ([]types.WebhookSubscription, error) {
+	return []types.WebhookSubscription{}, nil
+}
+
+func (f *fakeDB) EnqueueWebhookEvent(ctx context.Context, eventType types.WebhookEventType, payload []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = append(f.published, eventType)
+	return 1, nil
+}
+
+func (f *fakeDB) RedeliverWebhook(ctx context.Context, id int64) (*types.WebhookDelivery, error) {
+	if id == 999 {
+		return nil, sql.ErrNoRows
+	}
+	return &types.WebhookDelivery{
+		ID:             id,
+		SubscriptionID: 1,
+		EventType:      types.WebhookEventAlertTriggered,
+		Payload:        `{"type":"ALERT_TRIGGERED"}`,
+		Status:         types.WebhookDeliveryPending,
+		NextAttemptAt:  ptr(time.Now()),
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+func (f *fakeDB) GetWebhookDeliveries(ctx context.Context, subscriptionID *int64, status *types.WebhookDeliveryStatus, lastID int64, count int) ([]types.WebhookDelivery, error) {
+	return []types.WebhookDelivery{}, nil
+}
+
+func (f *fakeDB) GetWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]types.WebhookDeliveryAttempt, error) {
+	return []types.WebhookDeliveryAttempt{}, nil
+}
+
+func (f *fakeDB) RecordProductionReadings(ctx context.Context, readings []types.ProductionReading) (int, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.production == nil {
+		f.production = map[productionKey]types.ProductionReading{}
+	}
+	batch := map[productionKey]types.ProductionReading{}
+	for _, reading := range readings {
+		batch[productionKey{reading.PowerPlantID, reading.Time}] = reading
+	}
+
+	var inserted, updated int
+	for key, reading := range batch {
+		if _, ok := f.production[key]; ok {
+			updated++
+		} else {
+			inserted++
+		}
+		f.production[key] = reading
+	}
+	return inserted, updated, nil
+}
+
+func (f *fakeDB) GetProductionReadings(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ProductionReading, error) {
+	return []types.ProductionReading{}, nil
+}
+
+func (f *fakeDB) RecordIssuedForecasts(ctx context.Context, forecasts []types.IssuedForecast) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	recorded := 0
+	for _, forecast := range forecasts {
+		if slices.ContainsFunc(f.issued, func(issued types.IssuedForecast) bool {
+			return issued.PowerPlantID == forecast.PowerPlantID && issued.IssuedAt.Equal(forecast.IssuedAt) && issued.Time.Equal(forecast.Time)
+		}) {
+			continue
+		}
+		f.issued = append(f.issued, forecast)
+		recorded++
+	}
+	return recorded, nil
+}
+
+func (f *fakeDB) GetIssuedForecasts(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.IssuedForecast, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	forecasts := []types.IssuedForecast{}
+	for _, forecast := range f.issued {
+		if slices.Contains(powerPlantIDs, forecast.PowerPlantID) && !forecast.Time.Before(from) && forecast.Time.Before(to) {
+			forecasts = append(forecasts, forecast)
+		}
+	}
+	return forecasts, nil
+}
+
+func (f *fakeDB) GetIssuedForecastDays(ctx context.Context, before time.Time) ([]time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	days := []time.Time{}
+	for _, forecast := range f.issued {
+		day := forecast.Time.Truncate(24 * time.Hour)
+		if forecast.Time.Before(before) && !slices.ContainsFunc(days, day.Equal) {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	return days, nil
+}
+
+func (f *fakeDB) DeleteIssuedForecasts(ctx context.Context, before time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := len(f.issued)
+	f.issued = slices.DeleteFunc(f.issued, func(forecast types.IssuedForecast) bool {
+		return forecast.Time.Before(before)
+	})
+	return count - len(f.issued), nil
+}
+
+// GetHourlyProduction averages the recorded production readings at the end of their hour.
+func (f *fakeDB) GetHourlyProduction(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.ProductionReading, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type sum struct {
+		total float64
+		count int
+	}
+	sums := map[productionKey]*sum{}
+	for key, reading := range f.production {
+		hour := reading.Time.Truncate(time.Hour).Add(time.Hour)
+		if !slices.Contains(powerPlantIDs, key.powerPlantID) || hour.Before(from) || !hour.Before(to) ||
+			reading.Quality == types.ProductionQualitySuspect {
+			continue
+		}
+		k := productionKey{key.powerPlantID, hour}
+		if sums[k] == nil {
+			sums[k] = &sum{}
+		}
+		sums[k].total += reading.PowerMW
+		sums[k].count++
+	}
+
+	readings := []types.ProductionReading{}
+	for key, s := range sums {
+		readings = append(readings, types.ProductionReading{PowerPlantID: key.powerPlantID, Time: key.time, PowerMW: s.total / float64(s.count)})
+	}
+	return readings, nil
+}
+
+func (f *fakeDB) SaveForecastErrorSums(ctx context.Context, sums []types.ForecastErrorSums) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.errorSums = append(f.errorSums, sums...)
+	return nil
+}
+
+// GetForecastErrorSums returns the saved error sums of the power plant from the day from to the day to,
+// without adding up the days.
+func (f *fakeDB) GetForecastErrorSums(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ForecastErrorSums, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sums := []types.ForecastErrorSums{}
+	for _, s := range f.errorSums {
+		if s.PowerPlantID == powerPlantID && !s.Day.Before(from) && s.Day.Before(to) {
+			sums = append(sums, s)
+		}
+	}
+	return sums, nil
+}
+
+// fakeMaintenanceWindows are the maintenance windows in fakeDB, of the metered wind power plant 101.
+var fakeMaintenanceWindows = []types.MaintenanceWindow{
+	{ID: 31, PowerPlantID: 101, Start: time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC), End: time.Date(2024, 9, 6, 14, 0, 0, 0, time.UTC),
+		Reason: "Gearbox inspection", UnavailableMW: ptr(10.0)},
+	{ID: 32, PowerPlantID: 101, Start: time.Date(2024, 9, 7, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 9, 8, 0, 0, 0, 0, time.UTC),
+		Reason: "Grid works", UnavailablePercent: ptr(100.0)},
+}
+
+// fakeMaintenanceOverlap returns types.ErrMaintenanceOverlap when the window overlaps another of fakeMaintenanceWindows.
+func fakeMaintenanceOverlap(window *types.MaintenanceWindow) error {
+	for _, w := range fakeMaintenanceWindows {
+		if w.PowerPlantID == window.PowerPlantID && w.ID != window.ID && w.Overlap(window.Start, window.End) > 0 {
+			return types.ErrMaintenanceOverlap
+		}
+	}
+	return nil
+}
+
+func (f *fakeDB) CreateMaintenanceWindow(ctx context.Context, window *types.MaintenanceWindow) (*types.MaintenanceWindow, error) {
+	if window.PowerPlantID == 999 {
+		return nil, types.ErrPowerPlantNotFound
+	}
+	if err := fakeMaintenanceOverlap(window); err != nil {
+		return nil, err
+	}
+	window.ID = 1
+	window.CreatedAt = time.Now()
+	return window, nil
+}
+
+func (f *fakeDB) UpdateMaintenanceWindow(ctx context.Context, window *types.MaintenanceWindow) (*types.MaintenanceWindow, error) {
+	if err := fakeMaintenanceOverlap(window); err != nil {
+		return nil, err
+	}
+	window.UpdatedAt = time.Now()
+	return window, nil
+}
+
+func (f *fakeDB) DeleteMaintenanceWindow(ctx context.Context, id int64) error {
+	if id == 999 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (f *fakeDB) GetMaintenanceWindow(ctx context.Context, id int64) (*types.MaintenanceWindow, error) {
+	for _, window := range fakeMaintenanceWindows {
+		if window.ID == id {
+			return &window, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDB) GetMaintenanceWindows(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.MaintenanceWindow, error) {
+	windows := []types.MaintenanceWindow{}
+	for _, window := range fakeMaintenanceWindows {
+		if slices.Contains(powerPlantIDs, window.PowerPlantID) && window.Overlap(from, to) > 0 {
+			windows = append(windows, window)
+		}
+	}
+	return windows, nil
+}
+
+type fakeSnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[refreshKey]types.WeatherSnapshot
+}
+
+func newFakeSnapshotStore() *fakeSnapshotStore {
+	return &fakeSnapshotStore{snapshots: map[refreshKey]types.WeatherSnapshot{}}
+}
+
+func (f *fakeSnapshotStore) GetWeatherSnapshots(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	snapshots := make([]types.WeatherSnapshot, 0, len(latitudes))
+	for i := range latitudes {
+		if snapshot, ok := f.snapshots[refreshKey{point{latitudes[i], longitudes[i]}, forecastDays}]; ok {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+func (f *fakeSnapshotStore) SaveWeatherSnapshots(ctx context.Context, snapshots []types.WeatherSnapshot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, snapshot := range snapshots {
+		f.snapshots[refreshKey{point{snapshot.Latitude, snapshot.Longitude}, snapshot.ForecastDays}] = snapshot
+	}
+	return nil
+}
+
+// fakeOrganizations are the organizations in fakeDB, fakeAPIKey is the API key of the second one.
+var (
+	fakeOrganizations = []types.Organization{
+		{ID: 1, Name: "Default"},
+		{ID: 2, Name: "Trading"},
+	}
+	fakeAPIKey = "te_trading"
+)
+
+func (f *fakeDB) CreateOrganization(ctx context.Context, organization *types.Organization, apiKeyHash []byte) (*types.Organization, error) {
+	for _, o := range fakeOrganizations {
+		if o.Name == organization.Name {
+			return nil, types.ErrOrganizationNameTaken
+		}
+	}
+	f.mu.Lock()
+	f.apiKeyHash = apiKeyHash
+	f.mu.Unlock()
+
+	organization.ID = 3
+	return organization, nil
+}
+
+func (f *fakeDB) GetOrganization(ctx context.Context, id int64) (*types.Organization, error) {
+	for _, organization := range fakeOrganizations {
+		if organization.ID == id {
+			return &organization, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDB) GetOrganizationByAPIKey(ctx context.Context, apiKeyHash []byte) (*types.Organization, error) {
+	if bytes.Equal(apiKeyHash, hashAPIKey(fakeAPIKey)) {
+		return &fakeOrganizations[1], nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDB) GetOrganizations(ctx context.Context) ([]types.Organization, error) {
+	return fakeOrganizations, nil
+}
+
+var fakeAuditEvents = []types.AuditEvent{
+	{
+		ID:         1,
+		OccurredAt: time.Date(2024, 9, 1, 8, 0, 0, 0, time.UTC),
+		Actor:      "api-key:Default",
+		Action:     types.AuditActionCreate,
+		EntityType: types.AuditEntityPowerPlant,
+		EntityID:   1,
+		Changes:    []types.AuditChange{{Field: "name", After: ptr(`"Test Power Plant"`)}},
+	},
+	{
+		ID:         2,
+		OccurredAt: time.Date(2024, 9, 2, 8, 0, 0, 0, time.UTC),
+		Actor:      "system",
+		Action:     types.AuditActionUpdate,
+		EntityType: types.AuditEntityPowerPlant,
+		EntityID:   1,
+		Changes:    []types.AuditChange{{Field: "elevation", After: ptr("1000")}},
+	},
+}
+
+func (f *fakeDB) GetAuditEvents(ctx context.Context, filter types.AuditEventFilter, lastID int64, count int) ([]types.AuditEvent, error) {
+	events := []types.AuditEvent{}
+	for _, event := range fakeAuditEvents {
+		if event.ID <= lastID || len(events) == count {
+			continue
+		}
+		if filter.Actor != nil && event.Actor != *filter.Actor {
+			continue
+		}
+		if filter.From != nil && event.OccurredAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !event.OccurredAt.Before(*filter.To) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
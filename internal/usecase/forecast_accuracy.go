@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/generation"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// RecordIssuedForecasts records the hourly weather and generation forecasts of every power plant,
+// as served from the current weather model run, up to cfg.MaxLeadHours ahead, in batches of cfg.BatchSize
+// power plants. It runs after each forecast refresh of the prefetcher, so the forecasts are served
+// from the fresh snapshots. The forecasts of a run already recorded are kept, so each run is recorded once.
+// It returns the number of recorded forecasts.
+func (u *Usecase) RecordIssuedForecasts(ctx context.Context, cfg config.AccuracyConfig) (int, error) {
+	issuedAt := u.now().UTC().Truncate(u.snapshotCfg.ModelUpdateInterval)
+	today := issuedAt.Truncate(24 * time.Hour)
+	days := forecastDaysCovering(int(issuedAt.Sub(today)/time.Hour) + cfg.MaxLeadHours + 1)
+
+	var (
+		recorded int
+		lastID   int64
+	)
+	for {
+		powerPlants, err := u.db.GetPowerPlants(ctx, lastID, cfg.BatchSize)
+		if err != nil {
+			return recorded, fmt.Errorf("error getting power plants: %w", err)
+		}
+		if len(powerPlants) == 0 {
+			break
+		}
+
+		u.fetchUpstreamData(ctx, powerPlants, days)
+		inputs, errs := u.generationInputs(ctx, powerPlants, days)
+
+		var forecasts []types.IssuedForecast
+		for i, powerPlant := range powerPlants {
+			if powerPlant.ForecastErr != nil {
+				return recorded, powerPlant.ForecastErr
+			}
+
+			// Power plants without a generation forecast still have their weather tracked.
+			var generated []types.GenerationForecast
+			if errs[i] == nil {
+				generated, _ = generation.Forecast(powerPlant, inputs[i])
+			}
+
+			issued, err := issuedForecasts(powerPlant, generated, issuedAt, cfg.MaxLeadHours)
+			if err != nil {
+				return recorded, fmt.Errorf("error issuing forecasts of power plant %d: %w", powerPlant.ID, err)
+			}
+			forecasts = append(forecasts, issued...)
+		}
+
+		if len(forecasts) > 0 {
+			n, err := u.db.RecordIssuedForecasts(ctx, forecasts)
+			if err != nil {
+				return recorded, fmt.Errorf("error recording issued forecasts: %w", err)
+			}
+			recorded += n
+		}
+
+		if len(powerPlants) < cfg.BatchSize {
+			break
+		}
+		lastID = powerPlants[len(powerPlants)-1].ID
+	}
+
+	return recorded, nil
+}
+
+// issuedForecasts returns the forecasts of the power plant for the hours after issuedAt, up to maxLeadHours.
+// The generation forecast, one per weather forecast hour, may be empty.
+func issuedForecasts(powerPlant types.PowerPlant, generated []types.GenerationForecast, issuedAt time.Time, maxLeadHours int) ([]types.IssuedForecast, error) {
+	end := issuedAt.Add(time.Duration(maxLeadHours) * time.Hour)
+
+	var forecasts []types.IssuedForecast
+	for i, forecast := range powerPlant.WeatherForecasts {
+		t, err := time.Parse(types.ForecastTimeLayout, forecast.Time)
+		if err != nil {
+			return nil, err
+		}
+		if !t.After(issuedAt) || t.After(end) {
+			continue
+		}
+
+		issued := types.IssuedForecast{
+			PowerPlantID: powerPlant.ID,
+			IssuedAt:     issuedAt,
+			Time:         t,
+			Weather: types.WeatherForecast{
+				Temperature:        forecast.Temperature,
+				Precipitation:      forecast.Precipitation,
+				WindSpeed:          forecast.WindSpeed,
+				WindSpeed100m:      forecast.WindSpeed100m,
+				ShortwaveRadiation: forecast.ShortwaveRadiation,
+			},
+		}
+		if i < len(generated) {
+			issued.GenerationMW = generated[i].PowerMW
+		}
+		forecasts = append(forecasts, issued)
+	}
+	return forecasts, nil
+}
+
+// MaterializeForecastAccuracy compares the issued forecasts to what happened, for every day whose
+// observations are complete, cfg.ObservationDelay after its end. The weather is compared to the observations
+// of the weather archive and the generation to the hourly average of the metered production, where available.
+// The error sums of each day are saved per power plant, variable and lead time bucket of cfg.LeadBucketHours,
+// then the issued forecasts of the day are deleted. It returns the number of saved error sums.
+func (u *Usecase) MaterializeForecastAccuracy(ctx context.Context, cfg config.AccuracyConfig) (int, error) {
+	cutoff := u.now().UTC().Add(-cfg.ObservationDelay).Truncate(24 * time.Hour)
+	days, err := u.db.GetIssuedForecastDays(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("error getting issued forecast days: %w", err)
+	}
+
+	saved := 0
+	for _, day := range days {
+		n, err := u.materializeDay(ctx, day, cfg)
+		saved += n
+		if err != nil {
+			return saved, fmt.Errorf("error materializing forecast accuracy of %s: %w", day.Format(time.DateOnly), err)
+		}
+
+		if _, err := u.db.DeleteIssuedForecasts(ctx, day.Add(24*time.Hour)); err != nil {
+			return saved, fmt.Errorf("error deleting issued forecasts: %w", err)
+		}
+	}
+
+	return saved, nil
+}
+
+// accuracyKey identifies the error sums of a power plant variable and lead time bucket.
+type accuracyKey struct {
+	powerPlantID  int64
+	variable      types.AccuracyVariable
+	leadHoursFrom int
+}
+
+// plantHour identifies an hour of a power plant.
+type plantHour struct {
+	powerPlantID int64
+	time         time.Time
+}
+
+// materializeDay saves the error sums of the forecasts for the hours of the day,
+// in batches of cfg.BatchSize power plants.
+func (u *Usecase) materializeDay(ctx context.Context, day time.Time, cfg config.AccuracyConfig) (int, error) {
+	end := day.Add(24 * time.Hour)
+
+	var (
+		saved  int
+		lastID int64
+	)
+	for {
+		powerPlants, err := u.db.GetPowerPlants(ctx, lastID, cfg.BatchSize)
+		if err != nil {
+			return saved, fmt.Errorf("error getting power plants: %w", err)
+		}
+		if len(powerPlants) == 0 {
+			break
+		}
+
+		ids := make([]int64, 0, len(powerPlants))
+		for _, powerPlant := range powerPlants {
+			ids = append(ids, powerPlant.ID)
+		}
+		issued, err := u.db.GetIssuedForecasts(ctx, ids, day, end)
+		if err != nil {
+			return saved, fmt.Errorf("error getting issued forecasts: %w", err)
+		}
+
+		if len(issued) > 0 {
+			sums, err := u.forecastErrorSums(ctx, powerPlants, issued, day, cfg.LeadBucketHours)
+			if err != nil {
+				return saved, err
+			}
+			if err := u.db.SaveForecastErrorSums(ctx, sums); err != nil {
+				return saved, fmt.Errorf("error saving forecast error sums: %w", err)
+			}
+			saved += len(sums)
+		}
+
+		if len(powerPlants) < cfg.BatchSize {
+			break
+		}
+		lastID = powerPlants[len(powerPlants)-1].ID
+	}
+
+	return saved, nil
+}
+
+// forecastErrorSums returns the error sums of the issued forecasts of the power plants for the hours of the day,
+// ordered by power plant, variable and lead time. Hours without a forecasted or an observed value are skipped.
+func (u *Usecase) forecastErrorSums(ctx context.Context, powerPlants []types.PowerPlant, issued []types.IssuedForecast, day time.Time, leadBucketHours int) ([]types.ForecastErrorSums, error) {
+	forecasted := map[int64]bool{}
+	for _, f := range issued {
+		forecasted[f.PowerPlantID] = true
+	}
+
+	var (
+		ids   []int64
+		lats  []float64
+		longs []float64
+	)
+	for _, powerPlant := range powerPlants {
+		if forecasted[powerPlant.ID] {
+			ids = append(ids, powerPlant.ID)
+			lats = append(lats, powerPlant.Latitude)
+			longs = append(longs, powerPlant.Longitude)
+		}
+	}
+
+	observations, err := u.weatherAPI.GetWeatherObservations(ctx, lats, longs, day, day)
+	if err != nil {
+		return nil, fmt.Errorf("error getting weather observations: %w", err)
+	}
+	if len(observations) != len(ids) {
+		return nil, fmt.Errorf("observation count %d does not match location count %d", len(observations), len(ids))
+	}
+	observed := make(map[int64]map[time.Time]types.WeatherForecast, len(ids))
+	for i, id := range ids {
+		observed[id] = make(map[time.Time]types.WeatherForecast, len(observations[i]))
+		for _, observation := range observations[i] {
+			t, err := time.Parse(types.ForecastTimeLayout, observation.Time)
+			if err != nil {
+				return nil, err
+			}
+			observed[id][t] = observation
+		}
+	}
+
+	production, err := u.db.GetHourlyProduction(ctx, ids, day, day.Add(24*time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("error getting hourly production: %w", err)
+	}
+	produced := map[plantHour]float64{}
+	for _, reading := range production {
+		produced[plantHour{reading.PowerPlantID, reading.Time}] = reading.PowerMW
+	}
+
+	sums := map[accuracyKey]*types.ForecastErrorSums{}
+	for _, f := range issued {
+		leadHoursFrom := f.LeadHours() / leadBucketHours * leadBucketHours
+		for _, variable := range types.AccuracyVariables {
+			value := f.Value(variable)
+			if value == nil {
+				continue
+			}
+
+			var actual *float64
+			if variable == types.AccuracyVariableGeneration {
+				if v, ok := produced[plantHour{f.PowerPlantID, f.Time}]; ok {
+					actual = &v
+				}
+			} else if observation, ok := observed[f.PowerPlantID][f.Time]; ok {
+				actual = variable.Value(observation)
+			}
+			if actual == nil {
+				continue
+			}
+
+			key := accuracyKey{f.PowerPlantID, variable, leadHoursFrom}
+			s, ok := sums[key]
+			if !ok {
+				s = &types.ForecastErrorSums{
+					PowerPlantID:  f.PowerPlantID,
+					Day:           day,
+					Variable:      variable,
+					LeadHoursFrom: leadHoursFrom,
+					LeadHoursTo:   leadHoursFrom + leadBucketHours,
+				}
+				sums[key] = s
+			}
+			s.Add(*value, *actual)
+		}
+	}
+
+	result := make([]types.ForecastErrorSums, 0, len(sums))
+	for _, s := range sums {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.PowerPlantID != b.PowerPlantID {
+			return a.PowerPlantID < b.PowerPlantID
+		}
+		if a.Variable != b.Variable {
+			return a.Variable < b.Variable
+		}
+		return a.LeadHoursFrom < b.LeadHoursFrom
+	})
+	return result, nil
+}
+
+// GetForecastAccuracy returns the accuracy of the forecasts of a power plant for the hours of the last days,
+// up to yesterday, per variable and lead time bucket. The recent days are only counted once they are
+// materialized, after the observation delay.
+func (u *Usecase) GetForecastAccuracy(ctx context.Context, powerPlantID int64, days int) ([]types.ForecastAccuracy, error) {
+	if days < 1 || days > types.MaxAccuracyDays {
+		return nil, types.ErrInvalidAccuracyDays
+	}
+
+	to := u.now().UTC().Truncate(24 * time.Hour)
+	sums, err := u.db.GetForecastErrorSums(ctx, powerPlantID, to.AddDate(0, 0, -days), to)
+	if err != nil {
+		u.logger.Printf("error getting forecast error sums: %v", err)
+		return nil, types.ErrInternal
+	}
+
+	accuracy := make([]types.ForecastAccuracy, 0, len(sums))
+	for _, s := range sums {
+		if s.Count > 0 {
+			accuracy = append(accuracy, types.NewForecastAccuracy(s))
+		}
+	}
+	return accuracy, nil
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUsecase_RecordIssuedForecasts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	db := &fakeDB{}
+	u := NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+	u.now = func() time.Time { return time.Date(2024, 9, 5, 23, 30, 0, 0, time.UTC) }
+
+	// Only the 00:00 forecast is within the lead time of the 23:00 run.
+	cfg := config.AccuracyConfig{MaxLeadHours: 1, LeadBucketHours: 1, BatchSize: 2}
+	recorded, err := u.RecordIssuedForecasts(ctx, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recorded != fakePowerPlantCount {
+		t.Fatalf("expected %d recorded forecasts, got: %d", fakePowerPlantCount, recorded)
+	}
+
+	expected := types.IssuedForecast{
+		PowerPlantID: 1,
+		IssuedAt:     time.Date(2024, 9, 5, 23, 0, 0, 0, time.UTC),
+		Time:         time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC),
+		Weather:      types.WeatherForecast{Temperature: ptr(0.1), Precipitation: ptr(0.2), WindSpeed: ptr(0.3)},
+	}
+	if diff := cmp.Diff(expected, db.issued[0]); diff != "" {
+		t.Fatalf("unexpected issued forecast (-want +got):\n%s", diff)
+	}
+
+	// The same run is recorded once.
+	recorded, err = u.RecordIssuedForecasts(ctx, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recorded != 0 {
+		t.Fatalf("expected no recorded forecast, got: %d", recorded)
+	}
+}
+
+func TestUsecase_MaterializeForecastAccuracy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	day := time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)
+	hour := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+	issued := func(powerPlantID int64, issuedAt, at int, temperature float64, windSpeed, generationMW *float64) types.IssuedForecast {
+		return types.IssuedForecast{
+			PowerPlantID: powerPlantID,
+			IssuedAt:     hour(issuedAt),
+			Time:         hour(at),
+			Weather:      types.WeatherForecast{Temperature: &temperature, WindSpeed: windSpeed},
+			GenerationMW: generationMW,
+		}
+	}
+
+	db := &fakeDB{
+		issued: []types.IssuedForecast{
+			issued(1, -1, 0, 3, ptr(2.0), ptr(10.0)),
+			issued(1, -1, 1, 8, nil, ptr(12.0)),
+			issued(1, -12, 1, 12, nil, nil),
+			// The observations of the next day are not complete yet.
+			issued(2, 23, 24, 20, nil, nil),
+		},
+		production: map[productionKey]types.ProductionReading{
+			{1, hour(-1)}:                       {PowerPlantID: 1, Time: hour(-1), PowerMW: 9},
+			{1, hour(-1).Add(30 * time.Minute)}: {PowerPlantID: 1, Time: hour(-1).Add(30 * time.Minute), PowerMW: 11},
+			{1, hour(0).Add(15 * time.Minute)}: {
+				PowerPlantID: 1, Time: hour(0).Add(15 * time.Minute), PowerMW: 14, Quality: types.ProductionQualitySuspect,
+			},
+		},
+	}
+	u := NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+	u.now = func() time.Time { return time.Date(2024, 9, 12, 3, 0, 0, 0, time.UTC) }
+
+	cfg := config.AccuracyConfig{LeadBucketHours: 12, ObservationDelay: 5 * 24 * time.Hour, BatchSize: 2}
+	saved, err := u.MaterializeForecastAccuracy(ctx, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sums := func(variable types.AccuracyVariable, leadHoursFrom, count int, sumError, sumAbsError, sumSquaredError float64) types.ForecastErrorSums {
+		return types.ForecastErrorSums{
+			PowerPlantID: 1, Day: day, Variable: variable, LeadHoursFrom: leadHoursFrom, LeadHoursTo: leadHoursFrom + 12,
+			Count: count, SumError: sumError, SumAbsError: sumAbsError, SumSquaredError: sumSquaredError,
+		}
+	}
+	// The generation at 01:00 has no production, the suspect reading is ignored.
+	expected := []types.ForecastErrorSums{
+		sums(types.AccuracyVariableGeneration, 0, 1, 0, 0, 0),
+		sums(types.AccuracyVariableTemperature, 0, 2, 1, 3, 5),
+		sums(types.AccuracyVariableTemperature, 12, 1, 3, 3, 9),
+		sums(types.AccuracyVariableWindSpeed, 0, 1, 0, 0, 0),
+	}
+	if diff := cmp.Diff(expected, db.errorSums); diff != "" {
+		t.Fatalf("unexpected error sums (-want +got):\n%s", diff)
+	}
+	if saved != len(expected) {
+		t.Fatalf("expected %d saved error sums, got: %d", len(expected), saved)
+	}
+	if len(db.issued) != 1 || db.issued[0].PowerPlantID != 2 {
+		t.Fatalf("expected only the forecasts of the next day to be kept, got: %+v", db.issued)
+	}
+}
+
+func TestUsecase_GetForecastAccuracy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	db := &fakeDB{
+		errorSums: []types.ForecastErrorSums{
+			{
+				PowerPlantID: 1, Day: time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC), Variable: types.AccuracyVariableTemperature,
+				LeadHoursFrom: 0, LeadHoursTo: 24, Count: 2, SumError: 1, SumAbsError: 3, SumSquaredError: 5,
+			},
+		},
+	}
+	u := NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+	u.now = func() time.Time { return time.Date(2024, 9, 12, 3, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		testName  string
+		days      int
+		expected  []types.ForecastAccuracy
+		expectErr error
+	}{
+		{
+			testName: "success",
+			days:     30,
+			expected: []types.ForecastAccuracy{
+				{
+					Variable: types.AccuracyVariableTemperature, LeadHoursFrom: 0, LeadHoursTo: 24,
+					Count: 2, MAE: 1.5, RMSE: math.Sqrt(2.5), Bias: 0.5,
+				},
+			},
+		},
+		{
+			testName: "success, day out of the period",
+			days:     3,
+			expected: []types.ForecastAccuracy{},
+		},
+		{
+			testName:  "failed, no days",
+			days:      0,
+			expectErr: types.ErrInvalidAccuracyDays,
+		},
+		{
+			testName:  "failed, too many days",
+			days:      types.MaxAccuracyDays + 1,
+			expectErr: types.ErrInvalidAccuracyDays,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			accuracy, err := u.GetForecastAccuracy(ctx, 1, tt.days)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, accuracy); diff != "" {
+				t.Fatalf("unexpected accuracy (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
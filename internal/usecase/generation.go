@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/generation"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// GetGenerationForecast returns the expected hourly output of a power plant returned by GetPowerPlant
+// or GetPowerPlants, computed from its weather forecasts. The irradiance on the panels of solar power
+// plants with a solar array and the river discharges of hydro power plants are fetched within the
+// forecast read timeout, and wind power plants use the power curve of their turbine model.
+// The output is reduced by the maintenance windows of the power plant.
+func (u *Usecase) GetGenerationForecast(ctx context.Context, powerPlant *types.PowerPlant) ([]types.GenerationForecast, error) {
+	forecasts, errs := u.GetGenerationForecasts(ctx, []*types.PowerPlant{powerPlant})
+	return forecasts[0], errs[0]
+}
+
+// GetGenerationForecasts returns the generation forecasts of the power plants, see GetGenerationForecast,
+// with their upstream calls batched, e.g. for a page of power plants. The forecasts and the errors are
+// returned at the index of their power plant.
+func (u *Usecase) GetGenerationForecasts(ctx context.Context, powerPlants []*types.PowerPlant) ([][]types.GenerationForecast, []error) {
+	forecasts := make([][]types.GenerationForecast, len(powerPlants))
+	errs := make([]error, len(powerPlants))
+
+	// The power plants are batched by their number of forecast days, every power plant of a page has the same.
+	batches := map[int][]int{}
+	for i, powerPlant := range powerPlants {
+		if powerPlant.ForecastErr != nil {
+			errs[i] = powerPlant.ForecastErr
+			continue
+		}
+		days := max((len(powerPlant.WeatherForecasts)+23)/24, 1)
+		batches[days] = append(batches[days], i)
+	}
+
+	for days, indexes := range batches {
+		batch := make([]types.PowerPlant, len(indexes))
+		for j, i := range indexes {
+			batch[j] = *powerPlants[i]
+		}
+
+		inputs, inputErrs := u.generationInputs(ctx, batch, days)
+		for j, i := range indexes {
+			if inputErrs[j] != nil {
+				errs[i] = inputErrs[j]
+				continue
+			}
+			forecasts[i], errs[i] = generation.Forecast(batch[j], inputs[j])
+		}
+	}
+
+	return forecasts, errs
+}
+
+// generationInputs returns the inputs of the generation models of the power plants, see GetGenerationForecast.
+// The upstream calls are batched: one river discharge call for every hydro power plant and concurrent
+// tilted irradiance calls, all within the forecast read timeout, each turbine model is read once and
+// the maintenance windows of every power plant are read at once.
+// A failed input only fails the power plants needing it, the errors are returned at their index.
+func (u *Usecase) generationInputs(ctx context.Context, powerPlants []types.PowerPlant, days int) ([]generation.Inputs, []error) {
+	inputs := make([]generation.Inputs, len(powerPlants))
+	errs := make([]error, len(powerPlants))
+
+	upstreamCtx, cancel := context.WithTimeout(ctx, u.readTimeouts.Forecast)
+	defer cancel()
+
+	var (
+		wg          sync.WaitGroup
+		hydro       []int
+		lats, longs []float64
+		models      = map[int64]*types.TurbineModel{}
+		modelErrs   = map[int64]error{}
+	)
+	for i, powerPlant := range powerPlants {
+		switch {
+		case powerPlant.Type == types.PowerPlantTypeSolar && powerPlant.SolarArray != nil:
+			wg.Add(1)
+			go func(i int, powerPlant types.PowerPlant) {
+				defer wg.Done()
+
+				irradiances, err := u.weatherAPI.GetTiltedIrradiance(upstreamCtx, powerPlant.Latitude, powerPlant.Longitude, *powerPlant.SolarArray, days)
+				if err != nil {
+					errs[i] = u.upstreamError("error getting tilted irradiance", err)
+					return
+				}
+				inputs[i].TiltedIrradiances = irradiances
+			}(i, powerPlant)
+		case powerPlant.Type == types.PowerPlantTypeHydro && powerPlant.DesignDischargeM3s != nil:
+			hydro = append(hydro, i)
+			lats = append(lats, powerPlant.Latitude)
+			longs = append(longs, powerPlant.Longitude)
+		case powerPlant.Type == types.PowerPlantTypeWind && powerPlant.TurbineModelID != nil:
+			id := *powerPlant.TurbineModelID
+			if _, ok := models[id]; !ok {
+				models[id], modelErrs[id] = u.GetTurbineModel(ctx, id)
+			}
+			inputs[i].TurbineModel, errs[i] = models[id], modelErrs[id]
+		}
+	}
+
+	if len(hydro) > 0 {
+		discharges, err := u.weatherAPI.GetRiverDischarges(upstreamCtx, lats, longs, days)
+		if err == nil && len(discharges) != len(hydro) {
+			err = fmt.Errorf("discharge count %d does not match location count %d", len(discharges), len(hydro))
+		}
+		if err != nil {
+			err = u.upstreamError("error getting river discharges", err)
+		}
+		for j, i := range hydro {
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			inputs[i].Discharges = discharges[j]
+		}
+	}
+	wg.Wait()
+
+	// The forecasts start today, their first hour covering the last hour of yesterday, a day of margin
+	// is read on each side.
+	today := u.now().UTC().Truncate(24 * time.Hour)
+	ids := make([]int64, len(powerPlants))
+	for i, powerPlant := range powerPlants {
+		ids[i] = powerPlant.ID
+	}
+	windows, err := u.db.GetMaintenanceWindows(ctx, ids, today.Add(-24*time.Hour), today.AddDate(0, 0, days+1))
+	if err != nil {
+		u.logger.Printf("error getting maintenance windows: %v", err)
+	}
+	for i, powerPlant := range powerPlants {
+		if err != nil {
+			if errs[i] == nil {
+				errs[i] = types.ErrInternal
+			}
+			continue
+		}
+		for _, window := range windows {
+			if window.PowerPlantID == powerPlant.ID {
+				inputs[i].MaintenanceWindows = append(inputs[i].MaintenanceWindows, window)
+			}
+		}
+	}
+
+	return inputs, errs
+}
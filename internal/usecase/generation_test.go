@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestUsecase_GetGenerationForecast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	forecasts := types.WeatherForecastProperties{
+		WeatherForecasts: []types.WeatherForecast{
+			{Time: "2024-09-06T12:00", Temperature: ptr(25.0), ShortwaveRadiation: ptr(1000.0), WindSpeed100m: ptr(50.0)},
+			{Time: "2024-09-07T12:00", Temperature: ptr(25.0), WindSpeed100m: ptr(10.0)},
+		},
+	}
+
+	tests := []struct {
+		testName   string
+		powerPlant *types.PowerPlant
+		expected   []types.GenerationForecast
+		expectErr  error
+	}{
+		{
+			testName: "success, solar",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata:        types.PowerPlantMetadata{Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.5)},
+				WeatherForecastProperties: forecasts,
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T12:00", PowerMW: ptr(9.03)},
+				{Time: "2024-09-07T12:00"},
+			},
+		},
+		{
+			// Cell at 50 °C: 12.5 MW * 0.8 * 0.9 * 0.86 * 0.96 from the tilted irradiance.
+			testName: "success, solar with a fixed array",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata: types.PowerPlantMetadata{
+					Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.5),
+					SolarArray: &types.SolarArrayConfig{Tilt: 35, Tracking: types.SolarTrackingFixed, TemperatureCoefficient: -0.004, SystemLosses: 0.14},
+				},
+				WeatherForecastProperties: forecasts,
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T12:00", PowerMW: ptr(7.4304)},
+				{Time: "2024-09-07T12:00", PowerMW: ptr(0.0)},
+			},
+		},
+		{
+			// 14.448 MW of DC output, clipped at the inverter limit.
+			testName: "success, solar with a dual axis tracker",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata: types.PowerPlantMetadata{
+					Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(20.0),
+					SolarArray: &types.SolarArrayConfig{Tracking: types.SolarTrackingDualAxis, TemperatureCoefficient: -0.004, SystemLosses: 0.14},
+				},
+				WeatherForecastProperties: forecasts,
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T12:00", PowerMW: ptr(10.0)},
+				{Time: "2024-09-07T12:00", PowerMW: ptr(0.0)},
+			},
+		},
+		{
+			testName: "success, wind",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata:        types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(30.0)},
+				WeatherForecastProperties: forecasts,
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T12:00", PowerMW: ptr(30.0)},
+				{Time: "2024-09-07T12:00", PowerMW: ptr(0.0)},
+			},
+		},
+		{
+			// 50 km/h is 13.9 m/s, the rated power of the turbines at any air density.
+			testName: "success, wind with turbines",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata: types.PowerPlantMetadata{
+					Type: types.PowerPlantTypeWind, CapacityMW: ptr(10.0), TurbineModelID: ptr(int64(1)), TurbineCount: ptr(5),
+				},
+				WeatherForecastProperties: forecasts,
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T12:00", PowerMW: ptr(10.0)},
+				{Time: "2024-09-07T12:00", PowerMW: ptr(0.0)},
+			},
+		},
+		{
+			testName: "success, hydro with the river discharges",
+			powerPlant: &types.PowerPlant{
+				Latitude:                  46.2,
+				Longitude:                 6.1,
+				PowerPlantMetadata:        types.PowerPlantMetadata{Type: types.PowerPlantTypeHydro, CapacityMW: ptr(20.0), DesignDischargeM3s: ptr(80.0)},
+				WeatherForecastProperties: forecasts,
+			},
+			expected: []types.GenerationForecast{
+				{Time: "2024-09-06T12:00", PowerMW: ptr(10.0)},
+				{Time: "2024-09-07T12:00", PowerMW: ptr(20.0)},
+			},
+		},
+		{
+			testName: "failed, hydro without design discharge",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata:        types.PowerPlantMetadata{Type: types.PowerPlantTypeHydro, CapacityMW: ptr(20.0)},
+				WeatherForecastProperties: forecasts,
+			},
+			expectErr: types.ErrDesignDischargeRequired,
+		},
+		{
+			testName: "failed, other power plant",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata:        defaultMetadata,
+				WeatherForecastProperties: forecasts,
+			},
+			expectErr: types.ErrNoGenerationModel,
+		},
+		{
+			testName: "failed, forecast not fetched",
+			powerPlant: &types.PowerPlant{
+				PowerPlantMetadata: types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(30.0)},
+				ForecastErr:        types.ErrUpstreamRateLimited,
+			},
+			expectErr: types.ErrUpstreamRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			generation, err := testUsecase.GetGenerationForecast(ctx, tt.powerPlant)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// shortDischargeWeatherAPI counts the river discharge calls of fakeWeatherAPI, and drops the last location
+// of their response when short is set.
+type shortDischargeWeatherAPI struct {
+	fakeWeatherAPI
+
+	short bool
+	calls int
+}
+
+func (s *shortDischargeWeatherAPI) GetRiverDischarges(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([][]types.RiverDischarge, error) {
+	s.calls++
+	discharges, err := s.fakeWeatherAPI.GetRiverDischarges(ctx, latitudes, longitudes, forecastDays)
+	if s.short {
+		discharges = discharges[:len(discharges)-1]
+	}
+	return discharges, err
+}
+
+func TestUsecase_GetGenerationForecasts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	forecasts := types.WeatherForecastProperties{
+		WeatherForecasts: []types.WeatherForecast{{Time: "2024-09-06T12:00", Temperature: ptr(25.0)}},
+	}
+	hydro := func(id int64) *types.PowerPlant {
+		return &types.PowerPlant{
+			ID:                        id,
+			Latitude:                  46.2,
+			Longitude:                 6.1 + float64(id),
+			PowerPlantMetadata:        types.PowerPlantMetadata{Type: types.PowerPlantTypeHydro, CapacityMW: ptr(20.0), DesignDischargeM3s: ptr(80.0)},
+			WeatherForecastProperties: forecasts,
+		}
+	}
+	wind := &types.PowerPlant{
+		ID:                        3,
+		PowerPlantMetadata:        types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(30.0)},
+		WeatherForecastProperties: forecasts,
+	}
+
+	tests := []struct {
+		testName   string
+		short      bool
+		expected   [][]types.GenerationForecast
+		expectErrs []error
+	}{
+		{
+			testName: "success, one river discharge call for the page",
+			expected: [][]types.GenerationForecast{
+				{{Time: "2024-09-06T12:00", PowerMW: ptr(10.0)}},
+				{{Time: "2024-09-06T12:00", PowerMW: ptr(10.0)}},
+				{{Time: "2024-09-06T12:00"}},
+			},
+			expectErrs: []error{nil, nil, nil},
+		},
+		{
+			testName:   "failed, hydro power plants when the flood API returns fewer locations",
+			short:      true,
+			expected:   [][]types.GenerationForecast{nil, nil, {{Time: "2024-09-06T12:00"}}},
+			expectErrs: []error{types.ErrInternal, types.ErrInternal, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			weatherAPI := &shortDischargeWeatherAPI{short: tt.short}
+			u := NewUsecase(weatherAPI, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+
+			generation, errs := u.GetGenerationForecasts(ctx, []*types.PowerPlant{hydro(1), hydro(2), wind})
+			if diff := cmp.Diff(tt.expectErrs, errs, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("unexpected errors (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expected, generation, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+			if weatherAPI.calls != 1 {
+				t.Fatalf("expected 1 river discharge call, got: %d", weatherAPI.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+const (
+	// maxGeocodeCount is the maximum number of locations of one geocoding API request.
+	maxGeocodeCount = 100
+	// placeCandidateCount is the number of locations fetched to resolve a place name.
+	placeCandidateCount = 10
+)
+
+// Geocode returns at most count locations matching the query, ordered by relevance.
+func (u *Usecase) Geocode(ctx context.Context, query string, count int) ([]types.Location, error) {
+	query = strings.TrimSpace(query)
+	if len([]rune(query)) < 2 {
+		return nil, errors.New("query must have at least 2 characters")
+	}
+	if count < 1 || count > maxGeocodeCount {
+		return nil, errors.New("count must be between 1 and 100")
+	}
+
+	locations, err := u.weatherAPI.SearchLocations(ctx, query, count)
+	if err != nil {
+		return nil, u.upstreamError("error searching locations", err)
+	}
+
+	return locations, nil
+}
+
+// resolvePlace returns the only location matching the place name.
+// The place name can be narrowed down with comma separated qualifiers matching
+// the country, its code or an administrative area, e.g. "Springfield, Illinois" or "Berlin, DE".
+// It returns an AmbiguousPlaceError with the candidates when more than one location matches.
+func (u *Usecase) resolvePlace(ctx context.Context, placeName string) (*types.Location, error) {
+	parts := strings.Split(placeName, ",")
+	qualifiers := make([]string, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		if qualifier := strings.TrimSpace(part); qualifier != "" {
+			qualifiers = append(qualifiers, qualifier)
+		}
+	}
+
+	locations, err := u.Geocode(ctx, parts[0], placeCandidateCount)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]types.Location, 0, len(locations))
+	for _, location := range locations {
+		if matchesQualifiers(location, qualifiers) {
+			candidates = append(candidates, location)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, types.ErrPlaceNotFound
+	case 1:
+		return &candidates[0], nil
+	default:
+		return nil, &types.AmbiguousPlaceError{
+			PlaceName:  placeName,
+			Candidates: candidates,
+		}
+	}
+}
+
+// matchesQualifiers returns true if every qualifier matches the country, the country code
+// or an administrative area of the location.
+func matchesQualifiers(location types.Location, qualifiers []string) bool {
+	for _, qualifier := range qualifiers {
+		if !strings.EqualFold(qualifier, location.Country) &&
+			!strings.EqualFold(qualifier, location.CountryCode) &&
+			!strings.EqualFold(qualifier, location.Admin1) &&
+			!strings.EqualFold(qualifier, location.Admin2) {
+			return false
+		}
+	}
+	return true
+}
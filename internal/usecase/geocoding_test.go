@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUsecase_Geocode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		query     string
+		count     int
+		expected  []types.Location
+		expectErr error
+	}{
+		{
+			testName: "success",
+			query:    " Springfield ",
+			count:    10,
+			expected: []types.Location{fakeLocationSpringfieldMO, fakeLocationSpringfieldIL},
+		},
+		{
+			testName: "success, limited count",
+			query:    "Springfield",
+			count:    1,
+			expected: []types.Location{fakeLocationSpringfieldMO},
+		},
+		{
+			testName: "success, no match",
+			query:    "Atlantis",
+			count:    10,
+			expected: []types.Location{},
+		},
+		{
+			testName:  "failed, short query",
+			query:     "Z",
+			count:     10,
+			expectErr: errors.New("query must have at least 2 characters"),
+		},
+		{
+			testName:  "failed, invalid count",
+			query:     "Zurich",
+			count:     101,
+			expectErr: errors.New("count must be between 1 and 100"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			locations, err := testUsecase.Geocode(ctx, tt.query, tt.count)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, locations); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
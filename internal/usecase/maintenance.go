@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// CreateMaintenanceWindow validates and creates a new maintenance window, against the capacity of its power plant.
+// The window must not overlap another window of the power plant.
+func (u *Usecase) CreateMaintenanceWindow(ctx context.Context, window types.MaintenanceWindow) (*types.MaintenanceWindow, error) {
+	if window.PowerPlantID == 0 {
+		return nil, errors.New("powerPlantID is required")
+	}
+
+	capacityMW, err := u.maintenanceCapacity(ctx, window.PowerPlantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := window.Validate(capacityMW); err != nil {
+		return nil, err
+	}
+
+	created, err := u.db.CreateMaintenanceWindow(ctx, &window)
+	if err != nil {
+		if errors.Is(err, types.ErrPowerPlantNotFound) || errors.Is(err, types.ErrMaintenanceOverlap) {
+			return nil, err
+		}
+		u.logger.Printf("error creating maintenance window: %v", err)
+		return nil, types.ErrInternal
+	}
+	return created, nil
+}
+
+// UpdateMaintenanceWindow updates a maintenance window by ID, the window is validated again as a whole after the update.
+func (u *Usecase) UpdateMaintenanceWindow(ctx context.Context, id int64, update types.MaintenanceWindowUpdate) (*types.MaintenanceWindow, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+	if update.UnavailableMW != nil && update.UnavailablePercent != nil {
+		return nil, types.ErrInvalidMaintenanceUnavailable
+	}
+
+	window, err := u.db.GetMaintenanceWindow(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting maintenance window: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+
+	capacityMW, err := u.maintenanceCapacity(ctx, window.PowerPlantID)
+	if err != nil {
+		return nil, err
+	}
+	update.Apply(window)
+	if err := window.Validate(capacityMW); err != nil {
+		return nil, err
+	}
+
+	window, err = u.db.UpdateMaintenanceWindow(ctx, window)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.New("id not found")
+		case errors.Is(err, types.ErrPowerPlantNotFound), errors.Is(err, types.ErrMaintenanceOverlap):
+			return nil, err
+		default:
+			u.logger.Printf("error updating maintenance window: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return window, nil
+}
+
+// maintenanceCapacity returns the capacity of the power plant, the unavailable capacity of its windows is validated against.
+func (u *Usecase) maintenanceCapacity(ctx context.Context, powerPlantID int64) (*float64, error) {
+	powerPlant, err := u.db.GetPowerPlant(ctx, powerPlantID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, types.ErrPowerPlantNotFound
+		default:
+			u.logger.Printf("error getting power plant: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return powerPlant.CapacityMW, nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window by ID.
+func (u *Usecase) DeleteMaintenanceWindow(ctx context.Context, id int64) error {
+	if id == 0 {
+		return errors.New("id is required")
+	}
+
+	err := u.db.DeleteMaintenanceWindow(ctx, id)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errors.New("id not found")
+	default:
+		u.logger.Printf("error deleting maintenance window: %v", err)
+		return types.ErrInternal
+	}
+}
+
+// GetMaintenanceWindow returns a maintenance window by ID.
+func (u *Usecase) GetMaintenanceWindow(ctx context.Context, id int64) (*types.MaintenanceWindow, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	window, err := u.db.GetMaintenanceWindow(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting maintenance window: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return window, nil
+}
+
+// GetMaintenanceWindows returns the maintenance windows of a power plant overlapping from to to, excluded,
+// ordered by start. The range defaults to the next MaxAvailabilityRange from now.
+func (u *Usecase) GetMaintenanceWindows(ctx context.Context, powerPlantID int64, from *time.Time, to *time.Time) ([]types.MaintenanceWindow, error) {
+	start := u.now()
+	if from != nil {
+		start = *from
+	}
+	end := start.Add(types.MaxAvailabilityRange)
+	if to != nil {
+		end = *to
+	}
+	if !end.After(start) || end.Sub(start) > types.MaxAvailabilityRange {
+		return nil, types.ErrInvalidAvailabilityRange
+	}
+
+	windows, err := u.db.GetMaintenanceWindows(ctx, []int64{powerPlantID}, start, end)
+	if err != nil {
+		u.logger.Printf("error getting maintenance windows: %v", err)
+		return nil, types.ErrInternal
+	}
+	return windows, nil
+}
+
+// GetAvailability returns the timeline of the available capacity of a power plant from from to to, excluded:
+// the periods of its maintenance windows and the fully available periods between them, in order.
+func (u *Usecase) GetAvailability(ctx context.Context, powerPlant *types.PowerPlant, from time.Time, to time.Time) ([]types.AvailabilityPeriod, error) {
+	if powerPlant.CapacityMW == nil {
+		return nil, types.ErrMaintenanceCapacityRequired
+	}
+
+	windows, err := u.GetMaintenanceWindows(ctx, powerPlant.ID, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	capacityMW := *powerPlant.CapacityMW
+	periods := make([]types.AvailabilityPeriod, 0, 2*len(windows)+1)
+	cursor := from
+	for _, window := range windows {
+		start, end := window.Start, window.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		if start.After(cursor) {
+			periods = append(periods, types.AvailabilityPeriod{Start: cursor, End: start, AvailableMW: capacityMW})
+		}
+
+		id, reason := window.ID, window.Reason
+		unavailableMW := window.UnavailableFraction(capacityMW) * capacityMW
+		periods = append(periods, types.AvailabilityPeriod{
+			Start:               start,
+			End:                 end,
+			AvailableMW:         capacityMW - unavailableMW,
+			UnavailableMW:       unavailableMW,
+			MaintenanceWindowID: &id,
+			Reason:              &reason,
+		})
+		cursor = end
+	}
+	if to.After(cursor) {
+		periods = append(periods, types.AvailabilityPeriod{Start: cursor, End: to, AvailableMW: capacityMW})
+	}
+
+	return periods, nil
+}
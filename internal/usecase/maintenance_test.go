@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestUsecase_CreateMaintenanceWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	start := time.Date(2024, 9, 10, 8, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 9, 10, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		testName  string
+		window    types.MaintenanceWindow
+		expected  *types.MaintenanceWindow
+		expectErr error
+	}{
+		{
+			testName: "success, unavailable MW",
+			window:   types.MaintenanceWindow{PowerPlantID: 101, Start: start, End: end, Reason: "Blade repair", UnavailableMW: ptr(6.0)},
+			expected: &types.MaintenanceWindow{ID: 1, PowerPlantID: 101, Start: start, End: end, Reason: "Blade repair", UnavailableMW: ptr(6.0)},
+		},
+		{
+			testName: "success, unavailable percent",
+			window:   types.MaintenanceWindow{PowerPlantID: 101, Start: start, End: end, UnavailablePercent: ptr(100.0)},
+			expected: &types.MaintenanceWindow{ID: 1, PowerPlantID: 101, Start: start, End: end, UnavailablePercent: ptr(100.0)},
+		},
+		{
+			testName:  "failed, empty power plant",
+			window:    types.MaintenanceWindow{Start: start, End: end, UnavailableMW: ptr(6.0)},
+			expectErr: errors.New("powerPlantID is required"),
+		},
+		{
+			testName:  "failed, unknown power plant",
+			window:    types.MaintenanceWindow{PowerPlantID: 999, Start: start, End: end, UnavailableMW: ptr(6.0)},
+			expectErr: types.ErrPowerPlantNotFound,
+		},
+		{
+			testName:  "failed, power plant without capacity",
+			window:    types.MaintenanceWindow{PowerPlantID: 1, Start: start, End: end, UnavailableMW: ptr(6.0)},
+			expectErr: types.ErrMaintenanceCapacityRequired,
+		},
+		{
+			testName:  "failed, end before start",
+			window:    types.MaintenanceWindow{PowerPlantID: 101, Start: end, End: start, UnavailableMW: ptr(6.0)},
+			expectErr: types.ErrInvalidMaintenancePeriod,
+		},
+		{
+			testName:  "failed, both MW and percent",
+			window:    types.MaintenanceWindow{PowerPlantID: 101, Start: start, End: end, UnavailableMW: ptr(6.0), UnavailablePercent: ptr(20.0)},
+			expectErr: types.ErrInvalidMaintenanceUnavailable,
+		},
+		{
+			testName:  "failed, more MW than the capacity",
+			window:    types.MaintenanceWindow{PowerPlantID: 101, Start: start, End: end, UnavailableMW: ptr(31.0)},
+			expectErr: types.ErrInvalidUnavailableMW,
+		},
+		{
+			testName:  "failed, invalid percent",
+			window:    types.MaintenanceWindow{PowerPlantID: 101, Start: start, End: end, UnavailablePercent: ptr(0.0)},
+			expectErr: types.ErrInvalidUnavailablePercent,
+		},
+		{
+			testName: "failed, overlapping window",
+			window: types.MaintenanceWindow{
+				PowerPlantID: 101, Start: time.Date(2024, 9, 6, 13, 0, 0, 0, time.UTC), End: end, UnavailableMW: ptr(6.0),
+			},
+			expectErr: types.ErrMaintenanceOverlap,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			window, err := testUsecase.CreateMaintenanceWindow(ctx, tt.window)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, window, cmpopts.IgnoreFields(types.MaintenanceWindow{}, "CreatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_UpdateMaintenanceWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		update    types.MaintenanceWindowUpdate
+		expected  *types.MaintenanceWindow
+		expectErr error
+	}{
+		{
+			testName: "success, percent replaces MW",
+			id:       31,
+			update:   types.MaintenanceWindowUpdate{Reason: ptr("Gearbox replacement"), UnavailablePercent: ptr(50.0)},
+			expected: &types.MaintenanceWindow{
+				ID: 31, PowerPlantID: 101, Start: time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC), End: time.Date(2024, 9, 6, 14, 0, 0, 0, time.UTC),
+				Reason: "Gearbox replacement", UnavailablePercent: ptr(50.0),
+			},
+		},
+		{
+			testName:  "failed, both MW and percent",
+			id:        31,
+			update:    types.MaintenanceWindowUpdate{UnavailableMW: ptr(6.0), UnavailablePercent: ptr(20.0)},
+			expectErr: types.ErrInvalidMaintenanceUnavailable,
+		},
+		{
+			testName:  "failed, end before start",
+			id:        31,
+			update:    types.MaintenanceWindowUpdate{End: ptr(time.Date(2024, 9, 6, 9, 0, 0, 0, time.UTC))},
+			expectErr: types.ErrInvalidMaintenancePeriod,
+		},
+		{
+			testName:  "failed, overlapping window",
+			id:        31,
+			update:    types.MaintenanceWindowUpdate{End: ptr(time.Date(2024, 9, 7, 6, 0, 0, 0, time.UTC))},
+			expectErr: types.ErrMaintenanceOverlap,
+		},
+		{
+			testName:  "failed, empty id",
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			window, err := testUsecase.UpdateMaintenanceWindow(ctx, tt.id, tt.update)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, window, cmpopts.IgnoreFields(types.MaintenanceWindow{}, "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_DeleteMaintenanceWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       31,
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := testUsecase.DeleteMaintenanceWindow(ctx, tt.id)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUsecase_GetAvailability(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 9, day, hour, minute, 0, 0, time.UTC)
+	}
+	powerPlant := fakeMeteredPowerPlants[101]
+
+	tests := []struct {
+		testName   string
+		powerPlant types.PowerPlant
+		from       time.Time
+		to         time.Time
+		expected   []types.AvailabilityPeriod
+		expectErr  error
+	}{
+		{
+			testName:   "success, windows and gaps",
+			powerPlant: powerPlant,
+			from:       at(6, 0, 0),
+			to:         at(8, 12, 0),
+			expected: []types.AvailabilityPeriod{
+				{Start: at(6, 0, 0), End: at(6, 10, 30), AvailableMW: 30},
+				{Start: at(6, 10, 30), End: at(6, 14, 0), AvailableMW: 20, UnavailableMW: 10, MaintenanceWindowID: ptr(int64(31)), Reason: ptr("Gearbox inspection")},
+				{Start: at(6, 14, 0), End: at(7, 0, 0), AvailableMW: 30},
+				{Start: at(7, 0, 0), End: at(8, 0, 0), AvailableMW: 0, UnavailableMW: 30, MaintenanceWindowID: ptr(int64(32)), Reason: ptr("Grid works")},
+				{Start: at(8, 0, 0), End: at(8, 12, 0), AvailableMW: 30},
+			},
+		},
+		{
+			testName:   "success, range within a window",
+			powerPlant: powerPlant,
+			from:       at(6, 12, 0),
+			to:         at(6, 13, 0),
+			expected: []types.AvailabilityPeriod{
+				{Start: at(6, 12, 0), End: at(6, 13, 0), AvailableMW: 20, UnavailableMW: 10, MaintenanceWindowID: ptr(int64(31)), Reason: ptr("Gearbox inspection")},
+			},
+		},
+		{
+			testName:   "success, no window",
+			powerPlant: powerPlant,
+			from:       at(10, 0, 0),
+			to:         at(11, 0, 0),
+			expected:   []types.AvailabilityPeriod{{Start: at(10, 0, 0), End: at(11, 0, 0), AvailableMW: 30}},
+		},
+		{
+			testName:   "failed, to before from",
+			powerPlant: powerPlant,
+			from:       at(11, 0, 0),
+			to:         at(10, 0, 0),
+			expectErr:  types.ErrInvalidAvailabilityRange,
+		},
+		{
+			testName:   "failed, range too long",
+			powerPlant: powerPlant,
+			from:       at(1, 0, 0),
+			to:         at(1, 0, 0).AddDate(2, 0, 0),
+			expectErr:  types.ErrInvalidAvailabilityRange,
+		},
+		{
+			testName:   "failed, power plant without capacity",
+			powerPlant: types.PowerPlant{ID: 1},
+			from:       at(6, 0, 0),
+			to:         at(7, 0, 0),
+			expectErr:  types.ErrMaintenanceCapacityRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			periods, err := testUsecase.GetAvailability(ctx, &tt.powerPlant, tt.from, tt.to)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, periods); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_GetGenerationForecast_Maintenance(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	u := NewUsecase(&fakeWeatherAPI{}, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+	u.now = func() time.Time { return time.Date(2024, 9, 6, 0, 30, 0, 0, time.UTC) }
+
+	powerPlant := fakeMeteredPowerPlants[101]
+	powerPlant.WeatherForecasts = []types.WeatherForecast{
+		{Time: "2024-09-06T10:00", WindSpeed100m: ptr(50.0)},
+		{Time: "2024-09-06T11:00", WindSpeed100m: ptr(50.0)},
+		{Time: "2024-09-06T12:00", WindSpeed100m: ptr(50.0)},
+		{Time: "2024-09-06T15:00", WindSpeed100m: ptr(50.0)},
+		{Time: "2024-09-07T12:00", WindSpeed100m: ptr(50.0)},
+	}
+
+	// 10 MW of the 30 MW are down from 10:30 to 14:00 and the whole power plant on the 7th.
+	expected := []types.GenerationForecast{
+		{Time: "2024-09-06T10:00", PowerMW: ptr(30.0)},
+		{Time: "2024-09-06T11:00", PowerMW: ptr(25.0)},
+		{Time: "2024-09-06T12:00", PowerMW: ptr(20.0)},
+		{Time: "2024-09-06T15:00", PowerMW: ptr(30.0)},
+		{Time: "2024-09-07T12:00", PowerMW: ptr(0.0)},
+	}
+
+	got, err := u.GetGenerationForecast(ctx, &powerPlant)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("unexpected forecast (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// apiKeyPrefix starts the API keys, so a leaked key is easy to recognize.
+const apiKeyPrefix = "te_"
+
+// CreateOrganization validates and creates a new organization. It returns the organization and its API key,
+// which is only shown once: the database only keeps its hash.
+func (u *Usecase) CreateOrganization(ctx context.Context, organization types.Organization) (*types.Organization, string, error) {
+	if err := organization.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		u.logger.Printf("error generating API key: %v", err)
+		return nil, "", types.ErrInternal
+	}
+	apiKey := apiKeyPrefix + hex.EncodeToString(secret)
+
+	created, err := u.db.CreateOrganization(ctx, &organization, hashAPIKey(apiKey))
+	if err != nil {
+		if errors.Is(err, types.ErrOrganizationNameTaken) {
+			return nil, "", err
+		}
+		u.logger.Printf("error creating organization: %v", err)
+		return nil, "", types.ErrInternal
+	}
+	return created, apiKey, nil
+}
+
+// AuthenticateAPIKey returns the organization of the API key, types.ErrInvalidAPIKey if it has none.
+func (u *Usecase) AuthenticateAPIKey(ctx context.Context, apiKey string) (*types.Organization, error) {
+	if apiKey == "" {
+		return nil, types.ErrInvalidAPIKey
+	}
+
+	organization, err := u.db.GetOrganizationByAPIKey(ctx, hashAPIKey(apiKey))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, types.ErrInvalidAPIKey
+		default:
+			u.logger.Printf("error getting organization by API key: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return organization, nil
+}
+
+// hashAPIKey returns the hash an API key is stored and looked up by. The keys are random,
+// so a plain SHA-256 is enough to make a leak of the hashes useless.
+func hashAPIKey(apiKey string) []byte {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hash[:]
+}
+
+// GetOrganization returns the organization the context acts for.
+func (u *Usecase) GetOrganization(ctx context.Context) (*types.Organization, error) {
+	id, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return nil, types.ErrOrganizationRequired
+	}
+
+	organization, err := u.db.GetOrganization(ctx, id)
+	if err != nil {
+		u.logger.Printf("error getting organization: %v", err)
+		return nil, types.ErrInternal
+	}
+	return organization, nil
+}
+
+// GetOrganizations returns every organization, for the background jobs to run for each of them.
+func (u *Usecase) GetOrganizations(ctx context.Context) ([]types.Organization, error) {
+	organizations, err := u.db.GetOrganizations(ctx)
+	if err != nil {
+		u.logger.Printf("error getting organizations: %v", err)
+		return nil, types.ErrInternal
+	}
+	return organizations, nil
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUsecase_CreateOrganization(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName     string
+		organization types.Organization
+		expected     *types.Organization
+		expectErr    error
+	}{
+		{
+			testName:     "success, trims the name",
+			organization: types.Organization{Name: "  Retail  "},
+			expected:     &types.Organization{ID: 3, Name: "Retail"},
+		},
+		{
+			testName:     "error, name is required",
+			organization: types.Organization{Name: " "},
+			expectErr:    types.ErrInvalidOrganizationName,
+		},
+		{
+			testName:     "error, name is taken",
+			organization: types.Organization{Name: "Trading"},
+			expectErr:    types.ErrOrganizationNameTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			db := &fakeDB{}
+			u := NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+
+			organization, apiKey, err := u.CreateOrganization(ctx, tt.organization)
+			if err != nil {
+				if tt.expectErr == nil || tt.expectErr.Error() != err.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if tt.expectErr != nil {
+				t.Fatalf("expected error: %v, got nil", tt.expectErr)
+			}
+
+			if diff := cmp.Diff(tt.expected, organization); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+			if !strings.HasPrefix(apiKey, apiKeyPrefix) || len(apiKey) != len(apiKeyPrefix)+64 {
+				t.Fatalf("expected a random API key, got: %s", apiKey)
+			}
+			if !bytes.Equal(db.apiKeyHash, hashAPIKey(apiKey)) {
+				t.Fatal("expected the hash of the API key to be saved")
+			}
+		})
+	}
+}
+
+func TestUsecase_AuthenticateAPIKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		apiKey    string
+		expected  *types.Organization
+		expectErr error
+	}{
+		{
+			testName: "success",
+			apiKey:   fakeAPIKey,
+			expected: &fakeOrganizations[1],
+		},
+		{
+			testName:  "error, unknown API key",
+			apiKey:    "te_unknown",
+			expectErr: types.ErrInvalidAPIKey,
+		},
+		{
+			testName:  "error, empty API key",
+			expectErr: types.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			organization, err := testUsecase.AuthenticateAPIKey(ctx, tt.apiKey)
+			if err != nil {
+				if tt.expectErr == nil || tt.expectErr.Error() != err.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if tt.expectErr != nil {
+				t.Fatalf("expected error: %v, got nil", tt.expectErr)
+			}
+
+			if diff := cmp.Diff(tt.expected, organization); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_GetOrganization(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if _, err := testUsecase.GetOrganization(ctx); err != types.ErrOrganizationRequired {
+		t.Fatalf("expected error: %v, got: %v", types.ErrOrganizationRequired, err)
+	}
+
+	organization, err := testUsecase.GetOrganization(tenant.WithOrganization(ctx, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&fakeOrganizations[1], organization); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+}
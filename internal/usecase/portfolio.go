@@ -0,0 +1,382 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/generation"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// CreatePortfolio validates and creates a new portfolio, without power plants.
+func (u *Usecase) CreatePortfolio(ctx context.Context, portfolio types.Portfolio) (*types.Portfolio, error) {
+	if err := portfolio.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := u.db.CreatePortfolio(ctx, &portfolio)
+	if err != nil {
+		if errors.Is(err, types.ErrPortfolioNameTaken) {
+			return nil, err
+		}
+		u.logger.Printf("error creating portfolio: %v", err)
+		return nil, types.ErrInternal
+	}
+	return created, nil
+}
+
+// UpdatePortfolio updates a portfolio by ID.
+func (u *Usecase) UpdatePortfolio(ctx context.Context, id int64, update types.PortfolioUpdate) (*types.Portfolio, error) {
+	portfolio, err := u.GetPortfolio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update.Apply(portfolio)
+	if err := portfolio.Validate(); err != nil {
+		return nil, err
+	}
+
+	portfolio, err = u.db.UpdatePortfolio(ctx, portfolio)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.New("id not found")
+		case errors.Is(err, types.ErrPortfolioNameTaken):
+			return nil, err
+		default:
+			u.logger.Printf("error updating portfolio: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return portfolio, nil
+}
+
+// DeletePortfolio deletes a portfolio by ID, its power plants are kept.
+func (u *Usecase) DeletePortfolio(ctx context.Context, id int64) error {
+	if id == 0 {
+		return errors.New("id is required")
+	}
+
+	err := u.db.DeletePortfolio(ctx, id)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errors.New("id not found")
+	default:
+		u.logger.Printf("error deleting portfolio: %v", err)
+		return types.ErrInternal
+	}
+}
+
+// GetPortfolio returns a portfolio by ID.
+func (u *Usecase) GetPortfolio(ctx context.Context, id int64) (*types.Portfolio, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	portfolio, err := u.db.GetPortfolio(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting portfolio: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return portfolio, nil
+}
+
+// GetPortfolios returns a paginated list of portfolios.
+func (u *Usecase) GetPortfolios(ctx context.Context, lastID int64, count int) ([]types.Portfolio, error) {
+	portfolios, err := u.db.GetPortfolios(ctx, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting portfolios: %v", err)
+		return nil, types.ErrInternal
+	}
+	return portfolios, nil
+}
+
+// GetPowerPlantPortfolios returns the portfolios of a power plant, ordered by name.
+func (u *Usecase) GetPowerPlantPortfolios(ctx context.Context, powerPlantID int64) ([]types.Portfolio, error) {
+	portfolios, err := u.db.GetPowerPlantPortfolios(ctx, powerPlantID)
+	if err != nil {
+		u.logger.Printf("error getting power plant portfolios: %v", err)
+		return nil, types.ErrInternal
+	}
+	return portfolios, nil
+}
+
+// AddPowerPlantsToPortfolio adds power plants to a portfolio, the power plants already in it are kept.
+// Either every power plant is added or, when one does not exist, none is.
+func (u *Usecase) AddPowerPlantsToPortfolio(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (*types.Portfolio, error) {
+	if len(powerPlantIDs) == 0 || len(powerPlantIDs) > types.MaxMembershipBatch {
+		return nil, types.ErrInvalidMembershipIDs
+	}
+	portfolio, err := u.GetPortfolio(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.db.AddPortfolioPowerPlants(ctx, portfolioID, powerPlantIDs); err != nil {
+		if errors.Is(err, types.ErrPortfolioNotFound) || errors.Is(err, types.ErrPowerPlantNotFound) {
+			return nil, err
+		}
+		u.logger.Printf("error adding power plants to portfolio: %v", err)
+		return nil, types.ErrInternal
+	}
+	return portfolio, nil
+}
+
+// RemovePowerPlantsFromPortfolio removes power plants from a portfolio, the power plants not in it are ignored.
+func (u *Usecase) RemovePowerPlantsFromPortfolio(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (*types.Portfolio, error) {
+	if len(powerPlantIDs) == 0 || len(powerPlantIDs) > types.MaxMembershipBatch {
+		return nil, types.ErrInvalidMembershipIDs
+	}
+	portfolio, err := u.GetPortfolio(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.db.RemovePortfolioPowerPlants(ctx, portfolioID, powerPlantIDs); err != nil {
+		u.logger.Printf("error removing power plants from portfolio: %v", err)
+		return nil, types.ErrInternal
+	}
+	return portfolio, nil
+}
+
+// portfolioBatchSize is the number of power plants aggregated per batch by GetPortfolioSummary,
+// each batch costs one weather forecast call and one river discharge call.
+const portfolioBatchSize = 100
+
+// GetPortfolioSummary returns the expected generation of the power plants matching the filter between
+// from and to, in buckets of the granularity, in total and per power plant type. from is rounded down
+// and to is rounded up to the hour, both must be within the 16 forecast days starting today 00:00 UTC.
+// The power plants are read in batches, with the batched upstream calls of GetPowerPlants and
+// GetGenerationForecast. The power plants without a generation forecast, e.g. storage, are excluded
+// from the energy but count in the installed capacity. A filter without statuses matches the operational power plants.
+func (u *Usecase) GetPortfolioSummary(ctx context.Context, filter types.PowerPlantFilter, from, to time.Time, granularity types.Granularity) (*types.PortfolioSummary, error) {
+	if !granularity.IsValid() {
+		return nil, types.ErrInvalidGranularity
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []types.PowerPlantStatus{types.PowerPlantStatusOperational}
+	}
+
+	from = from.UTC().Truncate(time.Hour)
+	to = to.UTC().Add(time.Hour - time.Nanosecond).Truncate(time.Hour)
+	today := u.now().UTC().Truncate(24 * time.Hour)
+	// The forecast of an hour is at its end, the last forecast of 16 days is at 23:00 of the last day.
+	if !from.Before(to) || from.Before(today) || to.After(today.Add((16*24-1)*time.Hour)) {
+		return nil, types.ErrInvalidPortfolioRange
+	}
+	days := forecastDaysCovering(int(to.Sub(today)/time.Hour) + 1)
+
+	aggregator := newPortfolioAggregator(from, to, granularity)
+	var lastID int64
+	for {
+		powerPlants, err := u.db.GetPowerPlantsByFilter(ctx, filter, lastID, portfolioBatchSize)
+		if err != nil {
+			u.logger.Printf("error getting power plants: %v", err)
+			return nil, types.ErrInternal
+		}
+		if len(powerPlants) == 0 {
+			break
+		}
+
+		u.fetchUpstreamData(ctx, powerPlants, days)
+		inputs, errs := u.generationInputs(ctx, powerPlants, days)
+		for i, powerPlant := range powerPlants {
+			// The weather forecasts of the whole batch are missing, the summary would be far off.
+			if powerPlant.ForecastErr != nil {
+				return nil, powerPlant.ForecastErr
+			}
+
+			var forecast []types.GenerationForecast
+			err := errs[i]
+			if err == nil {
+				forecast, err = generation.Forecast(powerPlant, inputs[i])
+			}
+			if err != nil {
+				aggregator.exclude(powerPlant)
+				continue
+			}
+			if err := aggregator.add(powerPlant, forecast); err != nil {
+				u.logger.Printf("error aggregating generation forecast of power plant %d: %v", powerPlant.ID, err)
+				return nil, types.ErrInternal
+			}
+		}
+
+		if len(powerPlants) < portfolioBatchSize {
+			break
+		}
+		lastID = powerPlants[len(powerPlants)-1].ID
+	}
+
+	return aggregator.summary(), nil
+}
+
+// portfolioAggregator sums the generation forecasts of power plants into the buckets of a portfolio summary.
+type portfolioAggregator struct {
+	from        time.Time
+	to          time.Time
+	granularity types.Granularity
+	total       *portfolioGroup
+	byType      map[types.PowerPlantType]*portfolioGroup
+	excluded    []int64
+}
+
+// portfolioGroup is the running total of the power plants of a summary or of one type.
+type portfolioGroup struct {
+	plantCount  int
+	installedMW float64
+	// forecastMW is the capacity of the power plants with a generation forecast.
+	forecastMW float64
+	energyMWh  map[time.Time]float64
+}
+
+func newPortfolioAggregator(from, to time.Time, granularity types.Granularity) *portfolioAggregator {
+	return &portfolioAggregator{
+		from:        from,
+		to:          to,
+		granularity: granularity,
+		total:       &portfolioGroup{energyMWh: map[time.Time]float64{}},
+		byType:      map[types.PowerPlantType]*portfolioGroup{},
+		excluded:    []int64{},
+	}
+}
+
+// groups returns the groups the power plant counts in, counting it.
+func (a *portfolioAggregator) groups(powerPlant types.PowerPlant) []*portfolioGroup {
+	group, ok := a.byType[powerPlant.Type]
+	if !ok {
+		group = &portfolioGroup{energyMWh: map[time.Time]float64{}}
+		a.byType[powerPlant.Type] = group
+	}
+
+	groups := []*portfolioGroup{a.total, group}
+	for _, g := range groups {
+		g.plantCount++
+		if powerPlant.CapacityMW != nil {
+			g.installedMW += *powerPlant.CapacityMW
+		}
+	}
+	return groups
+}
+
+// exclude counts a power plant without a generation forecast.
+func (a *portfolioAggregator) exclude(powerPlant types.PowerPlant) {
+	a.groups(powerPlant)
+	a.excluded = append(a.excluded, powerPlant.ID)
+}
+
+// add counts a power plant and its generation forecast. An hourly forecast covers the hour before its time,
+// the way the weather API averages the hours, and the hours starting between from and to are summed.
+// Hours without data count as no output.
+func (a *portfolioAggregator) add(powerPlant types.PowerPlant, forecast []types.GenerationForecast) error {
+	energy := map[time.Time]float64{}
+	for _, hour := range forecast {
+		end, err := time.Parse(types.ForecastTimeLayout, hour.Time)
+		if err != nil {
+			return err
+		}
+		start := end.Add(-time.Hour)
+		if hour.PowerMW == nil || start.Before(a.from) || !start.Before(a.to) {
+			continue
+		}
+		energy[start.Truncate(a.granularity.Duration())] += *hour.PowerMW
+	}
+
+	for _, g := range a.groups(powerPlant) {
+		g.forecastMW += *powerPlant.CapacityMW
+		for bucket, mwh := range energy {
+			g.energyMWh[bucket] += mwh
+		}
+	}
+	return nil
+}
+
+// summary returns the portfolio summary of the power plants counted so far.
+func (a *portfolioAggregator) summary() *types.PortfolioSummary {
+	summary := &types.PortfolioSummary{
+		From:                  a.from,
+		To:                    a.to,
+		Granularity:           a.granularity,
+		PlantCount:            a.total.plantCount,
+		InstalledCapacityMW:   a.total.installedMW,
+		ExcludedPowerPlantIDs: a.excluded,
+		ByType:                make([]types.PortfolioTypeSummary, 0, len(a.byType)),
+	}
+	summary.Buckets, summary.ExpectedEnergyMWh, summary.CapacityFactor = a.buckets(a.total)
+
+	for powerPlantType, g := range a.byType {
+		typeSummary := types.PortfolioTypeSummary{
+			Type:                powerPlantType,
+			PlantCount:          g.plantCount,
+			InstalledCapacityMW: g.installedMW,
+		}
+		typeSummary.Buckets, typeSummary.ExpectedEnergyMWh, typeSummary.CapacityFactor = a.buckets(g)
+		summary.ByType = append(summary.ByType, typeSummary)
+	}
+	sort.Slice(summary.ByType, func(i, j int) bool {
+		return summary.ByType[i].Type < summary.ByType[j].Type
+	})
+
+	return summary
+}
+
+// buckets returns the buckets of the group between from and to, the first and the last ones clipped
+// to the range, with the total energy and capacity factor of the group.
+func (a *portfolioAggregator) buckets(g *portfolioGroup) ([]types.PortfolioBucket, float64, *float64) {
+	step := a.granularity.Duration()
+
+	var (
+		buckets []types.PortfolioBucket
+		total   float64
+	)
+	for start := a.from.Truncate(step); start.Before(a.to); start = start.Add(step) {
+		hours := minTime(start.Add(step), a.to).Sub(maxTime(start, a.from)).Hours()
+		energy := g.energyMWh[start]
+		total += energy
+
+		buckets = append(buckets, types.PortfolioBucket{
+			Start:          start,
+			EnergyMWh:      energy,
+			AveragePowerMW: energy / hours,
+			CapacityFactor: capacityFactor(energy, g.forecastMW, hours),
+		})
+	}
+
+	return buckets, total, capacityFactor(total, g.forecastMW, a.to.Sub(a.from).Hours())
+}
+
+// capacityFactor returns the energy over the energy at full capacity during the hours, nil without capacity.
+func capacityFactor(energyMWh, capacityMW, hours float64) *float64 {
+	if capacityMW*hours == 0 {
+		return nil
+	}
+	factor := energyMWh / (capacityMW * hours)
+	return &factor
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
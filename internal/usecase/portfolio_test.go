@@ -0,0 +1,372 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// portfolioWeatherAPI returns the weather forecasts of the generation tests, for the two hours after 00:00.
+type portfolioWeatherAPI struct {
+	fakeWeatherAPI
+}
+
+func (f *portfolioWeatherAPI) GetWeatherForecasts(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherForecastProperties, error) {
+	forecasts := make([]types.WeatherForecastProperties, 0, len(latitudes))
+	for range latitudes {
+		forecasts = append(forecasts, types.WeatherForecastProperties{
+			WeatherForecasts: []types.WeatherForecast{
+				{Time: "2024-09-06T01:00", Temperature: ptr(25.0), ShortwaveRadiation: ptr(1000.0), WindSpeed100m: ptr(50.0)},
+				{Time: "2024-09-06T02:00", Temperature: ptr(25.0), WindSpeed100m: ptr(10.0)},
+			},
+		})
+	}
+	return forecasts, nil
+}
+
+func TestUsecase_GetPortfolioSummary(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	u := NewUsecase(&portfolioWeatherAPI{}, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+	u.now = func() time.Time { return time.Date(2024, 9, 6, 0, 30, 0, 0, time.UTC) }
+
+	today := time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)
+	hour := today.Add(time.Hour)
+
+	// The first hour gets 30 MW of wind, 9.03 MW of solar and 10 MW of hydro, the second one 10 MW of hydro.
+	// The storage power plant and the wind power plant with an unknown turbine model are excluded.
+	// The decommissioned power plant is left out by the filters without statuses.
+	tests := []struct {
+		testName    string
+		filter      types.PowerPlantFilter
+		from        time.Time
+		to          time.Time
+		granularity types.Granularity
+		expected    *types.PortfolioSummary
+		expectErr   error
+	}{
+		{
+			testName:    "success, hourly, operational power plants without a filter",
+			from:        today.Add(10 * time.Minute),
+			to:          today.Add(90 * time.Minute),
+			granularity: types.GranularityHour,
+			expected: &types.PortfolioSummary{
+				From: today, To: today.Add(2 * time.Hour), Granularity: types.GranularityHour,
+				PlantCount: 5, InstalledCapacityMW: 75, ExpectedEnergyMWh: 59.03, CapacityFactor: ptr(59.03 / 120),
+				Buckets: []types.PortfolioBucket{
+					{Start: today, EnergyMWh: 49.03, AveragePowerMW: 49.03, CapacityFactor: ptr(49.03 / 60)},
+					{Start: hour, EnergyMWh: 10, AveragePowerMW: 10, CapacityFactor: ptr(10.0 / 60)},
+				},
+				ByType: []types.PortfolioTypeSummary{
+					{
+						Type: types.PowerPlantTypeHydro, PlantCount: 1, InstalledCapacityMW: 20, ExpectedEnergyMWh: 20, CapacityFactor: ptr(0.5),
+						Buckets: []types.PortfolioBucket{
+							{Start: today, EnergyMWh: 10, AveragePowerMW: 10, CapacityFactor: ptr(0.5)},
+							{Start: hour, EnergyMWh: 10, AveragePowerMW: 10, CapacityFactor: ptr(0.5)},
+						},
+					},
+					{
+						Type: types.PowerPlantTypeSolar, PlantCount: 1, InstalledCapacityMW: 10, ExpectedEnergyMWh: 9.03, CapacityFactor: ptr(0.4515),
+						Buckets: []types.PortfolioBucket{
+							{Start: today, EnergyMWh: 9.03, AveragePowerMW: 9.03, CapacityFactor: ptr(0.903)},
+							{Start: hour, EnergyMWh: 0, AveragePowerMW: 0, CapacityFactor: ptr(0.0)},
+						},
+					},
+					{
+						Type: types.PowerPlantTypeStorage, PlantCount: 1, InstalledCapacityMW: 5,
+						Buckets: []types.PortfolioBucket{{Start: today}, {Start: hour}},
+					},
+					{
+						Type: types.PowerPlantTypeWind, PlantCount: 2, InstalledCapacityMW: 40, ExpectedEnergyMWh: 30, CapacityFactor: ptr(0.5),
+						Buckets: []types.PortfolioBucket{
+							{Start: today, EnergyMWh: 30, AveragePowerMW: 30, CapacityFactor: ptr(1.0)},
+							{Start: hour, EnergyMWh: 0, AveragePowerMW: 0, CapacityFactor: ptr(0.0)},
+						},
+					},
+				},
+				ExcludedPowerPlantIDs: []int64{4, 5},
+			},
+		},
+		{
+			testName:    "success, daily",
+			filter:      types.PowerPlantFilter{Types: []types.PowerPlantType{types.PowerPlantTypeHydro, types.PowerPlantTypeSolar}},
+			from:        today,
+			to:          today.Add(24 * time.Hour),
+			granularity: types.GranularityDay,
+			expected: &types.PortfolioSummary{
+				From: today, To: today.Add(24 * time.Hour), Granularity: types.GranularityDay,
+				PlantCount: 2, InstalledCapacityMW: 30, ExpectedEnergyMWh: 29.03, CapacityFactor: ptr(29.03 / 720),
+				Buckets: []types.PortfolioBucket{
+					{Start: today, EnergyMWh: 29.03, AveragePowerMW: 29.03 / 24, CapacityFactor: ptr(29.03 / 720)},
+				},
+				ByType: []types.PortfolioTypeSummary{
+					{
+						Type: types.PowerPlantTypeHydro, PlantCount: 1, InstalledCapacityMW: 20, ExpectedEnergyMWh: 20, CapacityFactor: ptr(20.0 / 480),
+						Buckets: []types.PortfolioBucket{
+							{Start: today, EnergyMWh: 20, AveragePowerMW: 20.0 / 24, CapacityFactor: ptr(20.0 / 480)},
+						},
+					},
+					{
+						Type: types.PowerPlantTypeSolar, PlantCount: 1, InstalledCapacityMW: 10, ExpectedEnergyMWh: 9.03, CapacityFactor: ptr(9.03 / 240),
+						Buckets: []types.PortfolioBucket{
+							{Start: today, EnergyMWh: 9.03, AveragePowerMW: 9.03 / 24, CapacityFactor: ptr(9.03 / 240)},
+						},
+					},
+				},
+				ExcludedPowerPlantIDs: []int64{},
+			},
+		},
+		{
+			// The second hour starts at 01:00, the first one before from.
+			testName:    "success, range clipped to the last hour",
+			filter:      types.PowerPlantFilter{Types: []types.PowerPlantType{types.PowerPlantTypeHydro}},
+			from:        hour,
+			to:          hour.Add(3 * time.Hour),
+			granularity: types.GranularityDay,
+			expected: &types.PortfolioSummary{
+				From: hour, To: hour.Add(3 * time.Hour), Granularity: types.GranularityDay,
+				PlantCount: 1, InstalledCapacityMW: 20, ExpectedEnergyMWh: 10, CapacityFactor: ptr(10.0 / 60),
+				Buckets: []types.PortfolioBucket{
+					{Start: today, EnergyMWh: 10, AveragePowerMW: 10.0 / 3, CapacityFactor: ptr(10.0 / 60)},
+				},
+				ByType: []types.PortfolioTypeSummary{
+					{
+						Type: types.PowerPlantTypeHydro, PlantCount: 1, InstalledCapacityMW: 20, ExpectedEnergyMWh: 10, CapacityFactor: ptr(10.0 / 60),
+						Buckets: []types.PortfolioBucket{
+							{Start: today, EnergyMWh: 10, AveragePowerMW: 10.0 / 3, CapacityFactor: ptr(10.0 / 60)},
+						},
+					},
+				},
+				ExcludedPowerPlantIDs: []int64{},
+			},
+		},
+		{
+			testName:    "failed, invalid granularity",
+			from:        today,
+			to:          hour,
+			granularity: "WEEK",
+			expectErr:   types.ErrInvalidGranularity,
+		},
+		{
+			testName:    "failed, invalid region",
+			filter:      types.PowerPlantFilter{Region: &types.BoundingBox{MinLatitude: 60, MaxLatitude: 40, MinLongitude: 0, MaxLongitude: 10}},
+			from:        today,
+			to:          hour,
+			granularity: types.GranularityHour,
+			expectErr:   types.ErrInvalidBoundingBox,
+		},
+		{
+			testName:    "failed, to before from",
+			from:        hour,
+			to:          today,
+			granularity: types.GranularityHour,
+			expectErr:   types.ErrInvalidPortfolioRange,
+		},
+		{
+			testName:    "failed, from before today",
+			from:        today.Add(-time.Hour),
+			to:          hour,
+			granularity: types.GranularityHour,
+			expectErr:   types.ErrInvalidPortfolioRange,
+		},
+		{
+			testName:    "failed, to after the forecast",
+			from:        today,
+			to:          today.Add(16 * 24 * time.Hour),
+			granularity: types.GranularityDay,
+			expectErr:   types.ErrInvalidPortfolioRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			summary, err := u.GetPortfolioSummary(ctx, tt.filter, tt.from, tt.to, tt.granularity)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, summary, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_CreatePortfolio(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		portfolio types.Portfolio
+		expected  *types.Portfolio
+		expectErr error
+	}{
+		{
+			testName:  "success, name is trimmed",
+			portfolio: types.Portfolio{Name: " Fund II ", Description: ptr("Second renewable fund")},
+			expected:  &types.Portfolio{ID: 1, Name: "Fund II", Description: ptr("Second renewable fund")},
+		},
+		{
+			testName:  "failed, empty name",
+			portfolio: types.Portfolio{Name: "  "},
+			expectErr: types.ErrInvalidPortfolioName,
+		},
+		{
+			testName:  "failed, name too long",
+			portfolio: types.Portfolio{Name: strings.Repeat("a", 101)},
+			expectErr: types.ErrInvalidPortfolioName,
+		},
+		{
+			testName:  "failed, name taken",
+			portfolio: types.Portfolio{Name: "Nordics"},
+			expectErr: types.ErrPortfolioNameTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			portfolio, err := testUsecase.CreatePortfolio(ctx, tt.portfolio)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, portfolio, cmpopts.IgnoreFields(types.Portfolio{}, "CreatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_UpdatePortfolio(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		update    types.PortfolioUpdate
+		expected  *types.Portfolio
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       8,
+			update:   types.PortfolioUpdate{Description: ptr("Nordic wind farms")},
+			expected: &types.Portfolio{ID: 8, Name: "Nordics", Description: ptr("Nordic wind farms")},
+		},
+		{
+			testName:  "failed, name taken",
+			id:        8,
+			update:    types.PortfolioUpdate{Name: ptr("Fund I")},
+			expectErr: types.ErrPortfolioNameTaken,
+		},
+		{
+			testName:  "failed, empty id",
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			portfolio, err := testUsecase.UpdatePortfolio(ctx, tt.id, tt.update)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, portfolio, cmpopts.IgnoreFields(types.Portfolio{}, "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_AddPowerPlantsToPortfolio(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName      string
+		portfolioID   int64
+		powerPlantIDs []int64
+		expectErr     error
+	}{
+		{
+			testName:      "success",
+			portfolioID:   8,
+			powerPlantIDs: []int64{1, 4},
+		},
+		{
+			testName:      "failed, unknown power plant",
+			portfolioID:   8,
+			powerPlantIDs: []int64{1, 999},
+			expectErr:     types.ErrPowerPlantNotFound,
+		},
+		{
+			testName:      "failed, unknown portfolio",
+			portfolioID:   999,
+			powerPlantIDs: []int64{1},
+			expectErr:     errors.New("id not found"),
+		},
+		{
+			testName:    "failed, no power plant",
+			portfolioID: 8,
+			expectErr:   types.ErrInvalidMembershipIDs,
+		},
+		{
+			testName:      "failed, too many power plants",
+			portfolioID:   8,
+			powerPlantIDs: make([]int64, types.MaxMembershipBatch+1),
+			expectErr:     types.ErrInvalidMembershipIDs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			portfolio, err := testUsecase.AddPowerPlantsToPortfolio(ctx, tt.portfolioID, tt.powerPlantIDs)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if portfolio.ID != tt.portfolioID {
+				t.Fatalf("expected portfolio %d, got: %d", tt.portfolioID, portfolio.ID)
+			}
+		})
+	}
+}
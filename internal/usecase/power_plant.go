@@ -5,18 +5,29 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/gcathelines/tensor-energy-case/config"
 	"github.com/gcathelines/tensor-energy-case/internal/database"
 	"github.com/gcathelines/tensor-energy-case/internal/open_meteo"
 	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"golang.org/x/sync/errgroup"
 )
 
-var _ weatherAPI = (*open_meteo.OpenMeteoClient)(nil)
+var _ weatherAPI = (*open_meteo.CachedClient)(nil)
 
 type weatherAPI interface {
 	GetWeatherForecast(ctx context.Context, latitudes float64, longitudes float64, forecastDays int) (*types.WeatherForecastProperties, error)
 	GetWeatherForecasts(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherForecastProperties, error)
 	GetElevations(ctx context.Context, latitude []float64, longitude []float64) ([]float64, error)
+	GetRiverDischarges(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([][]types.RiverDischarge, error)
+	GetTiltedIrradiance(ctx context.Context, latitude float64, longitude float64, array types.SolarArrayConfig, forecastDays int) ([]types.TiltedIrradiance, error)
+	GetWeatherObservations(ctx context.Context, latitudes []float64, longitudes []float64, start time.Time, end time.Time) ([][]types.WeatherForecast, error)
+	SearchLocations(ctx context.Context, name string, count int) ([]types.Location, error)
+	Usage() []types.RateLimitWindow
+	CacheStats() types.CacheStats
+	SnapToGrid(coordinate float64) float64
 }
 
 var _ db = (*database.Database)(nil)
@@ -26,31 +37,123 @@ type db interface {
 	UpdatePowerPlant(ctx context.Context, powerPlant *types.PowerPlant) (*types.PowerPlant, error)
 	GetPowerPlant(ctx context.Context, id int64) (*types.PowerPlant, error)
 	GetPowerPlantForUpdate(ctx context.Context, id int64) (*types.PowerPlant, error)
+	GetPowerPlantAsOf(ctx context.Context, id int64, asOf time.Time) (*types.PowerPlant, error)
+	GetPowerPlantHistory(ctx context.Context, id int64) ([]types.PowerPlantVersion, error)
 	GetPowerPlants(ctx context.Context, lastID int64, count int) ([]types.PowerPlant, error)
+	GetPowerPlantsByFilter(ctx context.Context, filter types.PowerPlantFilter, lastID int64, count int) ([]types.PowerPlant, error)
+	AddPowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error)
+	RemovePowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error)
+	GetPowerPlantsMissingElevation(ctx context.Context, lastID int64, count int) ([]types.PowerPlant, error)
+	UpdatePowerPlantElevation(ctx context.Context, id int64, elevation float64) error
+	CreateTurbineModel(ctx context.Context, model *types.TurbineModel) (*types.TurbineModel, error)
+	UpdateTurbineModel(ctx context.Context, model *types.TurbineModel) (*types.TurbineModel, error)
+	DeleteTurbineModel(ctx context.Context, id int64) error
+	GetTurbineModel(ctx context.Context, id int64) (*types.TurbineModel, error)
+	GetTurbineModels(ctx context.Context, lastID int64, count int) ([]types.TurbineModel, error)
+	CreateAlertRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id int64) error
+	GetAlertRule(ctx context.Context, id int64) (*types.AlertRule, error)
+	GetAlertRules(ctx context.Context, powerPlantIDs []int64) ([]types.AlertRule, error)
+	RecordAlert(ctx context.Context, alert *types.Alert) (*types.Alert, error)
+	ResolveAlert(ctx context.Context, ruleID int64, resolvedAt time.Time) (*types.Alert, error)
+	GetActiveAlerts(ctx context.Context, powerPlantID int64) ([]types.Alert, error)
+	GetAlerts(ctx context.Context, powerPlantID *int64, lastID int64, count int) ([]types.Alert, error)
+	CreateWebhookSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+	GetWebhookSubscription(ctx context.Context, id int64) (*types.WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context, lastID int64, count int) ([]types.WebhookSubscription, error)
+	EnqueueWebhookEvent(ctx context.Context, eventType types.WebhookEventType, payload []byte) (int, error)
+	RedeliverWebhook(ctx context.Context, id int64) (*types.WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, subscriptionID *int64, status *types.WebhookDeliveryStatus, lastID int64, count int) ([]types.WebhookDelivery, error)
+	GetWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]types.WebhookDeliveryAttempt, error)
+	RecordProductionReadings(ctx context.Context, readings []types.ProductionReading) (int, int, error)
+	GetProductionReadings(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ProductionReading, error)
+	RecordIssuedForecasts(ctx context.Context, forecasts []types.IssuedForecast) (int, error)
+	GetIssuedForecasts(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.IssuedForecast, error)
+	GetIssuedForecastDays(ctx context.Context, before time.Time) ([]time.Time, error)
+	DeleteIssuedForecasts(ctx context.Context, before time.Time) (int, error)
+	GetHourlyProduction(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.ProductionReading, error)
+	SaveForecastErrorSums(ctx context.Context, sums []types.ForecastErrorSums) error
+	GetForecastErrorSums(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ForecastErrorSums, error)
+	CreateMaintenanceWindow(ctx context.Context, window *types.MaintenanceWindow) (*types.MaintenanceWindow, error)
+	UpdateMaintenanceWindow(ctx context.Context, window *types.MaintenanceWindow) (*types.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, id int64) error
+	GetMaintenanceWindow(ctx context.Context, id int64) (*types.MaintenanceWindow, error)
+	GetMaintenanceWindows(ctx context.Context, powerPlantIDs []int64, from time.Time, to time.Time) ([]types.MaintenanceWindow, error)
+	CreatePortfolio(ctx context.Context, portfolio *types.Portfolio) (*types.Portfolio, error)
+	UpdatePortfolio(ctx context.Context, portfolio *types.Portfolio) (*types.Portfolio, error)
+	DeletePortfolio(ctx context.Context, id int64) error
+	GetPortfolio(ctx context.Context, id int64) (*types.Portfolio, error)
+	GetPortfolios(ctx context.Context, lastID int64, count int) ([]types.Portfolio, error)
+	GetPowerPlantPortfolios(ctx context.Context, powerPlantID int64) ([]types.Portfolio, error)
+	AddPortfolioPowerPlants(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (int, error)
+	RemovePortfolioPowerPlants(ctx context.Context, portfolioID int64, powerPlantIDs []int64) (int, error)
+	CreateOrganization(ctx context.Context, organization *types.Organization, apiKeyHash []byte) (*types.Organization, error)
+	GetOrganization(ctx context.Context, id int64) (*types.Organization, error)
+	GetOrganizationByAPIKey(ctx context.Context, apiKeyHash []byte) (*types.Organization, error)
+	GetOrganizations(ctx context.Context) ([]types.Organization, error)
+	GetAuditEvents(ctx context.Context, filter types.AuditEventFilter, lastID int64, count int) ([]types.AuditEvent, error)
+}
+
+var _ snapshotStore = (*database.WeatherSnapshots)(nil)
+
+type snapshotStore interface {
+	GetWeatherSnapshots(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherSnapshot, error)
+	SaveWeatherSnapshots(ctx context.Context, snapshots []types.WeatherSnapshot) error
 }
 
 // Usecase represents the usecase of the service.
 type Usecase struct {
-	weatherAPI weatherAPI
-	db         db
-	logger     *log.Logger
+	weatherAPI   weatherAPI
+	db           db
+	snapshots    snapshotStore
+	snapshotCfg  config.SnapshotConfig
+	readTimeouts config.ReadTimeoutConfig
+	logger       *log.Logger
+	now          func() time.Time
+
+	// accessed holds the last time power plants were read, to prefetch their forecasts first.
+	accessedMu sync.Mutex
+	accessed   map[int64]time.Time
+
+	// refreshing holds the snapshots being refreshed in the background.
+	refreshingMu sync.Mutex
+	refreshing   map[refreshKey]struct{}
+	background   sync.WaitGroup
 }
 
 // NewUsecase creates a new usecase.
-func NewUsecase(weatherAPI weatherAPI, db db) *Usecase {
+func NewUsecase(weatherAPI weatherAPI, db db, snapshots snapshotStore, snapshotCfg config.SnapshotConfig, readTimeouts config.ReadTimeoutConfig) *Usecase {
 
 	return &Usecase{
-		weatherAPI: weatherAPI,
-		db:         db,
-		logger:     log.Default(),
+		weatherAPI:   weatherAPI,
+		db:           db,
+		snapshots:    snapshots,
+		snapshotCfg:  snapshotCfg,
+		readTimeouts: readTimeouts,
+		logger:       log.Default(),
+		now:          time.Now,
+		accessed:     map[int64]time.Time{},
+		refreshing:   map[refreshKey]struct{}{},
 	}
 }
 
 // CreatePowerPlant validates and creates a new power plant.
-func (u *Usecase) CreatePowerPlant(ctx context.Context, name string, lat float64, long float64) (*types.PowerPlant, error) {
+// When the coordinates are omitted, they are resolved from the place name by geocoding.
+// The type defaults to OTHER and the status to OPERATIONAL.
+func (u *Usecase) CreatePowerPlant(ctx context.Context, name string, lat float64, long float64, placeName string, metadata types.PowerPlantMetadata) (*types.PowerPlant, error) {
 	if name == "" {
 		return nil, errors.New("name is required")
 	}
+	if lat == 0 && long == 0 && placeName != "" {
+		location, err := u.resolvePlace(ctx, placeName)
+		if err != nil {
+			return nil, err
+		}
+		lat, long = location.Latitude, location.Longitude
+	}
 	if lat == 0 {
 		return nil, errors.New("latitude is required")
 	}
@@ -63,17 +166,39 @@ func (u *Usecase) CreatePowerPlant(ctx context.Context, name string, lat float64
 	if long > 180 || long < -180 {
 		return nil, types.ErrInvalidLongitude
 	}
+	if metadata.Type == "" {
+		metadata.Type = types.PowerPlantTypeOther
+	}
+	if metadata.Status == "" {
+		metadata.Status = types.PowerPlantStatusOperational
+	}
+	if err := metadata.Validate(u.now()); err != nil {
+		return nil, err
+	}
+	if err := u.checkTurbineModel(ctx, metadata.TurbineModelID); err != nil {
+		return nil, err
+	}
 
-	return u.db.CreatePowerPlant(ctx, &types.PowerPlant{
-		Name:      name,
-		Latitude:  lat,
-		Longitude: long,
+	powerPlant, err := u.db.CreatePowerPlant(ctx, &types.PowerPlant{
+		Name:               name,
+		Latitude:           lat,
+		Longitude:          long,
+		DEMElevation:       u.fetchElevation(ctx, lat, long),
+		PowerPlantMetadata: metadata,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.publishEvent(ctx, types.WebhookEventPowerPlantCreated, powerPlant)
+	return powerPlant, nil
 }
 
 // UpdatePowerPlant updates a power plant by ID.
 // We will use pessimistic lock to avoid write conflicts.
-func (u *Usecase) UpdatePowerPlant(ctx context.Context, id int64, name *string, lat *float64, long *float64) (*types.PowerPlant, error) {
+// The metadata is validated again as a whole after the update, e.g. changing the type to SOLAR requires a DC capacity.
+// Moving the power plant refetches its elevation and removes its surveyed elevation, which is the one of the old site.
+func (u *Usecase) UpdatePowerPlant(ctx context.Context, id int64, name *string, lat *float64, long *float64, metadata types.PowerPlantMetadataUpdate) (*types.PowerPlant, error) {
 	if id == 0 {
 		return nil, errors.New("id is required")
 	}
@@ -95,6 +220,7 @@ func (u *Usecase) UpdatePowerPlant(ctx context.Context, id int64, name *string,
 		}
 	}
 
+	moved := (lat != nil && *lat != powerPlant.Latitude) || (long != nil && *long != powerPlant.Longitude)
 	if lat != nil {
 		powerPlant.Latitude = *lat
 	}
@@ -104,6 +230,17 @@ func (u *Usecase) UpdatePowerPlant(ctx context.Context, id int64, name *string,
 	if name != nil {
 		powerPlant.Name = *name
 	}
+	metadata.Apply(&powerPlant.PowerPlantMetadata)
+	if err := powerPlant.PowerPlantMetadata.Validate(u.now()); err != nil {
+		return nil, err
+	}
+	if err := u.checkTurbineModel(ctx, metadata.TurbineModelID); err != nil {
+		return nil, err
+	}
+	if moved {
+		powerPlant.ElevationOverride = nil
+		powerPlant.DEMElevation = u.fetchElevation(ctx, powerPlant.Latitude, powerPlant.Longitude)
+	}
 
 	powerPlant, err = u.db.UpdatePowerPlant(ctx, powerPlant)
 	if err != nil {
@@ -115,11 +252,27 @@ func (u *Usecase) UpdatePowerPlant(ctx context.Context, id int64, name *string,
 			return nil, types.ErrInternal
 		}
 	}
+
+	u.publishEvent(ctx, types.WebhookEventPowerPlantUpdated, powerPlant)
 	return powerPlant, nil
 }
 
 // GetPowerPlant returns a power plant by ID.
+// It only fails when the power plant cannot be read, see fetchUpstreamData for the weather API data.
 func (u *Usecase) GetPowerPlant(ctx context.Context, id int64, forecastDays int) (*types.PowerPlant, error) {
+	return u.getPowerPlant(ctx, id, forecastDays, u.db.GetPowerPlant)
+}
+
+// GetPowerPlantAsOf returns a power plant by ID with the attributes it had at the given time, like GetPowerPlant.
+// Its forecasts are the current ones, computed from these attributes.
+func (u *Usecase) GetPowerPlantAsOf(ctx context.Context, id int64, asOf time.Time, forecastDays int) (*types.PowerPlant, error) {
+	return u.getPowerPlant(ctx, id, forecastDays, func(ctx context.Context, id int64) (*types.PowerPlant, error) {
+		return u.db.GetPowerPlantAsOf(ctx, id, asOf)
+	})
+}
+
+// getPowerPlant returns the power plant read by get with its weather API data.
+func (u *Usecase) getPowerPlant(ctx context.Context, id int64, forecastDays int, get func(ctx context.Context, id int64) (*types.PowerPlant, error)) (*types.PowerPlant, error) {
 	if id == 0 {
 		return nil, errors.New("id is required")
 	}
@@ -128,7 +281,7 @@ func (u *Usecase) GetPowerPlant(ctx context.Context, id int64, forecastDays int)
 		return nil, types.ErrInvalidForecastDay
 	}
 
-	powerPlant, err := u.db.GetPowerPlant(ctx, id)
+	powerPlant, err := get(ctx, id)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -139,23 +292,21 @@ func (u *Usecase) GetPowerPlant(ctx context.Context, id int64, forecastDays int)
 		}
 	}
 
-	forecast, err := u.weatherAPI.GetWeatherForecast(ctx, powerPlant.Latitude, powerPlant.Longitude, forecastDays)
-	if err != nil {
-		u.logger.Printf("error getting weather forecast: %v", err)
-		return nil, types.ErrInternal
-	}
+	powerPlants := []types.PowerPlant{*powerPlant}
+	u.recordAccess(powerPlants)
+	u.fetchUpstreamData(ctx, powerPlants, forecastDays)
 
-	powerPlant.WeatherForecastProperties = *forecast
+	return &powerPlants[0], nil
+}
 
-	elevations, err := u.weatherAPI.GetElevations(ctx, []float64{powerPlant.Latitude}, []float64{powerPlant.Longitude})
+// GetPowerPlantHistory returns every version of the attributes of a power plant, oldest first.
+func (u *Usecase) GetPowerPlantHistory(ctx context.Context, id int64) ([]types.PowerPlantVersion, error) {
+	versions, err := u.db.GetPowerPlantHistory(ctx, id)
 	if err != nil {
-		u.logger.Printf("error getting elevation: %v", err)
+		u.logger.Printf("error getting power plant history: %v", err)
 		return nil, types.ErrInternal
 	}
-
-	powerPlant.Elevation = elevations[0]
-
-	return powerPlant, nil
+	return versions, nil
 }
 
 // GetPowerPlants returns a list of power plants.
@@ -173,7 +324,81 @@ func (u *Usecase) GetPowerPlants(ctx context.Context, lastID int64, count int, f
 	if len(powerPlants) == 0 {
 		return powerPlants, nil
 	}
+	u.recordAccess(powerPlants)
+	u.fetchUpstreamData(ctx, powerPlants, forecastDays)
 
+	return powerPlants, nil
+}
+
+// GetPowerPlantsByFilter returns the power plants matching the filter, paginated like GetPowerPlants.
+func (u *Usecase) GetPowerPlantsByFilter(ctx context.Context, filter types.PowerPlantFilter, lastID int64, count int, forecastDays int) ([]types.PowerPlant, error) {
+	if _, ok := types.ValidForecastLengths[forecastDays]; !ok {
+		return nil, types.ErrInvalidForecastDay
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	powerPlants, err := u.db.GetPowerPlantsByFilter(ctx, filter, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting power plants: %v", err)
+		return nil, types.ErrInternal
+	}
+
+	if len(powerPlants) == 0 {
+		return powerPlants, nil
+	}
+	u.recordAccess(powerPlants)
+	u.fetchUpstreamData(ctx, powerPlants, forecastDays)
+
+	return powerPlants, nil
+}
+
+// AddPowerPlantTags adds tags to a power plant, they are trimmed and the tags it already has are kept once.
+func (u *Usecase) AddPowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	return u.updatePowerPlantTags(ctx, id, tags, u.db.AddPowerPlantTags)
+}
+
+// RemovePowerPlantTags removes tags from a power plant, the tags it does not have are ignored.
+func (u *Usecase) RemovePowerPlantTags(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error) {
+	return u.updatePowerPlantTags(ctx, id, tags, u.db.RemovePowerPlantTags)
+}
+
+// updatePowerPlantTags validates the tags and updates the tags of the power plant with update.
+func (u *Usecase) updatePowerPlantTags(ctx context.Context, id int64, tags []string,
+	update func(ctx context.Context, id int64, tags []string) (*types.PowerPlant, error),
+) (*types.PowerPlant, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("tags are required")
+	}
+	tags, err := types.NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	powerPlant, err := update(ctx, id, tags)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error updating power plant tags: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+
+	u.publishEvent(ctx, types.WebhookEventPowerPlantUpdated, powerPlant)
+	return powerPlant, nil
+}
+
+// fetchUpstreamData fetches the weather forecasts and the missing elevations of the power plants
+// concurrently, each call within its own time budget. A failed call does not fail the other one:
+// its error is set on the power plants, which are returned without the data of that call.
+// An exhausted rate limit fails both calls, the other one is cancelled instead of waiting for its budget.
+func (u *Usecase) fetchUpstreamData(ctx context.Context, powerPlants []types.PowerPlant, forecastDays int) {
 	lats := make([]float64, 0, len(powerPlants))
 	longs := make([]float64, 0, len(powerPlants))
 	for _, powerPlant := range powerPlants {
@@ -181,22 +406,71 @@ func (u *Usecase) GetPowerPlants(ctx context.Context, lastID int64, count int, f
 		longs = append(longs, powerPlant.Longitude)
 	}
 
-	forecasts, err := u.weatherAPI.GetWeatherForecasts(ctx, lats, longs, forecastDays)
-	if err != nil {
-		u.logger.Printf("error getting weather forecasts: %v", err)
-		return nil, types.ErrInternal
+	var (
+		forecasts   []types.WeatherForecastProperties
+		forecastErr error
+	)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		ctx, cancel := context.WithTimeout(groupCtx, u.readTimeouts.Forecast)
+		defer cancel()
+
+		var err error
+		forecasts, err = u.getWeatherForecasts(ctx, lats, longs, forecastDays)
+		if err != nil {
+			forecastErr = u.upstreamError("error getting weather forecasts", err)
+		}
+		return fatalUpstreamError(forecastErr)
+	})
+	group.Go(func() error {
+		ctx, cancel := context.WithTimeout(groupCtx, u.readTimeouts.Elevation)
+		defer cancel()
+
+		return fatalUpstreamError(u.fillMissingElevations(ctx, powerPlants))
+	})
+	// The call cancelled by the fatal error of the other one fails with it.
+	fatalErr := group.Wait()
+	if fatalErr != nil && forecastErr != nil {
+		forecastErr = fatalErr
 	}
 
-	elevations, err := u.weatherAPI.GetElevations(ctx, lats, longs)
-	if err != nil {
-		u.logger.Printf("error getting elevations: %v", err)
-		return nil, types.ErrInternal
+	for i := range powerPlants {
+		if fatalErr != nil && powerPlants[i].ElevationErr != nil {
+			powerPlants[i].ElevationErr = fatalErr
+		}
+		if forecastErr != nil {
+			powerPlants[i].ForecastErr = forecastErr
+			continue
+		}
+		powerPlants[i].WeatherForecastProperties = forecasts[i]
 	}
+}
 
-	for i, forecast := range forecasts {
-		powerPlants[i].WeatherForecastProperties = forecast
-		powerPlants[i].Elevation = elevations[i]
+// fatalUpstreamError returns the error of an upstream call if it should cancel the other calls
+// of the request, because they would fail the same, and nil otherwise.
+func fatalUpstreamError(err error) error {
+	if errors.Is(err, types.ErrUpstreamRateLimited) {
+		return err
 	}
+	return nil
+}
 
-	return powerPlants, nil
+// GetForecastCacheStats returns the hit and miss counters of the forecast cache.
+func (u *Usecase) GetForecastCacheStats() types.CacheStats {
+	return u.weatherAPI.CacheStats()
+}
+
+// GetWeatherAPIUsage returns the weather API calls used per rate limit window.
+func (u *Usecase) GetWeatherAPIUsage() []types.RateLimitWindow {
+	return u.weatherAPI.Usage()
+}
+
+// upstreamError logs an error of the weather API and hides it behind ErrInternal,
+// except for errors the caller can act upon such as an exhausted rate limit.
+func (u *Usecase) upstreamError(msg string, err error) error {
+	u.logger.Printf("%s: %v", msg, err)
+	if errors.Is(err, types.ErrUpstreamRateLimited) {
+		return types.ErrUpstreamRateLimited
+	}
+	return types.ErrInternal
 }
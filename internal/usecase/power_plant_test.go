@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +12,12 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
+// defaultMetadata is the metadata of a power plant created without any.
+var defaultMetadata = types.PowerPlantMetadata{
+	Type:   types.PowerPlantTypeOther,
+	Status: types.PowerPlantStatusOperational,
+}
+
 func TestUsecase_CreatePowerPlant(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -20,6 +27,9 @@ func TestUsecase_CreatePowerPlant(t *testing.T) {
 		name      string
 		lat       float64
 		long      float64
+		placeName string
+		metadata  types.PowerPlantMetadata
+		expected  *types.PowerPlant
 		expectErr error
 	}{
 		{
@@ -27,6 +37,240 @@ func TestUsecase_CreatePowerPlant(t *testing.T) {
 			name:     "My Cool Power Plant",
 			lat:      1.1,
 			long:     2.2,
+			expected: &types.PowerPlant{
+				ID:                 1,
+				Name:               "My Cool Power Plant",
+				Latitude:           1.1,
+				Longitude:          2.2,
+				Elevation:          0.5,
+				DEMElevation:       ptr(0.5),
+				PowerPlantMetadata: defaultMetadata,
+			},
+		},
+		{
+			testName: "success, solar power plant",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type:           types.PowerPlantTypeSolar,
+				CapacityMW:     ptr(10.0),
+				DCCapacityMW:   ptr(12.5),
+				CommissionedAt: ptr(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)),
+				Operator:       ptr("Sunny Energy"),
+			},
+			expected: &types.PowerPlant{
+				ID:           1,
+				Name:         "My Cool Power Plant",
+				Latitude:     1.1,
+				Longitude:    2.2,
+				Elevation:    0.5,
+				DEMElevation: ptr(0.5),
+				PowerPlantMetadata: types.PowerPlantMetadata{
+					Type:           types.PowerPlantTypeSolar,
+					CapacityMW:     ptr(10.0),
+					DCCapacityMW:   ptr(12.5),
+					CommissionedAt: ptr(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)),
+					Status:         types.PowerPlantStatusOperational,
+					Operator:       ptr("Sunny Energy"),
+				},
+			},
+		},
+		{
+			testName: "success, planned power plant commissioned in the future",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type:           types.PowerPlantTypeWind,
+				CapacityMW:     ptr(30.0),
+				CommissionedAt: ptr(time.Now().AddDate(1, 0, 0).UTC().Truncate(time.Hour)),
+				Status:         types.PowerPlantStatusPlanned,
+			},
+			expected: &types.PowerPlant{
+				ID:           1,
+				Name:         "My Cool Power Plant",
+				Latitude:     1.1,
+				Longitude:    2.2,
+				Elevation:    0.5,
+				DEMElevation: ptr(0.5),
+				PowerPlantMetadata: types.PowerPlantMetadata{
+					Type:           types.PowerPlantTypeWind,
+					CapacityMW:     ptr(30.0),
+					CommissionedAt: ptr(time.Now().AddDate(1, 0, 0).UTC().Truncate(time.Hour)),
+					Status:         types.PowerPlantStatusPlanned,
+				},
+			},
+		},
+		{
+			testName:  "failed, solar power plant without dc capacity",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0)},
+			expectErr: types.ErrDCCapacityRequired,
+		},
+		{
+			testName:  "failed, solar power plant with invalid dc/ac ratio",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(25.0)},
+			expectErr: types.ErrInvalidDCACRatio,
+		},
+		{
+			testName:  "failed, wind power plant without capacity",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeWind},
+			expectErr: types.ErrCapacityRequired,
+		},
+		{
+			testName:  "failed, dc capacity on a hydro power plant",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeHydro, CapacityMW: ptr(10.0), DCCapacityMW: ptr(10.0)},
+			expectErr: types.ErrDCCapacityNotSolar,
+		},
+		{
+			testName:  "failed, design discharge on a wind power plant",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(10.0), DesignDischargeM3s: ptr(80.0)},
+			expectErr: types.ErrDesignDischargeNotHydro,
+		},
+		{
+			testName: "failed, turbines on a solar power plant",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.0),
+				TurbineModelID: ptr(int64(1)), TurbineCount: ptr(5),
+			},
+			expectErr: types.ErrTurbineNotWind,
+		},
+		{
+			testName: "failed, solar array on a wind power plant",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type: types.PowerPlantTypeWind, CapacityMW: ptr(10.0),
+				SolarArray: &types.SolarArrayConfig{Tilt: 30, Tracking: types.SolarTrackingFixed},
+			},
+			expectErr: types.ErrSolarArrayNotSolar,
+		},
+		{
+			testName: "failed, solar array tilt above 90",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.0),
+				SolarArray: &types.SolarArrayConfig{Tilt: 95, Tracking: types.SolarTrackingFixed},
+			},
+			expectErr: types.ErrInvalidTilt,
+		},
+		{
+			testName: "failed, solar array losses above 50%",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type: types.PowerPlantTypeSolar, CapacityMW: ptr(10.0), DCCapacityMW: ptr(12.0),
+				SolarArray: &types.SolarArrayConfig{Tilt: 30, Tracking: types.SolarTrackingFixed, SystemLosses: 0.6},
+			},
+			expectErr: types.ErrInvalidSystemLosses,
+		},
+		{
+			testName:  "failed, turbine model without count",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(10.0), TurbineModelID: ptr(int64(1))},
+			expectErr: types.ErrTurbineModelAndCount,
+		},
+		{
+			testName:  "failed, unknown turbine model",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeWind, CapacityMW: ptr(10.0), TurbineModelID: ptr(int64(999)), TurbineCount: ptr(5)},
+			expectErr: types.ErrTurbineModelNotFound,
+		},
+		{
+			testName:  "failed, negative capacity",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: types.PowerPlantTypeStorage, CapacityMW: ptr(-1.0)},
+			expectErr: types.ErrInvalidCapacity,
+		},
+		{
+			testName: "failed, operational power plant commissioned in the future",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			metadata: types.PowerPlantMetadata{
+				Type:           types.PowerPlantTypeOther,
+				CommissionedAt: ptr(time.Now().AddDate(1, 0, 0)),
+			},
+			expectErr: types.ErrCommissionedInFuture,
+		},
+		{
+			testName:  "failed, invalid type",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			metadata:  types.PowerPlantMetadata{Type: "NUCLEAR"},
+			expectErr: types.ErrInvalidPowerPlantType,
+		},
+		{
+			testName:  "success, coordinates from place name",
+			name:      "My Cool Power Plant",
+			placeName: "Zurich",
+			expected: &types.PowerPlant{
+				ID:                 1,
+				Name:               "My Cool Power Plant",
+				Latitude:           47.36667,
+				Longitude:          8.55,
+				Elevation:          5.539961403508771,
+				DEMElevation:       ptr(5.539961403508771),
+				PowerPlantMetadata: defaultMetadata,
+			},
+		},
+		{
+			testName:  "success, place name narrowed down by region",
+			name:      "My Cool Power Plant",
+			placeName: "Springfield, illinois",
+			expected: &types.PowerPlant{
+				ID:                 1,
+				Name:               "My Cool Power Plant",
+				Latitude:           39.80172,
+				Longitude:          -89.64371,
+				Elevation:          -0.44399902681403974,
+				DEMElevation:       ptr(-0.44399902681403974),
+				PowerPlantMetadata: defaultMetadata,
+			},
+		},
+		{
+			testName:  "failed, ambiguous place name",
+			name:      "My Cool Power Plant",
+			placeName: "Springfield",
+			expectErr: &types.AmbiguousPlaceError{
+				PlaceName:  "Springfield",
+				Candidates: []types.Location{fakeLocationSpringfieldMO, fakeLocationSpringfieldIL},
+			},
+		},
+		{
+			testName:  "failed, unknown place name",
+			name:      "My Cool Power Plant",
+			placeName: "Atlantis",
+			expectErr: types.ErrPlaceNotFound,
 		},
 		{
 			testName:  "failed, empty name",
@@ -64,7 +308,7 @@ func TestUsecase_CreatePowerPlant(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
-			_, err := testUsecase.CreatePowerPlant(ctx, tt.name, tt.lat, tt.long)
+			powerPlant, err := testUsecase.CreatePowerPlant(ctx, tt.name, tt.lat, tt.long, tt.placeName, tt.metadata)
 			if tt.expectErr != nil {
 				if err == nil || err.Error() != tt.expectErr.Error() {
 					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
@@ -75,6 +319,10 @@ func TestUsecase_CreatePowerPlant(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+
+			if diff := cmp.Diff(tt.expected, powerPlant, cmpopts.IgnoreFields(types.PowerPlant{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
 		})
 	}
 
@@ -85,12 +333,15 @@ func TestUsecase_UpdatePowerPlant(t *testing.T) {
 	defer cancel()
 
 	tests := []struct {
-		testName  string
-		name      string
-		lat       float64
-		long      float64
-		id        int64
-		expectErr error
+		testName string
+		name     string
+		lat      float64
+		long     float64
+		id       int64
+		metadata types.PowerPlantMetadataUpdate
+		// expectOverride is the surveyed elevation after the update.
+		expectOverride *float64
+		expectErr      error
 	}{
 		{
 			testName: "success",
@@ -99,6 +350,42 @@ func TestUsecase_UpdatePowerPlant(t *testing.T) {
 			long:     2.2,
 			id:       1,
 		},
+		{
+			testName: "success, moving removes the surveyed elevation",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			id:       fakeSurveyedPowerPlantID,
+		},
+		{
+			testName:       "success, the surveyed elevation is kept in place",
+			name:           "Renamed Power Plant",
+			lat:            22.11,
+			long:           33.11,
+			id:             fakeSurveyedPowerPlantID,
+			expectOverride: ptr(1500.0),
+		},
+		{
+			testName: "success, change to solar power plant",
+			name:     "My Cool Power Plant",
+			lat:      1.1,
+			long:     2.2,
+			id:       1,
+			metadata: types.PowerPlantMetadataUpdate{
+				Type:         ptr(types.PowerPlantTypeSolar),
+				CapacityMW:   ptr(10.0),
+				DCCapacityMW: ptr(12.0),
+			},
+		},
+		{
+			testName:  "failed, change to solar power plant without dc capacity",
+			name:      "My Cool Power Plant",
+			lat:       1.1,
+			long:      2.2,
+			id:        1,
+			metadata:  types.PowerPlantMetadataUpdate{Type: ptr(types.PowerPlantTypeSolar), CapacityMW: ptr(10.0)},
+			expectErr: types.ErrDCCapacityRequired,
+		},
 		{
 			testName:  "failed, invalid id",
 			name:      "My Cool Power Plant",
@@ -134,7 +421,7 @@ func TestUsecase_UpdatePowerPlant(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
-			_, err := testUsecase.UpdatePowerPlant(ctx, tt.id, &tt.name, &tt.lat, &tt.long)
+			powerPlant, err := testUsecase.UpdatePowerPlant(ctx, tt.id, &tt.name, &tt.lat, &tt.long, tt.metadata)
 			if tt.expectErr != nil {
 				if err == nil || err.Error() != tt.expectErr.Error() {
 					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
@@ -145,6 +432,9 @@ func TestUsecase_UpdatePowerPlant(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+			if diff := cmp.Diff(tt.expectOverride, powerPlant.ElevationOverride); diff != "" {
+				t.Fatalf("unexpected surveyed elevation (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -165,26 +455,27 @@ func TestUsecase_GetPowerPlant(t *testing.T) {
 			id:           1,
 			forecastDays: 7,
 			expected: &types.PowerPlant{
-				ID:        1,
-				Name:      "My Cool Power Plant",
-				Latitude:  22.11,
-				Longitude: 33.11,
-				Elevation: 0.6677740863787376,
+				ID:           1,
+				Name:         "My Cool Power Plant",
+				Latitude:     22.11,
+				Longitude:    33.11,
+				Elevation:    0.6677740863787376,
+				DEMElevation: ptr(0.6677740863787376),
 				WeatherForecastProperties: types.WeatherForecastProperties{
 					WeatherForecasts: []types.WeatherForecast{
 						{
 							Time:          "2024-09-06T00:00",
-							Temperature:   1.1,
-							Precipitation: 2.2,
-							WindSpeed:     3.3,
-							WindDirection: 4.4,
+							Temperature:   ptr(0.1),
+							Precipitation: ptr(0.2),
+							WindSpeed:     ptr(0.3),
+							WindDirection: ptr(0.4),
 						},
 						{
 							Time:          "2024-09-06T01:00",
-							Temperature:   11.1,
-							Precipitation: 21.2,
-							WindSpeed:     31.3,
-							WindDirection: 41.4,
+							Temperature:   ptr(0.1),
+							Precipitation: ptr(0.2),
+							WindSpeed:     ptr(0.3),
+							WindDirection: ptr(0.4),
 						},
 					},
 					HasPrecipitationToday: true,
@@ -230,6 +521,65 @@ func TestUsecase_GetPowerPlant(t *testing.T) {
 	}
 }
 
+func TestUsecase_GetPowerPlantAsOf(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName     string
+		id           int64
+		asOf         time.Time
+		expectedName string
+		expectErr    error
+	}{
+		{
+			testName:     "success, past version",
+			id:           1,
+			asOf:         time.Date(2024, 8, 31, 23, 0, 0, 0, time.UTC),
+			expectedName: "Old Power Plant",
+		},
+		{
+			testName:     "success, current version from its start",
+			id:           1,
+			asOf:         time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			expectedName: "My Cool Power Plant",
+		},
+		{
+			testName:  "failed, not created yet",
+			id:        1,
+			asOf:      time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+			expectErr: errors.New("id not found"),
+		},
+		{
+			testName:  "failed, empty id",
+			asOf:      time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			expectErr: errors.New("id is required"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			powerPlant, err := testUsecase.GetPowerPlantAsOf(ctx, tt.id, tt.asOf, 7)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if powerPlant.Name != tt.expectedName {
+				t.Fatalf("expected name: %s, got: %s", tt.expectedName, powerPlant.Name)
+			}
+			if len(powerPlant.WeatherForecasts) == 0 {
+				t.Fatal("expected the weather forecasts of the power plant")
+			}
+		})
+	}
+}
+
 func TestUsecase_GetPowerPlants(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -249,55 +599,57 @@ func TestUsecase_GetPowerPlants(t *testing.T) {
 			forecastDays: 7,
 			expected: []types.PowerPlant{
 				{
-					ID:        1,
-					Name:      "My Cool Power Plant 1",
-					Latitude:  10.22,
-					Longitude: 10.44,
-					Elevation: 0.9789272030651343,
+					ID:           1,
+					Name:         "My Cool Power Plant 1",
+					Latitude:     10.22,
+					Longitude:    10.44,
+					Elevation:    0.9789272030651343,
+					DEMElevation: ptr(0.9789272030651343),
 					WeatherForecastProperties: types.WeatherForecastProperties{
 						WeatherForecasts: []types.WeatherForecast{
 							{
 								Time:          "2024-09-06T00:00",
-								Temperature:   0.1,
-								Precipitation: 0.2,
-								WindSpeed:     0.3,
-								WindDirection: 0.4,
+								Temperature:   ptr(0.1),
+								Precipitation: ptr(0.2),
+								WindSpeed:     ptr(0.3),
+								WindDirection: ptr(0.4),
 							},
 							{
 								Time:          "2024-09-06T01:00",
-								Temperature:   0.1,
-								Precipitation: 0.2,
-								WindSpeed:     0.3,
-								WindDirection: 0.4,
+								Temperature:   ptr(0.1),
+								Precipitation: ptr(0.2),
+								WindSpeed:     ptr(0.3),
+								WindDirection: ptr(0.4),
 							},
 						},
 						HasPrecipitationToday: true,
 					},
 				},
 				{
-					ID:        2,
-					Name:      "My Cool Power Plant 2",
-					Latitude:  20.22,
-					Longitude: 20.44,
-					Elevation: 0.9892367906066535,
+					ID:           2,
+					Name:         "My Cool Power Plant 2",
+					Latitude:     20.22,
+					Longitude:    20.44,
+					Elevation:    0.9892367906066535,
+					DEMElevation: ptr(0.9892367906066535),
 					WeatherForecastProperties: types.WeatherForecastProperties{
 						WeatherForecasts: []types.WeatherForecast{
 							{
 								Time:          "2024-09-06T00:00",
-								Temperature:   1.1,
-								Precipitation: 1.2,
-								WindSpeed:     1.3,
-								WindDirection: 1.4,
+								Temperature:   ptr(1.1),
+								Precipitation: ptr(1.2),
+								WindSpeed:     ptr(1.3),
+								WindDirection: ptr(1.4),
 							},
 							{
 								Time:          "2024-09-06T01:00",
-								Temperature:   10.1,
-								Precipitation: 10.2,
-								WindSpeed:     10.3,
-								WindDirection: 10.4,
+								Temperature:   ptr(10.1),
+								Precipitation: ptr(10.2),
+								WindSpeed:     ptr(10.3),
+								WindDirection: ptr(10.4),
 							},
 						},
-						HasPrecipitationToday: false,
+						HasPrecipitationToday: true,
 					},
 				},
 			},
@@ -330,3 +682,220 @@ func TestUsecase_GetPowerPlants(t *testing.T) {
 		})
 	}
 }
+
+func TestUsecase_GetPowerPlantPartialFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName           string
+		weatherAPI         *countingWeatherAPI
+		expectForecastErr  error
+		expectElevationErr error
+		expectForecasts    bool
+		expectDEMElevation bool
+		maxDuration        time.Duration
+	}{
+		{
+			testName:           "success, forecast and elevation are fetched concurrently",
+			weatherAPI:         &countingWeatherAPI{delay: 300 * time.Millisecond},
+			expectForecasts:    true,
+			expectDEMElevation: true,
+			maxDuration:        500 * time.Millisecond,
+		},
+		{
+			testName:           "success, without forecast when the weather API fails",
+			weatherAPI:         &countingWeatherAPI{err: errors.New("unexpected status code: 502")},
+			expectForecastErr:  types.ErrInternal,
+			expectDEMElevation: true,
+		},
+		{
+			testName:           "success, without forecast when the weather API is rate limited",
+			weatherAPI:         &countingWeatherAPI{err: types.ErrUpstreamRateLimited},
+			expectForecastErr:  types.ErrUpstreamRateLimited,
+			expectDEMElevation: true,
+		},
+		{
+			testName:           "success, without elevation when the elevation API fails",
+			weatherAPI:         &countingWeatherAPI{elevationErr: errors.New("unexpected status code: 502")},
+			expectElevationErr: types.ErrInternal,
+			expectForecasts:    true,
+		},
+		{
+			testName:           "success, without both when they run out of budget",
+			weatherAPI:         &countingWeatherAPI{delay: 2 * time.Second},
+			expectForecastErr:  types.ErrInternal,
+			expectElevationErr: types.ErrInternal,
+			maxDuration:        1500 * time.Millisecond,
+		},
+		{
+			testName:           "success, without both when the rate limit cancels the slower elevation call",
+			weatherAPI:         &countingWeatherAPI{err: types.ErrUpstreamRateLimited, elevationDelay: 2 * time.Second},
+			expectForecastErr:  types.ErrUpstreamRateLimited,
+			expectElevationErr: types.ErrUpstreamRateLimited,
+			maxDuration:        500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			u := NewUsecase(tt.weatherAPI, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+
+			start := time.Now()
+			powerPlant, err := u.GetPowerPlant(ctx, 1, 7)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.maxDuration > 0 && time.Since(start) > tt.maxDuration {
+				t.Fatalf("expected to take at most %s, took: %s", tt.maxDuration, time.Since(start))
+			}
+
+			if powerPlant.Name != "My Cool Power Plant" {
+				t.Fatalf("expected the power plant from the database, got: %+v", powerPlant)
+			}
+			if powerPlant.ForecastErr != tt.expectForecastErr {
+				t.Fatalf("expected forecast error: %v, got: %v", tt.expectForecastErr, powerPlant.ForecastErr)
+			}
+			if powerPlant.ElevationErr != tt.expectElevationErr {
+				t.Fatalf("expected elevation error: %v, got: %v", tt.expectElevationErr, powerPlant.ElevationErr)
+			}
+			if got := len(powerPlant.WeatherForecasts) > 0; got != tt.expectForecasts {
+				t.Fatalf("expected forecasts: %v, got: %+v", tt.expectForecasts, powerPlant.WeatherForecasts)
+			}
+			if got := powerPlant.DEMElevation != nil; got != tt.expectDEMElevation {
+				t.Fatalf("expected elevation: %v, got: %v", tt.expectDEMElevation, powerPlant.DEMElevation)
+			}
+		})
+	}
+}
+
+func TestUsecase_GetPowerPlantsByFilter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		filter    types.PowerPlantFilter
+		lastID    int64
+		count     int
+		expected  []int64
+		expectErr error
+	}{
+		{
+			testName: "success, portfolio",
+			filter:   types.PowerPlantFilter{PortfolioID: ptr(int64(7))},
+			count:    10,
+			expected: []int64{1, 2, 3},
+		},
+		{
+			testName: "success, portfolio second page",
+			filter:   types.PowerPlantFilter{PortfolioID: ptr(int64(7))},
+			lastID:   1,
+			count:    1,
+			expected: []int64{2},
+		},
+		{
+			testName: "success, every tag",
+			filter:   types.PowerPlantFilter{Tags: []string{"offshore", "north-sea"}},
+			count:    10,
+			expected: []int64{1},
+		},
+		{
+			testName: "success, portfolio and tag",
+			filter:   types.PowerPlantFilter{PortfolioID: ptr(int64(8)), Tags: []string{"offshore"}},
+			count:    10,
+			expected: []int64{5},
+		},
+		{
+			testName:  "failed, empty tag",
+			filter:    types.PowerPlantFilter{Tags: []string{""}},
+			count:     10,
+			expectErr: types.ErrInvalidTag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			powerPlants, err := testUsecase.GetPowerPlantsByFilter(ctx, tt.filter, tt.lastID, tt.count, 7)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ids := make([]int64, 0, len(powerPlants))
+			for _, powerPlant := range powerPlants {
+				ids = append(ids, powerPlant.ID)
+			}
+			if diff := cmp.Diff(tt.expected, ids); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_AddPowerPlantTags(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		tags      []string
+		expected  []string
+		expectErr error
+	}{
+		{
+			testName: "success, trimmed, sorted and deduplicated",
+			id:       1,
+			tags:     []string{" team-north", "Fund I", "team-north "},
+			expected: []string{"Fund I", "team-north"},
+		},
+		{
+			testName:  "failed, empty tag",
+			id:        1,
+			tags:      []string{"team-north", " "},
+			expectErr: types.ErrInvalidTag,
+		},
+		{
+			testName:  "failed, tag too long",
+			id:        1,
+			tags:      []string{strings.Repeat("a", types.MaxTagLength+1)},
+			expectErr: types.ErrInvalidTag,
+		},
+		{
+			testName:  "failed, no tag",
+			id:        1,
+			expectErr: errors.New("tags are required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			tags:      []string{"team-north"},
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			powerPlant, err := testUsecase.AddPowerPlantTags(ctx, tt.id, tt.tags)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, powerPlant.Tags); diff != "" {
+				t.Fatalf("unexpected tags (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
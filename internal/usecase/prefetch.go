@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// recordAccess marks the power plants as read, so their forecasts are prefetched first.
+func (u *Usecase) recordAccess(powerPlants []types.PowerPlant) {
+	now := u.now()
+
+	u.accessedMu.Lock()
+	defer u.accessedMu.Unlock()
+	for _, powerPlant := range powerPlants {
+		u.accessed[powerPlant.ID] = now
+	}
+}
+
+// PrefetchForecasts refreshes the forecast snapshots of every power plant that are not fresh,
+// so reads are served from the snapshots without waiting on the weather API.
+// Power plants read within cfg.RecentTraffic are refreshed first, most recent first.
+// A failed batch does not stop the others, it returns the number of refreshed snapshots
+// with the errors of the failed batches.
+func (u *Usecase) PrefetchForecasts(ctx context.Context, cfg config.PrefetchConfig) (int, error) {
+	for _, forecastDays := range cfg.ForecastDays {
+		if _, ok := types.ValidForecastLengths[forecastDays]; !ok {
+			return 0, types.ErrInvalidForecastDay
+		}
+	}
+
+	keys, err := u.prefetchOrder(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		refreshed int
+		errs      []error
+	)
+	for _, forecastDays := range cfg.ForecastDays {
+		n, err := u.prefetchForecastDays(ctx, keys, forecastDays, cfg)
+		refreshed += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return refreshed, errors.Join(errs...)
+}
+
+// prefetchOrder pages through every power plant and returns their distinct grid cells,
+// with the locations of recently read power plants first.
+func (u *Usecase) prefetchOrder(ctx context.Context, cfg config.PrefetchConfig) ([]point, error) {
+	type location struct {
+		key        point
+		accessedAt time.Time
+	}
+
+	recentSince := u.now().Add(-cfg.RecentTraffic)
+
+	u.accessedMu.Lock()
+	for id, accessedAt := range u.accessed {
+		if accessedAt.Before(recentSince) {
+			delete(u.accessed, id)
+		}
+	}
+	accessed := make(map[int64]time.Time, len(u.accessed))
+	for id, accessedAt := range u.accessed {
+		accessed[id] = accessedAt
+	}
+	u.accessedMu.Unlock()
+
+	var (
+		locations []location
+		// index maps a location to its position in locations.
+		index  = map[point]int{}
+		lastID int64
+	)
+	for {
+		powerPlants, err := u.db.GetPowerPlants(ctx, lastID, cfg.BatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("error getting power plants: %w", err)
+		}
+
+		for _, powerPlant := range powerPlants {
+			key := point{u.weatherAPI.SnapToGrid(powerPlant.Latitude), u.weatherAPI.SnapToGrid(powerPlant.Longitude)}
+			i, ok := index[key]
+			if !ok {
+				i = len(locations)
+				index[key] = i
+				locations = append(locations, location{key: key})
+			}
+			if accessedAt := accessed[powerPlant.ID]; accessedAt.After(locations[i].accessedAt) {
+				locations[i].accessedAt = accessedAt
+			}
+		}
+
+		if len(powerPlants) < cfg.BatchSize {
+			break
+		}
+		lastID = powerPlants[len(powerPlants)-1].ID
+	}
+
+	// Locations never read keep a zero access time, so they stay in ID order after the read ones.
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].accessedAt.After(locations[j].accessedAt)
+	})
+
+	keys := make([]point, 0, len(locations))
+	for _, location := range locations {
+		keys = append(keys, location.key)
+	}
+	return keys, nil
+}
+
+// prefetchForecastDays refreshes the snapshots of the locations that are not fresh
+// in batches of cfg.BatchSize, with at most cfg.Concurrency batches at the same time.
+func (u *Usecase) prefetchForecastDays(ctx context.Context, keys []point, forecastDays int, cfg config.PrefetchConfig) (int, error) {
+	stale := make([]point, 0, len(keys))
+	currentInterval := u.now().Truncate(u.snapshotCfg.ModelUpdateInterval)
+	for start := 0; start < len(keys); start += cfg.BatchSize {
+		batch := keys[start:min(start+cfg.BatchSize, len(keys))]
+
+		snapshots := u.loadSnapshots(ctx, batch, forecastDays)
+		for _, key := range batch {
+			if snapshot, ok := snapshots[key]; ok && !snapshot.FetchedAt.Before(currentInterval) {
+				continue
+			}
+			stale = append(stale, key)
+		}
+	}
+
+	var (
+		group errgroup.Group
+		mu    sync.Mutex
+		count int
+		errs  []error
+	)
+	group.SetLimit(cfg.Concurrency)
+	for start := 0; start < len(stale); start += cfg.BatchSize {
+		if ctx.Err() != nil {
+			break
+		}
+		batch := stale[start:min(start+cfg.BatchSize, len(stale))]
+
+		group.Go(func() error {
+			_, err := u.fetchSnapshots(ctx, batch, forecastDays)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error prefetching %d forecasts of %d days: %w", len(batch), forecastDays, err))
+				return nil
+			}
+			count += len(batch)
+			return nil
+		})
+	}
+	group.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return count, errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUsecase_PrefetchForecasts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		weatherAPI = &countingWeatherAPI{}
+		u          = NewUsecase(weatherAPI, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+		now        = time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC)
+		cfg        = config.PrefetchConfig{
+			BatchSize:     2,
+			Concurrency:   1,
+			ForecastDays:  []int{7},
+			RecentTraffic: time.Hour,
+		}
+	)
+	u.now = func() time.Time { return now }
+
+	// Power plant 4 was read recently, so it is refreshed first.
+	if _, err := u.GetPowerPlants(ctx, 3, 1, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	weatherAPI.calls = nil
+	now = now.Add(time.Hour)
+
+	refreshed, err := u.PrefetchForecasts(ctx, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refreshed != 5 {
+		t.Fatalf("expected 5 refreshed forecasts, got: %d", refreshed)
+	}
+	if diff := cmp.Diff([][]float64{{40.22, 10.22}, {20.22, 30.22}, {50.22}}, weatherAPI.calls); diff != "" {
+		t.Fatalf("unexpected weather API calls (-want +got):\n%s", diff)
+	}
+
+	// The snapshots are fresh, so nothing is refreshed until the next model run.
+	refreshed, err = u.PrefetchForecasts(ctx, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refreshed != 0 {
+		t.Fatalf("expected no refreshed forecast, got: %d", refreshed)
+	}
+
+	// A failed batch is reported without stopping the others.
+	now = now.Add(time.Hour)
+	weatherAPI.fail(errors.New("unexpected status code: 502"))
+	refreshed, err = u.PrefetchForecasts(ctx, cfg)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if refreshed != 0 {
+		t.Fatalf("expected no refreshed forecast, got: %d", refreshed)
+	}
+	if got := weatherAPI.callCount(); got != 6 {
+		t.Fatalf("expected every batch to be tried, got: %d weather API calls", got)
+	}
+
+	_, err = u.PrefetchForecasts(ctx, config.PrefetchConfig{BatchSize: 2, Concurrency: 1, ForecastDays: []int{8}})
+	if err != types.ErrInvalidForecastDay {
+		t.Fatalf("expected error: %v, got: %v", types.ErrInvalidForecastDay, err)
+	}
+}
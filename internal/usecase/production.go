@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// RecordProduction validates and saves a batch of production readings, see types.ProductionIngestResult.
+// The readings are validated against the capacity of their power plant, read once per batch, and
+// a reading without quality is MEASURED. Invalid readings are rejected with the reason, the valid
+// ones are saved all at once.
+func (u *Usecase) RecordProduction(ctx context.Context, readings []types.ProductionReading) (*types.ProductionIngestResult, error) {
+	if len(readings) > types.MaxProductionBatch {
+		return nil, types.ErrProductionBatchTooLarge
+	}
+
+	result := &types.ProductionIngestResult{
+		Received:   len(readings),
+		Rejections: []types.ProductionRejection{},
+	}
+	reject := func(i int, err error) {
+		result.Rejected++
+		result.Rejections = append(result.Rejections, types.ProductionRejection{Row: i + 1, Error: err.Error()})
+	}
+
+	now := u.now().UTC()
+	powerPlants := map[int64]*types.PowerPlant{}
+	valid := make([]types.ProductionReading, 0, len(readings))
+	for i, reading := range readings {
+		if reading.PowerPlantID == 0 {
+			reject(i, errors.New("powerPlantID is required"))
+			continue
+		}
+
+		powerPlant, ok := powerPlants[reading.PowerPlantID]
+		if !ok {
+			var err error
+			powerPlant, err = u.db.GetPowerPlant(ctx, reading.PowerPlantID)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				powerPlant = nil
+			case err != nil:
+				u.logger.Printf("error getting power plant: %v", err)
+				return nil, types.ErrInternal
+			}
+			powerPlants[reading.PowerPlantID] = powerPlant
+		}
+		if powerPlant == nil {
+			reject(i, types.ErrPowerPlantNotFound)
+			continue
+		}
+
+		if reading.Quality == "" {
+			reading.Quality = types.ProductionQualityMeasured
+		}
+		reading.Time = reading.Time.UTC()
+		if err := reading.Validate(*powerPlant, now); err != nil {
+			reject(i, err)
+			continue
+		}
+		valid = append(valid, reading)
+	}
+
+	if len(valid) == 0 {
+		return result, nil
+	}
+
+	inserted, updated, err := u.db.RecordProductionReadings(ctx, valid)
+	if err != nil {
+		u.logger.Printf("error recording production readings: %v", err)
+		return nil, types.ErrInternal
+	}
+	result.Inserted = inserted
+	result.Updated = updated
+	result.Duplicates = len(valid) - inserted - updated
+
+	return result, nil
+}
+
+// GetProductionReadings returns the production readings of a power plant from from to to, excluded.
+func (u *Usecase) GetProductionReadings(ctx context.Context, powerPlantID int64, from time.Time, to time.Time) ([]types.ProductionReading, error) {
+	if !from.Before(to) || to.Sub(from) > types.MaxProductionRange {
+		return nil, types.ErrInvalidProductionRange
+	}
+
+	readings, err := u.db.GetProductionReadings(ctx, powerPlantID, from, to)
+	if err != nil {
+		u.logger.Printf("error getting production readings: %v", err)
+		return nil, types.ErrInternal
+	}
+	return readings, nil
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUsecase_RecordProduction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	now := time.Date(2024, 9, 6, 12, 0, 0, 0, time.UTC)
+	reading := func(powerPlantID int64, minutes int, powerMW float64) types.ProductionReading {
+		return types.ProductionReading{
+			PowerPlantID: powerPlantID,
+			Time:         now.Add(time.Duration(minutes) * time.Minute),
+			PowerMW:      powerMW,
+		}
+	}
+
+	tests := []struct {
+		testName  string
+		readings  []types.ProductionReading
+		expected  *types.ProductionIngestResult
+		expectErr error
+	}{
+		{
+			testName: "success, duplicates recorded once",
+			readings: []types.ProductionReading{
+				reading(101, -60, 12),
+				reading(101, -45, 12.5),
+				reading(101, -45, 13),
+				reading(102, -60, -5),
+			},
+			expected: &types.ProductionIngestResult{
+				Received: 4, Inserted: 3, Duplicates: 1, Rejections: []types.ProductionRejection{},
+			},
+		},
+		{
+			testName: "success, invalid readings rejected",
+			readings: []types.ProductionReading{
+				reading(101, -30, 33),
+				reading(101, -30, 33.5),
+				reading(101, -30, -3.5),
+				reading(101, 10, 1),
+				{PowerPlantID: 101, PowerMW: 1},
+				reading(101, -30, math.NaN()),
+				{PowerPlantID: 101, Time: now, PowerMW: 1, Quality: "GUESSED"},
+				reading(102, -30, -5.5),
+				reading(999, -30, 1),
+				reading(0, -30, 1),
+			},
+			expected: &types.ProductionIngestResult{
+				Received: 10, Inserted: 2, Rejected: 8,
+				Rejections: []types.ProductionRejection{
+					{Row: 2, Error: types.ErrProductionExceedsCapacity.Error()},
+					{Row: 3, Error: types.ErrProductionExceedsCapacity.Error()},
+					{Row: 4, Error: types.ErrProductionInFuture.Error()},
+					{Row: 5, Error: types.ErrProductionTimeRequired.Error()},
+					{Row: 6, Error: types.ErrInvalidProductionPower.Error()},
+					{Row: 7, Error: types.ErrInvalidProductionQuality.Error()},
+					{Row: 9, Error: types.ErrPowerPlantNotFound.Error()},
+					{Row: 10, Error: "powerPlantID is required"},
+				},
+			},
+		},
+		{
+			testName:  "failed, batch too large",
+			readings:  make([]types.ProductionReading, types.MaxProductionBatch+1),
+			expectErr: types.ErrProductionBatchTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			u := NewUsecase(&fakeWeatherAPI{}, &fakeDB{}, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+			u.now = func() time.Time { return now }
+
+			result, err := u.RecordProduction(ctx, tt.readings)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_RecordProduction_Replaces(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	db := &fakeDB{}
+	u := NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+
+	at := time.Date(2024, 9, 6, 0, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	if _, err := u.RecordProduction(ctx, []types.ProductionReading{{PowerPlantID: 101, Time: at, PowerMW: 10}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := u.RecordProduction(ctx, []types.ProductionReading{
+		{PowerPlantID: 101, Time: at.UTC(), PowerMW: 11, Quality: types.ProductionQualityEstimated},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Inserted != 0 || result.Updated != 1 {
+		t.Fatalf("expected the reading to be replaced, got: %+v", result)
+	}
+
+	expected := types.ProductionReading{PowerPlantID: 101, Time: at.UTC(), PowerMW: 11, Quality: types.ProductionQualityEstimated}
+	if got := db.production[productionKey{101, at.UTC()}]; got != expected {
+		t.Fatalf("expected reading: %+v, got: %+v", expected, got)
+	}
+}
+
+func TestUsecase_GetProductionReadings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	from := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		testName  string
+		to        time.Time
+		expectErr error
+	}{
+		{
+			testName: "success",
+			to:       from.Add(types.MaxProductionRange),
+		},
+		{
+			testName:  "failed, to before from",
+			to:        from,
+			expectErr: types.ErrInvalidProductionRange,
+		},
+		{
+			testName:  "failed, range too long",
+			to:        from.Add(types.MaxProductionRange + time.Hour),
+			expectErr: types.ErrInvalidProductionRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			_, err := testUsecase.GetProductionReadings(ctx, 101, from, tt.to)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// CreateTurbineModel validates and creates a new turbine model.
+func (u *Usecase) CreateTurbineModel(ctx context.Context, model types.TurbineModel) (*types.TurbineModel, error) {
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := u.db.CreateTurbineModel(ctx, &model)
+	if err != nil {
+		u.logger.Printf("error creating turbine model: %v", err)
+		return nil, types.ErrInternal
+	}
+	return created, nil
+}
+
+// UpdateTurbineModel updates a turbine model by ID, the model is validated again as a whole after the update.
+// The generation forecasts of the power plants using it change with it.
+func (u *Usecase) UpdateTurbineModel(ctx context.Context, id int64, update types.TurbineModelUpdate) (*types.TurbineModel, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	model, err := u.GetTurbineModel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update.Apply(model)
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	model, err = u.db.UpdateTurbineModel(ctx, model)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error updating turbine model: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return model, nil
+}
+
+// DeleteTurbineModel deletes a turbine model by ID.
+// It fails with types.ErrTurbineModelInUse while power plants are assigned to it.
+func (u *Usecase) DeleteTurbineModel(ctx context.Context, id int64) error {
+	if id == 0 {
+		return errors.New("id is required")
+	}
+
+	err := u.db.DeleteTurbineModel(ctx, id)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errors.New("id not found")
+	case errors.Is(err, types.ErrTurbineModelInUse):
+		return err
+	default:
+		u.logger.Printf("error deleting turbine model: %v", err)
+		return types.ErrInternal
+	}
+}
+
+// GetTurbineModel returns a turbine model by ID.
+func (u *Usecase) GetTurbineModel(ctx context.Context, id int64) (*types.TurbineModel, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	model, err := u.db.GetTurbineModel(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting turbine model: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return model, nil
+}
+
+// GetTurbineModels returns a list of turbine models, paginated like GetPowerPlants.
+func (u *Usecase) GetTurbineModels(ctx context.Context, lastID int64, count int) ([]types.TurbineModel, error) {
+	models, err := u.db.GetTurbineModels(ctx, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting turbine models: %v", err)
+		return nil, types.ErrInternal
+	}
+	return models, nil
+}
+
+// checkTurbineModel checks that the turbine model assigned to a power plant exists, if one is assigned.
+func (u *Usecase) checkTurbineModel(ctx context.Context, id *int64) error {
+	if id == nil {
+		return nil
+	}
+
+	_, err := u.db.GetTurbineModel(ctx, *id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return types.ErrTurbineModelNotFound
+		default:
+			u.logger.Printf("error getting turbine model: %v", err)
+			return types.ErrInternal
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestUsecase_CreateTurbineModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	unsorted := fakeTurbineModel
+	unsorted.PowerCurve = []types.PowerCurvePoint{{WindSpeed: 8, PowerKW: 886}, {WindSpeed: 3, PowerKW: 0}}
+
+	aboveRated := fakeTurbineModel
+	aboveRated.PowerCurve = []types.PowerCurvePoint{{WindSpeed: 3, PowerKW: 0}, {WindSpeed: 12, PowerKW: 2100}}
+
+	tests := []struct {
+		testName  string
+		model     types.TurbineModel
+		expected  *types.TurbineModel
+		expectErr error
+	}{
+		{
+			testName: "success",
+			model:    fakeTurbineModel,
+			expected: &types.TurbineModel{
+				ID:           1,
+				Name:         fakeTurbineModel.Name,
+				Manufacturer: fakeTurbineModel.Manufacturer,
+				RatedPowerKW: fakeTurbineModel.RatedPowerKW,
+				CutInSpeed:   fakeTurbineModel.CutInSpeed,
+				CutOutSpeed:  fakeTurbineModel.CutOutSpeed,
+				PowerCurve:   fakeTurbineModel.PowerCurve,
+			},
+		},
+		{
+			testName:  "failed, empty name",
+			model:     types.TurbineModel{RatedPowerKW: 2000, CutInSpeed: 3, CutOutSpeed: 25},
+			expectErr: types.ErrTurbineModelNameRequired,
+		},
+		{
+			testName:  "failed, cut-out below cut-in",
+			model:     types.TurbineModel{Name: "V90-2.0", RatedPowerKW: 2000, CutInSpeed: 25, CutOutSpeed: 3},
+			expectErr: types.ErrInvalidCutInCutOut,
+		},
+		{
+			testName:  "failed, single point power curve",
+			model:     types.TurbineModel{Name: "V90-2.0", RatedPowerKW: 2000, CutInSpeed: 3, CutOutSpeed: 25, PowerCurve: []types.PowerCurvePoint{{WindSpeed: 3}}},
+			expectErr: types.ErrPowerCurveTooShort,
+		},
+		{
+			testName:  "failed, unsorted power curve",
+			model:     unsorted,
+			expectErr: types.ErrPowerCurveNotSorted,
+		},
+		{
+			testName:  "failed, power curve above the rated power",
+			model:     aboveRated,
+			expectErr: types.ErrInvalidPowerCurvePower,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			model, err := testUsecase.CreateTurbineModel(ctx, tt.model)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, model, cmpopts.IgnoreFields(types.TurbineModel{}, "CreatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_UpdateTurbineModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		update    types.TurbineModelUpdate
+		expected  *types.TurbineModel
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       1,
+			update:   types.TurbineModelUpdate{Name: ptr("V90-1.8"), RatedPowerKW: ptr(2100.0)},
+			expected: &types.TurbineModel{
+				ID:           1,
+				Name:         "V90-1.8",
+				Manufacturer: fakeTurbineModel.Manufacturer,
+				RatedPowerKW: 2100,
+				CutInSpeed:   fakeTurbineModel.CutInSpeed,
+				CutOutSpeed:  fakeTurbineModel.CutOutSpeed,
+				PowerCurve:   fakeTurbineModel.PowerCurve,
+			},
+		},
+		{
+			testName:  "failed, rated power below the power curve",
+			id:        1,
+			update:    types.TurbineModelUpdate{RatedPowerKW: ptr(1800.0)},
+			expectErr: types.ErrInvalidPowerCurvePower,
+		},
+		{
+			testName:  "failed, empty id",
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			model, err := testUsecase.UpdateTurbineModel(ctx, tt.id, tt.update)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, model, cmpopts.IgnoreFields(types.TurbineModel{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_DeleteTurbineModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       1,
+		},
+		{
+			testName:  "failed, assigned to power plants",
+			id:        fakeTurbineModelInUse,
+			expectErr: types.ErrTurbineModelInUse,
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := testUsecase.DeleteTurbineModel(ctx, tt.id)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
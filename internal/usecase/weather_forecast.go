@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"math"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// maxGapFillHours is the longest gap FillForecastGaps can be asked to interpolate.
+const maxGapFillHours = 24
+
+// FillForecastGaps fills gaps of at most maxHours consecutive missing values with a linear
+// interpolation between the values around the gap, each variable is filled on its own.
+// Longer gaps and gaps at the start or the end of the forecasts are left missing.
+// Filled forecasts are marked as interpolated, the given forecasts are not modified.
+func FillForecastGaps(forecasts []types.WeatherForecast, maxHours int) ([]types.WeatherForecast, error) {
+	if maxHours < 0 || maxHours > maxGapFillHours {
+		return nil, types.ErrInvalidGapFillHours
+	}
+
+	filled := make([]types.WeatherForecast, len(forecasts))
+	copy(filled, forecasts)
+	if maxHours == 0 {
+		return filled, nil
+	}
+
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.Temperature }, interpolateLinear)
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.Precipitation }, interpolateLinear)
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.WindSpeed }, interpolateLinear)
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.WindDirection }, interpolateDegrees)
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.ShortwaveRadiation }, interpolateLinear)
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.WindSpeed100m }, interpolateLinear)
+	fillGaps(filled, maxHours, func(f *types.WeatherForecast) **float64 { return &f.PressureMSL }, interpolateLinear)
+
+	return filled, nil
+}
+
+// fillGaps fills the gaps of the variable selected by field.
+func fillGaps(
+	forecasts []types.WeatherForecast,
+	maxHours int,
+	field func(*types.WeatherForecast) **float64,
+	interpolate func(from, to, ratio float64) float64,
+) {
+	// last is the index of the last known value.
+	last := -1
+	for i := range forecasts {
+		to := *field(&forecasts[i])
+		if to == nil {
+			continue
+		}
+
+		if gap := i - last - 1; last >= 0 && gap > 0 && gap <= maxHours {
+			from := **field(&forecasts[last])
+			for j := last + 1; j < i; j++ {
+				value := interpolate(from, *to, float64(j-last)/float64(i-last))
+				*field(&forecasts[j]) = &value
+				forecasts[j].Interpolated = true
+			}
+		}
+		last = i
+	}
+}
+
+func interpolateLinear(from, to, ratio float64) float64 {
+	return from + (to-from)*ratio
+}
+
+// interpolateDegrees interpolates directions along the shortest arc, e.g. 350° to 10° passes 0°.
+func interpolateDegrees(from, to, ratio float64) float64 {
+	delta := math.Mod(to-from+540, 360) - 180
+	return math.Mod(from+delta*ratio+360, 360)
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFillForecastGaps(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		{Time: "2024-09-06T00:00", Temperature: ptr(10.0), Precipitation: nil, WindSpeed: ptr(5.0), WindDirection: ptr(350.0)},
+		{Time: "2024-09-06T01:00", Temperature: nil, Precipitation: ptr(1.0), WindSpeed: nil, WindDirection: nil},
+		{Time: "2024-09-06T02:00", Temperature: nil, Precipitation: ptr(2.0), WindSpeed: nil, WindDirection: ptr(10.0)},
+		{Time: "2024-09-06T03:00", Temperature: ptr(13.0), Precipitation: nil, WindSpeed: nil, WindDirection: ptr(20.0)},
+		{Time: "2024-09-06T04:00", Temperature: ptr(14.0), Precipitation: nil, WindSpeed: ptr(9.0), WindDirection: ptr(30.0)},
+	}
+
+	tests := []struct {
+		name      string
+		maxHours  int
+		expected  []types.WeatherForecast
+		expectErr error
+	}{
+		{
+			name:      "failed, negative hours",
+			maxHours:  -1,
+			expectErr: types.ErrInvalidGapFillHours,
+		},
+		{
+			name:      "failed, too many hours",
+			maxHours:  25,
+			expectErr: types.ErrInvalidGapFillHours,
+		},
+		{
+			name:     "success, disabled",
+			maxHours: 0,
+			expected: forecasts,
+		},
+		{
+			name:     "success, short gaps only",
+			maxHours: 2,
+			expected: []types.WeatherForecast{
+				{Time: "2024-09-06T00:00", Temperature: ptr(10.0), Precipitation: nil, WindSpeed: ptr(5.0), WindDirection: ptr(350.0)},
+				{Time: "2024-09-06T01:00", Temperature: ptr(11.0), Precipitation: ptr(1.0), WindSpeed: nil, WindDirection: ptr(0.0), Interpolated: true},
+				{Time: "2024-09-06T02:00", Temperature: ptr(12.0), Precipitation: ptr(2.0), WindSpeed: nil, WindDirection: ptr(10.0), Interpolated: true},
+				{Time: "2024-09-06T03:00", Temperature: ptr(13.0), Precipitation: nil, WindSpeed: nil, WindDirection: ptr(20.0)},
+				{Time: "2024-09-06T04:00", Temperature: ptr(14.0), Precipitation: nil, WindSpeed: ptr(9.0), WindDirection: ptr(30.0)},
+			},
+		},
+		{
+			name:     "success, long gaps",
+			maxHours: 3,
+			expected: []types.WeatherForecast{
+				{Time: "2024-09-06T00:00", Temperature: ptr(10.0), Precipitation: nil, WindSpeed: ptr(5.0), WindDirection: ptr(350.0)},
+				{Time: "2024-09-06T01:00", Temperature: ptr(11.0), Precipitation: ptr(1.0), WindSpeed: ptr(6.0), WindDirection: ptr(0.0), Interpolated: true},
+				{Time: "2024-09-06T02:00", Temperature: ptr(12.0), Precipitation: ptr(2.0), WindSpeed: ptr(7.0), WindDirection: ptr(10.0), Interpolated: true},
+				{Time: "2024-09-06T03:00", Temperature: ptr(13.0), Precipitation: nil, WindSpeed: ptr(8.0), WindDirection: ptr(20.0), Interpolated: true},
+				{Time: "2024-09-06T04:00", Temperature: ptr(14.0), Precipitation: nil, WindSpeed: ptr(9.0), WindDirection: ptr(30.0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filled, err := FillForecastGaps(forecasts, tt.maxHours)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, filled, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if forecasts[1].Temperature != nil {
+		t.Fatalf("expected input forecasts to be left untouched")
+	}
+}
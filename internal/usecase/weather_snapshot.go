@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// refreshKey identifies a snapshot being refreshed.
+type refreshKey struct {
+	point
+	forecastDays int
+}
+
+// getWeatherForecasts returns the weather forecasts of the locations, in the order of the input.
+// The locations are snapped to the weather model grid, so every grid cell is looked up and fetched once.
+//  1. Fresh snapshots, fetched within the current model update interval, are served as is.
+//  2. Snapshots that are not fresh but within the stale-while-revalidate window are served,
+//     and refreshed in the background.
+//  3. The other locations are fetched from the weather API and saved as snapshots.
+//     If the weather API fails, their snapshots are served regardless of their age;
+//     it only fails when a location has no snapshot at all.
+//
+// HasPrecipitationToday is computed from the hourly forecasts of today (UTC) when they are served,
+// so a snapshot fetched yesterday does not report the precipitation of yesterday.
+func (u *Usecase) getWeatherForecasts(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherForecastProperties, error) {
+	points, indexes := dedupePoints(latitudes, longitudes, u.weatherAPI.SnapToGrid)
+
+	snapshots := u.loadSnapshots(ctx, points, forecastDays)
+
+	var (
+		now             = u.now()
+		currentInterval = now.Truncate(u.snapshotCfg.ModelUpdateInterval)
+		stale           []point
+		missing         []point
+	)
+	for _, p := range points {
+		snapshot, ok := snapshots[p]
+		switch {
+		case !ok:
+			missing = append(missing, p)
+		case !snapshot.FetchedAt.Before(currentInterval):
+			// fresh
+		case now.Sub(snapshot.FetchedAt) <= u.snapshotCfg.StaleWhileRevalidate:
+			stale = append(stale, p)
+		default:
+			missing = append(missing, p)
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := u.fetchSnapshots(ctx, missing, forecastDays)
+		if err != nil {
+			for _, p := range missing {
+				if _, ok := snapshots[p]; !ok {
+					return nil, err
+				}
+			}
+			u.logger.Printf("serving stale weather snapshots: %v", err)
+		}
+		for _, snapshot := range fetched {
+			snapshots[point{snapshot.Latitude, snapshot.Longitude}] = snapshot
+		}
+	}
+
+	if len(stale) > 0 {
+		u.refreshSnapshots(ctx, stale, forecastDays)
+	}
+
+	today := now.UTC().Format(time.DateOnly)
+	forecasts := make([]types.WeatherForecastProperties, len(indexes))
+	for i, index := range indexes {
+		forecasts[i] = snapshots[points[index]].Forecast
+		forecasts[i].HasPrecipitationToday = hasPrecipitationOn(forecasts[i].WeatherForecasts, today)
+	}
+
+	return forecasts, nil
+}
+
+// hasPrecipitationOn returns true if an hourly forecast of the day, formatted as time.DateOnly, has precipitation.
+// A missing value is treated as no precipitation.
+func hasPrecipitationOn(forecasts []types.WeatherForecast, day string) bool {
+	for _, forecast := range forecasts {
+		if strings.HasPrefix(forecast.Time, day) && forecast.Precipitation != nil && *forecast.Precipitation > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSnapshots returns the stored snapshots of the locations.
+// The snapshots only save weather API calls, so errors are logged and no snapshot is returned.
+func (u *Usecase) loadSnapshots(ctx context.Context, points []point, forecastDays int) map[point]types.WeatherSnapshot {
+	lats, longs := splitPoints(points)
+
+	snapshots := make(map[point]types.WeatherSnapshot, len(points))
+	stored, err := u.snapshots.GetWeatherSnapshots(ctx, lats, longs, forecastDays)
+	if err != nil {
+		u.logger.Printf("error getting weather snapshots: %v", err)
+		return snapshots
+	}
+
+	for _, snapshot := range stored {
+		snapshots[point{snapshot.Latitude, snapshot.Longitude}] = snapshot
+	}
+
+	return snapshots
+}
+
+// fetchSnapshots fetches the forecasts of the locations from the weather API and saves them as snapshots.
+// Saving errors are only logged, the fetched snapshots are returned anyway.
+func (u *Usecase) fetchSnapshots(ctx context.Context, points []point, forecastDays int) ([]types.WeatherSnapshot, error) {
+	lats, longs := splitPoints(points)
+
+	forecasts, err := u.weatherAPI.GetWeatherForecasts(ctx, lats, longs, forecastDays)
+	if err != nil {
+		return nil, err
+	}
+	if len(forecasts) != len(points) {
+		return nil, fmt.Errorf("forecast count %d does not match location count %d", len(forecasts), len(points))
+	}
+
+	fetchedAt := u.now()
+	snapshots := make([]types.WeatherSnapshot, 0, len(points))
+	for i, p := range points {
+		snapshots = append(snapshots, types.WeatherSnapshot{
+			Latitude:     p.latitude,
+			Longitude:    p.longitude,
+			ForecastDays: forecastDays,
+			Forecast:     forecasts[i],
+			FetchedAt:    fetchedAt,
+		})
+	}
+
+	if err := u.snapshots.SaveWeatherSnapshots(ctx, snapshots); err != nil {
+		u.logger.Printf("error saving weather snapshots: %v", err)
+	}
+
+	return snapshots, nil
+}
+
+// refreshSnapshots refreshes the snapshots of the locations in the background.
+// Locations already being refreshed are skipped.
+func (u *Usecase) refreshSnapshots(ctx context.Context, points []point, forecastDays int) {
+	u.refreshingMu.Lock()
+	refresh := make([]point, 0, len(points))
+	for _, p := range points {
+		key := refreshKey{p, forecastDays}
+		if _, ok := u.refreshing[key]; ok {
+			continue
+		}
+		u.refreshing[key] = struct{}{}
+		refresh = append(refresh, p)
+	}
+	u.refreshingMu.Unlock()
+
+	if len(refresh) == 0 {
+		return
+	}
+
+	// The refresh outlives the request, so it must not be canceled with it.
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.snapshotCfg.RefreshTimeout)
+
+	u.background.Add(1)
+	go func() {
+		defer u.background.Done()
+		defer cancel()
+		defer func() {
+			u.refreshingMu.Lock()
+			for _, p := range refresh {
+				delete(u.refreshing, refreshKey{p, forecastDays})
+			}
+			u.refreshingMu.Unlock()
+		}()
+
+		if _, err := u.fetchSnapshots(refreshCtx, refresh, forecastDays); err != nil {
+			u.logger.Printf("error refreshing weather snapshots: %v", err)
+		}
+	}()
+}
+
+// Wait waits for the background work of the usecase, e.g. snapshot refreshes, to finish.
+func (u *Usecase) Wait() {
+	u.background.Wait()
+}
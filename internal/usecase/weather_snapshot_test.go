@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingWeatherAPI counts the forecast calls of fakeWeatherAPI, and fails them when err is set.
+// The forecast and elevation calls take delay, or elevationDelay when set for the elevation calls,
+// or until the context is done.
+type countingWeatherAPI struct {
+	fakeWeatherAPI
+
+	mu             sync.Mutex
+	calls          [][]float64
+	err            error
+	elevationErr   error
+	delay          time.Duration
+	elevationDelay time.Duration
+}
+
+func (c *countingWeatherAPI) GetWeatherForecasts(ctx context.Context, latitudes []float64, longitudes []float64, forecastDays int) ([]types.WeatherForecastProperties, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, latitudes)
+	err := c.err
+	c.mu.Unlock()
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.fakeWeatherAPI.GetWeatherForecasts(ctx, latitudes, longitudes, forecastDays)
+}
+
+func (c *countingWeatherAPI) GetElevations(ctx context.Context, latitudes []float64, longitudes []float64) ([]float64, error) {
+	delay := c.delay
+	if c.elevationDelay > 0 {
+		delay = c.elevationDelay
+	}
+	if err := c.waitFor(ctx, delay); err != nil {
+		return nil, err
+	}
+	if c.elevationErr != nil {
+		return nil, c.elevationErr
+	}
+	return c.fakeWeatherAPI.GetElevations(ctx, latitudes, longitudes)
+}
+
+func (c *countingWeatherAPI) wait(ctx context.Context) error {
+	return c.waitFor(ctx, c.delay)
+}
+
+func (c *countingWeatherAPI) waitFor(ctx context.Context, delay time.Duration) error {
+	if delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+func (c *countingWeatherAPI) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *countingWeatherAPI) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.calls)
+}
+
+func TestUsecase_getWeatherForecasts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		weatherAPI = &countingWeatherAPI{}
+		store      = newFakeSnapshotStore()
+		u          = NewUsecase(weatherAPI, &fakeDB{}, store, testSnapshotConfig, testReadTimeouts)
+		now        = time.Date(2024, 9, 6, 10, 30, 0, 0, time.UTC)
+	)
+	u.now = func() time.Time { return now }
+
+	// The locations in the same grid cell are fetched once.
+	forecasts, err := u.getWeatherForecasts(ctx, []float64{10.221, 20.22, 10.22}, []float64{10.44, 20.44, 10.439}, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([][]float64{{10.22, 20.22}}, weatherAPI.calls); diff != "" {
+		t.Fatalf("unexpected weather API calls (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(forecasts[0], forecasts[2]); diff != "" {
+		t.Fatalf("expected duplicated locations to share a forecast (-want +got):\n%s", diff)
+	}
+	if len(store.snapshots) != 2 {
+		t.Fatalf("expected 2 saved snapshots, got: %d", len(store.snapshots))
+	}
+
+	// Fresh snapshots are served without calling the weather API.
+	fresh, err := u.getWeatherForecasts(ctx, []float64{10.22}, []float64{10.44}, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(forecasts[0], fresh[0]); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+	if got := weatherAPI.callCount(); got != 1 {
+		t.Fatalf("expected 1 weather API call, got: %d", got)
+	}
+
+	// After the next model run, the stale snapshot is served and refreshed in the background.
+	now = time.Date(2024, 9, 6, 11, 5, 0, 0, time.UTC)
+	stale, err := u.getWeatherForecasts(ctx, []float64{10.22}, []float64{10.44}, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(forecasts[0], stale[0]); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+	u.Wait()
+	if got := weatherAPI.callCount(); got != 2 {
+		t.Fatalf("expected a background refresh, got: %d weather API calls", got)
+	}
+	snapshot := store.snapshots[refreshKey{point{10.22, 10.44}, 7}]
+	if !snapshot.FetchedAt.Equal(now) {
+		t.Fatalf("expected the snapshot refreshed at %v, got: %v", now, snapshot.FetchedAt)
+	}
+
+	// Past the stale-while-revalidate window, the weather API is called right away,
+	// and the old snapshot is served when it fails.
+	now = now.Add(24 * time.Hour)
+	weatherAPI.fail(errors.New("unexpected status code: 502"))
+	fallback, err := u.getWeatherForecasts(ctx, []float64{10.22}, []float64{10.44}, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The precipitation of the old snapshot fell yesterday.
+	expected := forecasts[0]
+	expected.HasPrecipitationToday = false
+	if diff := cmp.Diff(expected, fallback[0]); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+	if got := weatherAPI.callCount(); got != 3 {
+		t.Fatalf("expected 3 weather API calls, got: %d", got)
+	}
+
+	// Without any snapshot, the weather API error is returned.
+	_, err = u.getWeatherForecasts(ctx, []float64{30.22}, []float64{30.44}, 7)
+	if err == nil || err.Error() != "unexpected status code: 502" {
+		t.Fatalf("expected error: unexpected status code: 502, got: %v", err)
+	}
+}
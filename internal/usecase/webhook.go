@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+// CreateWebhookSubscription validates and creates a new webhook subscription.
+func (u *Usecase) CreateWebhookSubscription(ctx context.Context, subscription types.WebhookSubscription) (*types.WebhookSubscription, error) {
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := u.db.CreateWebhookSubscription(ctx, &subscription)
+	if err != nil {
+		u.logger.Printf("error creating webhook subscription: %v", err)
+		return nil, types.ErrInternal
+	}
+	return created, nil
+}
+
+// UpdateWebhookSubscription updates a webhook subscription by ID, the subscription is validated again
+// as a whole after the update. The queued deliveries are sent to the new URL, signed with the new secret.
+func (u *Usecase) UpdateWebhookSubscription(ctx context.Context, id int64, update types.WebhookSubscriptionUpdate) (*types.WebhookSubscription, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	subscription, err := u.db.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error getting webhook subscription: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+
+	update.Apply(subscription)
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	subscription, err = u.db.UpdateWebhookSubscription(ctx, subscription)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error updating webhook subscription: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return subscription, nil
+}
+
+// DeleteWebhookSubscription deletes a webhook subscription by ID, with its deliveries.
+func (u *Usecase) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	if id == 0 {
+		return errors.New("id is required")
+	}
+
+	err := u.db.DeleteWebhookSubscription(ctx, id)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errors.New("id not found")
+	default:
+		u.logger.Printf("error deleting webhook subscription: %v", err)
+		return types.ErrInternal
+	}
+}
+
+// GetWebhookSubscriptions returns a list of webhook subscriptions, paginated like GetPowerPlants.
+func (u *Usecase) GetWebhookSubscriptions(ctx context.Context, lastID int64, count int) ([]types.WebhookSubscription, error) {
+	subscriptions, err := u.db.GetWebhookSubscriptions(ctx, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting webhook subscriptions: %v", err)
+		return nil, types.ErrInternal
+	}
+	return subscriptions, nil
+}
+
+// GetWebhookDeliveries returns the webhook deliveries, of every subscription when subscriptionID is nil
+// and of every status when status is nil, paginated like GetPowerPlants.
+func (u *Usecase) GetWebhookDeliveries(ctx context.Context, subscriptionID *int64, status *types.WebhookDeliveryStatus, lastID int64, count int) ([]types.WebhookDelivery, error) {
+	deliveries, err := u.db.GetWebhookDeliveries(ctx, subscriptionID, status, lastID, count)
+	if err != nil {
+		u.logger.Printf("error getting webhook deliveries: %v", err)
+		return nil, types.ErrInternal
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDeliveryAttempts returns the delivery log of a webhook delivery.
+func (u *Usecase) GetWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]types.WebhookDeliveryAttempt, error) {
+	attempts, err := u.db.GetWebhookDeliveryAttempts(ctx, deliveryID)
+	if err != nil {
+		u.logger.Printf("error getting webhook delivery attempts: %v", err)
+		return nil, types.ErrInternal
+	}
+	return attempts, nil
+}
+
+// RedeliverWebhook queues a webhook delivery again, whatever its status, e.g. a dead one once
+// the receiver is fixed. It is attempted right away with a fresh count of attempts.
+func (u *Usecase) RedeliverWebhook(ctx context.Context, id int64) (*types.WebhookDelivery, error) {
+	if id == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	delivery, err := u.db.RedeliverWebhook(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, errors.New("id not found")
+		default:
+			u.logger.Printf("error redelivering webhook: %v", err)
+			return nil, types.ErrInternal
+		}
+	}
+	return delivery, nil
+}
+
+// publishEvent queues the event for the webhook subscriptions to its type.
+// A failure is logged, it does not fail the change that raised the event.
+func (u *Usecase) publishEvent(ctx context.Context, eventType types.WebhookEventType, data any) {
+	payload, err := json.Marshal(types.WebhookEvent{
+		Type:       eventType,
+		OccurredAt: u.now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		u.logger.Printf("error marshaling %s webhook event: %v", eventType, err)
+		return
+	}
+
+	if _, err := u.db.EnqueueWebhookEvent(ctx, eventType, payload); err != nil {
+		u.logger.Printf("error queuing %s webhook event: %v", eventType, err)
+	}
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestUsecase_CreateWebhookSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	valid := types.WebhookSubscription{
+		URL:        "https://incidents.example.com/hooks/energy",
+		EventTypes: []types.WebhookEventType{types.WebhookEventAlertTriggered, types.WebhookEventAlertResolved},
+		Secret:     "0123456789abcdef",
+	}
+
+	tests := []struct {
+		testName     string
+		subscription func(s types.WebhookSubscription) types.WebhookSubscription
+		expectErr    error
+	}{
+		{
+			testName:     "success",
+			subscription: func(s types.WebhookSubscription) types.WebhookSubscription { return s },
+		},
+		{
+			testName: "failed, relative url",
+			subscription: func(s types.WebhookSubscription) types.WebhookSubscription {
+				s.URL = "/hooks/energy"
+				return s
+			},
+			expectErr: types.ErrInvalidWebhookURL,
+		},
+		{
+			testName: "failed, unsupported scheme",
+			subscription: func(s types.WebhookSubscription) types.WebhookSubscription {
+				s.URL = "ftp://incidents.example.com/hooks"
+				return s
+			},
+			expectErr: types.ErrInvalidWebhookURL,
+		},
+		{
+			testName: "failed, empty event types",
+			subscription: func(s types.WebhookSubscription) types.WebhookSubscription {
+				s.EventTypes = nil
+				return s
+			},
+			expectErr: types.ErrWebhookEventTypeRequired,
+		},
+		{
+			testName: "failed, invalid event type",
+			subscription: func(s types.WebhookSubscription) types.WebhookSubscription {
+				s.EventTypes = []types.WebhookEventType{"POWER_PLANT_DELETED"}
+				return s
+			},
+			expectErr: types.ErrInvalidWebhookEventType,
+		},
+		{
+			testName: "failed, short secret",
+			subscription: func(s types.WebhookSubscription) types.WebhookSubscription {
+				s.Secret = "secret"
+				return s
+			},
+			expectErr: types.ErrInvalidWebhookSecret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			subscription, err := testUsecase.CreateWebhookSubscription(ctx, tt.subscription(valid))
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := valid
+			expected.ID = 1
+			if diff := cmp.Diff(&expected, subscription, cmpopts.IgnoreFields(types.WebhookSubscription{}, "CreatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_UpdateWebhookSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		update    types.WebhookSubscriptionUpdate
+		expected  *types.WebhookSubscription
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       1,
+			update: types.WebhookSubscriptionUpdate{
+				EventTypes: []types.WebhookEventType{types.WebhookEventPowerPlantCreated, types.WebhookEventPowerPlantUpdated},
+			},
+			expected: &types.WebhookSubscription{
+				ID:         1,
+				URL:        "https://incidents.example.com/hooks/energy",
+				EventTypes: []types.WebhookEventType{types.WebhookEventPowerPlantCreated, types.WebhookEventPowerPlantUpdated},
+				Secret:     "0123456789abcdef",
+			},
+		},
+		{
+			testName:  "failed, invalid url",
+			id:        1,
+			update:    types.WebhookSubscriptionUpdate{URL: ptr("incidents.example.com")},
+			expectErr: types.ErrInvalidWebhookURL,
+		},
+		{
+			testName:  "failed, empty event types",
+			id:        1,
+			update:    types.WebhookSubscriptionUpdate{EventTypes: []types.WebhookEventType{}},
+			expectErr: types.ErrWebhookEventTypeRequired,
+		},
+		{
+			testName:  "failed, empty id",
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			subscription, err := testUsecase.UpdateWebhookSubscription(ctx, tt.id, tt.update)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, subscription, cmpopts.IgnoreFields(types.WebhookSubscription{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsecase_RedeliverWebhook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		testName  string
+		id        int64
+		expectErr error
+	}{
+		{
+			testName: "success",
+			id:       3,
+		},
+		{
+			testName:  "failed, empty id",
+			expectErr: errors.New("id is required"),
+		},
+		{
+			testName:  "failed, invalid id",
+			id:        999,
+			expectErr: errors.New("id not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			delivery, err := testUsecase.RedeliverWebhook(ctx, tt.id)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if delivery.ID != tt.id || delivery.Status != types.WebhookDeliveryPending {
+				t.Fatalf("expected delivery %d to be pending, got: %+v", tt.id, delivery)
+			}
+		})
+	}
+}
+
+func TestUsecase_PublishPowerPlantEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	db := &fakeDB{}
+	u := NewUsecase(&fakeWeatherAPI{}, db, newFakeSnapshotStore(), testSnapshotConfig, testReadTimeouts)
+
+	powerPlant, err := u.CreatePowerPlant(ctx, "Test Power Plant", 1.1, 2.2, "", types.PowerPlantMetadata{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := u.UpdatePowerPlant(ctx, powerPlant.ID, ptr("Renamed Power Plant"), nil, nil, types.PowerPlantMetadataUpdate{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := u.SetPowerPlantElevation(ctx, powerPlant.ID, ptr(120.0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A failed change publishes nothing.
+	if _, err := u.UpdatePowerPlant(ctx, 999, ptr("Unknown Power Plant"), nil, nil, types.PowerPlantMetadataUpdate{}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	expected := []types.WebhookEventType{types.WebhookEventPowerPlantCreated, types.WebhookEventPowerPlantUpdated, types.WebhookEventPowerPlantUpdated}
+	if diff := cmp.Diff(expected, db.published); diff != "" {
+		t.Fatalf("unexpected published events (-want +got):\n%s", diff)
+	}
+}
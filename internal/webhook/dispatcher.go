@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/database"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+)
+
+var _ store = (*database.Database)(nil)
+
+type store interface {
+	GetOrganizations(ctx context.Context) ([]types.Organization, error)
+	ClaimWebhookDeliveries(ctx context.Context, count int, lease time.Duration) ([]types.WebhookDispatch, error)
+	RecordWebhookAttempt(ctx context.Context, delivery *types.WebhookDelivery, leasedUntil time.Time, attempt *types.WebhookDeliveryAttempt) error
+}
+
+// Dispatcher posts the due webhook deliveries of the queue to their subscriptions,
+// and retries the failed ones with an exponential backoff.
+type Dispatcher struct {
+	store  store
+	client *http.Client
+	cfg    config.WebhookConfig
+	logger *log.Logger
+	now    func() time.Time
+}
+
+// NewDispatcher creates a new webhook dispatcher.
+func NewDispatcher(store store, cfg config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			Transport: newTransport(cfg.AllowPrivateNetworks),
+			// A redirect is a failed attempt, a POST would be replayed as a GET.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:    cfg,
+		logger: log.Default(),
+		now:    time.Now,
+	}
+}
+
+// Run delivers the due deliveries of every organization right away, then every cfg.PollInterval,
+// until the context is done. It returns right away when the dispatcher is disabled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.cfg.PollInterval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverOrganizations(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverOrganizations delivers the due deliveries of every organization in turn.
+func (d *Dispatcher) deliverOrganizations(ctx context.Context) {
+	organizations, err := d.store.GetOrganizations(ctx)
+	if err != nil {
+		d.logger.Printf("error getting organizations: %v", err)
+		return
+	}
+
+	for _, organization := range organizations {
+		if _, err := d.DeliverDue(tenant.WithOrganization(ctx, organization.ID)); err != nil {
+			d.logger.Printf("error delivering webhooks of organization %d: %v", organization.ID, err)
+		}
+	}
+}
+
+// DeliverDue attempts the due deliveries of the organization the context acts for, cfg.BatchSize at a time
+// until the queue has no due delivery. The deliveries of a batch are attempted concurrently. It returns the number of delivered ones.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	// The lease outlives the attempts of the batch, which are bounded by the timeout.
+	lease := 2 * d.cfg.Timeout
+
+	delivered := 0
+	for ctx.Err() == nil {
+		dispatches, err := d.store.ClaimWebhookDeliveries(ctx, d.cfg.BatchSize, lease)
+		if err != nil {
+			return delivered, fmt.Errorf("error claiming webhook deliveries: %w", err)
+		}
+
+		var (
+			wg sync.WaitGroup
+			mu sync.Mutex
+		)
+		for _, dispatch := range dispatches {
+			wg.Add(1)
+			go func(dispatch types.WebhookDispatch) {
+				defer wg.Done()
+
+				ok, err := d.deliver(ctx, dispatch)
+				if err != nil {
+					d.logger.Printf("error recording attempt of webhook delivery %d: %v", dispatch.Delivery.ID, err)
+					return
+				}
+				if ok {
+					mu.Lock()
+					delivered++
+					mu.Unlock()
+				}
+			}(dispatch)
+		}
+		wg.Wait()
+
+		if len(dispatches) < d.cfg.BatchSize {
+			break
+		}
+	}
+
+	return delivered, nil
+}
+
+// deliver makes one attempt of the delivery and records it. It returns true if the subscription
+// acknowledged the delivery with a 2xx response.
+func (d *Dispatcher) deliver(ctx context.Context, dispatch types.WebhookDispatch) (bool, error) {
+	delivery := dispatch.Delivery
+	start := d.now()
+
+	statusCode, err := d.post(ctx, dispatch, start.Unix())
+	attempt := types.WebhookDeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		AttemptedAt: start.UTC(),
+		DurationMS:  int(d.now().Sub(start).Milliseconds()),
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if err != nil {
+		msg := err.Error()
+		attempt.Error = &msg
+	}
+
+	delivery.Attempts++
+	ok := err == nil && statusCode >= 200 && statusCode < 300
+	switch {
+	case ok:
+		deliveredAt := d.now().UTC()
+		delivery.Status = types.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &deliveredAt
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		d.logger.Printf("webhook delivery %d to %s is dead after %d attempts", delivery.ID, dispatch.URL, delivery.Attempts)
+		delivery.Status = types.WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+	default:
+		nextAttemptAt := d.now().Add(d.backoff(delivery.Attempts)).UTC()
+		delivery.Status = types.WebhookDeliveryPending
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	return ok, d.store.RecordWebhookAttempt(ctx, &delivery, dispatch.LeasedUntil, &attempt)
+}
+
+// post posts the signed payload of the delivery, it returns the status code of the response,
+// 0 when no response was received.
+func (d *Dispatcher) post(ctx context.Context, dispatch types.WebhookDispatch, timestamp int64) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	payload := []byte(dispatch.Delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatch.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(dispatch.Delivery.EventType))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(dispatch.Delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(dispatch.Secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a bounded part of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before the next attempt after the given number of failed attempts:
+// cfg.InitialBackoff doubled for every retry, capped at cfg.MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.cfg.InitialBackoff
+	for i := 1; i < attempts && backoff < d.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.cfg.MaxBackoff)
+}
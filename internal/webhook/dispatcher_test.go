@@ -0,0 +1,337 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gcathelines/tensor-energy-case/config"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testSecret = "0123456789abcdef"
+
+// testConfig allows private networks, the receivers of the tests listen on the loopback.
+var testConfig = config.WebhookConfig{
+	BatchSize:            10,
+	Timeout:              100 * time.Millisecond,
+	MaxAttempts:          8,
+	InitialBackoff:       30 * time.Second,
+	MaxBackoff:           time.Hour,
+	AllowPrivateNetworks: true,
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// fakeStore serves its queue on the first claim, then records the attempts.
+type fakeStore struct {
+	mu         sync.Mutex
+	queue      []types.WebhookDispatch
+	deliveries []types.WebhookDelivery
+	leases     []time.Time
+	attempts   []types.WebhookDeliveryAttempt
+}
+
+func (f *fakeStore) GetOrganizations(ctx context.Context) ([]types.Organization, error) {
+	return []types.Organization{{ID: 1, Name: "Default"}}, nil
+}
+
+func (f *fakeStore) ClaimWebhookDeliveries(ctx context.Context, count int, lease time.Duration) ([]types.WebhookDispatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := min(count, len(f.queue))
+	claimed := f.queue[:n]
+	f.queue = f.queue[n:]
+	return claimed, nil
+}
+
+func (f *fakeStore) RecordWebhookAttempt(ctx context.Context, delivery *types.WebhookDelivery, leasedUntil time.Time, attempt *types.WebhookDeliveryAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries = append(f.deliveries, *delivery)
+	f.leases = append(f.leases, leasedUntil)
+	f.attempts = append(f.attempts, *attempt)
+	return nil
+}
+
+// receiver is a webhook receiver checking the signature of the deliveries before replying with the status code.
+func receiver(t *testing.T, statusCode int, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if !Verify(testSecret, timestamp, body, r.Header.Get(HeaderSignature)) {
+			t.Errorf("invalid signature: %s", r.Header.Get(HeaderSignature))
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON POST, got: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get(HeaderEvent) != string(types.WebhookEventAlertTriggered) || r.Header.Get(HeaderDelivery) != "1" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		if string(body) != `{"type":"ALERT_TRIGGERED"}` {
+			t.Errorf("unexpected payload: %s", body)
+		}
+
+		time.Sleep(delay)
+		if statusCode == http.StatusFound {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(statusCode)
+	}))
+}
+
+func TestDispatcher_DeliverDue(t *testing.T) {
+	now := time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		statusCode         int
+		delay              time.Duration
+		unreachable        bool
+		previousAttempts   int
+		expectedDelivered  int
+		expectedDelivery   types.WebhookDelivery
+		expectedStatusCode *int
+		expectErrorLogged  bool
+	}{
+		{
+			name:              "success, delivered",
+			statusCode:        http.StatusNoContent,
+			expectedDelivered: 1,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryDelivered, Attempts: 1, DeliveredAt: &now,
+			},
+			expectedStatusCode: ptr(http.StatusNoContent),
+		},
+		{
+			name:       "server error, retried after the initial backoff",
+			statusCode: http.StatusInternalServerError,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: ptr(now.Add(30 * time.Second)),
+			},
+			expectedStatusCode: ptr(http.StatusInternalServerError),
+		},
+		{
+			name:             "server error, backoff doubled for every retry",
+			statusCode:       http.StatusServiceUnavailable,
+			previousAttempts: 2,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryPending, Attempts: 3, NextAttemptAt: ptr(now.Add(2 * time.Minute)),
+			},
+			expectedStatusCode: ptr(http.StatusServiceUnavailable),
+		},
+		{
+			name:             "server error, dead after the last attempt",
+			statusCode:       http.StatusInternalServerError,
+			previousAttempts: 7,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryDead, Attempts: 8,
+			},
+			expectedStatusCode: ptr(http.StatusInternalServerError),
+		},
+		{
+			name:       "redirect, not followed",
+			statusCode: http.StatusFound,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: ptr(now.Add(30 * time.Second)),
+			},
+			expectedStatusCode: ptr(http.StatusFound),
+		},
+		{
+			name:       "timeout, retried",
+			statusCode: http.StatusOK,
+			delay:      300 * time.Millisecond,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: ptr(now.Add(30 * time.Second)),
+			},
+			expectErrorLogged: true,
+		},
+		{
+			name:        "unreachable, retried",
+			unreachable: true,
+			expectedDelivery: types.WebhookDelivery{
+				Status: types.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: ptr(now.Add(30 * time.Second)),
+			},
+			expectErrorLogged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			srv := receiver(t, tt.statusCode, tt.delay)
+			defer srv.Close()
+			if tt.unreachable {
+				srv.Close()
+			}
+
+			delivery := types.WebhookDelivery{
+				ID:             1,
+				SubscriptionID: 2,
+				EventType:      types.WebhookEventAlertTriggered,
+				Payload:        `{"type":"ALERT_TRIGGERED"}`,
+				Status:         types.WebhookDeliveryPending,
+				Attempts:       tt.previousAttempts,
+				NextAttemptAt:  &now,
+				CreatedAt:      now,
+			}
+			store := &fakeStore{queue: []types.WebhookDispatch{{Delivery: delivery, URL: srv.URL, Secret: testSecret, LeasedUntil: now}}}
+			d := NewDispatcher(store, testConfig)
+			d.now = func() time.Time { return now }
+
+			delivered, err := d.DeliverDue(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if delivered != tt.expectedDelivered {
+				t.Fatalf("expected %d delivered, got: %d", tt.expectedDelivered, delivered)
+			}
+
+			expected := delivery
+			expected.Status = tt.expectedDelivery.Status
+			expected.Attempts = tt.expectedDelivery.Attempts
+			expected.NextAttemptAt = tt.expectedDelivery.NextAttemptAt
+			expected.DeliveredAt = tt.expectedDelivery.DeliveredAt
+			if diff := cmp.Diff([]types.WebhookDelivery{expected}, store.deliveries); diff != "" {
+				t.Fatalf("unexpected delivery (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]time.Time{now}, store.leases); diff != "" {
+				t.Fatalf("expected the attempt to be recorded under the lease of the claim (-want +got):\n%s", diff)
+			}
+
+			attempt := store.attempts[0]
+			if diff := cmp.Diff(tt.expectedStatusCode, attempt.StatusCode); diff != "" {
+				t.Fatalf("unexpected status code (-want +got):\n%s", diff)
+			}
+			if (attempt.Error != nil) != tt.expectErrorLogged {
+				t.Fatalf("expected error logged: %v, got: %v", tt.expectErrorLogged, attempt.Error)
+			}
+			if attempt.DeliveryID != delivery.ID || !attempt.AttemptedAt.Equal(now) {
+				t.Fatalf("unexpected attempt: %+v", attempt)
+			}
+		})
+	}
+}
+
+func TestDispatcher_DeliverDueBatches(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv := receiver(t, http.StatusOK, 0)
+	defer srv.Close()
+
+	store := &fakeStore{}
+	for i := 0; i < 25; i++ {
+		store.queue = append(store.queue, types.WebhookDispatch{
+			Delivery: types.WebhookDelivery{
+				ID:        1,
+				EventType: types.WebhookEventAlertTriggered,
+				Payload:   `{"type":"ALERT_TRIGGERED"}`,
+				Status:    types.WebhookDeliveryPending,
+			},
+			URL:    srv.URL,
+			Secret: testSecret,
+		})
+	}
+
+	delivered, err := NewDispatcher(store, testConfig).DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if delivered != 25 || len(store.attempts) != 25 {
+		t.Fatalf("expected 25 delivered, got: %d delivered and %d attempts", delivered, len(store.attempts))
+	}
+}
+
+func TestDispatcher_DeliverDuePrivateNetwork(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Store(true)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{queue: []types.WebhookDispatch{{
+		Delivery: types.WebhookDelivery{ID: 1, EventType: types.WebhookEventAlertTriggered, Payload: `{}`, Status: types.WebhookDeliveryPending},
+		URL:      srv.URL,
+		Secret:   testSecret,
+	}}}
+	cfg := testConfig
+	cfg.AllowPrivateNetworks = false
+
+	delivered, err := NewDispatcher(store, cfg).DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if delivered != 0 || received.Load() {
+		t.Fatalf("expected the delivery to the loopback to be refused, got: %d delivered", delivered)
+	}
+	if attempt := store.attempts[0]; attempt.Error == nil || !strings.Contains(*attempt.Error, ErrForbiddenAddress.Error()) {
+		t.Fatalf("expected error: %v, got: %v", ErrForbiddenAddress, attempt.Error)
+	}
+	if store.deliveries[0].Status != types.WebhookDeliveryPending || store.deliveries[0].Attempts != 1 {
+		t.Fatalf("expected a failed attempt, got: %+v", store.deliveries[0])
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: 30 * time.Second},
+		{attempts: 2, expected: time.Minute},
+		{attempts: 5, expected: 8 * time.Minute},
+		{attempts: 8, expected: time.Hour},
+		{attempts: 100, expected: time.Hour},
+	}
+
+	d := NewDispatcher(&fakeStore{}, testConfig)
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := d.backoff(tt.attempts); got != tt.expected {
+				t.Fatalf("expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	cfg := testConfig
+	cfg.PollInterval = 0
+	done := make(chan struct{})
+	go func() {
+		NewDispatcher(&fakeStore{}, cfg).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("expected a disabled dispatcher to return right away")
+	}
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	const (
+		secret    = "0123456789abcdef"
+		timestamp = int64(1725580800)
+		expected  = "sha256=24290120997f9fb47841d3f74a445498f5f39cdfb074b9016afc2c206965871e"
+	)
+	payload := []byte(`{"type":"ALERT_TRIGGERED"}`)
+
+	if got := Sign(secret, timestamp, payload); got != expected {
+		t.Fatalf("expected signature: %s, got: %s", expected, got)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		payload   []byte
+		expected  bool
+	}{
+		{
+			name:      "valid",
+			secret:    secret,
+			timestamp: timestamp,
+			payload:   payload,
+			expected:  true,
+		},
+		{
+			name:      "invalid, other secret",
+			secret:    "fedcba9876543210",
+			timestamp: timestamp,
+			payload:   payload,
+		},
+		{
+			name:      "invalid, replayed with another timestamp",
+			secret:    secret,
+			timestamp: timestamp + 60,
+			payload:   payload,
+		},
+		{
+			name:      "invalid, tampered payload",
+			secret:    secret,
+			timestamp: timestamp,
+			payload:   []byte(`{"type":"ALERT_RESOLVED"}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.payload, expected); got != tt.expected {
+				t.Fatalf("expected %v, got: %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for a delivery to a loopback, link-local, private, shared or unspecified address.
+var ErrForbiddenAddress = errors.New("address is not allowed")
+
+// newTransport returns the transport of the deliveries. Unless allowPrivateNetworks is set, its dialer refuses
+// the addresses for which publicAddress is false. They are checked on the resolved address of each connection,
+// so a host name resolving to an internal address is refused as well. The deliveries do not go through the
+// proxy of the environment, which would be the address checked instead of the receiver.
+func newTransport(allowPrivateNetworks bool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !publicAddress(addr) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// nonPublicPrefixes are the special-purpose ranges netip.Addr has no method for.
+var nonPublicPrefixes = []netip.Prefix{
+	// The shared address space of carrier-grade NAT, also used for internal cloud addresses.
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// The IETF protocol assignments.
+	netip.MustParsePrefix("192.0.0.0/24"),
+	// The benchmarking networks.
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// The NAT64 prefix, which embeds any IPv4 address, internal ones included.
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// publicAddress returns false for the loopback, link-local, private, unspecified and multicast addresses
+// and nonPublicPrefixes, e.g. 127.0.0.1, the 169.254.169.254 metadata service or 10.0.0.1,
+// IPv4-mapped IPv6 addresses included.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return !addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{addr: "93.184.216.34", expected: true},
+		{addr: "2606:4700::1111", expected: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "10.0.0.1"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "fd00::1"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "224.0.0.1"},
+		{addr: "100.64.0.1"},
+		{addr: "100.127.255.254"},
+		{addr: "192.0.0.8"},
+		{addr: "198.18.0.1"},
+		{addr: "198.19.255.255"},
+		{addr: "64:ff9b::a9fe:a9fe"},
+		{addr: "::ffff:100.64.0.1"},
+		{addr: "100.128.0.1", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := publicAddress(netip.MustParseAddr(tt.addr)); got != tt.expected {
+				t.Fatalf("expected %v, got: %v", tt.expected, got)
+			}
+		})
+	}
+}
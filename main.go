@@ -1,32 +1,46 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	_ "github.com/lib/pq"
 	"gopkg.in/yaml.v3"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gcathelines/tensor-energy-case/config"
 	"github.com/gcathelines/tensor-energy-case/graph"
+	"github.com/gcathelines/tensor-energy-case/internal/audit"
+	"github.com/gcathelines/tensor-energy-case/internal/auth"
 	"github.com/gcathelines/tensor-energy-case/internal/database"
+	"github.com/gcathelines/tensor-energy-case/internal/ingest"
 	"github.com/gcathelines/tensor-energy-case/internal/open_meteo"
+	"github.com/gcathelines/tensor-energy-case/internal/scheduler"
+	"github.com/gcathelines/tensor-energy-case/internal/tenant"
+	"github.com/gcathelines/tensor-energy-case/internal/types"
 	"github.com/gcathelines/tensor-energy-case/internal/usecase"
+	"github.com/gcathelines/tensor-energy-case/internal/webhook"
 )
 
 func main() {
 	cfg := config.Config{}
 	var (
-		configPath      string
-		graphiQLEnabled bool
+		configPath         string
+		graphiQLEnabled    bool
+		backfillElevations bool
+		createOrganization string
 	)
 	flag.StringVar(&configPath, "config", "", "configuration path")
 	flag.BoolVar(&graphiQLEnabled, "graphiql", false, "enable graphiql")
+	flag.BoolVar(&backfillElevations, "backfill-elevations", false, "fetch the elevation of power plants without one, then exit")
+	flag.StringVar(&createOrganization, "create-organization", "", "create an organization with the given name, print its API key, then exit")
 	flag.Parse()
 
 	out, err := os.ReadFile(configPath)
@@ -43,24 +57,102 @@ func main() {
 	}
 
 	// initialize database
-	sqlDB, err := sql.Open("postgres", cfg.DBConfig.DSN())
+	sqlDB, err := database.Open(cfg.DBConfig.DSN())
 	if err != nil {
 		panic(err)
 	}
 	db := database.NewDatabase(sqlDB)
 
 	// initialize open_meteo client
-	weatherAPI := open_meteo.NewOpenMeteoClient(cfg.OpenMeteoConfig)
-	usecase := usecase.NewUsecase(weatherAPI, db)
+	weatherAPI := open_meteo.NewCachedClient(open_meteo.NewOpenMeteoClient(cfg.OpenMeteoConfig), cfg.ForecastCacheConfig)
+	snapshots := database.NewWeatherSnapshots(sqlDB)
+	usecase := usecase.NewUsecase(weatherAPI, db, snapshots, cfg.SnapshotConfig, cfg.ReadTimeoutConfig)
 
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(usecase)}))
+	if createOrganization != "" {
+		organization, apiKey, err := usecase.CreateOrganization(context.Background(), types.Organization{Name: createOrganization})
+		if err != nil {
+			log.Fatalf("error creating organization: %v", err)
+		}
+		log.Printf("created organization %d, its API key is only shown once: %s", organization.ID, apiKey)
+		return
+	}
+
+	if backfillElevations {
+		organizations, err := usecase.GetOrganizations(context.Background())
+		if err != nil {
+			log.Fatalf("error getting organizations: %v", err)
+		}
+		for _, organization := range organizations {
+			updated, err := usecase.BackfillElevations(tenant.WithOrganization(context.Background(), organization.ID), 100)
+			if err != nil {
+				log.Fatalf("backfilled %d power plants of organization %d before error: %v", updated, organization.ID, err)
+			}
+			log.Printf("backfilled elevation of %d power plants of organization %d", updated, organization.ID)
+		}
+		return
+	}
+
+	var verifier *auth.JWTVerifier
+	if cfg.AuthConfig.JWTEnabled() {
+		verifier, err = auth.NewJWTVerifier(context.Background(), cfg.AuthConfig)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	resolver := graph.NewResolver(usecase)
+	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  resolver,
+		Directives: graph.DirectiveRoot{HasRole: graph.HasRole},
+	}))
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.AroundRootFields(graph.AuditOperation)
+	srv.AroundOperations(resolver.WithLoaders)
 
 	if graphiQLEnabled {
 		http.Handle("/", playground.Handler("GraphQL playground", "/query"))
 	}
 
-	http.Handle("/query", srv)
+	http.Handle("/query", audit.Middleware(auth.Middleware(usecase, verifier, srv)))
+	http.Handle("/production", audit.Middleware(auth.Middleware(usecase, verifier, auth.RequireRole(types.RoleEditor, ingest.NewHandler(usecase)))))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// start background jobs
+	var jobs sync.WaitGroup
+	jobs.Add(3)
+	jobScheduler := scheduler.NewScheduler(usecase, cfg.PrefetchConfig, cfg.AccuracyConfig)
+	go func() {
+		defer jobs.Done()
+		jobScheduler.Run(ctx)
+	}()
+	go func() {
+		defer jobs.Done()
+		jobScheduler.RunAccuracy(ctx)
+	}()
+	go func() {
+		defer jobs.Done()
+		webhook.NewDispatcher(db, cfg.WebhookConfig).Run(ctx)
+	}()
+
+	server := &http.Server{Addr: ":" + cfg.ServerConfig.Port}
+	go func() {
+		log.Printf("running server on port: %s", cfg.ServerConfig.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error shutting down server: %v", err)
+	}
 
-	log.Printf("running server on port: %s", cfg.ServerConfig.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.ServerConfig.Port, nil))
+	jobs.Wait()
+	usecase.Wait()
 }
@@ -5,12 +5,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gcathelines/tensor-energy-case/internal/auth"
 	"github.com/gcathelines/tensor-energy-case/internal/types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/machinebox/graphql"
 )
 
+// testAPIKey is the API key of the Default organization of the seed.
+const testAPIKey = "dev-api-key"
+
 func TestE2EProcess(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -95,6 +99,7 @@ func getPowerPlantRequest(id int64, forecastDays int) *graphql.Request {
 	req.Var("id", id)
 	req.Var("forecastDays", forecastDays)
 
+	req.Header.Set(auth.APIKeyHeader, testAPIKey)
 	return req
 }
 
@@ -123,6 +128,7 @@ func getPaginatedPowerPlantsRequest(lastID int64, count, forecastDays int) *grap
 	req.Var("count", count)
 	req.Var("forecastDays", forecastDays)
 
+	req.Header.Set(auth.APIKeyHeader, testAPIKey)
 	return req
 }
 
@@ -146,6 +152,7 @@ func createPowerPlantRequest(name string, latitude, longitude float64) *graphql.
 		"longitude": longitude,
 	})
 
+	req.Header.Set(auth.APIKeyHeader, testAPIKey)
 	return req
 }
 
@@ -179,5 +186,6 @@ func updatePowerPlantRequest(id int64, name *string, latitude, longitude *float6
 	req := graphql.NewRequest(mutation)
 	req.Var("input", input)
 
+	req.Header.Set(auth.APIKeyHeader, testAPIKey)
 	return req
 }
@@ -1 +1,22 @@
-DROP TABLE power_plants;
\ No newline at end of file
+DROP TABLE power_plants_history;
+DROP FUNCTION power_plants_history_change;
+DROP TABLE audit_events;
+DROP FUNCTION audit_events_append_only;
+DROP TABLE portfolio_power_plants;
+DROP TABLE portfolios;
+DROP TABLE maintenance_windows;
+DROP TABLE forecast_error_sums;
+DROP TABLE issued_forecasts;
+DROP TABLE production_readings;
+DROP TABLE webhook_delivery_attempts;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
+DROP TABLE alerts;
+DROP TABLE alert_rules;
+DROP TABLE weather_snapshots;
+DROP TABLE power_plants;
+DROP TABLE turbine_models;
+DROP FUNCTION audit_change;
+DROP TABLE organizations;
+DROP FUNCTION current_organization_id;
+DROP ROLE tenant_user;
\ No newline at end of file
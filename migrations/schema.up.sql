@@ -7,3 +7,452 @@ CREATE TABLE IF NOT EXISTS power_plants(
     "updated_at" TIMESTAMP NULL
 );
 
+-- elevation is fetched from the elevation API, elevation_override is a surveyed elevation set manually.
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "elevation" NUMERIC NULL;
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "elevation_override" NUMERIC NULL;
+
+-- weather_snapshots stores the last forecast fetched for a rounded location, shared between replicas.
+CREATE TABLE IF NOT EXISTS weather_snapshots(
+    "latitude" NUMERIC NOT NULL,
+    "longitude" NUMERIC NOT NULL,
+    "forecast_days" INT NOT NULL,
+    "forecast" JSONB NOT NULL,
+    "fetched_at" TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY ("latitude", "longitude", "forecast_days")
+);
+
+-- type and status are the enums of types.PowerPlantType and types.PowerPlantStatus.
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "type" VARCHAR NOT NULL DEFAULT 'OTHER';
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "capacity_mw" NUMERIC NULL;
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "dc_capacity_mw" NUMERIC NULL;
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "design_discharge_m3s" NUMERIC NULL;
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "commissioned_at" TIMESTAMP NULL;
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "status" VARCHAR NOT NULL DEFAULT 'OPERATIONAL';
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "operator" VARCHAR NULL;
+
+-- turbine_models holds the wind turbine models, power_curve is a JSON array of types.PowerCurvePoint.
+CREATE TABLE IF NOT EXISTS turbine_models(
+    "id" BIGSERIAL PRIMARY KEY,
+    "name" VARCHAR NOT NULL,
+    "manufacturer" VARCHAR NULL,
+    "rated_power_kw" NUMERIC NOT NULL,
+    "cut_in_speed" NUMERIC NOT NULL,
+    "cut_out_speed" NUMERIC NOT NULL,
+    "power_curve" JSONB NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NULL
+);
+
+-- A turbine model cannot be deleted while wind power plants use it.
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "turbine_model_id" BIGINT NULL REFERENCES turbine_models("id");
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "turbine_count" INT NULL;
+
+-- solar_array is the JSON types.SolarArrayConfig of solar power plants.
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "solar_array" JSONB NULL;
+
+-- alert_rules holds the weather thresholds watched on the forecasts of the power plants.
+CREATE TABLE IF NOT EXISTS alert_rules(
+    "id" BIGSERIAL PRIMARY KEY,
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "variable" VARCHAR NOT NULL,
+    "operator" VARCHAR NOT NULL,
+    "threshold" NUMERIC NOT NULL,
+    "horizon_hours" INT NOT NULL,
+    "aggregation" VARCHAR NOT NULL DEFAULT 'NONE',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS alert_rules_power_plant_id ON alert_rules("power_plant_id");
+
+-- alerts holds the triggered alert rules, the active ones have no resolved_at.
+-- The condition of the rule is copied so the history survives changes and deletions of the rule.
+CREATE TABLE IF NOT EXISTS alerts(
+    "id" BIGSERIAL PRIMARY KEY,
+    "rule_id" BIGINT NULL REFERENCES alert_rules("id") ON DELETE SET NULL,
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "variable" VARCHAR NOT NULL,
+    "operator" VARCHAR NOT NULL,
+    "threshold" NUMERIC NOT NULL,
+    "aggregation" VARCHAR NOT NULL,
+    "forecast_time" VARCHAR NOT NULL,
+    "value" NUMERIC NOT NULL,
+    "triggered_at" TIMESTAMP NOT NULL,
+    "last_seen_at" TIMESTAMP NOT NULL,
+    "resolved_at" TIMESTAMP NULL
+);
+
+-- A rule has at most one active alert.
+CREATE UNIQUE INDEX IF NOT EXISTS alerts_active_rule_id ON alerts("rule_id") WHERE "resolved_at" IS NULL;
+CREATE INDEX IF NOT EXISTS alerts_power_plant_id ON alerts("power_plant_id");
+
+-- webhook_subscriptions holds the URLs notified of the events of the given types.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions(
+    "id" BIGSERIAL PRIMARY KEY,
+    "url" VARCHAR NOT NULL,
+    "event_types" VARCHAR[] NOT NULL,
+    "secret" VARCHAR NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NULL
+);
+
+-- webhook_deliveries is the queue of events to post to the subscriptions.
+-- Pending deliveries are attempted from next_attempt_at, in UTC, dead ones failed every attempt.
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+    "id" BIGSERIAL PRIMARY KEY,
+    "subscription_id" BIGINT NOT NULL REFERENCES webhook_subscriptions("id") ON DELETE CASCADE,
+    "event_type" VARCHAR NOT NULL,
+    "payload" JSONB NOT NULL,
+    "status" VARCHAR NOT NULL DEFAULT 'PENDING',
+    "attempts" INT NOT NULL DEFAULT 0,
+    "next_attempt_at" TIMESTAMP NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    "delivered_at" TIMESTAMP NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries("next_attempt_at") WHERE "status" = 'PENDING';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_id ON webhook_deliveries("subscription_id");
+
+-- webhook_delivery_attempts is the delivery log, one row per attempt.
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts(
+    "id" BIGSERIAL PRIMARY KEY,
+    "delivery_id" BIGINT NOT NULL REFERENCES webhook_deliveries("id") ON DELETE CASCADE,
+    "attempted_at" TIMESTAMP NOT NULL,
+    "status_code" INT NULL,
+    "error" VARCHAR NULL,
+    "duration_ms" INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts("delivery_id");
+
+-- production_readings holds the metered output of the power plants, one row per power plant and time.
+-- quality is the enum of types.ProductionQuality.
+CREATE TABLE IF NOT EXISTS production_readings(
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "time" TIMESTAMP NOT NULL,
+    "power_mw" NUMERIC NOT NULL,
+    "quality" VARCHAR NOT NULL,
+    "recorded_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("power_plant_id", "time")
+);
+
+-- issued_forecasts holds the hourly forecasts of the power plants as they were served, one row per
+-- power plant, weather model run and forecasted hour. They are compared to the observations by the
+-- nightly accuracy job, then deleted.
+CREATE TABLE IF NOT EXISTS issued_forecasts(
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "issued_at" TIMESTAMP NOT NULL,
+    "time" TIMESTAMP NOT NULL,
+    "temperature" NUMERIC NULL,
+    "precipitation" NUMERIC NULL,
+    "wind_speed" NUMERIC NULL,
+    "wind_speed_100m" NUMERIC NULL,
+    "shortwave_radiation" NUMERIC NULL,
+    "generation_mw" NUMERIC NULL,
+    PRIMARY KEY ("power_plant_id", "time", "issued_at")
+);
+
+CREATE INDEX IF NOT EXISTS issued_forecasts_time ON issued_forecasts("time");
+
+-- forecast_error_sums holds the sums of the forecast errors per power plant, day of the forecasted hours,
+-- variable and lead time bucket, the accuracy of a period is aggregated from them.
+-- variable is the enum of types.AccuracyVariable.
+CREATE TABLE IF NOT EXISTS forecast_error_sums(
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "day" DATE NOT NULL,
+    "variable" VARCHAR NOT NULL,
+    "lead_hours_from" INT NOT NULL,
+    "lead_hours_to" INT NOT NULL,
+    "count" INT NOT NULL,
+    "sum_error" DOUBLE PRECISION NOT NULL,
+    "sum_abs_error" DOUBLE PRECISION NOT NULL,
+    "sum_squared_error" DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY ("power_plant_id", "day", "variable", "lead_hours_from")
+);
+
+-- maintenance_windows holds the planned outages of the power plants, from start_time to end_time excluded.
+-- The unavailable capacity is set either in MW or in percent, and the windows of a power plant do not overlap.
+CREATE TABLE IF NOT EXISTS maintenance_windows(
+    "id" BIGSERIAL PRIMARY KEY,
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "start_time" TIMESTAMP NOT NULL,
+    "end_time" TIMESTAMP NOT NULL,
+    "reason" VARCHAR NOT NULL DEFAULT '',
+    "unavailable_mw" NUMERIC NULL,
+    "unavailable_percent" NUMERIC NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NULL,
+    CHECK ("end_time" > "start_time"),
+    CHECK (("unavailable_mw" IS NULL) <> ("unavailable_percent" IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS maintenance_windows_power_plant_id ON maintenance_windows("power_plant_id", "start_time");
+
+-- tags are the free-form labels of the power plants, sorted and without duplicates.
+ALTER TABLE power_plants ADD COLUMN IF NOT EXISTS "tags" VARCHAR[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS power_plants_tags ON power_plants USING GIN ("tags");
+
+-- portfolios are named groups of power plants, e.g. funds, a power plant belongs to any number of them.
+CREATE TABLE IF NOT EXISTS portfolios(
+    "id" BIGSERIAL PRIMARY KEY,
+    "name" VARCHAR NOT NULL UNIQUE,
+    "description" VARCHAR NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_power_plants(
+    "portfolio_id" BIGINT NOT NULL REFERENCES portfolios("id") ON DELETE CASCADE,
+    "power_plant_id" BIGINT NOT NULL REFERENCES power_plants("id") ON DELETE CASCADE,
+    "added_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("portfolio_id", "power_plant_id")
+);
+
+CREATE INDEX IF NOT EXISTS portfolio_power_plants_power_plant_id ON portfolio_power_plants("power_plant_id");
+
+-- organizations are the tenants of the service, e.g. business units. The callers authenticate with the API key
+-- of their organization, only its SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS organizations(
+    "id" BIGSERIAL PRIMARY KEY,
+    "name" VARCHAR NOT NULL UNIQUE,
+    "api_key_hash" BYTEA NOT NULL UNIQUE,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The rows saved before organizations belong to the Default organization, its API key is random until rotated.
+INSERT INTO organizations ("name", "api_key_hash") VALUES ('Default', sha256(gen_random_uuid()::TEXT::BYTEA))
+ON CONFLICT DO NOTHING;
+
+-- current_organization_id is the organization the session acts for, set by the service before each statement,
+-- NULL when it acts for none.
+CREATE OR REPLACE FUNCTION current_organization_id() RETURNS BIGINT
+LANGUAGE SQL STABLE AS $$ SELECT NULLIF(current_setting('app.organization_id', true), '')::BIGINT $$;
+
+-- Every table of the organizations gets an organization_id, defaulting to the organization of the session, and a row
+-- level security policy so the session only sees and writes the rows of its organization. The owner of the tables
+-- bypasses the policies, the service connects as it then acts as tenant_user.
+DO $$
+DECLARE
+    tbl TEXT;
+BEGIN
+    FOREACH tbl IN ARRAY ARRAY['power_plants', 'turbine_models', 'alert_rules', 'alerts', 'webhook_subscriptions',
+        'webhook_deliveries', 'webhook_delivery_attempts', 'production_readings', 'issued_forecasts',
+        'forecast_error_sums', 'maintenance_windows', 'portfolios', 'portfolio_power_plants'] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS "organization_id" BIGINT NULL REFERENCES organizations("id")', tbl);
+        EXECUTE format('UPDATE %I SET "organization_id" = (SELECT "id" FROM organizations WHERE "name" = %L) WHERE "organization_id" IS NULL', tbl, 'Default');
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN "organization_id" SET NOT NULL, ALTER COLUMN "organization_id" SET DEFAULT current_organization_id()', tbl);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', tbl);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', tbl);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I USING ("organization_id" = current_organization_id())', tbl);
+    END LOOP;
+END $$;
+
+-- The references between the tables of the organizations include the organization, so a row cannot reference
+-- the row of another organization even by ID: the foreign key checks are not subject to the policies.
+DO $$
+DECLARE
+    tbl TEXT;
+    fk RECORD;
+BEGIN
+    FOREACH tbl IN ARRAY ARRAY['power_plants', 'turbine_models', 'webhook_subscriptions', 'webhook_deliveries', 'portfolios'] LOOP
+        EXECUTE format('CREATE UNIQUE INDEX IF NOT EXISTS %I ON %I("organization_id", "id")', tbl || '_organization_id', tbl);
+    END LOOP;
+
+    FOR fk IN SELECT * FROM (VALUES
+        ('power_plants', 'turbine_model_id', 'turbine_models', 'NO ACTION'),
+        ('alert_rules', 'power_plant_id', 'power_plants', 'CASCADE'),
+        ('alerts', 'power_plant_id', 'power_plants', 'CASCADE'),
+        ('webhook_deliveries', 'subscription_id', 'webhook_subscriptions', 'CASCADE'),
+        ('webhook_delivery_attempts', 'delivery_id', 'webhook_deliveries', 'CASCADE'),
+        ('production_readings', 'power_plant_id', 'power_plants', 'CASCADE'),
+        ('issued_forecasts', 'power_plant_id', 'power_plants', 'CASCADE'),
+        ('forecast_error_sums', 'power_plant_id', 'power_plants', 'CASCADE'),
+        ('maintenance_windows', 'power_plant_id', 'power_plants', 'CASCADE'),
+        ('portfolio_power_plants', 'portfolio_id', 'portfolios', 'CASCADE'),
+        ('portfolio_power_plants', 'power_plant_id', 'power_plants', 'CASCADE')
+    ) AS fks("tbl", "col", "parent", "on_delete") LOOP
+        EXECUTE format('ALTER TABLE %I DROP CONSTRAINT IF EXISTS %I', fk.tbl, fk.tbl || '_' || fk.col || '_fkey');
+        EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY ("organization_id", %I) REFERENCES %I("organization_id", "id") ON DELETE %s',
+            fk.tbl, fk.tbl || '_' || fk.col || '_fkey', fk.col, fk.parent, fk.on_delete);
+    END LOOP;
+END $$;
+
+-- The names of the portfolios are unique within their organization.
+ALTER TABLE portfolios DROP CONSTRAINT IF EXISTS portfolios_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS portfolios_organization_id_name ON portfolios("organization_id", "name");
+
+-- tenant_user is the role the service acts as, subject to the policies.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'tenant_user') THEN
+        CREATE ROLE tenant_user NOLOGIN;
+    END IF;
+END $$;
+
+GRANT tenant_user TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO tenant_user;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO tenant_user;
+
+-- audit_events is the append-only log of the changes of the entities. A trigger records every inserted, updated and
+-- deleted row in the transaction of the change, with the values of the changed columns before and after it.
+-- actor, operation and request_id are set on the session by the service before each statement, see database.Open:
+-- the changes made without an actor, e.g. by the background jobs, are recorded with the system actor.
+CREATE TABLE IF NOT EXISTS audit_events(
+    "id" BIGSERIAL PRIMARY KEY,
+    "organization_id" BIGINT NOT NULL REFERENCES organizations("id") DEFAULT current_organization_id(),
+    "occurred_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "actor" VARCHAR NOT NULL,
+    "action" VARCHAR NOT NULL,
+    "operation" VARCHAR NULL,
+    "entity_type" VARCHAR NOT NULL,
+    "entity_id" BIGINT NOT NULL,
+    "changes" JSONB NOT NULL,
+    "request_id" VARCHAR NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_entity ON audit_events("organization_id", "entity_id", "occurred_at");
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at ON audit_events("organization_id", "occurred_at");
+
+ALTER TABLE audit_events ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON audit_events;
+CREATE POLICY tenant_isolation ON audit_events USING ("organization_id" = current_organization_id());
+
+GRANT SELECT, INSERT ON audit_events TO tenant_user;
+GRANT USAGE, SELECT ON SEQUENCE audit_events_id_seq TO tenant_user;
+REVOKE UPDATE, DELETE, TRUNCATE ON audit_events FROM tenant_user;
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END $$;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- audit_change records the change of a row in audit_events. Its arguments are the entity type, the column of the
+-- entity ID, then the columns left out of the changes, e.g. secrets. The values are compared as JSON, the null
+-- values of an inserted or deleted row are left out.
+CREATE OR REPLACE FUNCTION audit_change() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    old_row JSONB := CASE WHEN TG_OP <> 'INSERT' THEN to_jsonb(OLD) END;
+    new_row JSONB := CASE WHEN TG_OP <> 'DELETE' THEN to_jsonb(NEW) END;
+    changes JSONB;
+BEGIN
+    SELECT COALESCE(jsonb_object_agg("key", jsonb_build_object('before', old_row -> "key", 'after', new_row -> "key")), '{}')
+    INTO changes
+    FROM jsonb_object_keys(COALESCE(new_row, old_row)) AS "key"
+    WHERE "key" NOT IN ('organization_id', 'created_at', 'updated_at', 'added_at')
+        AND "key" <> ALL (TG_ARGV[2:])
+        AND COALESCE(old_row -> "key", 'null') IS DISTINCT FROM COALESCE(new_row -> "key", 'null');
+
+    INSERT INTO audit_events (organization_id, actor, action, operation, entity_type, entity_id, changes, request_id)
+    VALUES (
+        (COALESCE(new_row, old_row) ->> 'organization_id')::BIGINT,
+        COALESCE(NULLIF(current_setting('app.actor', true), ''), 'system'),
+        CASE TG_OP WHEN 'INSERT' THEN 'CREATE' ELSE TG_OP END,
+        NULLIF(current_setting('app.operation', true), ''),
+        TG_ARGV[0],
+        (COALESCE(new_row, old_row) ->> TG_ARGV[1])::BIGINT,
+        changes,
+        NULLIF(current_setting('app.request_id', true), '')
+    );
+    RETURN NULL;
+END $$;
+
+DO $$
+DECLARE
+    audited RECORD;
+BEGIN
+    FOR audited IN SELECT * FROM (VALUES
+        ('power_plants', 'POWER_PLANT', 'id', ARRAY[]::TEXT[]),
+        ('turbine_models', 'TURBINE_MODEL', 'id', ARRAY[]::TEXT[]),
+        ('alert_rules', 'ALERT_RULE', 'id', ARRAY[]::TEXT[]),
+        ('webhook_subscriptions', 'WEBHOOK_SUBSCRIPTION', 'id', ARRAY['secret']),
+        ('maintenance_windows', 'MAINTENANCE_WINDOW', 'id', ARRAY[]::TEXT[]),
+        ('portfolios', 'PORTFOLIO', 'id', ARRAY[]::TEXT[]),
+        ('portfolio_power_plants', 'PORTFOLIO_MEMBERSHIP', 'portfolio_id', ARRAY[]::TEXT[]),
+        ('production_readings', 'PRODUCTION_READING', 'power_plant_id', ARRAY['recorded_at']),
+        ('webhook_deliveries', 'WEBHOOK_DELIVERY', 'id', ARRAY[]::TEXT[])
+    ) AS t("tbl", "entity_type", "id_column", "excluded") LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS audit_change ON %I', audited.tbl);
+        EXECUTE format('CREATE TRIGGER audit_change AFTER INSERT OR UPDATE OR DELETE ON %I FOR EACH ROW EXECUTE FUNCTION audit_change(%s)',
+            audited.tbl, array_to_string(ARRAY[quote_literal(audited.entity_type), quote_literal(audited.id_column)] || (
+                SELECT COALESCE(array_agg(quote_literal(c)), ARRAY[]::TEXT[]) FROM unnest(audited.excluded) AS c), ', '));
+    END LOOP;
+END $$;
+
+-- power_plants_history keeps every version of the power plants, valid from valid_from to valid_to, excluded, or
+-- still valid when valid_to is null. A trigger closes the current version and adds the new one on every change,
+-- the versions replaced in the transaction that added them are dropped and the updates only touching updated_at
+-- are ignored. The columns are copied by name, a column added to power_plants is only versioned once it is added to
+-- power_plants_history, to the trigger and to the backfill below. The power plants created before it start with their
+-- current version, valid from their creation.
+CREATE TABLE IF NOT EXISTS power_plants_history(
+    LIKE power_plants,
+    "valid_from" TIMESTAMP NOT NULL,
+    "valid_to" TIMESTAMP NULL,
+    PRIMARY KEY ("id", "valid_from")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS power_plants_history_current ON power_plants_history("id") WHERE "valid_to" IS NULL;
+
+ALTER TABLE power_plants_history ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON power_plants_history;
+CREATE POLICY tenant_isolation ON power_plants_history USING ("organization_id" = current_organization_id());
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON power_plants_history TO tenant_user;
+
+CREATE OR REPLACE FUNCTION power_plants_history_change() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND to_jsonb(OLD) - 'updated_at' = to_jsonb(NEW) - 'updated_at' THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP <> 'INSERT' THEN
+        DELETE FROM power_plants_history WHERE "id" = OLD."id" AND "valid_to" IS NULL AND "valid_from" = NOW() AT TIME ZONE 'UTC';
+        UPDATE power_plants_history SET "valid_to" = NOW() AT TIME ZONE 'UTC' WHERE "id" = OLD."id" AND "valid_to" IS NULL;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        INSERT INTO power_plants_history (
+            "id", "name", "latitude", "longitude", "created_at", "updated_at", "elevation",
+            "elevation_override", "type", "capacity_mw", "dc_capacity_mw", "design_discharge_m3s",
+            "commissioned_at", "status", "operator", "turbine_model_id", "turbine_count", "solar_array",
+            "tags", "organization_id",
+            "valid_from", "valid_to"
+        ) VALUES (
+            NEW."id", NEW."name", NEW."latitude", NEW."longitude", NEW."created_at", NEW."updated_at",
+            NEW."elevation", NEW."elevation_override", NEW."type", NEW."capacity_mw", NEW."dc_capacity_mw",
+            NEW."design_discharge_m3s", NEW."commissioned_at", NEW."status", NEW."operator",
+            NEW."turbine_model_id", NEW."turbine_count", NEW."solar_array", NEW."tags", NEW."organization_id",
+            NOW() AT TIME ZONE 'UTC', NULL
+        );
+    END IF;
+    RETURN NULL;
+END $$;
+
+DROP TRIGGER IF EXISTS power_plants_history_change ON power_plants;
+CREATE TRIGGER power_plants_history_change AFTER INSERT OR UPDATE OR DELETE ON power_plants
+FOR EACH ROW EXECUTE FUNCTION power_plants_history_change();
+
+INSERT INTO power_plants_history (
+    "id", "name", "latitude", "longitude", "created_at", "updated_at", "elevation", "elevation_override",
+    "type", "capacity_mw", "dc_capacity_mw", "design_discharge_m3s", "commissioned_at", "status", "operator",
+    "turbine_model_id", "turbine_count", "solar_array", "tags", "organization_id",
+    "valid_from", "valid_to"
+)
+SELECT
+    p."id", p."name", p."latitude", p."longitude", p."created_at", p."updated_at", p."elevation",
+    p."elevation_override", p."type", p."capacity_mw", p."dc_capacity_mw", p."design_discharge_m3s",
+    p."commissioned_at", p."status", p."operator", p."turbine_model_id", p."turbine_count", p."solar_array",
+    p."tags", p."organization_id",
+    p."created_at", NULL
+FROM power_plants p
+WHERE NOT EXISTS (SELECT 1 FROM power_plants_history h WHERE h."id" = p."id");
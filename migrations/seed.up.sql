@@ -1,3 +1,7 @@
+-- The seed belongs to the Default organization, its API key is dev-api-key.
+UPDATE organizations SET "api_key_hash" = sha256('dev-api-key') WHERE "name" = 'Default';
+SELECT set_config('app.organization_id', (SELECT "id" FROM organizations WHERE "name" = 'Default')::TEXT, false);
+
 INSERT INTO power_plants("name", "latitude", "longitude") VALUES
 ('Solar Power Plant', 40.7128, -74.0060),
 ('Wind Power Plant', 34.0522, -118.2437),